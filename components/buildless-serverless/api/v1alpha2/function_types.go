@@ -17,8 +17,11 @@ limitations under the License.
 package v1alpha2
 
 import (
+	"slices"
 	"strings"
+	"time"
 
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/config"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -29,19 +32,26 @@ import (
 type Runtime string
 
 const (
-	PythonPrefix string  = "python"
-	NodeJsPrefix string  = "nodejs"
-	Python312    Runtime = "python312"
-	NodeJs22     Runtime = "nodejs22"
-	NodeJs24     Runtime = "nodejs24"
+	// Deprecated: the runtimes are described by the runtime registry of the Serverless configuration, not by their names
+	PythonPrefix string = "python"
+	// Deprecated: the runtimes are described by the runtime registry of the Serverless configuration, not by their names
+	NodeJsPrefix string = "nodejs"
+
+	Python312 Runtime = "python312"
+	Python314 Runtime = "python314"
+	NodeJs22  Runtime = "nodejs22"
+	NodeJs24  Runtime = "nodejs24"
+	NodeJs26  Runtime = "nodejs26"
 	// deprecated runtimes
 	NodeJs20 Runtime = "nodejs20"
 )
 
 // FunctionSpec defines the desired state of Function.
 type FunctionSpec struct {
-	// Specifies the runtime of the Function. The available values are `nodejs20` - deprecated, `nodejs22`, `nodejs24`, and `python312`.
-	// +kubebuilder:validation:Enum=nodejs20;nodejs22;nodejs24;python312;
+	// Specifies the runtime of the Function. The built-in values are `nodejs20` - deprecated, `nodejs22`, `nodejs24`, `nodejs26`, `python312`, and `python314`.
+	// Additional runtimes can be added in the Serverless controller configuration.
+	// +kubebuilder:validation:Pattern=`^[a-z][a-z0-9]*$`
+	// +kubebuilder:validation:MaxLength=63
 	Runtime Runtime `json:"runtime"`
 
 	// Specifies the runtime image used instead of the default one.
@@ -49,7 +59,7 @@ type FunctionSpec struct {
 	RuntimeImageOverride string `json:"runtimeImageOverride,omitempty"`
 
 	// Contains the Function's source code configuration.
-	// +kubebuilder:validation:XValidation:message="Use GitRepository or Inline source",rule="has(self.gitRepository) && !has(self.inline) || !has(self.gitRepository) && has(self.inline)"
+	// +kubebuilder:validation:XValidation:message="Use one of GitRepository, Inline, OCIArtifact, HTTPArchive or ConfigMap source",rule="[has(self.gitRepository), has(self.inline), has(self.ociArtifact), has(self.httpArchive), has(self.configMap)].filter(x, x).size() == 1"
 	// +kubebuilder:validation:Required
 	Source Source `json:"source"`
 
@@ -65,24 +75,54 @@ type FunctionSpec struct {
 	// +kubebuilder:validation:XValidation:message="Following envs are reserved and cannot be used: ['FUNC_RUNTIME','FUNC_HANDLER','FUNC_PORT','FUNC_HANDLER_SOURCE','FUNC_HANDLER_DEPENDENCIES','MOD_NAME','NODE_PATH','PYTHONPATH']",rule="(self.all(e, !(e.name in ['FUNC_RUNTIME','FUNC_HANDLER','FUNC_PORT','FUNC_HANDLER_SOURCE','FUNC_HANDLER_DEPENDENCIES','MOD_NAME','NODE_PATH','PYTHONPATH'])))"
 	Env []corev1.EnvVar `json:"env,omitempty"`
 
+	// Specifies ConfigMaps and Secrets whose keys are used as environment variables for the Function.
+	// The variables defined in **Env** take precedence over them.
+	// +optional
+	EnvFrom []corev1.EnvFromSource `json:"envFrom,omitempty"`
+
 	// Specifies resources requested by the Function and the build Job.
 	// +optional
 	ResourceConfiguration *ResourceConfiguration `json:"resourceConfiguration,omitempty"`
 
-	// Deprecated:
-	// This setting will be removed. Serverless no longer automatically creates HPA.
+	// Overrides the startup, readiness, and liveness probes of the Function's container.
+	// By default, all probes call the `/healthz` path on the Function's port.
+	// +optional
+	Probes *Probes `json:"probes,omitempty"`
+
+	// Defines the minimum and maximum number of Function's Pods to run at a time.
+	// When it is configured, a HorizontalPodAutoscaler is created to scale the Function based on its CPU utilization.
+	// Set **MinReplicas** to `0` to scale the Function to zero when it is idle.
 	// +optional
 	ScaleConfig *ScaleConfig `json:"scaleConfig,omitempty"`
 
 	// Defines the exact number of Function's Pods to run at a time.
-	// If the Function is targeted by an external scaler,
+	// If **ScaleConfig** is configured, or if the Function is targeted by an external scaler,
 	// then the **Replicas** field is used by the relevant HorizontalPodAutoscaler to control the number of active replicas.
 	// +kubebuilder:validation:Minimum=0
 	// +kubebuilder:default:=1
 	// +optional
 	Replicas *int32 `json:"replicas,omitempty"`
 
-	// Deprecated: Use **Labels** and **Annotations** to label and/or annotate Function's Pods.
+	// Defines how changes of the Function are rolled out to its Pods.
+	// By default, the Function's Deployment is updated in place.
+	// +optional
+	Rollout *Rollout `json:"rollout,omitempty"`
+
+	// Specifies the name of the recorded revision the Function is rolled back to.
+	// When it is set, the Function runs the source, runtime and the other Pod settings of that revision instead of the ones defined in the spec.
+	// The Function can also be rolled back with the `serverless.kyma-project.io/rollback-revision` annotation.
+	// Remove it to run the Function's current source again.
+	// +optional
+	Revision string `json:"revision,omitempty"`
+
+	// Specifies the number of the Function's revisions to keep. Set it to `0` to disable the revision history.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:default:=10
+	// +optional
+	RevisionHistoryLimit *int32 `json:"revisionHistoryLimit,omitempty"`
+
+	// Defines the scheduling of the Function's Pods. The settings must be allowed by the Serverless configuration.
+	// Use **Labels** and **Annotations** to label and/or annotate Function's Pods.
 	// +optional
 	// +kubebuilder:validation:XValidation:message="Not supported: Use spec.labels and spec.annotations to label and/or annotate Function's Pods.",rule="!has(self.labels) && !has(self.annotations)"
 	Template *Template `json:"template,omitempty"`
@@ -90,6 +130,15 @@ type FunctionSpec struct {
 	// Specifies Secrets to mount into the Function's container filesystem.
 	SecretMounts []SecretMount `json:"secretMounts,omitempty"`
 
+	// Specifies ConfigMaps to mount into the Function's container filesystem.
+	// +optional
+	ConfigMapMounts []ConfigMapMount `json:"configMapMounts,omitempty"`
+
+	// Specifies the projected ServiceAccount tokens to mount into the Function's container filesystem,
+	// for example, to authenticate the Function with the workload identity of a cloud provider.
+	// +optional
+	ServiceAccountTokenMounts []ServiceAccountTokenMount `json:"serviceAccountTokenMounts,omitempty"`
+
 	// Defines labels used in Deployment's PodTemplate and applied on the Function's runtime Pod.
 	// +optional
 	// +kubebuilder:validation:XValidation:message="Labels has key starting with serverless.kyma-project.io/ which is not allowed",rule="!(self.exists(e, e.startsWith('serverless.kyma-project.io/')))"
@@ -104,13 +153,71 @@ type FunctionSpec struct {
 }
 
 type Source struct {
-	// Defines the Function as git-sourced. Can't be used together with **Inline**.
+	// Defines the Function as git-sourced. Can't be used together with the other sources.
 	// +optional
 	GitRepository *GitRepositorySource `json:"gitRepository,omitempty"`
 
-	// Defines the Function as the inline Function. Can't be used together with **GitRepository**.
+	// Defines the Function as the inline Function. Can't be used together with the other sources.
 	// +optional
 	Inline *InlineSource `json:"inline,omitempty"`
+
+	// Defines the Function's sources as the OCI artifact pulled from a registry. Can't be used together with the other sources.
+	// +optional
+	OCIArtifact *OCIArtifactSource `json:"ociArtifact,omitempty"`
+
+	// Defines the Function's sources as the tar or zip archive downloaded over HTTPS. Can't be used together with the other sources.
+	// +optional
+	HTTPArchive *HTTPArchiveSource `json:"httpArchive,omitempty"`
+
+	// Defines the Function's sources as the files stored in a ConfigMap. Can't be used together with the other sources.
+	// +optional
+	ConfigMap *ConfigMapSource `json:"configMap,omitempty"`
+}
+
+type OCIArtifactSource struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:XValidation:message="Reference is required and cannot be empty",rule="self.trim().size() != 0"
+
+	// Specifies the reference of the OCI artifact with the Function's code and dependencies, like `registry.example.com/team/function:1.0.0`.
+	// The tag is resolved to the digest, which is pulled by the Function's Pods. The reference can also point to the digest directly.
+	// The artifact's layers are either tar archives, optionally compressed with gzip, or single files named with the `org.opencontainers.image.title` annotation.
+	Reference string `json:"reference"`
+
+	// Specifies the relative path to the artifact's directory that contains the source code.
+	// +optional
+	BaseDir string `json:"baseDir,omitempty"`
+
+	// Specifies the name of the Secret of the `kubernetes.io/dockerconfigjson` type used to authenticate to the registry.
+	// This Secret must be stored in the same Namespace as the Function CR.
+	// +optional
+	PullSecretName string `json:"pullSecretName,omitempty"`
+}
+
+type HTTPArchiveSource struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:XValidation:message="URL must use the https scheme",rule="self.startsWith('https://')"
+
+	// Specifies the HTTPS URL of the tar archive, optionally compressed with gzip, or the zip archive with the Function's code and dependencies.
+	URL string `json:"url"`
+
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern=`^sha256:[0-9a-f]{64}$`
+
+	// Specifies the checksum of the archive in the `sha256:<hex>` format. The archive with another checksum is not extracted.
+	Checksum string `json:"checksum"`
+
+	// Specifies the relative path to the archive's directory that contains the source code.
+	// +optional
+	BaseDir string `json:"baseDir,omitempty"`
+}
+
+type ConfigMapSource struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:XValidation:message="Name is required and cannot be empty",rule="self.trim().size() != 0"
+
+	// Specifies the name of the ConfigMap whose keys are the names of the Function's files and whose values are their contents.
+	// This ConfigMap must be stored in the same Namespace as the Function CR.
+	Name string `json:"name"`
 }
 
 type InlineSource struct {
@@ -136,6 +243,32 @@ type GitRepositorySource struct {
 	// +optional
 	Auth *RepositoryAuth `json:"auth,omitempty"`
 
+	// Enables the Git webhook redeploying the Function as soon as a new commit is pushed to the repository.
+	// +optional
+	Webhook *RepositoryWebhook `json:"webhook,omitempty"`
+
+	// Specifies how the repository is checked out in the Function's Pods.
+	// +optional
+	Checkout *RepositoryCheckout `json:"checkout,omitempty"`
+
+	// Specifies how the new commits of the reference are rolled out. The value is `auto` to roll them out automatically,
+	// `manual` to roll out only the commit approved with the `serverless.kyma-project.io/approved-commit` annotation,
+	// or `pinned` to keep running the current commit until the URL or the reference changes.
+	// +optional
+	// +kubebuilder:default=auto
+	UpdatePolicy UpdatePolicy `json:"updatePolicy,omitempty"`
+
+	// Specifies the keys trusted to sign the Function's commits. The commit that isn't signed by any of them is not rolled out.
+	// If not set, the cluster's default trusted keys are used, if configured.
+	// +optional
+	Verification *RepositoryVerification `json:"verification,omitempty"`
+
+	// Specifies the additional paths in the repository, like the shared libraries, whose changes redeploy the Function.
+	// The Function is redeployed only when the files under **BaseDir** or these paths change,
+	// unless **BaseDir** is the root of the repository.
+	// +optional
+	WatchPaths []string `json:"watchPaths,omitempty"`
+
 	// +kubebuilder:validation:XValidation:message="BaseDir is required and cannot be empty",rule="has(self.baseDir) && (self.baseDir.trim().size() != 0)"
 	// +kubebuilder:validation:XValidation:message="Reference is required and cannot be empty",rule="has(self.reference) && (self.reference.trim().size() != 0)"
 	Repository `json:",inline"`
@@ -144,8 +277,8 @@ type GitRepositorySource struct {
 // RepositoryAuth defines authentication method used for repository operations
 type RepositoryAuth struct {
 	// +kubebuilder:validation:Required
-	// Defines the repository authentication method. The value is either `basic` if you use a password or token,
-	// or `key` if you use an SSH key.
+	// Defines the repository authentication method. The value is `basic` if you use a password or token,
+	// `key` if you use an SSH key, `token` if you use a bearer token, or `githubApp` if you use a GitHub App installation.
 	Type RepositoryAuthType `json:"type"`
 
 	// +kubebuilder:validation:Required
@@ -155,15 +288,88 @@ type RepositoryAuth struct {
 	// to authenticate to the Git repository in order to fetch the Function's source code and dependencies.
 	// This Secret must be stored in the same Namespace as the Function CR.
 	SecretName string `json:"secretName"`
+
+	// Specifies the trusted SSH host keys of the Git repository server in the `known_hosts` format.
+	// The keys are used together with the `known_hosts` key of the Secret and the cluster's default known hosts.
+	// +optional
+	KnownHosts string `json:"knownHosts,omitempty"`
+
+	// Specifies how the SSH host key of the Git repository server is verified. The value is either `strict`
+	// to trust only the known hosts, or `trustOnFirstUse` to trust the key of an unknown server on the first connection.
+	// +optional
+	// +kubebuilder:default=strict
+	HostKeyPolicy HostKeyPolicy `json:"hostKeyPolicy,omitempty"`
+}
+
+// UpdatePolicy is the enum of available policies of rolling out the new commits
+// +kubebuilder:validation:Enum=auto;manual;pinned
+type UpdatePolicy string
+
+const (
+	UpdatePolicyAuto   UpdatePolicy = "auto"
+	UpdatePolicyManual UpdatePolicy = "manual"
+	UpdatePolicyPinned UpdatePolicy = "pinned"
+)
+
+// HostKeyPolicy is the enum of available SSH host key verification policies
+// +kubebuilder:validation:Enum=strict;trustOnFirstUse
+type HostKeyPolicy string
+
+const (
+	HostKeyPolicyStrict          HostKeyPolicy = "strict"
+	HostKeyPolicyTrustOnFirstUse HostKeyPolicy = "trustOnFirstUse"
+)
+
+// RepositoryCheckout defines the options of cloning the repository in the Function's Pods
+type RepositoryCheckout struct {
+	// Clones the repository's submodules recursively, using the same authentication as the repository.
+	// +optional
+	Submodules bool `json:"submodules,omitempty"`
+
+	// Replaces the Git LFS pointers in the base directory with the objects they point to.
+	// Supported only for repositories accessed over HTTP(S).
+	// +optional
+	LFS bool `json:"lfs,omitempty"`
+
+	// Clones only the Function's commit instead of the repository's full history.
+	// +optional
+	Shallow bool `json:"shallow,omitempty"`
+
+	// Checks out only the files in the base directory.
+	// +optional
+	Sparse bool `json:"sparse,omitempty"`
+}
+
+// RepositoryVerification defines the secret with the keys trusted to sign the Function's commits
+type RepositoryVerification struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:XValidation:message="SecretName is required and cannot be empty",rule="self.trim().size() != 0"
+
+	// Specifies the name of the Secret with the trusted keys. Every key of the Secret holds the GPG public keys
+	// in the armored format or the SSH public keys in the `authorized_keys` format.
+	// This Secret must be stored in the same Namespace as the Function CR.
+	SecretName string `json:"secretName"`
+}
+
+// RepositoryWebhook defines the secret used to verify the Git webhook's requests
+type RepositoryWebhook struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:XValidation:message="SecretName is required and cannot be empty",rule="self.trim().size() != 0"
+
+	// Specifies the name of the Secret with the `secret` key used to verify the signature of the webhook's requests.
+	// This Secret must be stored in the same Namespace as the Function CR.
+	SecretName string `json:"secretName"`
 }
 
 // RepositoryAuthType is the enum of available authentication types
-// +kubebuilder:validation:Enum=basic;key
+// +kubebuilder:validation:Enum=basic;key;token;githubApp
 type RepositoryAuthType string
 
 const (
-	RepositoryAuthBasic  RepositoryAuthType = "basic"
-	RepositoryAuthSSHKey RepositoryAuthType = "key"
+	RepositoryAuthBasic     RepositoryAuthType = "basic"
+	RepositoryAuthSSHKey    RepositoryAuthType = "key"
+	RepositoryAuthToken     RepositoryAuthType = "token"
+	RepositoryAuthGitHubApp RepositoryAuthType = "githubApp"
 )
 
 type Repository struct {
@@ -173,6 +379,8 @@ type Repository struct {
 
 	// Specifies either the branch name, tag or commit revision from which the Function Controller
 	// automatically fetches the changes in the Function's code and dependencies.
+	// It can be also a semver constraint, like `^1.4`, or a pattern, like `release-*`,
+	// resolved to the highest version of the matching tags.
 	Reference string `json:"reference,omitempty"`
 }
 
@@ -206,7 +414,9 @@ type ResourceRequirements struct {
 
 type ScaleConfig struct {
 	// Defines the minimum number of Function's Pods to run at a time.
-	// +kubebuilder:validation:Minimum:=1
+	// If set to `0`, the Function is scaled to zero after it has not received any request for the configured idle window,
+	// and it is scaled back up by the activator when the next request arrives.
+	// +kubebuilder:validation:Minimum:=0
 	MinReplicas *int32 `json:"minReplicas"`
 
 	// Defines the maximum number of Function's Pods to run at a time.
@@ -214,6 +424,91 @@ type ScaleConfig struct {
 	MaxReplicas *int32 `json:"maxReplicas"`
 }
 
+type Probes struct {
+	// Overrides the startup probe, which holds the other probes until the Function has started.
+	// By default, the Function has 150 seconds to start, checked every 5 seconds.
+	// +optional
+	Startup *Probe `json:"startup,omitempty"`
+
+	// Overrides the readiness probe, which stops sending requests to the Function's Pod when it fails.
+	// By default, it's checked every 5 seconds, with a 2-second timeout, and fails after the first failure.
+	// +optional
+	Readiness *Probe `json:"readiness,omitempty"`
+
+	// Overrides the liveness probe, which restarts the Function's container when it fails.
+	// By default, it's checked every 5 seconds, with a 4-second timeout, and fails after 3 consecutive failures.
+	// +optional
+	Liveness *Probe `json:"liveness,omitempty"`
+}
+
+// Probe overrides the values of the Function's default probe, the values which are not set are taken from the default probe.
+type Probe struct {
+	// Specifies the HTTP path of the health check endpoint on the Function's port.
+	// +kubebuilder:validation:Pattern=`^/`
+	// +kubebuilder:validation:MaxLength=2048
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// Specifies the number of seconds after the container has started before the probe is initiated.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	InitialDelaySeconds *int32 `json:"initialDelaySeconds,omitempty"`
+
+	// Specifies how often, in seconds, the probe is performed.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	PeriodSeconds *int32 `json:"periodSeconds,omitempty"`
+
+	// Specifies the number of seconds after which the probe times out.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	TimeoutSeconds *int32 `json:"timeoutSeconds,omitempty"`
+
+	// Specifies the minimum number of consecutive successes for the probe to be considered successful after having failed.
+	// It must be `1` for the startup and liveness probes.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	SuccessThreshold *int32 `json:"successThreshold,omitempty"`
+
+	// Specifies the number of consecutive failures for the probe to be considered failed after having succeeded.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	FailureThreshold *int32 `json:"failureThreshold,omitempty"`
+}
+
+type Rollout struct {
+	// Rolls out changes of the Function to a new Deployment running side by side with the previous one
+	// and shifts the traffic to it step by step.
+	// +optional
+	Canary *CanaryStrategy `json:"canary,omitempty"`
+}
+
+type CanaryStrategy struct {
+	// Defines the steps of the rollout. The traffic is shifted to the new Deployment by scaling it and the previous Deployment
+	// proportionally to the step's weight. The new Deployment is promoted after the last step.
+	// +kubebuilder:validation:MinItems=1
+	Steps []CanaryStep `json:"steps"`
+
+	// Defines the time the new Deployment has to become ready at each step.
+	// If it's not ready within this time, the rollout is aborted and the Function is rolled back to the previous Deployment.
+	// +kubebuilder:default:="10m"
+	// +optional
+	ProgressDeadline *metav1.Duration `json:"progressDeadline,omitempty"`
+}
+
+type CanaryStep struct {
+	// Specifies the percentage of the Function's replicas, and so of the traffic, served by the new Deployment.
+	// The previous Deployment keeps at least one replica until the weight reaches 100, so the traffic follows the weight
+	// only when the Function runs at least 100/weight replicas. For example, a Function with one replica is split 50/50 at every step below 100.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	Weight int32 `json:"weight"`
+
+	// Specifies how long the step is held after the new Deployment becomes ready.
+	// +optional
+	Pause *metav1.Duration `json:"pause,omitempty"`
+}
+
 type SecretMount struct {
 	// Specifies the name of the Secret in the Function's Namespace.
 	// +kubebuilder:validation:Required
@@ -225,6 +520,51 @@ type SecretMount struct {
 	// +kubebuilder:validation:Required
 	// +kubebuilder:validation:MinLength=1
 	MountPath string `json:"mountPath"`
+
+	// Specifies the keys of the Secret to mount, and the paths and modes of their files.
+	// By default, all keys are mounted as files named after them.
+	// +optional
+	Items []corev1.KeyToPath `json:"items,omitempty"`
+}
+
+type ConfigMapMount struct {
+	// Specifies the name of the ConfigMap in the Function's Namespace.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MaxLength=253
+	// +kubebuilder:validation:MinLength=1
+	ConfigMapName string `json:"configMapName"`
+
+	// Specifies the path within the container where the ConfigMap should be mounted.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	MountPath string `json:"mountPath"`
+
+	// Specifies the keys of the ConfigMap to mount, and the paths and modes of their files.
+	// By default, all keys are mounted as files named after them.
+	// +optional
+	Items []corev1.KeyToPath `json:"items,omitempty"`
+}
+
+type ServiceAccountTokenMount struct {
+	// Specifies the audience of the token. The recipient of the token must identify itself with it.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Audience string `json:"audience"`
+
+	// Specifies the requested validity of the token in seconds. The token is rotated before it expires.
+	// The default value is `3600`.
+	// +kubebuilder:validation:Minimum=600
+	// +optional
+	ExpirationSeconds *int64 `json:"expirationSeconds,omitempty"`
+
+	// Specifies the path within the container of the directory where the token should be mounted.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	MountPath string `json:"mountPath"`
+
+	// Specifies the name of the token file in the mounted directory. The default value is `token`.
+	// +optional
+	Path string `json:"path,omitempty"`
 }
 
 type Template struct {
@@ -234,6 +574,31 @@ type Template struct {
 	// Deprecated: Use **FunctionSpec.Annotations** to annotate Function's Pods.
 	// +optional
 	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// Specifies the labels of the nodes the Function's Pods can be scheduled on.
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// Specifies the taints of the nodes tolerated by the Function's Pods.
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// Specifies the node affinity, and the affinity and anti-affinity to other Pods, of the Function's Pods.
+	// +optional
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+
+	// Specifies how the Function's Pods are spread across the topology domains, like zones or nodes.
+	// If **labelSelector** is not set, the constraint selects the Function's Pods.
+	// +optional
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+
+	// Specifies the name of the PriorityClass of the Function's Pods.
+	// +optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+
+	// Specifies the name of the ServiceAccount the Function's Pods run as.
+	// +optional
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
 }
 
 // FunctionStatus defines the observed state of the Function.
@@ -262,6 +627,18 @@ type FunctionStatus struct {
 	Repository `json:",inline,omitempty"`
 	// Specifies the GitRepository status when the Function is sourced from a Git repository.
 	GitRepository *GitRepositoryStatus `json:"gitRepository,omitempty"`
+	// Specifies the resolved digest of the OCI artifact, the HTTP archive or the ConfigMap the Function is sourced from.
+	Source *SourceStatus `json:"source,omitempty"`
+	// Specifies the progress of the Function's canary rollout
+	Rollout *RolloutStatus `json:"rollout,omitempty"`
+	// Specifies the name of the revision the Function is running
+	CurrentRevision string `json:"currentRevision,omitempty"`
+	// Specifies the recorded revisions of the Function, from the oldest to the newest
+	Revisions []FunctionRevision `json:"revisions,omitempty"`
+	// Specifies the state of the cache with the Function's prebuilt dependencies
+	DependencyCache *DependencyCacheStatus `json:"dependencyCache,omitempty"`
+	// Specifies the port of the activator receiving the requests sent to the scale-to-zero Function while it has no ready Pods
+	ActivatorPort int32 `json:"activatorPort,omitempty"`
 	// ContainerSecurityContext used by the Function's container
 	ContainerSecurityContext *corev1.SecurityContext `json:"containerSecurityContext,omitempty"`
 	// PodSecurityContext used by the Function's Pod
@@ -272,6 +649,92 @@ type GitRepositoryStatus struct {
 	URL        string `json:"url"`
 	Repository `json:",inline,omitempty"`
 	Commit     string `json:"commit,omitempty"`
+	// Specifies the tag the semver constraint or the pattern reference was resolved to
+	ResolvedTag string `json:"resolvedTag,omitempty"`
+	// Specifies the newest commit of the reference waiting for the approval when the update policy is `manual`
+	AvailableCommit string `json:"availableCommit,omitempty"`
+	// Specifies the commit the Function's Pods run when it differs from **Commit**,
+	// because the newer commits did not change the files under **BaseDir** and the watched paths
+	DeployedCommit string `json:"deployedCommit,omitempty"`
+	// Specifies the hash of the trees of **BaseDir** and the watched paths in the deployed commit
+	TreeHash string `json:"treeHash,omitempty"`
+	// Specifies the SSH host keys trusted on the first connection to the Git repository server, in the `known_hosts` format
+	TrustedHostKeys string `json:"trustedHostKeys,omitempty"`
+}
+
+// SourceType is the enum of the Function's source types resolved to the digest
+type SourceType string
+
+const (
+	SourceTypeOCIArtifact SourceType = "ociArtifact"
+	SourceTypeHTTPArchive SourceType = "httpArchive"
+	SourceTypeConfigMap   SourceType = "configMap"
+)
+
+type SourceStatus struct {
+	// Specifies the type of the Function's source
+	Type SourceType `json:"type"`
+	// Specifies the digest of the source the Function's Pods run, in the `sha256:<hex>` format
+	Digest string `json:"digest,omitempty"`
+}
+
+// RunningCommit returns the commit the Function's Pods run
+func (s *GitRepositoryStatus) RunningCommit() string {
+	if s.DeployedCommit != "" {
+		return s.DeployedCommit
+	}
+	return s.Commit
+}
+
+type FunctionRevision struct {
+	// Specifies the name of the revision
+	Name string `json:"name"`
+	// Specifies the runtime of the revision
+	Runtime Runtime `json:"runtime,omitempty"`
+	// Specifies the commit hash of the revision when the Function is sourced from a Git repository
+	Commit string `json:"commit,omitempty"`
+	// Specifies the hash of the Pod template the revision was running with
+	TemplateHash string `json:"templateHash,omitempty"`
+	// Specifies when the revision was recorded
+	CreationTimestamp metav1.Time `json:"creationTimestamp,omitempty"`
+}
+
+type DependencyCacheState string
+
+const (
+	// DependencyCachePopulating means the dependencies are being installed into the cache
+	DependencyCachePopulating DependencyCacheState = "Populating"
+	// DependencyCacheHit means the Function's Pods reuse the prebuilt dependencies
+	DependencyCacheHit DependencyCacheState = "Hit"
+	// DependencyCacheMiss means the cache can't be populated and the Function's Pods install the dependencies on start
+	DependencyCacheMiss DependencyCacheState = "Miss"
+)
+
+type DependencyCacheStatus struct {
+	// Specifies the hash of the Function's resolved dependencies
+	Hash string `json:"hash"`
+	// Specifies if the Function's Pods reuse the prebuilt dependencies
+	State DependencyCacheState `json:"state"`
+	// Specifies the name of the PersistentVolumeClaim holding the prebuilt dependencies
+	VolumeClaimName string `json:"volumeClaimName,omitempty"`
+	// Specifies the reason of the cache miss
+	Message string `json:"message,omitempty"`
+}
+
+type RolloutStatus struct {
+	// Specifies the name of the Deployment running the previous version of the Function
+	StableDeployment string `json:"stableDeployment,omitempty"`
+	// Specifies the name of the Deployment running the new version of the Function
+	CanaryDeployment string `json:"canaryDeployment,omitempty"`
+	// Specifies the index of the current canary step
+	Step int32 `json:"step,omitempty"`
+	// Specifies when the current canary step has started
+	StepStartTime *metav1.Time `json:"stepStartTime,omitempty"`
+	// Specifies when the new Deployment has become ready in the current canary step
+	StepReadyTime *metav1.Time `json:"stepReadyTime,omitempty"`
+	// Specifies the hash of the Pod template rolled back by the last rollout.
+	// The same template is not rolled out again until the Function changes.
+	FailedTemplateHash string `json:"failedTemplateHash,omitempty"`
 }
 
 type ConditionType string
@@ -279,6 +742,8 @@ type ConditionType string
 const (
 	ConditionRunning            ConditionType = "Running"
 	ConditionConfigurationReady ConditionType = "ConfigurationReady"
+	ConditionRolloutComplete    ConditionType = "RolloutComplete"
+	ConditionScalingReady       ConditionType = "ScalingReady"
 )
 
 type ConditionReason string
@@ -288,6 +753,8 @@ const (
 	ConditionReasonFunctionSpecValidated    ConditionReason = "FunctionSpecValidated"
 	ConditionReasonSourceUpdated            ConditionReason = "SourceUpdated"
 	ConditionReasonSourceUpdateFailed       ConditionReason = "SourceUpdateFailed"
+	ConditionReasonSourceUpdatePending      ConditionReason = "SourceUpdatePending"
+	ConditionReasonSourceVerificationFailed ConditionReason = "SourceVerificationFailed"
 	ConditionReasonDeploymentCreated        ConditionReason = "DeploymentCreated"
 	ConditionReasonDeploymentUpdated        ConditionReason = "DeploymentUpdated"
 	ConditionReasonDeploymentFailed         ConditionReason = "DeploymentFailed"
@@ -299,6 +766,19 @@ const (
 	ConditionReasonServiceUpdated           ConditionReason = "ServiceUpdated"
 	ConditionReasonServiceFailed            ConditionReason = "ServiceFailed"
 	ConditionReasonMinReplicasNotAvailable  ConditionReason = "MinReplicasNotAvailable"
+	ConditionReasonHPACreated               ConditionReason = "HorizontalPodAutoscalerCreated"
+	ConditionReasonHPAUpdated               ConditionReason = "HorizontalPodAutoscalerUpdated"
+	ConditionReasonHPADeleted               ConditionReason = "HorizontalPodAutoscalerDeleted"
+	ConditionReasonHPAFailed                ConditionReason = "HorizontalPodAutoscalerFailed"
+	ConditionReasonHPAReady                 ConditionReason = "HorizontalPodAutoscalerReady"
+	ConditionReasonScaledToZero             ConditionReason = "ScaledToZero"
+	ConditionReasonCanaryProgressing        ConditionReason = "CanaryProgressing"
+	ConditionReasonCanaryPaused             ConditionReason = "CanaryPaused"
+	ConditionReasonCanaryPromoted           ConditionReason = "CanaryPromoted"
+	ConditionReasonCanaryRolledBack         ConditionReason = "CanaryRolledBack"
+	ConditionReasonRevisionFailed           ConditionReason = "RevisionFailed"
+	ConditionReasonRevisionNameConflict     ConditionReason = "RevisionNameConflict"
+	ConditionReasonFunctionRuntimeFailed    ConditionReason = "FunctionRuntimeFailed"
 )
 
 // +kubebuilder:object:root=true
@@ -354,6 +834,10 @@ func (f *Function) UpdateCondition(c ConditionType, s metav1.ConditionStatus, r
 	meta.SetStatusCondition(&f.Status.Conditions, condition)
 }
 
+func (f *Function) RemoveCondition(c ConditionType) {
+	meta.RemoveStatusCondition(&f.Status.Conditions, string(c))
+}
+
 func (s *FunctionStatus) Condition(c ConditionType) *metav1.Condition {
 	for _, cond := range s.Conditions {
 		if cond.Type == string(c) {
@@ -364,13 +848,31 @@ func (s *FunctionStatus) Condition(c ConditionType) *metav1.Condition {
 }
 
 const (
-	FunctionNameLabel                    = "serverless.kyma-project.io/function-name"
-	FunctionManagedByLabel               = "serverless.kyma-project.io/managed-by"
-	FunctionControllerValue              = "function-controller"
-	FunctionUUIDLabel                    = "serverless.kyma-project.io/uuid"
-	FunctionResourceLabel                = "serverless.kyma-project.io/resource"
-	FunctionResourceLabelDeploymentValue = "deployment"
-	PodAppNameLabel                      = "app.kubernetes.io/name"
+	FunctionNameLabel                         = "serverless.kyma-project.io/function-name"
+	FunctionManagedByLabel                    = "serverless.kyma-project.io/managed-by"
+	FunctionControllerValue                   = "function-controller"
+	FunctionUUIDLabel                         = "serverless.kyma-project.io/uuid"
+	FunctionResourceLabel                     = "serverless.kyma-project.io/resource"
+	FunctionResourceLabelDeploymentValue      = "deployment"
+	FunctionResourceLabelRevisionValue        = "revision"
+	FunctionRevisionLabel                     = "serverless.kyma-project.io/revision"
+	FunctionResourceLabelDependencyCacheValue = "dependency-cache"
+	FunctionResourceLabelGitTokenValue        = "git-token"
+	FunctionResourceLabelSourceValue          = "source"
+	FunctionDependencyHashLabel               = "serverless.kyma-project.io/dependency-hash"
+	PodAppNameLabel                           = "app.kubernetes.io/name"
+	// FunctionLastActivityAnnotation is set by the activator to the time of the last request served by the scale-to-zero Function
+	FunctionLastActivityAnnotation = "serverless.kyma-project.io/last-activity"
+	// FunctionApprovedCommitAnnotation approves rolling out the commit when the Function's update policy is `manual`
+	FunctionApprovedCommitAnnotation = "serverless.kyma-project.io/approved-commit"
+	// FunctionRollbackRevisionAnnotation rolls the Function back to the named revision, the spec's revision takes precedence over it
+	FunctionRollbackRevisionAnnotation = "serverless.kyma-project.io/rollback-revision"
+	// DeploymentTemplateHashAnnotation is set by Function Controller to the hash of the pod template it applied to the Function's Deployment
+	DeploymentTemplateHashAnnotation = "serverless.kyma-project.io/template-hash"
+	// ServiceActivatorPortAnnotation is set by Function Controller on the Function's Service routed to the activator's port
+	ServiceActivatorPortAnnotation = "serverless.kyma-project.io/activator-port"
+	// PodReferencedContentHashAnnotation is set by Function Controller to the hash of the Secrets and ConfigMaps consumed by the Function's Pods
+	PodReferencedContentHashAnnotation = "serverless.kyma-project.io/referenced-content-hash"
 )
 
 func (f *Function) InternalFunctionLabels() map[string]string {
@@ -423,51 +925,195 @@ func (f *Function) HasGitAuth() bool {
 	return f.Spec.Source.GitRepository != nil && f.Spec.Source.GitRepository.Auth != nil
 }
 
+// RollbackRevision returns the name of the revision the Function is rolled back to, from the spec or the rollback annotation
+func (f *Function) RollbackRevision() string {
+	if f.Spec.Revision != "" {
+		return f.Spec.Revision
+	}
+	return f.GetAnnotations()[FunctionRollbackRevisionAnnotation]
+}
+
+func (f *Function) HasGitWebhook() bool {
+	return f.Spec.Source.GitRepository != nil && f.Spec.Source.GitRepository.Webhook != nil
+}
+
 func (f *Function) HasInlineSources() bool {
 	return f.Spec.Source.Inline != nil
 }
 
+func (f *Function) HasOCIArtifactSources() bool {
+	return f.Spec.Source.OCIArtifact != nil
+}
+
+func (f *Function) HasHTTPArchiveSources() bool {
+	return f.Spec.Source.HTTPArchive != nil
+}
+
+func (f *Function) HasConfigMapSources() bool {
+	return f.Spec.Source.ConfigMap != nil
+}
+
+// HasFetchedSources returns true when the Function's Pods fetch the sources before the start
+func (f *Function) HasFetchedSources() bool {
+	return f.HasGitSources() || f.HasOCIArtifactSources() || f.HasHTTPArchiveSources() || f.HasConfigMapSources()
+}
+
+// ResolvedSourceType returns the type of the source resolved to the digest, or empty for the git and inline sources
+func (f *Function) ResolvedSourceType() SourceType {
+	switch {
+	case f.HasOCIArtifactSources():
+		return SourceTypeOCIArtifact
+	case f.HasHTTPArchiveSources():
+		return SourceTypeHTTPArchive
+	case f.HasConfigMapSources():
+		return SourceTypeConfigMap
+	}
+	return ""
+}
+
+// Deprecated: use the runtime registry of the Serverless configuration, only the built-in runtimes are recognized
 func (f *Function) HasPythonRuntime() bool {
 	return f.Spec.Runtime.IsRuntimePython()
 }
 
+// Deprecated: use the runtime registry of the Serverless configuration, only the built-in runtimes are recognized
 func (f *Function) HasNodejsRuntime() bool {
 	return f.Spec.Runtime.IsRuntimeNodejs()
 }
 
+func (f *Function) IsScaleToZeroEnabled() bool {
+	return f.Spec.ScaleConfig != nil &&
+		f.Spec.ScaleConfig.MinReplicas != nil &&
+		*f.Spec.ScaleConfig.MinReplicas == 0
+}
+
+// LastActivityTime returns the time of the last request recorded by the activator
+// or the creation time if the Function has not received any request yet
+func (f *Function) LastActivityTime() time.Time {
+	lastActivity, err := time.Parse(time.RFC3339, f.GetAnnotations()[FunctionLastActivityAnnotation])
+	if err != nil {
+		return f.GetCreationTimestamp().Time
+	}
+	return lastActivity
+}
+
+func (f *Function) IsCanaryRolloutEnabled() bool {
+	return f.Spec.Rollout != nil && f.Spec.Rollout.Canary != nil
+}
+
+// IsCanaryRolloutInProgress returns true when the Function's canary Deployment is running side by side with the previous one
+func (f *Function) IsCanaryRolloutInProgress() bool {
+	return f.Status.Rollout != nil && f.Status.Rollout.CanaryDeployment != ""
+}
+
+// PodSecretNames returns the names of the Secrets mounted into the Function's Pods or used as their environment variables
+func (f *Function) PodSecretNames() []string {
+	names := []string{}
+	for _, secretMount := range f.Spec.SecretMounts {
+		names = append(names, secretMount.SecretName)
+	}
+	for _, envFrom := range f.Spec.EnvFrom {
+		if envFrom.SecretRef != nil {
+			names = append(names, envFrom.SecretRef.Name)
+		}
+	}
+	for _, env := range f.Spec.Env {
+		if env.ValueFrom != nil && env.ValueFrom.SecretKeyRef != nil {
+			names = append(names, env.ValueFrom.SecretKeyRef.Name)
+		}
+	}
+	return uniqueNames(names)
+}
+
+// PodConfigMapNames returns the names of the ConfigMaps mounted into the Function's Pods or used as their environment variables
+func (f *Function) PodConfigMapNames() []string {
+	names := []string{}
+	for _, configMapMount := range f.Spec.ConfigMapMounts {
+		names = append(names, configMapMount.ConfigMapName)
+	}
+	for _, envFrom := range f.Spec.EnvFrom {
+		if envFrom.ConfigMapRef != nil {
+			names = append(names, envFrom.ConfigMapRef.Name)
+		}
+	}
+	for _, env := range f.Spec.Env {
+		if env.ValueFrom != nil && env.ValueFrom.ConfigMapKeyRef != nil {
+			names = append(names, env.ValueFrom.ConfigMapKeyRef.Name)
+		}
+	}
+	return uniqueNames(names)
+}
+
+// ReferencedSecretNames returns the names of all Secrets the Function depends on, including the ones of its source
+func (f *Function) ReferencedSecretNames() []string {
+	names := f.PodSecretNames()
+	if repository := f.Spec.Source.GitRepository; repository != nil {
+		if repository.Auth != nil {
+			names = append(names, repository.Auth.SecretName)
+		}
+		if repository.Verification != nil {
+			names = append(names, repository.Verification.SecretName)
+		}
+		if repository.Webhook != nil {
+			names = append(names, repository.Webhook.SecretName)
+		}
+	}
+	if artifact := f.Spec.Source.OCIArtifact; artifact != nil && artifact.PullSecretName != "" {
+		names = append(names, artifact.PullSecretName)
+	}
+	return uniqueNames(names)
+}
+
+// ReferencedConfigMapNames returns the names of all ConfigMaps the Function depends on, including the one of its source
+func (f *Function) ReferencedConfigMapNames() []string {
+	names := f.PodConfigMapNames()
+	if f.HasConfigMapSources() {
+		names = append(names, f.Spec.Source.ConfigMap.Name)
+	}
+	return uniqueNames(names)
+}
+
+func uniqueNames(names []string) []string {
+	slices.Sort(names)
+	return slices.Compact(names)
+}
+
 func (f *Function) CopyAnnotationsToStatus() {
 	f.Status.FunctionAnnotations = f.Spec.Annotations
 }
 
 // runtime helper functions
-// almost all functions that check for supported runtime versions should be here, for simpler bumps
+// they recognize only the built-in runtimes of the runtime registry, not the runtimes added in the configuration
 
+// Deprecated: use the runtime registry of the Serverless configuration, it includes the runtimes added in the configuration
 func (runtime Runtime) IsRuntimeSupported() bool {
-	supportedRuntimes := []Runtime{NodeJs20, NodeJs22, NodeJs24, Python312}
-	for _, r := range supportedRuntimes {
-		if r == runtime {
-			return true
-		}
-	}
-	return false
+	_, ok := config.BuiltinRuntime(string(runtime))
+	return ok
 }
 
 // IsRuntimeDeprecated checks if the runtime is deprecated
 // Deprecated runtimes are still supported, but their use is discouraged
+//
+// Deprecated: use the runtime registry of the Serverless configuration, it includes the runtimes added in the configuration
 func (runtime Runtime) IsRuntimeDeprecated() bool {
-	deprecatedRuntimes := []Runtime{NodeJs20}
-	for _, r := range deprecatedRuntimes {
-		if r == runtime {
-			return true
-		}
-	}
-	return false
+	rc, _ := config.BuiltinRuntime(string(runtime))
+	return rc.Deprecated
+}
+
+// IsRuntimeLegacyAPI checks if the runtime calls the handler with the legacy event and context objects
+// Runtimes with the new API configure the handler with the HANDLER_* environment variables
+//
+// Deprecated: use the runtime registry of the Serverless configuration, it configures the handler of the runtime
+func (runtime Runtime) IsRuntimeLegacyAPI() bool {
+	return config.IsLegacyAPIRuntime(string(runtime))
 }
 
+// Deprecated: use the runtime registry of the Serverless configuration, the runtime isn't described by its name
 func (runtime Runtime) IsRuntimePython() bool {
-	return strings.HasPrefix(string(runtime), PythonPrefix)
+	return runtime.IsRuntimeSupported() && strings.HasPrefix(string(runtime), PythonPrefix)
 }
 
+// Deprecated: use the runtime registry of the Serverless configuration, the runtime isn't described by its name
 func (runtime Runtime) IsRuntimeNodejs() bool {
-	return strings.HasPrefix(string(runtime), NodeJsPrefix)
+	return runtime.IsRuntimeSupported() && strings.HasPrefix(string(runtime), NodeJsPrefix)
 }
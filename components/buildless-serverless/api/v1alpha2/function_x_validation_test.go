@@ -261,6 +261,26 @@ func Test_XKubernetesValidations_Valid(t *testing.T) {
 				},
 			},
 		},
+		"allowed runtime: nodejs26": {
+			fn: &serverlessv1alpha2.Function{
+				ObjectMeta: fixMetadata,
+				Spec: serverlessv1alpha2.FunctionSpec{
+					Runtime: serverlessv1alpha2.NodeJs26,
+					Source: serverlessv1alpha2.Source{
+						Inline: &serverlessv1alpha2.InlineSource{Source: "a"}},
+				},
+			},
+		},
+		"allowed runtime: python314": {
+			fn: &serverlessv1alpha2.Function{
+				ObjectMeta: fixMetadata,
+				Spec: serverlessv1alpha2.FunctionSpec{
+					Runtime: serverlessv1alpha2.Python314,
+					Source: serverlessv1alpha2.Source{
+						Inline: &serverlessv1alpha2.InlineSource{Source: "a"}},
+				},
+			},
+		},
 		"allowed runtime: python312": {
 			fn: &serverlessv1alpha2.Function{
 				ObjectMeta: fixMetadata,
@@ -271,6 +291,16 @@ func Test_XKubernetesValidations_Valid(t *testing.T) {
 				},
 			},
 		},
+		"allowed runtime: custom": {
+			fn: &serverlessv1alpha2.Function{
+				ObjectMeta: fixMetadata,
+				Spec: serverlessv1alpha2.FunctionSpec{
+					Runtime: serverlessv1alpha2.Runtime("custom"),
+					Source: serverlessv1alpha2.Source{
+						Inline: &serverlessv1alpha2.InlineSource{Source: "a"}},
+				},
+			},
+		},
 		"allowed envs": {
 			fn: &serverlessv1alpha2.Function{
 				ObjectMeta: fixMetadata,
@@ -434,6 +464,19 @@ func Test_XKubernetesValidations_Invalid(t *testing.T) {
 		fieldPath      string
 		expectedCause  metav1.CauseType
 	}{
+		"disallowed runtime: Custom_Runtime": {
+			fn: &serverlessv1alpha2.Function{
+				ObjectMeta: fixMetadata,
+				Spec: serverlessv1alpha2.FunctionSpec{
+					Source: serverlessv1alpha2.Source{
+						Inline: &serverlessv1alpha2.InlineSource{Source: "a"}},
+					Runtime: serverlessv1alpha2.Runtime("Custom_Runtime"),
+				},
+			},
+			expectedCause:  metav1.CauseTypeFieldValueInvalid,
+			expectedErrMsg: "Invalid value: \"Custom_Runtime\": spec.runtime in body should match '^[a-z][a-z0-9]*$'",
+			fieldPath:      "spec.runtime",
+		},
 		"Resource and Profiles used together in function": {
 			fn: &serverlessv1alpha2.Function{
 				ObjectMeta: fixMetadata,
@@ -866,19 +909,6 @@ func Test_XKubernetesValidations_InvalidMultipleCauses(t *testing.T) {
 		fieldPath      string
 		expectedCause  metav1.CauseType
 	}{
-		"disallowed runtime: custom": {
-			fn: &serverlessv1alpha2.Function{
-				ObjectMeta: fixMetadata,
-				Spec: serverlessv1alpha2.FunctionSpec{
-					Source: serverlessv1alpha2.Source{
-						Inline: &serverlessv1alpha2.InlineSource{Source: "a"}},
-					Runtime: serverlessv1alpha2.Runtime("custom"),
-				},
-			},
-			expectedCause:  metav1.CauseTypeFieldValueNotSupported,
-			fieldPath:      "spec.runtime",
-			expectedErrMsg: "Unsupported value: \"custom\": supported values: \"nodejs20\", \"nodejs22\", \"nodejs24\", \"python312\"",
-		},
 		"Git source auth has incorrect Type": {
 			fn: &serverlessv1alpha2.Function{
 				ObjectMeta: fixMetadata,
@@ -0,0 +1,170 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DependenciesFormat specifies how the Function's inline dependencies are validated and merged.
+// +kubebuilder:validation:Enum=json;text
+type DependenciesFormat string
+
+const (
+	DependenciesFormatJSON DependenciesFormat = "json"
+	DependenciesFormatText DependenciesFormat = "text"
+)
+
+// FunctionRuntimeSpec defines the desired state of FunctionRuntime.
+type FunctionRuntimeSpec struct {
+	// Specifies the image of the runtime.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Image string `json:"image"`
+
+	// Marks the runtime as deprecated. Functions using it get a warning in the **ConfigurationReady** condition.
+	// +optional
+	Deprecated bool `json:"deprecated,omitempty"`
+
+	// Allows the runtime when Kyma runs in the FIPS mode.
+	// +optional
+	FIPSCompliant bool `json:"fipsCompliant,omitempty"`
+
+	// Specifies the directory the Function's sources are saved to. The commands are run in this directory.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	WorkingDir string `json:"workingDir"`
+
+	// Specifies the name of the file the Function's inline source is saved to.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	HandlerFile string `json:"handlerFile"`
+
+	// Specifies the name of the runtime's server file shipped with the ejected Function.
+	// +optional
+	ServerFile string `json:"serverFile,omitempty"`
+
+	// Specifies the name of the file the Function's inline dependencies are saved to.
+	// +optional
+	DependenciesFile string `json:"dependenciesFile,omitempty"`
+
+	// Specifies the format of the Function's inline dependencies.
+	// +optional
+	DependenciesFormat DependenciesFormat `json:"dependenciesFormat,omitempty"`
+
+	// Specifies the content of the dependencies file when the Function has no dependencies.
+	// +optional
+	EmptyDependencies string `json:"emptyDependencies,omitempty"`
+
+	// Specifies the key of the package registry configuration Secret mounted into the working directory.
+	// +optional
+	PackageRegistryConfigFile string `json:"packageRegistryConfigFile,omitempty"`
+
+	// Specifies the shell command installing the dependencies in the working directory.
+	// +optional
+	InstallCommand string `json:"installCommand,omitempty"`
+
+	// Specifies the shell command starting the runtime's server.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	StartCommand string `json:"startCommand"`
+
+	// Specifies the commands used when the dependency cache is enabled.
+	// +optional
+	DependencyCache *FunctionRuntimeDependencyCache `json:"dependencyCache,omitempty"`
+
+	// Specifies writable directories required by the runtime, mounted as `emptyDir` volumes.
+	// +optional
+	EmptyDirs []FunctionRuntimeEmptyDir `json:"emptyDirs,omitempty"`
+
+	// Specifies environment variables set for the Function's container.
+	// +optional
+	Env []FunctionRuntimeEnvVar `json:"env,omitempty"`
+
+	// Specifies environment variables set only when the Function's sources are mounted into the container.
+	// +optional
+	SourceEnv []FunctionRuntimeEnvVar `json:"sourceEnv,omitempty"`
+}
+
+type FunctionRuntimeDependencyCache struct {
+	// Specifies the shell command copying the installed dependencies to the `/dependency-cache` directory.
+	SaveCommand string `json:"saveCommand,omitempty"`
+	// Specifies the shell command making the dependencies from the `/dependency-cache` directory available to the runtime.
+	RestoreCommand string `json:"restoreCommand,omitempty"`
+}
+
+type FunctionRuntimeEmptyDir struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	MountPath string `json:"mountPath"`
+}
+
+type FunctionRuntimeEnvVar struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Name  string `json:"name"`
+	Value string `json:"value,omitempty"`
+}
+
+// FunctionReference points to a Function using the runtime.
+type FunctionReference struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+// FunctionRuntimeStatus defines the observed state of FunctionRuntime.
+type FunctionRuntimeStatus struct {
+	// Specifies the number of Functions using the runtime.
+	FunctionCount int32 `json:"functionCount"`
+	// Lists the Functions using the runtime.
+	// +optional
+	Functions []FunctionReference `json:"functions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster,categories={all},shortName={fnrt}
+// +kubebuilder:storageversion
+// +kubebuilder:printcolumn:name="Image",type="string",JSONPath=".spec.image"
+// +kubebuilder:printcolumn:name="Deprecated",type="boolean",JSONPath=".spec.deprecated"
+// +kubebuilder:printcolumn:name="Functions",type="integer",JSONPath=".status.functionCount"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// FunctionRuntime is the Schema for the functionruntimes API.
+type FunctionRuntime struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+
+	Spec   FunctionRuntimeSpec   `json:"spec"`
+	Status FunctionRuntimeStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// FunctionRuntimeList contains a list of FunctionRuntime.
+type FunctionRuntimeList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []FunctionRuntime `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&FunctionRuntime{}, &FunctionRuntimeList{})
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	archiveRequestTimeout = 10 * time.Minute
+	archiveChecksumPrefix = "sha256:"
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zipMagic  = []byte("PK\x03\x04")
+)
+
+// fetchHTTPArchive downloads the archive, verifies its checksum and extracts it to the destination path
+func fetchHTTPArchive(c initConfig) error {
+	expected, found := strings.CutPrefix(c.ArchiveChecksum, archiveChecksumPrefix)
+	if !found {
+		return fmt.Errorf("checksum %s is not in the %s<hex> format", c.ArchiveChecksum, archiveChecksumPrefix)
+	}
+
+	// the root filesystem is read-only, so the archive is downloaded next to the destination path
+	f, err := os.CreateTemp(filepath.Dir(c.DestinationPath), "archive-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	if err := downloadArchive(c.ArchiveURL, f, expected); err != nil {
+		return errors.Wrap(err, "while downloading archive")
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	return extractArchive(f, c.DestinationPath)
+}
+
+func downloadArchive(url string, f *os.File, expectedChecksum string) error {
+	client := &http.Client{Timeout: archiveRequestTimeout}
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(f, hash), resp.Body); err != nil {
+		return err
+	}
+	if checksum := hex.EncodeToString(hash.Sum(nil)); checksum != expectedChecksum {
+		return fmt.Errorf("checksum mismatch, got %s%s", archiveChecksumPrefix, checksum)
+	}
+	return nil
+}
+
+// extractArchive extracts the zip archive or the tar archive, optionally compressed with gzip
+func extractArchive(f *os.File, destination string) error {
+	header := make([]byte, len(zipMagic))
+	n, err := io.ReadFull(f, header)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	if bytes.Equal(header[:n], zipMagic) {
+		info, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		return extractZip(f, info.Size(), destination)
+	}
+	return extractTar(f, destination)
+}
+
+// extractTar extracts the tar archive, the gzip compression is detected by the content
+func extractTar(r io.Reader, destination string) error {
+	buffered := bufio.NewReader(r)
+	header, err := buffered.Peek(len(gzipMagic))
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	r = buffered
+	if bytes.Equal(header, gzipMagic) {
+		gz, err := gzip.NewReader(buffered)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		entry, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch entry.Typeflag {
+		case tar.TypeDir:
+			path, err := archivePath(destination, entry.Name)
+			if err != nil {
+				return err
+			}
+			if err := os.MkdirAll(path, 0o755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := writeArchiveFile(destination, entry.Name, entry.FileInfo().Mode(), tr); err != nil {
+				return err
+			}
+		default:
+			// the links could point outside of the destination path
+			log.Printf("Skip %s of unsupported type %c", entry.Name, entry.Typeflag)
+		}
+	}
+}
+
+func extractZip(r io.ReaderAt, size int64, destination string) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range zr.File {
+		mode := entry.Mode()
+		switch {
+		case mode.IsDir():
+			path, err := archivePath(destination, entry.Name)
+			if err != nil {
+				return err
+			}
+			if err := os.MkdirAll(path, 0o755); err != nil {
+				return err
+			}
+		case mode.IsRegular():
+			if err := extractZipFile(entry, destination); err != nil {
+				return err
+			}
+		default:
+			log.Printf("Skip %s of unsupported type %s", entry.Name, mode.Type())
+		}
+	}
+	return nil
+}
+
+func extractZipFile(entry *zip.File, destination string) error {
+	rc, err := entry.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return writeArchiveFile(destination, entry.Name, entry.Mode(), rc)
+}
+
+func writeArchiveFile(destination, name string, mode os.FileMode, r io.Reader) error {
+	path, err := archivePath(destination, name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode.Perm()|0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, r); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// archivePath returns the path of the archive's entry in the destination path, the entries outside of it are rejected
+func archivePath(destination, name string) (string, error) {
+	path := filepath.Join(destination, name)
+	if path != filepath.Clean(destination) && !strings.HasPrefix(path, filepath.Clean(destination)+string(os.PathSeparator)) {
+		return "", fmt.Errorf("archive entry %s is outside of the destination path", name)
+	}
+	return path, nil
+}
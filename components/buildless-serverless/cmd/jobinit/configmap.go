@@ -0,0 +1,32 @@
+package main
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// copyConfigMap copies the files of the mounted ConfigMap to the destination path
+func copyConfigMap(c initConfig) error {
+	entries, err := os.ReadDir(c.ConfigMapPath)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		// the mounted ConfigMap keeps its files in the ..data directory, linked by the files named after its keys
+		if strings.HasPrefix(entry.Name(), "..") {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(c.ConfigMapPath, entry.Name()))
+		if err != nil {
+			return err
+		}
+		log.Printf("Copy file %s...", entry.Name())
+		if err := os.WriteFile(filepath.Join(c.DestinationPath, entry.Name()), content, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
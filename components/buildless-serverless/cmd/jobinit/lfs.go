@@ -0,0 +1,302 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/pkg/errors"
+)
+
+const (
+	lfsPointerVersion  = "version https://git-lfs.github.com/spec/v1"
+	lfsMaxPointerSize  = 1024
+	lfsMediaType       = "application/vnd.git-lfs+json"
+	lfsBatchSize       = 100
+	lfsRequestTimeout  = 10 * time.Minute
+	lfsMaxResponseSize = 10 << 20 // 10 MiB
+)
+
+type lfsObject struct {
+	OID  string `json:"oid"`
+	Size int64  `json:"size"`
+}
+
+type lfsBatchRequest struct {
+	Operation string      `json:"operation"`
+	Transfers []string    `json:"transfers"`
+	Objects   []lfsObject `json:"objects"`
+}
+
+type lfsBatchResponse struct {
+	Objects []struct {
+		lfsObject
+		Actions struct {
+			Download *struct {
+				Href   string            `json:"href"`
+				Header map[string]string `json:"header"`
+			} `json:"download"`
+		} `json:"actions"`
+		Error *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	} `json:"objects"`
+}
+
+// fetchLFSObjects replaces the LFS pointers in the base directory with the objects they point to
+func fetchLFSObjects(c initConfig, auth transport.AuthMethod) error {
+	endpoint, err := lfsEndpoint(c.RepositoryURL)
+	if err != nil {
+		return err
+	}
+
+	pointers, err := findLFSPointers(filepath.Join(c.DestinationPath, strings.Trim(c.RepositoryBaseDir, "/ ")))
+	if err != nil {
+		return err
+	}
+	if len(pointers) == 0 {
+		log.Println("No LFS pointers found")
+		return nil
+	}
+
+	objects := []lfsObject{}
+	seen := map[string]bool{}
+	for _, object := range pointers {
+		if !seen[object.OID] {
+			seen[object.OID] = true
+			objects = append(objects, object)
+		}
+	}
+
+	client := &http.Client{Timeout: lfsRequestTimeout}
+	downloaded := map[string]string{}
+	for start := 0; start < len(objects); start += lfsBatchSize {
+		batch := objects[start:min(start+lfsBatchSize, len(objects))]
+		if err := downloadLFSBatch(client, endpoint, auth, batch, c.DestinationPath, downloaded); err != nil {
+			return err
+		}
+	}
+
+	for path, object := range pointers {
+		if err := replaceLFSPointer(path, downloaded[object.OID]); err != nil {
+			return err
+		}
+	}
+	log.Printf("Fetched %d LFS objects for %d files", len(objects), len(pointers))
+	return nil
+}
+
+// lfsEndpoint returns the LFS server of the repository, it is served next to the repository by the git hosting services
+func lfsEndpoint(repoURL string) (string, error) {
+	if !strings.HasPrefix(repoURL, "https://") && !strings.HasPrefix(repoURL, "http://") {
+		return "", fmt.Errorf("LFS objects can be fetched only from HTTP(S) repositories, got '%s'", repoURL)
+	}
+	endpoint := strings.TrimSuffix(repoURL, "/")
+	if !strings.HasSuffix(endpoint, ".git") {
+		endpoint += ".git"
+	}
+	return endpoint + "/info/lfs", nil
+}
+
+// findLFSPointers returns the LFS objects pointed by the files in the directory
+func findLFSPointers(dir string) (map[string]lfsObject, error) {
+	pointers := map[string]lfsObject{}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if info.Size() > lfsMaxPointerSize {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if object, ok := parseLFSPointer(content); ok {
+			pointers[path] = object
+		}
+		return nil
+	})
+	return pointers, err
+}
+
+// parseLFSPointer parses the LFS pointer file
+// https://github.com/git-lfs/git-lfs/blob/main/docs/spec.md
+func parseLFSPointer(content []byte) (lfsObject, bool) {
+	if !bytes.HasPrefix(content, []byte(lfsPointerVersion+"\n")) {
+		return lfsObject{}, false
+	}
+
+	object := lfsObject{Size: -1}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		key, value, _ := strings.Cut(scanner.Text(), " ")
+		switch key {
+		case "oid":
+			oid, ok := strings.CutPrefix(value, "sha256:")
+			// the oid is a part of the object's path, so only the hex sha256 is accepted
+			if _, err := hex.DecodeString(oid); !ok || err != nil || len(oid) != sha256.Size*2 {
+				return lfsObject{}, false
+			}
+			object.OID = oid
+		case "size":
+			size, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return lfsObject{}, false
+			}
+			object.Size = size
+		}
+	}
+	return object, object.OID != "" && object.Size >= 0
+}
+
+func downloadLFSBatch(client *http.Client, endpoint string, auth transport.AuthMethod, objects []lfsObject, destinationPath string, downloaded map[string]string) error {
+	body, _ := json.Marshal(lfsBatchRequest{
+		Operation: "download",
+		Transfers: []string{"basic"},
+		Objects:   objects,
+	})
+	req, err := http.NewRequest(http.MethodPost, endpoint+"/objects/batch", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", lfsMediaType)
+	req.Header.Set("Content-Type", lfsMediaType)
+	if httpAuth, ok := auth.(githttp.AuthMethod); ok {
+		httpAuth.SetAuth(req)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "while requesting LFS batch")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unexpected status code %d of LFS batch: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+
+	batch := lfsBatchResponse{}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, lfsMaxResponseSize)).Decode(&batch); err != nil {
+		return errors.Wrap(err, "while parsing LFS batch")
+	}
+
+	requested := map[string]lfsObject{}
+	for _, object := range objects {
+		requested[object.OID] = object
+	}
+	for _, object := range batch.Objects {
+		// the server's response is not trusted, the object is verified against the pointer it was requested for
+		pointer, ok := requested[object.OID]
+		if !ok {
+			return fmt.Errorf("LFS batch returned not requested object %q", object.OID)
+		}
+		if object.Error != nil {
+			return fmt.Errorf("LFS object %s: %s (%d)", pointer.OID, object.Error.Message, object.Error.Code)
+		}
+		if object.Actions.Download == nil {
+			return fmt.Errorf("LFS object %s has no download action", pointer.OID)
+		}
+		// the object is downloaded out of the worktree, the files pointing to it are replaced later
+		path := filepath.Join(destinationPath, ".git", "lfs", "objects", pointer.OID)
+		err := downloadLFSObject(client, object.Actions.Download.Href, object.Actions.Download.Header, pointer, path)
+		if err != nil {
+			return errors.Wrapf(err, "while downloading LFS object %s", pointer.OID)
+		}
+		downloaded[pointer.OID] = path
+	}
+	return nil
+}
+
+func downloadLFSObject(client *http.Client, href string, header map[string]string, object lfsObject, path string) error {
+	req, err := http.NewRequest(http.MethodGet, href, nil)
+	if err != nil {
+		return err
+	}
+	// the download may be served by other host, so only the headers given by the LFS server are sent
+	for key, value := range header {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(f, hash), io.LimitReader(resp.Body, object.Size+1))
+	if err != nil {
+		return err
+	}
+	if size != object.Size {
+		return fmt.Errorf("expected %d bytes, got %d", object.Size, size)
+	}
+	if oid := hex.EncodeToString(hash.Sum(nil)); oid != object.OID {
+		return fmt.Errorf("checksum mismatch, got %s", oid)
+	}
+	return f.Close()
+}
+
+func replaceLFSPointer(path, objectPath string) error {
+	if objectPath == "" {
+		return fmt.Errorf("LFS object of %s was not downloaded", path)
+	}
+	object, err := os.Open(objectPath)
+	if err != nil {
+		return err
+	}
+	defer object.Close()
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_TRUNC, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, object); err != nil {
+		return err
+	}
+	return f.Close()
+}
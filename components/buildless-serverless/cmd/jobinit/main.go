@@ -5,11 +5,13 @@ import (
 	"log"
 	"os"
 	"regexp"
+	"strings"
 
 	"github.com/go-git/go-git/v5/plumbing/protocol/packp/capability"
 	"github.com/go-git/go-git/v5/plumbing/transport"
 	"github.com/go-git/go-git/v5/plumbing/transport/http"
 	serverlessv1alpha2 "github.com/kyma-project/serverless/components/buildless-serverless/api/v1alpha2"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/git/hostkey"
 	"github.com/kyma-project/serverless/components/common/fips"
 	"github.com/vrischmann/envconfig"
 
@@ -17,22 +19,39 @@ import (
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
 	"github.com/pkg/errors"
-	crypto_ssh "golang.org/x/crypto/ssh"
 )
 
-const envPrefix = "APP"
+const (
+	envPrefix = "APP"
+	// GitHub accepts the installation token as the password of the x-access-token user
+	gitHubAppUsername = "x-access-token"
+)
 
 var commitSHARegexp = regexp.MustCompile(`^[0-9a-fA-F]{40}$`)
 
 type initConfig struct {
-	RepositoryURL         string
-	RepositoryReference   string
-	RepositoryCommit      string
 	DestinationPath       string
+	SourceType            serverlessv1alpha2.SourceType         `envconfig:"optional"`
+	SourceDigest          string                                `envconfig:"optional"`
+	RepositoryURL         string                                `envconfig:"optional"`
+	RepositoryReference   string                                `envconfig:"optional"`
+	RepositoryCommit      string                                `envconfig:"optional"`
 	RepositoryAuthType    serverlessv1alpha2.RepositoryAuthType `envconfig:"optional"`
 	RepositoryUsername    string                                `envconfig:"optional"`
 	RepositoryPassword    string                                `envconfig:"optional"`
 	RepositoryKey         string                                `envconfig:"optional"`
+	RepositoryKnownHosts  string                                `envconfig:"optional"`
+	RepositoryToken       string                                `envconfig:"optional"`
+	RepositoryBaseDir     string                                `envconfig:"optional"`
+	RepositorySubmodules  bool                                  `envconfig:"default=false"`
+	RepositoryLFS         bool                                  `envconfig:"default=false"`
+	RepositoryShallow     bool                                  `envconfig:"default=false"`
+	RepositorySparse      bool                                  `envconfig:"default=false"`
+	OCIReference          string                                `envconfig:"optional"`
+	OCIDockerConfig       string                                `envconfig:"optional"`
+	ArchiveURL            string                                `envconfig:"optional"`
+	ArchiveChecksum       string                                `envconfig:"optional"`
+	ConfigMapPath         string                                `envconfig:"optional"`
 	IsKymaFipsModeEnabled bool                                  `envconfig:"default=false"`
 }
 
@@ -49,6 +68,15 @@ func main() {
 		panic("FIPS 140 exclusive mode is not enabled. Check GODEBUG flags.")
 	}
 
+	if cfg.SourceType != "" {
+		log.Printf("Fetch %s source with digest: %s...\n", cfg.SourceType, cfg.SourceDigest)
+		err := fetchSource(cfg)
+		failOnErr(err, fmt.Sprintf("while fetching %s source", cfg.SourceType))
+
+		log.Printf("Fetched %s source with digest: %s, to path: %s", cfg.SourceType, cfg.SourceDigest, cfg.DestinationPath)
+		return
+	}
+
 	auth, err := chooseAuth(cfg)
 	failOnErr(err, "unable to choose auth")
 
@@ -65,49 +93,176 @@ func main() {
 	log.Printf("Cloned repository: %s, from commit: %s, to path: %s", cfg.RepositoryURL, cfg.RepositoryCommit, cfg.DestinationPath)
 }
 
+func fetchSource(c initConfig) error {
+	if err := os.MkdirAll(c.DestinationPath, 0o755); err != nil {
+		return errors.Wrap(err, "while creating destination path")
+	}
+
+	switch c.SourceType {
+	case serverlessv1alpha2.SourceTypeOCIArtifact:
+		return fetchOCIArtifact(c)
+	case serverlessv1alpha2.SourceTypeHTTPArchive:
+		return fetchHTTPArchive(c)
+	case serverlessv1alpha2.SourceTypeConfigMap:
+		return copyConfigMap(c)
+	default:
+		return fmt.Errorf("unknown source type: %s", c.SourceType)
+	}
+}
+
 func clone(c initConfig, auth transport.AuthMethod) error {
+	r, err := cloneRepository(c, auth, c.RepositoryShallow)
+	if err == nil {
+		err = checkout(r, c)
+	}
+	if err != nil && c.RepositoryShallow {
+		// the reference may have moved since the controller resolved the commit,
+		// so the commit is not the tip of the shallow clone
+		log.Printf("Shallow clone failed: %s, cloning full history...", err)
+		r, err = cloneRepository(c, auth, false)
+		if err == nil {
+			err = checkout(r, c)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	if c.RepositorySubmodules {
+		log.Println("Update submodules...")
+		if err := updateSubmodules(r, c, auth); err != nil {
+			return errors.Wrap(err, "while updating submodules")
+		}
+	}
+
+	if c.RepositoryLFS {
+		log.Println("Fetch LFS objects...")
+		if err := fetchLFSObjects(c, auth); err != nil {
+			return errors.Wrap(err, "while fetching LFS objects")
+		}
+	}
+
+	return nil
+}
+
+func cloneRepository(c initConfig, auth transport.AuthMethod, shallow bool) (*git.Repository, error) {
+	if err := os.RemoveAll(c.DestinationPath); err != nil {
+		return nil, errors.Wrap(err, "while cleaning destination path")
+	}
+
 	cloneOpts := &git.CloneOptions{
 		URL:  c.RepositoryURL,
 		Auth: auth,
+		// the commit is checked out below
+		NoCheckout: true,
 	}
 
 	// For a commit SHA the controller already resolved the exact hash; clone the
 	// default branch and let the checkout below land on it.  For a named reference
 	// (branch or tag) use SingleBranch for efficiency: try refs/heads/ first,
 	// fall back to refs/tags/ if the branch clone fails.
-	if !commitSHARegexp.MatchString(c.RepositoryReference) {
+	isCommit := commitSHARegexp.MatchString(c.RepositoryReference)
+	if !isCommit {
 		cloneOpts.ReferenceName = plumbing.NewBranchReferenceName(c.RepositoryReference)
 		cloneOpts.SingleBranch = true
 	}
+	if shallow {
+		cloneOpts.Depth = 1
+	}
 
 	r, err := git.PlainClone(c.DestinationPath, false, cloneOpts)
-	if err != nil && !commitSHARegexp.MatchString(c.RepositoryReference) {
+	if err != nil && !isCommit {
 		// Branch ref not found — retry as a tag.
 		cloneOpts.ReferenceName = plumbing.NewTagReferenceName(c.RepositoryReference)
 		if err2 := os.RemoveAll(c.DestinationPath); err2 != nil {
-			return errors.Wrap(err2, "while cleaning destination path before tag retry")
+			return nil, errors.Wrap(err2, "while cleaning destination path before tag retry")
 		}
 		r, err = git.PlainClone(c.DestinationPath, false, cloneOpts)
 	}
-	if err != nil {
-		return err
+	if err != nil && !isCommit {
+		// The semver constraint or pattern reference is neither a branch nor a tag,
+		// e.g. after the rollback to the recorded commit — clone all branches.
+		log.Printf("Reference %s is neither a branch nor a tag: %s, cloning all branches...", c.RepositoryReference, err)
+		cloneOpts.ReferenceName = ""
+		cloneOpts.SingleBranch = false
+		if err2 := os.RemoveAll(c.DestinationPath); err2 != nil {
+			return nil, errors.Wrap(err2, "while cleaning destination path before full retry")
+		}
+		r, err = git.PlainClone(c.DestinationPath, false, cloneOpts)
 	}
+	return r, err
+}
 
+func checkout(r *git.Repository, c initConfig) error {
 	wt, err := r.Worktree()
 	if err != nil {
 		return err
 	}
 
-	err = wt.Checkout(&git.CheckoutOptions{
-		Hash: plumbing.NewHash(c.RepositoryCommit),
+	sparseDirs := sparseCheckoutDirectories(c)
+	if len(sparseDirs) != 0 && c.RepositorySubmodules {
+		// the submodules are listed in the .gitmodules file in the root directory
+		sparseDirs = append(sparseDirs, ".gitmodules")
+	}
+	return wt.Checkout(&git.CheckoutOptions{
+		Hash:                      plumbing.NewHash(c.RepositoryCommit),
+		SparseCheckoutDirectories: sparseDirs,
 	})
+}
+
+// sparseCheckoutDirectories returns the base directory when only it is checked out
+func sparseCheckoutDirectories(c initConfig) []string {
+	baseDir := strings.Trim(c.RepositoryBaseDir, "/ ")
+	if !c.RepositorySparse || baseDir == "" {
+		return nil
+	}
+	return []string{baseDir}
+}
+
+func updateSubmodules(r *git.Repository, c initConfig, auth transport.AuthMethod) error {
+	wt, err := r.Worktree()
+	if err != nil {
+		return err
+	}
+	submodules, err := wt.Submodules()
 	if err != nil {
 		return err
 	}
 
+	opts := &git.SubmoduleUpdateOptions{
+		Init:              true,
+		RecurseSubmodules: git.DefaultSubmoduleRecursionDepth,
+		Auth:              auth,
+	}
+	if c.RepositoryShallow {
+		opts.Depth = 1
+	}
+	for _, submodule := range submodules {
+		path := submodule.Config().Path
+		if !isInSparseCheckout(path, sparseCheckoutDirectories(c)) {
+			log.Printf("Skip submodule %s outside of base directory", path)
+			continue
+		}
+		log.Printf("Update submodule %s...", path)
+		if err := submodule.Update(opts); err != nil {
+			return errors.Wrapf(err, "while updating submodule %s", path)
+		}
+	}
 	return nil
 }
 
+func isInSparseCheckout(path string, sparseDirs []string) bool {
+	if len(sparseDirs) == 0 {
+		return true
+	}
+	for _, dir := range sparseDirs {
+		if path == dir || strings.HasPrefix(path, dir+"/") || strings.HasPrefix(dir, path+"/") {
+			return true
+		}
+	}
+	return false
+}
+
 func failOnErr(err error, msg string) {
 	if err != nil {
 		if msg != "" {
@@ -125,21 +280,24 @@ func chooseAuth(cfg initConfig) (transport.AuthMethod, error) {
 	}
 	switch cfg.RepositoryAuthType {
 	case serverlessv1alpha2.RepositoryAuthSSHKey:
-		return sshAuth([]byte(cfg.RepositoryKey), cfg.RepositoryPassword)
+		return sshAuth([]byte(cfg.RepositoryKey), cfg.RepositoryPassword, cfg.RepositoryKnownHosts)
 	case serverlessv1alpha2.RepositoryAuthBasic:
 		return basicAuth(cfg.RepositoryUsername, cfg.RepositoryPassword)
+	case serverlessv1alpha2.RepositoryAuthToken:
+		return tokenAuth(cfg.RepositoryToken)
+	case serverlessv1alpha2.RepositoryAuthGitHubApp:
+		return basicAuth(gitHubAppUsername, cfg.RepositoryToken)
 	default:
 		return nil, fmt.Errorf("unknown repository auth type: %s", cfg.RepositoryAuthType)
 	}
 }
 
-func sshAuth(sshPrivateKey []byte, sshPassword string) (transport.AuthMethod, error) {
+func sshAuth(sshPrivateKey []byte, sshPassword, knownHosts string) (transport.AuthMethod, error) {
 	auth, err := ssh.NewPublicKeys("git", sshPrivateKey, sshPassword)
 	failOnErr(err, "unable to parse private key")
 
-	// set callback to func that always returns nil while checking known hosts
-	// this disables known hosts validation
-	auth.HostKeyCallback = crypto_ssh.InsecureIgnoreHostKey()
+	auth.HostKeyCallback, err = hostkey.Callback(knownHosts)
+	failOnErr(err, "unable to parse known hosts")
 
 	return auth, nil
 }
@@ -150,3 +308,9 @@ func basicAuth(username, password string) (transport.AuthMethod, error) {
 		Password: password,
 	}, nil
 }
+
+func tokenAuth(token string) (transport.AuthMethod, error) {
+	return &http.TokenAuth{
+		Token: token,
+	}, nil
+}
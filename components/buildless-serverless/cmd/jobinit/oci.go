@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/oci"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/registry/remote"
+)
+
+const (
+	ociPullTimeout = 10 * time.Minute
+	// oras pushes the directories as the tar archives compressed with gzip, annotated to be unpacked
+	orasUnpackAnnotation = "io.deis.oras.content.unpack"
+)
+
+// fetchOCIArtifact pulls the artifact's layers and places them in the destination path
+// the layers named with the title annotation are written as the files, the other layers are extracted as tar archives
+func fetchOCIArtifact(c initConfig) error {
+	ctx, cancel := context.WithTimeout(context.Background(), ociPullTimeout)
+	defer cancel()
+
+	repo, err := oci.NewRepository(c.OCIReference, []byte(c.OCIDockerConfig))
+	if err != nil {
+		return err
+	}
+
+	desc, rc, err := repo.FetchReference(ctx, repo.Reference.ReferenceOrDefault())
+	if err != nil {
+		return errors.Wrap(err, "while fetching manifest")
+	}
+	defer rc.Close()
+	if desc.MediaType != ocispec.MediaTypeImageManifest {
+		return fmt.Errorf("unsupported manifest media type %s", desc.MediaType)
+	}
+	body, err := content.ReadAll(rc, desc)
+	if err != nil {
+		return errors.Wrap(err, "while reading manifest")
+	}
+	manifest := ocispec.Manifest{}
+	if err := json.Unmarshal(body, &manifest); err != nil {
+		return errors.Wrap(err, "while parsing manifest")
+	}
+
+	for _, layer := range manifest.Layers {
+		if err := fetchOCILayer(ctx, repo, layer, c.DestinationPath); err != nil {
+			return errors.Wrapf(err, "while fetching layer %s", layer.Digest)
+		}
+	}
+	return nil
+}
+
+func fetchOCILayer(ctx context.Context, repo *remote.Repository, layer ocispec.Descriptor, destination string) error {
+	rc, err := repo.Fetch(ctx, layer)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	vr := content.NewVerifyReader(rc, layer)
+
+	title := layer.Annotations[ocispec.AnnotationTitle]
+	if title != "" && layer.Annotations[orasUnpackAnnotation] != "true" {
+		log.Printf("Write file %s...", title)
+		err = writeArchiveFile(destination, title, 0o644, vr)
+	} else {
+		log.Printf("Extract layer %s...", layer.Digest)
+		err = extractTar(vr, destination)
+	}
+	if err != nil {
+		return err
+	}
+
+	// the tar archive may be followed by the padding, which is read to verify the layer's digest
+	if _, err := io.Copy(io.Discard, vr); err != nil {
+		return err
+	}
+	return vr.Verify()
+}
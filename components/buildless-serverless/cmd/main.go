@@ -23,6 +23,7 @@ import (
 	"log"
 	"os"
 
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
@@ -33,10 +34,12 @@ import (
 	"github.com/go-logr/zapr"
 	logconfig "github.com/kyma-project/manager-toolkit/logging/config"
 	serverlessv1alpha2 "github.com/kyma-project/serverless/components/buildless-serverless/api/v1alpha2"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/activator"
 	"github.com/kyma-project/serverless/components/buildless-serverless/internal/config"
 	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller"
 	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/git"
 	serverlessmetrics "github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/metrics"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/oci"
 	"github.com/kyma-project/serverless/components/buildless-serverless/internal/endpoint"
 	"github.com/kyma-project/serverless/components/buildless-serverless/internal/logging"
 	"github.com/kyma-project/serverless/components/common/fips"
@@ -44,10 +47,13 @@ import (
 	uberzap "go.uber.org/zap"
 	uberzapcore "go.uber.org/zap/zapcore"
 	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	ctrlzap "sigs.k8s.io/controller-runtime/pkg/log/zap"
@@ -138,6 +144,19 @@ func main() {
 			Port: cfg.SecretMutatingWebhookPort,
 		}),
 		HealthProbeBindAddress: cfg.Healthz.Port,
+		Cache: cache.Options{
+			ByObject: map[client.Object]cache.ByObject{
+				// the activator reads the pods of the functions only
+				&corev1.Pod{}: {
+					Label: labels.SelectorFromSet(labels.Set{serverlessv1alpha2.FunctionManagedByLabel: serverlessv1alpha2.FunctionControllerValue}),
+				},
+				// the services of the functions without ready pods are routed to the activator's endpoints
+				&discoveryv1.EndpointSlice{}: {
+					Namespaces: map[string]cache.Config{cfg.ScaleToZero.ActivatorNamespace: {}},
+					Label:      labels.SelectorFromSet(labels.Set{discoveryv1.LabelServiceName: cfg.ScaleToZero.ActivatorServiceName}),
+				},
+			},
+		},
 		Client: client.Options{
 			Cache: &client.CacheOptions{
 				DisableFor: []client.Object{
@@ -166,13 +185,17 @@ func main() {
 		os.Exit(1)
 	}
 
+	gitChecker := git.NewAsyncLatestCommitChecker(ctx, logWithCtx, cfg.GitRemote)
 	fnCtrl, err := (&controller.FunctionReconciler{
 		Client:                mgr.GetClient(),
 		Scheme:                mgr.GetScheme(),
 		Log:                   logWithCtx,
 		Config:                cfg,
 		EventRecorder:         mgr.GetEventRecorderFor(serverlessv1alpha2.FunctionControllerValue),
-		GitChecker:            git.NewAsyncLatestCommitChecker(ctx, logWithCtx),
+		GitChecker:            gitChecker,
+		SourceTrees:           git.NewSourceTrees(),
+		CommitVerifier:        git.NewCommitVerifier(),
+		OCIResolver:           oci.NewResolver(cfg.OCIRemote.DigestCacheTTL),
 		HealthCh:              healthResponseCh,
 		IsKymaFipsModeEnabled: envCfg.KymaFipsModeEnabled,
 	}).SetupWithManager(mgr)
@@ -180,6 +203,14 @@ func main() {
 		setupLog.Error(err, "unable to create controller", "controller", "Function")
 		os.Exit(1)
 	}
+
+	if err := (&controller.FunctionRuntimeReconciler{
+		Client: mgr.GetClient(),
+		Log:    logWithCtx.Named("functionruntime"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "FunctionRuntime")
+		os.Exit(1)
+	}
 	// +kubebuilder:scaffold:builder
 
 	err = fnCtrl.Watch(source.Channel(healthEventsCh, &handler.EnqueueRequestForObject{}))
@@ -188,10 +219,21 @@ func main() {
 		os.Exit(1)
 	}
 
+	gitWebhookEventsCh := make(chan event.GenericEvent)
+	err = fnCtrl.Watch(source.Channel(gitWebhookEventsCh, &handler.EnqueueRequestForObject{}))
+	if err != nil {
+		setupLog.Error(err, "unable to watch git webhook events channel")
+		os.Exit(1)
+	}
+
 	// disable default log to prevent http server from logging returned status codes
 	log.SetOutput(io.Discard)
 
-	internalServer := endpoint.NewInternalServer(ctx, logWithCtx, mgr.GetClient(), cfg, envCfg.KymaFipsModeEnabled)
+	if err := endpoint.IndexGitWebhookFunctions(ctx, mgr.GetFieldIndexer()); err != nil {
+		setupLog.Error(err, "unable to index functions for git webhook")
+		os.Exit(1)
+	}
+	internalServer := endpoint.NewInternalServer(ctx, logWithCtx, mgr.GetClient(), mgr.GetCache(), cfg, envCfg.KymaFipsModeEnabled, gitChecker, gitWebhookEventsCh)
 	go func() {
 		err := internalServer.ListenAndServe(cfg.InternalEndpointPort)
 		if err != nil {
@@ -199,6 +241,16 @@ func main() {
 		}
 	}()
 
+	if err := activator.IndexFunctions(ctx, mgr.GetFieldIndexer()); err != nil {
+		setupLog.Error(err, "unable to index functions for activator")
+		os.Exit(1)
+	}
+	functionActivator := activator.NewActivator(ctx, logWithCtx.Named("activator"), mgr.GetClient(), mgr.GetCache(), cfg.ScaleToZero)
+	if err := mgr.Add(functionActivator); err != nil {
+		setupLog.Error(err, "unable to add activator")
+		os.Exit(1)
+	}
+
 	setupLog.Info("starting manager")
 	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
 		setupLog.Error(err, "problem running manager")
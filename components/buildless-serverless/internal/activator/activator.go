@@ -0,0 +1,538 @@
+package activator
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	serverlessv1alpha2 "github.com/kyma-project/serverless/components/buildless-serverless/api/v1alpha2"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/config"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch
+
+const (
+	// functionPort is the port the function's runtime listens on
+	functionPort = "8080"
+	// functionServicePort is the port of the function's service
+	functionServicePort = "80"
+	// activatorPortIndex indexes the functions by the activator's port they are reached through
+	activatorPortIndex = "status.activatorPort"
+	// callsMetricName is the counter of the function's calls exposed by the function's runtime
+	callsMetricName = "function_calls_total"
+	metricsTimeout  = 5 * time.Second
+	// listenersSyncInterval is the interval of opening the ports assigned to new functions
+	listenersSyncInterval = time.Second
+)
+
+var errFunctionNotReady = errors.New("function is not ready")
+
+// Activator receives requests sent to scale-to-zero functions without ready pods.
+// Each function is reached through its own port of the activator, the activator records the function's activity,
+// wakes the function up, buffers the request until the function is ready and forwards it to the function's service.
+// The requests sent to the running functions are not received by the activator,
+// so it reads their activity from the calls counted by the function's runtime.
+type Activator struct {
+	ctx                   context.Context
+	k8s                   client.Client
+	reader                client.Reader
+	log                   *zap.SugaredLogger
+	config                config.ScaleToZeroConfig
+	pollInterval          time.Duration
+	listenersSyncInterval time.Duration
+	listenHost            string
+	servers               map[int32]*http.Server
+	functionPort          string
+	serviceAddress        func(f *serverlessv1alpha2.Function) string
+	transport             http.RoundTripper
+	metricsClient         *http.Client
+	lastRecorded          sync.Map
+	observedCalls         map[types.UID]float64
+}
+
+// NewActivator returns the activator reading the functions and their pods with the reader, it should be the manager's cache
+func NewActivator(ctx context.Context, log *zap.SugaredLogger, k8s client.Client, reader client.Reader, config config.ScaleToZeroConfig) *Activator {
+	a := &Activator{
+		ctx:                   ctx,
+		k8s:                   k8s,
+		reader:                reader,
+		log:                   log,
+		config:                config,
+		pollInterval:          500 * time.Millisecond,
+		listenersSyncInterval: listenersSyncInterval,
+		servers:               map[int32]*http.Server{},
+		functionPort:          functionPort,
+		serviceAddress:        functionServiceAddress,
+		metricsClient:         &http.Client{Timeout: metricsTimeout},
+		observedCalls:         map[types.UID]float64{},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = a.dialUntilAccepted((&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).DialContext)
+	a.transport = transport
+	return a
+}
+
+// IndexFunctions indexes the functions by the activator's port they are reached through
+func IndexFunctions(ctx context.Context, indexer client.FieldIndexer) error {
+	return indexer.IndexField(ctx, &serverlessv1alpha2.Function{}, activatorPortIndex, activatorPortIndexer)
+}
+
+func activatorPortIndexer(obj client.Object) []string {
+	port := obj.(*serverlessv1alpha2.Function).Status.ActivatorPort
+	if port == 0 {
+		return nil
+	}
+	return []string{strconv.Itoa(int(port))}
+}
+
+// Start listens on the activator's ports assigned to the functions and watches the activity of the running functions until the context is done
+func (a *Activator) Start(ctx context.Context) error {
+	ports := a.config.ActivatorPorts
+	if ports.Size() == 0 {
+		return fmt.Errorf("invalid activator ports range from %d to %d", ports.Min, ports.Max)
+	}
+
+	go a.watchActivity(ctx)
+
+	ticker := time.NewTicker(a.listenersSyncInterval)
+	defer ticker.Stop()
+	defer a.closeListeners()
+	for {
+		a.syncListeners(ctx)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// NeedLeaderElection returns false, the activator serves the requests in all replicas of the controller
+func (a *Activator) NeedLeaderElection() bool {
+	return false
+}
+
+// syncListeners listens only on the ports assigned to the functions, so the unused ports of the range aren't opened.
+// The port which can't be listened on is retried with the next sync.
+func (a *Activator) syncListeners(ctx context.Context) {
+	functions := &serverlessv1alpha2.FunctionList{}
+	if err := a.reader.List(ctx, functions, client.UnsafeDisableDeepCopy); err != nil {
+		a.log.Warnf("can't list functions to sync activator's listeners: %s", err.Error())
+		return
+	}
+
+	assignedPorts := map[int32]bool{}
+	for _, f := range functions.Items {
+		if a.config.ActivatorPorts.Contains(f.Status.ActivatorPort) {
+			assignedPorts[f.Status.ActivatorPort] = true
+		}
+	}
+
+	for port, server := range a.servers {
+		if !assignedPorts[port] {
+			_ = server.Close()
+			delete(a.servers, port)
+		}
+	}
+	for port := range assignedPorts {
+		if _, ok := a.servers[port]; ok {
+			continue
+		}
+		listener, err := net.Listen("tcp", net.JoinHostPort(a.listenHost, strconv.Itoa(int(port))))
+		if err != nil {
+			a.log.Warnf("can't listen on activator's port %d: %s", port, err.Error())
+			continue
+		}
+		server := &http.Server{Handler: a.handler(port)}
+		a.servers[port] = server
+		go func() {
+			if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				a.log.Errorf("activator's port %d stopped serving: %s", port, err.Error())
+			}
+		}()
+	}
+}
+
+func (a *Activator) closeListeners() {
+	for port, server := range a.servers {
+		_ = server.Close()
+		delete(a.servers, port)
+	}
+}
+
+func (a *Activator) handler(port int32) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		a.serve(w, r, port)
+	})
+}
+
+func (a *Activator) serve(w http.ResponseWriter, r *http.Request, port int32) {
+	ctx, cancel := context.WithTimeout(r.Context(), a.config.ActivationTimeout)
+	defer cancel()
+
+	function, err := a.functionForPort(ctx, port)
+	if err != nil {
+		a.log.Warnf("can't resolve function for port %d: %s", port, err.Error())
+		http.Error(w, fmt.Sprintf("function for port %d not found", port), http.StatusNotFound)
+		return
+	}
+	key := client.ObjectKeyFromObject(function)
+	log := a.log.With("function", key.String())
+
+	if err := a.recordActivity(ctx, function, false); err != nil {
+		// the request can be served anyway, the activity will be recorded with the next one
+		log.Warnf("can't record function activity: %s", err.Error())
+	}
+
+	if err := a.waitForFunction(ctx, function); err != nil {
+		log.Errorf("function not activated: %s", err.Error())
+		http.Error(w, fmt.Sprintf("function %s not ready", key), http.StatusGatewayTimeout)
+		return
+	}
+
+	a.forward(w, r, function)
+}
+
+// functionForPort returns the function reached through the activator's port,
+// the port is assigned to the function by the controller and the function's service is routed to it
+func (a *Activator) functionForPort(ctx context.Context, port int32) (*serverlessv1alpha2.Function, error) {
+	functions, err := FunctionsReachedThroughPort(ctx, a.reader, port)
+	if err != nil {
+		return nil, err
+	}
+	if len(functions) != 1 {
+		return nil, fmt.Errorf("found %d functions reached through the port", len(functions))
+	}
+	return &functions[0], nil
+}
+
+// FunctionsReachedThroughPort lists the functions the activator's port is assigned to,
+// the reader must serve the index registered by IndexFunctions
+func FunctionsReachedThroughPort(ctx context.Context, reader client.Reader, port int32) ([]serverlessv1alpha2.Function, error) {
+	functions := &serverlessv1alpha2.FunctionList{}
+	err := reader.List(ctx, functions, client.MatchingFields{activatorPortIndex: strconv.Itoa(int(port))})
+	if err != nil {
+		return nil, errors.Wrap(err, "while listing functions")
+	}
+	return functions.Items, nil
+}
+
+// recordActivity sets the last activity annotation on the function.
+// To not overload the api-server the annotation is updated only once per quarter of the idle window, unless forced.
+func (a *Activator) recordActivity(ctx context.Context, f *serverlessv1alpha2.Function, force bool) error {
+	key := client.ObjectKeyFromObject(f).String()
+	now := time.Now()
+	if lastRecorded, ok := a.lastRecorded.Load(key); ok && !force &&
+		now.Sub(lastRecorded.(time.Time)) < a.config.IdleWindow/4 {
+		return nil
+	}
+
+	patch := client.MergeFrom(f.DeepCopy())
+	annotations := f.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[serverlessv1alpha2.FunctionLastActivityAnnotation] = now.UTC().Format(time.RFC3339)
+	f.SetAnnotations(annotations)
+	if err := a.k8s.Patch(ctx, f, patch); err != nil {
+		return errors.Wrap(err, "while patching function")
+	}
+
+	a.lastRecorded.Store(key, now)
+	return nil
+}
+
+// waitForFunction returns when the function's service is routed to its ready pods.
+// When the function is scaled to zero it's woken up and the request is held until the function is ready.
+func (a *Activator) waitForFunction(ctx context.Context, f *serverlessv1alpha2.Function) error {
+	err := a.functionServiceReady(ctx, f)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, errFunctionNotReady) {
+		return err
+	}
+
+	a.log.With("function", client.ObjectKeyFromObject(f).String()).Info("waking up function")
+	if err := a.recordActivity(ctx, f, true); err != nil {
+		return errors.Wrap(err, "while waking up function")
+	}
+
+	ticker := time.NewTicker(a.pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-a.ctx.Done():
+			return a.ctx.Err()
+		case <-ticker.C:
+			err := a.activatedFunctionServiceReady(ctx, f)
+			if err == nil {
+				return nil
+			}
+			if !errors.Is(err, errFunctionNotReady) {
+				return err
+			}
+		}
+	}
+}
+
+// activatedFunctionServiceReady checks the function's service once the controller reports the function as ready
+func (a *Activator) activatedFunctionServiceReady(ctx context.Context, f *serverlessv1alpha2.Function) error {
+	function := &serverlessv1alpha2.Function{}
+	if err := a.reader.Get(ctx, client.ObjectKeyFromObject(f), function); err != nil {
+		return errors.Wrap(err, "while getting function")
+	}
+	if !isFunctionReady(function) {
+		return errFunctionNotReady
+	}
+	return a.functionServiceReady(ctx, function)
+}
+
+// functionServiceReady checks that the function has ready pods and the controller routed its service back to them,
+// otherwise the request forwarded to the service would be received by the activator again
+func (a *Activator) functionServiceReady(ctx context.Context, f *serverlessv1alpha2.Function) error {
+	readyPods, err := a.readyPods(ctx, f)
+	if err != nil {
+		return err
+	}
+	if len(readyPods) == 0 {
+		return errFunctionNotReady
+	}
+
+	service := &corev1.Service{}
+	if err := a.reader.Get(ctx, client.ObjectKeyFromObject(f), service); err != nil {
+		if apierrors.IsNotFound(err) {
+			return errFunctionNotReady
+		}
+		return errors.Wrap(err, "while getting function service")
+	}
+	if service.GetAnnotations()[serverlessv1alpha2.ServiceActivatorPortAnnotation] != "" {
+		return errFunctionNotReady
+	}
+	return nil
+}
+
+func (a *Activator) readyPods(ctx context.Context, f *serverlessv1alpha2.Function) ([]corev1.Pod, error) {
+	pods := &corev1.PodList{}
+	err := a.reader.List(ctx, pods, client.InNamespace(f.GetNamespace()), client.MatchingLabels(f.SelectorLabels()))
+	if err != nil {
+		return nil, errors.Wrap(err, "while listing function pods")
+	}
+
+	var readyPods []corev1.Pod
+	for _, pod := range pods.Items {
+		if isPodReady(pod) {
+			readyPods = append(readyPods, pod)
+		}
+	}
+	return readyPods, nil
+}
+
+// forward sends the request to the function's service, so it's load balanced and passes the mesh like any other request to the function
+func (a *Activator) forward(w http.ResponseWriter, r *http.Request, f *serverlessv1alpha2.Function) {
+	target := &url.URL{
+		Scheme: "http",
+		Host:   a.serviceAddress(f),
+	}
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(target)
+			pr.SetXForwarded()
+			// keep the original host the function was called with
+			pr.Out.Host = pr.In.Host
+		},
+		Transport: a.transport,
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			a.log.Errorf("can't forward request to %s: %s", target.Host, err.Error())
+			w.WriteHeader(http.StatusBadGateway)
+		},
+	}
+	proxy.ServeHTTP(w, r)
+}
+
+// dialUntilAccepted retries the refused connections until the request's context is done,
+// the service's endpoints may not be programmed yet right after the service is routed back to the function's pods
+func (a *Activator) dialUntilAccepted(dial func(ctx context.Context, network, address string) (net.Conn, error)) func(ctx context.Context, network, address string) (net.Conn, error) {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		for {
+			conn, err := dial(ctx, network, address)
+			if err == nil || !errors.Is(err, syscall.ECONNREFUSED) {
+				return conn, err
+			}
+			select {
+			case <-ctx.Done():
+				return nil, err
+			case <-time.After(a.pollInterval):
+			}
+		}
+	}
+}
+
+// functionServiceAddress returns the in-cluster address of the function's service
+func functionServiceAddress(f *serverlessv1alpha2.Function) string {
+	return net.JoinHostPort(fmt.Sprintf("%s.%s.svc", f.GetName(), f.GetNamespace()), functionServicePort)
+}
+
+func (a *Activator) watchActivity(ctx context.Context) {
+	ticker := time.NewTicker(a.config.ActivityCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			a.checkActivity(ctx)
+		}
+	}
+}
+
+// checkActivity records the activity of the running scale-to-zero functions whose pods counted new calls since the last check
+func (a *Activator) checkActivity(ctx context.Context) {
+	functions := &serverlessv1alpha2.FunctionList{}
+	if err := a.reader.List(ctx, functions); err != nil {
+		a.log.Warnf("can't list functions to check their activity: %s", err.Error())
+		return
+	}
+
+	observedCalls := map[types.UID]float64{}
+	for i := range functions.Items {
+		f := &functions.Items[i]
+		if !f.IsScaleToZeroEnabled() {
+			continue
+		}
+		log := a.log.With("function", client.ObjectKeyFromObject(f).String())
+
+		pods, err := a.readyPods(ctx, f)
+		if err != nil {
+			log.Warnf("can't check function activity: %s", err.Error())
+			continue
+		}
+		active := false
+		for _, pod := range pods {
+			calls, err := a.podCalls(ctx, pod.Status.PodIP)
+			if err != nil {
+				log.Debugf("can't read calls of pod %s: %s", pod.GetName(), err.Error())
+				continue
+			}
+			// the calls counted before the first check of the pod are not known to be recent
+			if lastCalls, ok := a.observedCalls[pod.GetUID()]; ok && calls > lastCalls {
+				active = true
+			}
+			observedCalls[pod.GetUID()] = calls
+		}
+		if !active {
+			continue
+		}
+		if err := a.recordActivity(ctx, f, false); err != nil {
+			log.Warnf("can't record function activity: %s", err.Error())
+		}
+	}
+	// the pods which are gone are forgotten
+	a.observedCalls = observedCalls
+}
+
+// podCalls returns the number of calls counted by the runtime of the function's pod
+func (a *Activator) podCalls(ctx context.Context, podIP string) (float64, error) {
+	metricsURL := url.URL{
+		Scheme: "http",
+		Host:   net.JoinHostPort(podIP, a.functionPort),
+		Path:   "/metrics",
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, metricsURL.String(), nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := a.metricsClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return parseCalls(resp.Body)
+}
+
+// parseCalls sums the calls counter's samples of all methods from the metrics in the Prometheus text format
+func parseCalls(metrics io.Reader) (float64, error) {
+	calls := 0.0
+	found := false
+	scanner := bufio.NewScanner(metrics)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name := line
+		if i := strings.IndexAny(line, "{ "); i >= 0 {
+			name = line[:i]
+		}
+		if name != callsMetricName {
+			continue
+		}
+		// the sample is the value following the labels, it may be followed by the timestamp
+		sampleAndTimestamp := line[len(name):]
+		if strings.HasPrefix(sampleAndTimestamp, "{") {
+			sampleAndTimestamp = sampleAndTimestamp[strings.LastIndex(sampleAndTimestamp, "}")+1:]
+		}
+		fields := strings.Fields(sampleAndTimestamp)
+		if len(fields) == 0 {
+			continue
+		}
+		sample, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			return 0, errors.Wrapf(err, "while parsing %s", callsMetricName)
+		}
+		calls += sample
+		found = true
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	if !found {
+		return 0, fmt.Errorf("metric %s not found", callsMetricName)
+	}
+	return calls, nil
+}
+
+// isFunctionReady returns true when the function's deployment has been reported as ready by the controller
+func isFunctionReady(f *serverlessv1alpha2.Function) bool {
+	condition := meta.FindStatusCondition(f.Status.Conditions, string(serverlessv1alpha2.ConditionRunning))
+	return condition != nil &&
+		condition.Status == metav1.ConditionTrue &&
+		condition.Reason == string(serverlessv1alpha2.ConditionReasonDeploymentReady)
+}
+
+func isPodReady(pod corev1.Pod) bool {
+	if pod.DeletionTimestamp != nil || pod.Status.PodIP == "" {
+		return false
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
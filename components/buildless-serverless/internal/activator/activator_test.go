@@ -0,0 +1,407 @@
+package activator
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	serverlessv1alpha2 "github.com/kyma-project/serverless/components/buildless-serverless/api/v1alpha2"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/config"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestActivator_serve(t *testing.T) {
+	t.Run("forward request to ready pod", func(t *testing.T) {
+		backend, backendPort := fixBackend(t)
+		defer backend.Close()
+
+		f := fixFunction(serverlessv1alpha2.ConditionReasonDeploymentReady)
+		k8sClient := fixClientBuilder(t).WithObjects(f, fixReadyPod(f, "127.0.0.1"), fixService(f, false)).Build()
+		a := fixActivator(k8sClient, backendPort)
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "http://test-function.test-namespace.svc.cluster.local/path", nil)
+		a.handler(20001).ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "test-function.test-namespace.svc.cluster.local /path", w.Body.String())
+		updatedFunction := &serverlessv1alpha2.Function{}
+		require.NoError(t, k8sClient.Get(context.Background(), client.ObjectKeyFromObject(f), updatedFunction))
+		require.Contains(t, updatedFunction.GetAnnotations(), serverlessv1alpha2.FunctionLastActivityAnnotation)
+	})
+	t.Run("wake up function scaled to zero", func(t *testing.T) {
+		backend, backendPort := fixBackend(t)
+		defer backend.Close()
+
+		f := fixFunction(serverlessv1alpha2.ConditionReasonScaledToZero)
+		k8sClient := fixClientBuilder(t).
+			WithStatusSubresource(&serverlessv1alpha2.Function{}).
+			WithObjects(f, fixService(f, true)).
+			WithInterceptorFuncs(interceptor.Funcs{
+				Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+					// simulate the controller scaling the function up and routing its service back to its pods
+					if err := c.Patch(ctx, obj, patch, opts...); err != nil {
+						return err
+					}
+					function := obj.(*serverlessv1alpha2.Function)
+					function.UpdateCondition(serverlessv1alpha2.ConditionRunning, metav1.ConditionTrue, serverlessv1alpha2.ConditionReasonDeploymentReady, "")
+					if err := c.Status().Update(ctx, function); err != nil {
+						return err
+					}
+					if err := c.Update(ctx, fixService(function, false)); err != nil {
+						return err
+					}
+					return c.Create(ctx, fixReadyPod(function, "127.0.0.1"))
+				},
+			}).Build()
+		a := fixActivator(k8sClient, backendPort)
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "http://test-function.test-namespace/", nil)
+		a.handler(20001).ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "test-function.test-namespace /", w.Body.String())
+	})
+	t.Run("retry refused connections to service", func(t *testing.T) {
+		port := fixFreePort(t)
+		backend := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.WriteString(w, r.Host+" "+r.URL.Path)
+		}))
+		defer backend.Close()
+		go func() {
+			// the service's endpoints are programmed after the request is forwarded
+			time.Sleep(100 * time.Millisecond)
+			listener, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(int(port))))
+			if err != nil {
+				return
+			}
+			backend.Listener = listener
+			backend.Start()
+		}()
+
+		f := fixFunction(serverlessv1alpha2.ConditionReasonDeploymentReady)
+		k8sClient := fixClientBuilder(t).WithObjects(f, fixReadyPod(f, "127.0.0.1"), fixService(f, false)).Build()
+		a := fixActivator(k8sClient, strconv.Itoa(int(port)))
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "http://test-function.test-namespace/path", nil)
+		a.handler(20001).ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "test-function.test-namespace /path", w.Body.String())
+	})
+	t.Run("timeout when function service is still routed to activator", func(t *testing.T) {
+		f := fixFunction(serverlessv1alpha2.ConditionReasonDeploymentReady)
+		k8sClient := fixClientBuilder(t).WithObjects(f, fixReadyPod(f, "127.0.0.1"), fixService(f, true)).Build()
+		a := fixActivator(k8sClient, "8080")
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "http://test-function.test-namespace/", nil)
+		a.handler(20001).ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusGatewayTimeout, w.Code)
+	})
+	t.Run("timeout when function is not activated", func(t *testing.T) {
+		f := fixFunction(serverlessv1alpha2.ConditionReasonScaledToZero)
+		k8sClient := fixClientBuilder(t).WithObjects(f).Build()
+		a := fixActivator(k8sClient, "8080")
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "http://test-function.test-namespace/", nil)
+		a.handler(20001).ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusGatewayTimeout, w.Code)
+	})
+	t.Run("function not found for port", func(t *testing.T) {
+		f := fixFunction(serverlessv1alpha2.ConditionReasonDeploymentReady)
+		k8sClient := fixClientBuilder(t).WithObjects(f).Build()
+		a := fixActivator(k8sClient, "8080")
+
+		w := httptest.NewRecorder()
+		// the host of another function doesn't matter, the function is recognized by the port
+		r := httptest.NewRequest(http.MethodGet, "http://test-function.test-namespace/", nil)
+		a.handler(20002).ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestActivator_syncListeners(t *testing.T) {
+	port := fixFreePort(t)
+	f := fixFunction(serverlessv1alpha2.ConditionReasonScaledToZero)
+	f.Status.ActivatorPort = port
+	k8sClient := fixClientBuilder(t).WithStatusSubresource(&serverlessv1alpha2.Function{}).WithObjects(f).Build()
+	a := fixActivator(k8sClient, "8080")
+	a.config.ActivatorPorts = config.PortRange{Min: port, Max: port}
+	defer a.closeListeners()
+
+	t.Run("listen on port assigned to function", func(t *testing.T) {
+		a.syncListeners(context.Background())
+
+		require.Len(t, a.servers, 1)
+		require.Contains(t, a.servers, port)
+		conn, err := net.Dial("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(int(port))))
+		require.NoError(t, err)
+		_ = conn.Close()
+	})
+	t.Run("close port released by function", func(t *testing.T) {
+		f.Status.ActivatorPort = 0
+		require.NoError(t, k8sClient.Status().Update(context.Background(), f))
+
+		a.syncListeners(context.Background())
+
+		require.Empty(t, a.servers)
+		require.Eventually(t, func() bool {
+			conn, err := net.Dial("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(int(port))))
+			if err == nil {
+				_ = conn.Close()
+			}
+			return err != nil
+		}, time.Second, 10*time.Millisecond)
+	})
+}
+
+func TestActivator_checkActivity(t *testing.T) {
+	t.Run("record activity when running function counts new calls", func(t *testing.T) {
+		calls := 3
+		backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = fmt.Fprintf(w, "function_calls_total{method=\"GET\"} %d\n", calls)
+		}))
+		defer backend.Close()
+		backendPort := fixPort(t, backend)
+
+		f := fixFunction(serverlessv1alpha2.ConditionReasonDeploymentReady)
+		k8sClient := fixClientBuilder(t).WithObjects(f, fixReadyPod(f, "127.0.0.1")).Build()
+		a := fixActivator(k8sClient, backendPort)
+
+		// the calls counted before the first check are not recent
+		a.checkActivity(context.Background())
+		requireNoActivity(t, k8sClient, f)
+
+		// no new calls
+		a.checkActivity(context.Background())
+		requireNoActivity(t, k8sClient, f)
+
+		calls = 4
+		a.checkActivity(context.Background())
+		updatedFunction := &serverlessv1alpha2.Function{}
+		require.NoError(t, k8sClient.Get(context.Background(), client.ObjectKeyFromObject(f), updatedFunction))
+		require.WithinDuration(t, time.Now(), updatedFunction.LastActivityTime(), 2*time.Second)
+	})
+	t.Run("skip pods without calls metric", func(t *testing.T) {
+		backend, backendPort := fixBackend(t)
+		defer backend.Close()
+
+		f := fixFunction(serverlessv1alpha2.ConditionReasonDeploymentReady)
+		k8sClient := fixClientBuilder(t).WithObjects(f, fixReadyPod(f, "127.0.0.1")).Build()
+		a := fixActivator(k8sClient, backendPort)
+
+		a.checkActivity(context.Background())
+		a.checkActivity(context.Background())
+
+		requireNoActivity(t, k8sClient, f)
+		require.Empty(t, a.observedCalls)
+	})
+}
+
+func Test_parseCalls(t *testing.T) {
+	tests := []struct {
+		name    string
+		metrics string
+		want    float64
+		wantErr bool
+	}{
+		{
+			name: "sum calls of all methods",
+			metrics: `# HELP function_calls_total Number of calls to user function
+# TYPE function_calls_total counter
+function_calls_total{method="GET"} 3.0
+function_calls_total{method="POST"} 2.0
+function_calls_created{method="GET"} 1.7e+09
+function_failures_total{method="GET"} 1.0
+`,
+			want: 5,
+		},
+		{
+			name:    "calls without labels and with timestamp",
+			metrics: "function_calls_total 7 1700000000000\n",
+			want:    7,
+		},
+		{
+			name:    "label value with brace",
+			metrics: `function_calls_total{path="/{id}"} 2` + "\n",
+			want:    2,
+		},
+		{
+			name:    "missing calls",
+			metrics: "function_failures_total 1\n",
+			wantErr: true,
+		},
+		{
+			name:    "invalid calls",
+			metrics: "function_calls_total many\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseCalls(strings.NewReader(tt.metrics))
+
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestActivator_recordActivity(t *testing.T) {
+	t.Run("record activity once per quarter of idle window", func(t *testing.T) {
+		patches := 0
+		f := fixFunction(serverlessv1alpha2.ConditionReasonDeploymentReady)
+		k8sClient := fake.NewClientBuilder().WithScheme(minimalScheme(t)).WithObjects(f).
+			WithInterceptorFuncs(interceptor.Funcs{
+				Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+					patches++
+					return c.Patch(ctx, obj, patch, opts...)
+				},
+			}).Build()
+		a := fixActivator(k8sClient, "8080")
+
+		require.NoError(t, a.recordActivity(context.Background(), f, false))
+		require.NoError(t, a.recordActivity(context.Background(), f, false))
+		require.Equal(t, 1, patches)
+
+		require.NoError(t, a.recordActivity(context.Background(), f, true))
+		require.Equal(t, 2, patches)
+
+		updatedFunction := &serverlessv1alpha2.Function{}
+		require.NoError(t, k8sClient.Get(context.Background(), client.ObjectKeyFromObject(f), updatedFunction))
+		lastActivity := updatedFunction.LastActivityTime()
+		require.WithinDuration(t, time.Now(), lastActivity, 2*time.Second)
+	})
+}
+
+func fixActivator(k8sClient client.Client, port string) *Activator {
+	a := NewActivator(context.Background(), zap.NewNop().Sugar(), k8sClient, k8sClient, config.ScaleToZeroConfig{
+		IdleWindow:        time.Hour,
+		ActivationTimeout: time.Second,
+		ActivatorPorts:    config.PortRange{Min: 20000, Max: 20099},
+	})
+	a.pollInterval = 10 * time.Millisecond
+	a.listenHost = "127.0.0.1"
+	a.functionPort = port
+	a.serviceAddress = func(_ *serverlessv1alpha2.Function) string {
+		return net.JoinHostPort("127.0.0.1", port)
+	}
+	return a
+}
+
+func fixFreePort(t *testing.T) int32 {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	return int32(listener.Addr().(*net.TCPAddr).Port)
+}
+
+func fixBackend(t *testing.T) (*httptest.Server, string) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.Host+" "+r.URL.Path)
+	}))
+	return backend, fixPort(t, backend)
+}
+
+func fixPort(t *testing.T, server *httptest.Server) string {
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	_, port, err := net.SplitHostPort(u.Host)
+	require.NoError(t, err)
+	return port
+}
+
+func fixClientBuilder(t *testing.T) *fake.ClientBuilder {
+	return fake.NewClientBuilder().WithScheme(minimalScheme(t)).
+		WithIndex(&serverlessv1alpha2.Function{}, activatorPortIndex, activatorPortIndexer)
+}
+
+func requireNoActivity(t *testing.T, k8sClient client.Client, f *serverlessv1alpha2.Function) {
+	updatedFunction := &serverlessv1alpha2.Function{}
+	require.NoError(t, k8sClient.Get(context.Background(), client.ObjectKeyFromObject(f), updatedFunction))
+	require.NotContains(t, updatedFunction.GetAnnotations(), serverlessv1alpha2.FunctionLastActivityAnnotation)
+}
+
+func fixFunction(reason serverlessv1alpha2.ConditionReason) *serverlessv1alpha2.Function {
+	f := &serverlessv1alpha2.Function{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-function",
+			Namespace: "test-namespace",
+			UID:       "test-uid",
+		},
+		Spec: serverlessv1alpha2.FunctionSpec{
+			ScaleConfig: &serverlessv1alpha2.ScaleConfig{
+				MinReplicas: new(int32),
+			},
+		},
+		Status: serverlessv1alpha2.FunctionStatus{
+			ActivatorPort: 20001,
+		},
+	}
+	f.UpdateCondition(serverlessv1alpha2.ConditionRunning, metav1.ConditionTrue, reason, "")
+	return f
+}
+
+func fixReadyPod(f *serverlessv1alpha2.Function, ip string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-function-pod",
+			Namespace: f.GetNamespace(),
+			Labels:    f.SelectorLabels(),
+		},
+		Status: corev1.PodStatus{
+			PodIP: ip,
+			Conditions: []corev1.PodCondition{
+				{Type: corev1.PodReady, Status: corev1.ConditionTrue},
+			},
+		},
+	}
+}
+
+func fixService(f *serverlessv1alpha2.Function, routedToActivator bool) *corev1.Service {
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      f.GetName(),
+			Namespace: f.GetNamespace(),
+		},
+	}
+	if routedToActivator {
+		service.SetAnnotations(map[string]string{
+			serverlessv1alpha2.ServiceActivatorPortAnnotation: strconv.Itoa(int(f.Status.ActivatorPort)),
+		})
+	}
+	return service
+}
+
+func minimalScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	require.NoError(t, serverlessv1alpha2.AddToScheme(scheme))
+	require.NoError(t, corev1.AddToScheme(scheme))
+	return scheme
+}
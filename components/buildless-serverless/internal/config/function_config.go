@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"slices"
 	"time"
 
 	"github.com/pkg/errors"
@@ -18,14 +19,22 @@ type FunctionConfig struct {
 	LeaderElectionID                string `yaml:"leaderElectionID"`
 	SecretMutatingWebhookPort       int    `yaml:"secretMutatingWebhookPort"`
 	Healthz                         healthzConfig
-	Images                          ImagesConfig   `yaml:"images"`
-	RequeueDuration                 time.Duration  `yaml:"requeueDuration"`
-	FunctionReadyRequeueDuration    time.Duration  `yaml:"functionReadyRequeueDuration"`
-	PackageRegistryConfigSecretName string         `yaml:"packageRegistryConfigSecretName"`
-	FunctionTraceCollectorEndpoint  string         `yaml:"functionTraceCollectorEndpoint"`
-	FunctionPublisherProxyAddress   string         `yaml:"functionPublisherProxyAddress"`
-	ResourceConfig                  ResourceConfig `yaml:"resourcesConfiguration"`
-	InternalEndpointPort            string         `yaml:"internalEndpointPort"`
+	Images                          ImagesConfig          `yaml:"images"`
+	RequeueDuration                 time.Duration         `yaml:"requeueDuration"`
+	FunctionReadyRequeueDuration    time.Duration         `yaml:"functionReadyRequeueDuration"`
+	PackageRegistryConfigSecretName string                `yaml:"packageRegistryConfigSecretName"`
+	FunctionTraceCollectorEndpoint  string                `yaml:"functionTraceCollectorEndpoint"`
+	FunctionPublisherProxyAddress   string                `yaml:"functionPublisherProxyAddress"`
+	ResourceConfig                  ResourceConfig        `yaml:"resourcesConfiguration"`
+	InternalEndpointPort            string                `yaml:"internalEndpointPort"`
+	TargetCPUUtilizationPercentage  int32                 `yaml:"targetCPUUtilizationPercentage"`
+	ScaleToZero                     ScaleToZeroConfig     `yaml:"scaleToZero"`
+	DependencyCache                 DependencyCacheConfig `yaml:"dependencyCache"`
+	GitRemote                       GitRemoteConfig       `yaml:"gitRemote"`
+	OCIRemote                       OCIRemoteConfig       `yaml:"ociRemote"`
+	FunctionScheduling              SchedulingConfig      `yaml:"functionScheduling"`
+	// Runtimes extends or overrides the built-in runtimes
+	Runtimes Runtimes `yaml:"runtimes"`
 }
 type healthzConfig struct {
 	Port            string        `yaml:"healthzPort"`
@@ -47,14 +56,143 @@ func defaultFunctionConfig() FunctionConfig {
 		PackageRegistryConfigSecretName: "serverless-package-registry-config",
 		FunctionPublisherProxyAddress:   "http://eventing-publisher-proxy.kyma-system.svc.cluster.local/publish",
 		InternalEndpointPort:            ":12137",
+		TargetCPUUtilizationPercentage:  50,
+		ScaleToZero: ScaleToZeroConfig{
+			IdleWindow:            15 * time.Minute,
+			ActivationTimeout:     2 * time.Minute,
+			ActivityCheckInterval: time.Minute,
+			ActivatorPorts:        PortRange{Min: 20000, Max: 20999},
+			ActivatorNamespace:    "kyma-system",
+			ActivatorServiceName:  "serverless-activator",
+		},
+		DependencyCache: DependencyCacheConfig{
+			Enabled:    false,
+			Size:       Quantity{Quantity: resource.MustParse("1Gi")},
+			AccessMode: corev1.ReadWriteMany,
+			JobTimeout: 10 * time.Minute,
+		},
+		GitRemote: GitRemoteConfig{
+			RefsCacheTTL:      time.Minute,
+			MaxListsPerHost:   4,
+			MinFailureBackoff: 5 * time.Second,
+			MaxFailureBackoff: 5 * time.Minute,
+			OperationTimeout:  30 * time.Second,
+		},
+		OCIRemote: OCIRemoteConfig{
+			DigestCacheTTL: time.Minute,
+		},
+		FunctionScheduling: SchedulingConfig{
+			AllowTopologySpreadConstraints: true,
+		},
 	}
 }
 
+type GitVerificationConfig struct {
+	// SecretName is the name of the secret with the trusted keys, the commits are not verified by default if empty
+	SecretName string `yaml:"secretName"`
+	// SecretNamespace is the namespace of the secret with the trusted keys
+	SecretNamespace string `yaml:"secretNamespace"`
+}
+
+type ScaleToZeroConfig struct {
+	// IdleWindow is the time after which a Function that has not received any request is scaled to zero
+	IdleWindow time.Duration `yaml:"idleWindow"`
+	// ActivationTimeout is the maximum time the activator buffers a request while waiting for the Function to become ready
+	ActivationTimeout time.Duration `yaml:"activationTimeout"`
+	// ActivityCheckInterval is how often the activator reads the calls of the running Functions from their metrics
+	ActivityCheckInterval time.Duration `yaml:"activityCheckInterval"`
+	// ActivatorPorts are the ports assigned to the scale-to-zero Functions, each Function is reached through its own port
+	// the activator listens only on the ports assigned to the Functions
+	ActivatorPorts PortRange `yaml:"activatorPorts"`
+	// ActivatorNamespace is the namespace of the activator Service
+	ActivatorNamespace string `yaml:"activatorNamespace"`
+	// ActivatorServiceName is the name of the activator Service, its endpoints receive the requests sent to Functions without ready Pods
+	ActivatorServiceName string `yaml:"activatorServiceName"`
+}
+
+// PortRange is an inclusive range of ports
+type PortRange struct {
+	Min int32 `yaml:"min"`
+	Max int32 `yaml:"max"`
+}
+
+// Contains returns true when the port is in the range
+func (r PortRange) Contains(port int32) bool {
+	return port >= r.Min && port <= r.Max
+}
+
+// Size returns the number of ports in the range
+func (r PortRange) Size() int32 {
+	return max(r.Max-r.Min+1, 0)
+}
+
+type DependencyCacheConfig struct {
+	// Enabled turns on installing the Function's dependencies once by a Job instead of on every Pod start
+	Enabled bool `yaml:"enabled"`
+	// StorageClassName is the storage class of the cache volumes, the cluster's default one is used when empty
+	StorageClassName string `yaml:"storageClassName"`
+	// Size is the requested storage of a single cache volume
+	Size Quantity `yaml:"size"`
+	// AccessMode of the cache volumes, it must allow mounting the volume by Pods running on different nodes
+	AccessMode corev1.PersistentVolumeAccessMode `yaml:"accessMode"`
+	// JobTimeout limits the time the Job installs the dependencies, afterwards the Function's Pods install them on start
+	JobTimeout time.Duration `yaml:"jobTimeout"`
+}
+
+type GitRemoteConfig struct {
+	// RefsCacheTTL is the time the listed references of the repository are shared by the Functions using it
+	RefsCacheTTL time.Duration `yaml:"refsCacheTTL"`
+	// MaxListsPerHost limits the number of references listed from a single git host at the same time
+	MaxListsPerHost int `yaml:"maxListsPerHost"`
+	// MinFailureBackoff is the time the failed listing is not retried, it doubles with every consecutive failure
+	MinFailureBackoff time.Duration `yaml:"minFailureBackoff"`
+	// MaxFailureBackoff limits the time the failed listing is not retried
+	MaxFailureBackoff time.Duration `yaml:"maxFailureBackoff"`
+	// OperationTimeout limits the time of a single operation on the git server, like listing the references or fetching the commit
+	OperationTimeout time.Duration `yaml:"operationTimeout"`
+	// Verification is the secret with the keys trusted to sign the commits of the Functions not defining their own
+	Verification GitVerificationConfig `yaml:"verification"`
+	// KnownHosts are the SSH host keys of the git servers trusted by all Functions, in the known_hosts format
+	KnownHosts string `yaml:"knownHosts"`
+}
+
+type OCIRemoteConfig struct {
+	// DigestCacheTTL is the time the digest the artifact's tag is resolved to is shared by the Functions using the same reference and credentials
+	DigestCacheTTL time.Duration `yaml:"digestCacheTTL"`
+}
+
+// SchedulingConfig lists the scheduling settings the Functions are allowed to set in their template,
+// the lists allow any value when they contain AllowAny
+type SchedulingConfig struct {
+	// AllowedNodeSelectorKeys are the keys of the node labels the Functions can select the nodes by
+	AllowedNodeSelectorKeys []string `yaml:"allowedNodeSelectorKeys"`
+	// AllowedTolerationKeys are the keys of the taints the Functions can tolerate
+	AllowedTolerationKeys []string `yaml:"allowedTolerationKeys"`
+	// AllowedPriorityClassNames are the priority classes the Functions can run with
+	AllowedPriorityClassNames []string `yaml:"allowedPriorityClassNames"`
+	// AllowedServiceAccountNames are the service accounts the Functions can run as
+	AllowedServiceAccountNames []string `yaml:"allowedServiceAccountNames"`
+	// AllowAffinity allows the Functions to set the node and pod affinity of their Pods
+	AllowAffinity bool `yaml:"allowAffinity"`
+	// AllowTopologySpreadConstraints allows the Functions to spread their Pods across the topology domains
+	AllowTopologySpreadConstraints bool `yaml:"allowTopologySpreadConstraints"`
+}
+
+// AllowAny is the entry of the scheduling config's lists allowing any value
+const AllowAny = "*"
+
+// IsAllowed checks if the value is on the allow-list
+func IsAllowed(allowed []string, value string) bool {
+	return slices.Contains(allowed, AllowAny) || slices.Contains(allowed, value)
+}
+
 type ImagesConfig struct {
 	NodeJs20    string `yaml:"nodejs20"`
 	NodeJs22    string `yaml:"nodejs22"`
 	NodeJs24    string `yaml:"nodejs24"`
+	NodeJs26    string `yaml:"nodejs26"`
 	Python312   string `yaml:"python312"`
+	Python314   string `yaml:"python314"`
 	RepoFetcher string `yaml:"repoFetcher"`
 }
 
@@ -116,8 +254,10 @@ func LoadFunctionConfig(path string) (FunctionConfig, error) {
 		return cfg, err
 	}
 
-	err = yaml.Unmarshal(yamlFile, &cfg)
-	return cfg, err
+	if err := yaml.Unmarshal(yamlFile, &cfg); err != nil {
+		return cfg, err
+	}
+	return cfg, cfg.validateRuntimes()
 }
 
 func (r Resource) ToResourceRequirements() corev1.ResourceRequirements {
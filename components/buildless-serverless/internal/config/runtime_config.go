@@ -0,0 +1,252 @@
+package config
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/pkg/errors"
+	"go.yaml.in/yaml/v3"
+)
+
+// DependenciesFormat describes how the Function's inline dependencies are validated and merged
+type DependenciesFormat string
+
+const (
+	// DependenciesFormatJSON dependencies are a JSON object, like the package.json file
+	DependenciesFormatJSON DependenciesFormat = "json"
+	// DependenciesFormatText dependencies are a list of lines, like the requirements.txt file
+	DependenciesFormatText DependenciesFormat = "text"
+)
+
+// RuntimeConfig describes how the Function's Pod installs the dependencies and starts the handler of the runtime
+type RuntimeConfig struct {
+	// Image of the runtime, the image from the images configuration is used for built-in runtimes when empty
+	Image string `yaml:"image"`
+	// Deprecated runtimes are still supported, but their use is discouraged
+	Deprecated bool `yaml:"deprecated"`
+	// FIPSCompliant runtimes are allowed when Kyma runs in the FIPS mode
+	FIPSCompliant bool `yaml:"fipsCompliant"`
+	// WorkingDir is the directory the Function's sources are saved to
+	WorkingDir string `yaml:"workingDir"`
+	// HandlerFile is the name of the file the Function's inline source is saved to
+	HandlerFile string `yaml:"handlerFile"`
+	// ServerFile is the name of the runtime's server file shipped with the ejected Function
+	ServerFile string `yaml:"serverFile"`
+	// DependenciesFile is the name of the file the Function's inline dependencies are saved to
+	DependenciesFile string `yaml:"dependenciesFile"`
+	// DependenciesFormat is used to validate the Function's inline dependencies
+	DependenciesFormat DependenciesFormat `yaml:"dependenciesFormat"`
+	// EmptyDependencies is the content of the dependencies file when the Function has no dependencies
+	EmptyDependencies string `yaml:"emptyDependencies"`
+	// PackageRegistryConfigFile is the key of the package registry config Secret mounted into the working dir
+	PackageRegistryConfigFile string `yaml:"packageRegistryConfigFile"`
+	// InstallCommand installs the dependencies in the working dir
+	InstallCommand string `yaml:"installCommand"`
+	// StartCommand starts the runtime's server from the working dir
+	StartCommand string `yaml:"startCommand"`
+	// DependencyCache commands copy the installed dependencies to the cache volume and reuse them from it
+	DependencyCache RuntimeDependencyCacheConfig `yaml:"dependencyCache"`
+	// EmptyDirs are writable directories required by the runtime, the root filesystem is read-only
+	EmptyDirs []RuntimeEmptyDir `yaml:"emptyDirs"`
+	// Env is set for every Function's container
+	Env []RuntimeEnvVar `yaml:"env"`
+	// SourceEnv is set only when the Function's sources are mounted into the container
+	SourceEnv []RuntimeEnvVar `yaml:"sourceEnv"`
+}
+
+type RuntimeDependencyCacheConfig struct {
+	// SaveCommand copies the installed dependencies to the /dependency-cache dir
+	SaveCommand string `yaml:"saveCommand"`
+	// RestoreCommand makes the dependencies from the /dependency-cache dir available to the runtime
+	RestoreCommand string `yaml:"restoreCommand"`
+}
+
+type RuntimeEmptyDir struct {
+	Name      string `yaml:"name"`
+	MountPath string `yaml:"mountPath"`
+}
+
+type RuntimeEnvVar struct {
+	Name  string `yaml:"name"`
+	Value string `yaml:"value"`
+}
+
+// Runtimes are the runtimes configured in addition to the built-in ones, keyed by the runtime name
+type Runtimes map[string]RuntimeConfig
+
+// UnmarshalYAML merges the configured runtime into the built-in one with the same name,
+// so only the fields changed in the built-in runtime have to be configured
+func (r *Runtimes) UnmarshalYAML(value *yaml.Node) error {
+	var nodes map[string]yaml.Node
+	if err := value.Decode(&nodes); err != nil {
+		return err
+	}
+
+	runtimes := Runtimes{}
+	for name, node := range nodes {
+		// the lists of the built-in runtime are replaced by the configured ones, not modified
+		rc := builtinRuntimes[name]
+		if err := node.Decode(&rc); err != nil {
+			return errors.Wrapf(err, "while unmarshalling runtime %s", name)
+		}
+		runtimes[name] = rc
+	}
+	*r = runtimes
+	return nil
+}
+
+// validateRuntimes rejects the configured runtimes the Function's Pods can't be started with
+func (c *FunctionConfig) validateRuntimes() error {
+	for _, name := range slices.Sorted(maps.Keys(c.Runtimes)) {
+		rc, _ := c.RuntimeConfig(name)
+		requiredFields := []struct {
+			name  string
+			value string
+		}{
+			{name: "image", value: rc.Image},
+			{name: "handlerFile", value: rc.HandlerFile},
+			{name: "installCommand", value: rc.InstallCommand},
+			{name: "startCommand", value: rc.StartCommand},
+		}
+		for _, field := range requiredFields {
+			if field.value == "" {
+				return errors.Errorf("invalid runtime %s: %s must not be empty", name, field.name)
+			}
+		}
+	}
+	return nil
+}
+
+// BuiltinRuntime returns the configuration of the runtime shipped with serverless, without its image
+func BuiltinRuntime(runtime string) (RuntimeConfig, bool) {
+	rc, ok := builtinRuntimes[runtime]
+	return rc, ok
+}
+
+// IsLegacyAPIRuntime returns true for the built-in runtimes calling the handler with the legacy event and context objects
+func IsLegacyAPIRuntime(runtime string) bool {
+	return slices.Contains(legacyAPIRuntimes, runtime)
+}
+
+// RuntimeConfig returns the configuration of the runtime
+// the runtimes configured in the function config take precedence over the built-in ones
+func (c *FunctionConfig) RuntimeConfig(runtime string) (RuntimeConfig, bool) {
+	rc, ok := c.Runtimes[runtime]
+	if !ok {
+		rc, ok = builtinRuntimes[runtime]
+	}
+	if !ok {
+		return RuntimeConfig{}, false
+	}
+	if rc.Image == "" {
+		rc.Image = c.Images.runtimeImage(runtime)
+	}
+	return rc, true
+}
+
+func (ic ImagesConfig) runtimeImage(runtime string) string {
+	return map[string]string{
+		"nodejs20":  ic.NodeJs20,
+		"nodejs22":  ic.NodeJs22,
+		"nodejs24":  ic.NodeJs24,
+		"nodejs26":  ic.NodeJs26,
+		"python312": ic.Python312,
+		"python314": ic.Python314,
+	}[runtime]
+}
+
+var nodejsRuntime = RuntimeConfig{
+	FIPSCompliant:             true,
+	WorkingDir:                "/usr/src/app/function",
+	HandlerFile:               "handler.js",
+	ServerFile:                "server.mjs",
+	DependenciesFile:          "package.json",
+	DependenciesFormat:        DependenciesFormatJSON,
+	EmptyDependencies:         "{}",
+	PackageRegistryConfigFile: ".npmrc",
+	InstallCommand:            `NPM_CONFIG_USERCONFIG=package-registry-config/.npmrc npm install --prefer-offline --no-audit --progress=false;`,
+	StartCommand: `cd ..;
+npm start;`,
+	DependencyCache: RuntimeDependencyCacheConfig{
+		SaveCommand: `mkdir -p node_modules;
+rm -rf /dependency-cache/node_modules;
+cp -r node_modules /dependency-cache/;`,
+		RestoreCommand: `rm -rf node_modules;
+ln -s /dependency-cache/node_modules node_modules;`,
+	},
+	SourceEnv: []RuntimeEnvVar{
+		{Name: "HANDLER_PATH", Value: "./function/handler.js"},
+	},
+}
+
+var pythonRuntime = RuntimeConfig{
+	FIPSCompliant:             true,
+	WorkingDir:                "/kubeless",
+	HandlerFile:               "handler.py",
+	ServerFile:                "server.py",
+	DependenciesFile:          "requirements.txt",
+	DependenciesFormat:        DependenciesFormatText,
+	PackageRegistryConfigFile: "pip.conf",
+	InstallCommand: `export PYTHONPATH="/kubeless/.local:${PYTHONPATH}"
+PIP_CONFIG_FILE=package-registry-config/pip.conf pip install --target=/kubeless/.local --no-cache-dir -r requirements.txt;`,
+	StartCommand: `cd /usr/src/app;
+python server.py;`,
+	DependencyCache: RuntimeDependencyCacheConfig{
+		SaveCommand: `mkdir -p /kubeless/.local;
+rm -rf /dependency-cache/.local;
+cp -r /kubeless/.local /dependency-cache/;`,
+		RestoreCommand: `export PYTHONPATH="/dependency-cache/.local:${PYTHONPATH}"`,
+	},
+	EmptyDirs: []RuntimeEmptyDir{
+		// required by pip to save deps to .local dir
+		{Name: "local", MountPath: "/.local"},
+	},
+	Env: []RuntimeEnvVar{
+		{Name: "PYTHONUNBUFFERED", Value: "TRUE"},
+	},
+	SourceEnv: []RuntimeEnvVar{
+		{Name: "HANDLER_PATH", Value: "/kubeless"},
+	},
+}
+
+// builtinRuntimes are the runtimes shipped with serverless, their images are configured in the images configuration
+var builtinRuntimes = map[string]RuntimeConfig{
+	"nodejs20":  deprecated(fipsNonCompliant(nodejsRuntime)),
+	"nodejs22":  nodejsRuntime,
+	"nodejs24":  nodejsRuntime,
+	"nodejs26":  nodejsRuntime,
+	"python312": fipsNonCompliant(legacyPython(pythonRuntime)),
+	"python314": pythonRuntime,
+}
+
+// legacyAPIRuntimes call the handler with the legacy event and context objects
+var legacyAPIRuntimes = []string{"nodejs20", "nodejs22", "nodejs24", "python312"}
+
+// legacyPython configures the handler of the python runtimes calling it with the event and context objects
+func legacyPython(rc RuntimeConfig) RuntimeConfig {
+	rc.StartCommand = `cd ..;
+if [ -f "./kubeless.py" ]; then
+  # old file location support
+  python kubeless.py;
+else
+  python server.py;
+fi`
+	rc.Env = append(slices.Clone(rc.Env),
+		RuntimeEnvVar{Name: "MOD_NAME", Value: "handler"},
+		RuntimeEnvVar{Name: "FUNC_HANDLER", Value: "main"},
+	)
+	rc.SourceEnv = []RuntimeEnvVar{
+		{Name: "FUNCTION_PATH", Value: "/kubeless"},
+	}
+	return rc
+}
+
+func deprecated(rc RuntimeConfig) RuntimeConfig {
+	rc.Deprecated = true
+	return rc
+}
+
+func fipsNonCompliant(rc RuntimeConfig) RuntimeConfig {
+	rc.FIPSCompliant = false
+	return rc
+}
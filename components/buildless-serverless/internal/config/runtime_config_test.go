@@ -0,0 +1,141 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFunctionConfig_RuntimeConfig(t *testing.T) {
+	t.Run("get built-in runtime with image from images config", func(t *testing.T) {
+		c := &FunctionConfig{Images: ImagesConfig{NodeJs24: "image-for-nodejs24"}}
+
+		rc, ok := c.RuntimeConfig("nodejs24")
+
+		require.True(t, ok)
+		require.Equal(t, "image-for-nodejs24", rc.Image)
+		require.Equal(t, "/usr/src/app/function", rc.WorkingDir)
+		require.True(t, rc.FIPSCompliant)
+		require.False(t, rc.Deprecated)
+	})
+	t.Run("get deprecated runtime", func(t *testing.T) {
+		rc, ok := (&FunctionConfig{}).RuntimeConfig("nodejs20")
+
+		require.True(t, ok)
+		require.True(t, rc.Deprecated)
+		require.False(t, rc.FIPSCompliant)
+	})
+	t.Run("configured runtime overrides built-in one", func(t *testing.T) {
+		c := &FunctionConfig{
+			Images: ImagesConfig{Python314: "image-for-python314"},
+			Runtimes: map[string]RuntimeConfig{
+				"python314": {Image: "custom-image", WorkingDir: "/custom"},
+			},
+		}
+
+		rc, ok := c.RuntimeConfig("python314")
+
+		require.True(t, ok)
+		require.Equal(t, RuntimeConfig{Image: "custom-image", WorkingDir: "/custom"}, rc)
+	})
+	t.Run("get custom runtime", func(t *testing.T) {
+		c := &FunctionConfig{
+			Runtimes: map[string]RuntimeConfig{
+				"go124": {Image: "image-for-go124"},
+			},
+		}
+
+		rc, ok := c.RuntimeConfig("go124")
+
+		require.True(t, ok)
+		require.Equal(t, "image-for-go124", rc.Image)
+	})
+	t.Run("unknown runtime", func(t *testing.T) {
+		_, ok := (&FunctionConfig{}).RuntimeConfig("go124")
+
+		require.False(t, ok)
+	})
+}
+
+func TestLoadFunctionConfig_runtimes(t *testing.T) {
+	t.Run("merge configured runtime into built-in one", func(t *testing.T) {
+		path := fixConfigFile(t, `
+images:
+  python314: image-for-python314
+runtimes:
+  python314:
+    fipsCompliant: false
+    env:
+    - name: PIP_INDEX_URL
+      value: https://pypi.example.com/simple
+`)
+
+		cfg, err := LoadFunctionConfig(path)
+
+		require.NoError(t, err)
+		rc, ok := cfg.RuntimeConfig("python314")
+		require.True(t, ok)
+		require.Equal(t, "image-for-python314", rc.Image)
+		require.Equal(t, "/kubeless", rc.WorkingDir)
+		require.Equal(t, "handler.py", rc.HandlerFile)
+		require.False(t, rc.FIPSCompliant)
+		require.Equal(t, []RuntimeEnvVar{{Name: "PIP_INDEX_URL", Value: "https://pypi.example.com/simple"}}, rc.Env)
+		// the built-in runtime is not modified
+		require.Equal(t, []RuntimeEnvVar{{Name: "PYTHONUNBUFFERED", Value: "TRUE"}}, builtinRuntimes["python314"].Env)
+		require.True(t, builtinRuntimes["python314"].FIPSCompliant)
+	})
+	t.Run("load custom runtime", func(t *testing.T) {
+		path := fixConfigFile(t, `
+runtimes:
+  go124:
+    image: image-for-go124
+    handlerFile: handler.go
+    installCommand: go mod download
+    startCommand: go run .
+`)
+
+		cfg, err := LoadFunctionConfig(path)
+
+		require.NoError(t, err)
+		require.Equal(t, Runtimes{"go124": {
+			Image:          "image-for-go124",
+			HandlerFile:    "handler.go",
+			InstallCommand: "go mod download",
+			StartCommand:   "go run .",
+		}}, cfg.Runtimes)
+	})
+	t.Run("reject custom runtime without image", func(t *testing.T) {
+		path := fixConfigFile(t, `
+runtimes:
+  go124:
+    handlerFile: handler.go
+    installCommand: go mod download
+    startCommand: go run .
+`)
+
+		_, err := LoadFunctionConfig(path)
+
+		require.EqualError(t, err, "invalid runtime go124: image must not be empty")
+	})
+	t.Run("reject runtime with emptied command", func(t *testing.T) {
+		path := fixConfigFile(t, `
+images:
+  nodejs24: image-for-nodejs24
+runtimes:
+  nodejs24:
+    startCommand: ""
+`)
+
+		_, err := LoadFunctionConfig(path)
+
+		require.EqualError(t, err, "invalid runtime nodejs24: startCommand must not be empty")
+	})
+}
+
+func fixConfigFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
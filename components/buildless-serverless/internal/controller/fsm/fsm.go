@@ -12,6 +12,7 @@ import (
 	"github.com/kyma-project/serverless/components/buildless-serverless/internal/config"
 	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/git"
 	serverlessmetrics "github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/metrics"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/oci"
 	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/resources"
 	"go.uber.org/zap"
 	appsv1 "k8s.io/api/apps/v1"
@@ -28,8 +29,18 @@ type SystemState struct {
 	statusSnapshot    serverlessv1alpha2.FunctionStatus
 	BuiltDeployment   *resources.Deployment
 	ClusterDeployment *appsv1.Deployment
+	CanaryDeployment  *appsv1.Deployment
 	Commit            string
+	ResolvedTag       string
+	AvailableCommit   string
+	LatestCommit      string
+	TreeHash          string
 	GitAuth           *git.GitAuth
+	ScaledToZero      bool
+	Revision          *resources.Revision
+	DependencyCache   string
+	// ReferencedContentHash is the hash of the Secrets and ConfigMaps consumed by the Function's Pods
+	ReferencedContentHash string
 }
 
 func (s *SystemState) saveStatusSnapshot() {
@@ -41,13 +52,18 @@ func (s *SystemState) saveStatusSnapshot() {
 }
 
 type StateMachine struct {
-	nextFn                StateFn
-	State                 SystemState
-	Log                   *zap.SugaredLogger
-	Client                client.Client
+	nextFn StateFn
+	State  SystemState
+	Log    *zap.SugaredLogger
+	Client client.Client
+	// Cache serves the indexed Functions, the Client doesn't cache them
+	Cache                 client.Reader
 	FunctionConfig        config.FunctionConfig
 	Scheme                *apimachineryruntime.Scheme
 	GitChecker            git.AsyncLatestCommitChecker
+	SourceTrees           git.SourceTrees
+	CommitVerifier        git.CommitVerifier
+	OCIResolver           oci.Resolver
 	EventRecorder         record.EventRecorder
 	IsKymaFipsModeEnabled bool
 }
@@ -101,7 +117,7 @@ type StateMachineReconciler interface {
 	Reconcile(ctx context.Context) (ctrl.Result, error)
 }
 
-func New(client client.Client, functionConfig config.FunctionConfig, instance *serverlessv1alpha2.Function, startState StateFn, recorder record.EventRecorder, gitChecker git.AsyncLatestCommitChecker, scheme *apimachineryruntime.Scheme, log *zap.SugaredLogger, isKymaFipsModeEnabled bool) StateMachineReconciler {
+func New(client client.Client, cache client.Reader, functionConfig config.FunctionConfig, instance *serverlessv1alpha2.Function, startState StateFn, recorder record.EventRecorder, gitChecker git.AsyncLatestCommitChecker, sourceTrees git.SourceTrees, commitVerifier git.CommitVerifier, ociResolver oci.Resolver, scheme *apimachineryruntime.Scheme, log *zap.SugaredLogger, isKymaFipsModeEnabled bool) StateMachineReconciler {
 	sm := StateMachine{
 		nextFn: startState,
 		State: SystemState{
@@ -110,8 +126,12 @@ func New(client client.Client, functionConfig config.FunctionConfig, instance *s
 		Log:                   log,
 		FunctionConfig:        functionConfig,
 		Client:                client,
+		Cache:                 cache,
 		Scheme:                scheme,
 		GitChecker:            gitChecker,
+		SourceTrees:           sourceTrees,
+		CommitVerifier:        commitVerifier,
+		OCIResolver:           ociResolver,
 		EventRecorder:         recorder,
 		IsKymaFipsModeEnabled: isKymaFipsModeEnabled,
 	}
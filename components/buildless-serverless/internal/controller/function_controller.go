@@ -25,18 +25,24 @@ import (
 	"github.com/kyma-project/serverless/components/buildless-serverless/internal/config"
 	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/fsm"
 	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/git"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/oci"
 	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/state"
 	"go.uber.org/zap"
 	"golang.org/x/time/rate"
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
@@ -51,16 +57,28 @@ type FunctionReconciler struct {
 	Config                config.FunctionConfig
 	EventRecorder         record.EventRecorder
 	GitChecker            git.AsyncLatestCommitChecker
+	SourceTrees           git.SourceTrees
+	CommitVerifier        git.CommitVerifier
+	OCIResolver           oci.Resolver
 	HealthCh              chan bool
 	IsKymaFipsModeEnabled bool
+
+	// cache serves the indexed Functions, the Client doesn't cache them
+	cache client.Reader
 }
 
 // +kubebuilder:rbac:groups=serverless.kyma-project.io,resources=functions,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=serverless.kyma-project.io,resources=functions/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=serverless.kyma-project.io,resources=functionruntimes,verbs=get;list;watch
 // +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete;deletecollection
 // +kubebuilder:rbac:groups=apps,resources=deployments/status,verbs=get
-// +kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch;create;update;delete
-// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;update;delete
+// +kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=autoscaling,resources=horizontalpodautoscalers,verbs=get;list;watch;create;update;delete
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;delete
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;delete
+// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;delete
+// +kubebuilder:rbac:groups=discovery.k8s.io,resources=endpointslices,verbs=get;list;watch;create;update;patch;delete;deletecollection
+// +kubebuilder:rbac:groups="",resources=persistentvolumeclaims,verbs=get;list;watch;create;delete
 // +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
@@ -82,18 +100,34 @@ func (fr *FunctionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		return ctrl.Result{}, nil
 	}
 
-	sm := fsm.New(fr.Client, fr.Config, &instance, state.StartState(), fr.EventRecorder, fr.GitChecker, fr.Scheme, log, fr.IsKymaFipsModeEnabled)
+	sm := fsm.New(fr.Client, fr.cache, fr.Config, &instance, state.StartState(), fr.EventRecorder, fr.GitChecker, fr.SourceTrees, fr.CommitVerifier, fr.OCIResolver, fr.Scheme, log, fr.IsKymaFipsModeEnabled)
 	return sm.Reconcile(ctx)
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (fr *FunctionReconciler) SetupWithManager(mgr ctrl.Manager) (controller.Controller, error) {
+	if err := indexReferencedObjects(context.Background(), mgr.GetFieldIndexer()); err != nil {
+		return nil, err
+	}
+	fr.cache = mgr.GetCache()
+
+	// only metadata of Secrets and ConfigMaps is watched, so their content isn't kept in the cache
 	return ctrl.NewControllerManagedBy(mgr).
 		Named("function-controller").
-		For(&serverlessv1alpha2.Function{}).
-		WithEventFilter(buildPredicates()).
-		Owns(&appsv1.Deployment{}).
-		Owns(&corev1.Service{}).
+		For(&serverlessv1alpha2.Function{}, builder.WithPredicates(buildPredicates())).
+		Owns(&appsv1.Deployment{}, builder.WithPredicates(buildPredicates())).
+		Owns(&corev1.Service{}, builder.WithPredicates(buildPredicates())).
+		Owns(&autoscalingv2.HorizontalPodAutoscaler{}, builder.WithPredicates(buildPredicates())).
+		Owns(&batchv1.Job{}, builder.WithPredicates(buildPredicates())).
+		Watches(&serverlessv1alpha2.FunctionRuntime{}, handler.EnqueueRequestsFromMapFunc(fr.functionsForRuntime(mgr.GetCache())),
+			builder.WithPredicates(buildPredicates(), predicate.GenerationChangedPredicate{})).
+		// the predicates are set per watch, an event filter would drop the deletion of the referenced objects
+		WatchesMetadata(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(fr.functionsReferencing(mgr.GetCache(), referencedSecretsIndex)),
+			builder.WithPredicates(referencedObjectPredicate())).
+		WatchesMetadata(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(fr.functionsReferencing(mgr.GetCache(), referencedConfigMapsIndex)),
+			builder.WithPredicates(referencedObjectPredicate())).
+		Watches(&discoveryv1.EndpointSlice{}, handler.EnqueueRequestsFromMapFunc(fr.functionsRoutedToActivator(mgr.GetCache())),
+			builder.WithPredicates(buildPredicates(), predicate.NewPredicateFuncs(fr.isActivatorEndpointSlice))).
 		Named("function").
 		WithOptions(controller.Options{
 			RateLimiter: workqueue.NewTypedMaxOfRateLimiter[reconcile.Request](
@@ -109,6 +143,50 @@ func (fr *FunctionReconciler) SetupWithManager(mgr ctrl.Manager) (controller.Con
 		Build(fr)
 }
 
+// functionsForRuntime enqueues the Functions using the changed FunctionRuntime
+// only spec changes are watched, the status of the FunctionRuntime lists the Functions and doesn't affect them
+func (fr *FunctionReconciler) functionsForRuntime(reader client.Reader) handler.MapFunc {
+	return func(ctx context.Context, obj client.Object) []reconcile.Request {
+		functions, err := functionsUsingRuntime(ctx, reader, obj.GetName())
+		if err != nil {
+			fr.Log.Errorf("while listing functions using runtime %s: %s", obj.GetName(), err)
+			return nil
+		}
+
+		var requests []reconcile.Request
+		for _, f := range functions {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&f)})
+		}
+		return requests
+	}
+}
+
+// isActivatorEndpointSlice returns true for the endpoints of the activator's service
+func (fr *FunctionReconciler) isActivatorEndpointSlice(obj client.Object) bool {
+	return obj.GetNamespace() == fr.Config.ScaleToZero.ActivatorNamespace &&
+		obj.GetLabels()[discoveryv1.LabelServiceName] == fr.Config.ScaleToZero.ActivatorServiceName
+}
+
+// functionsRoutedToActivator enqueues the scale-to-zero Functions when the activator's endpoints change,
+// so their services are routed to the current ones
+func (fr *FunctionReconciler) functionsRoutedToActivator(reader client.Reader) handler.MapFunc {
+	return func(ctx context.Context, _ client.Object) []reconcile.Request {
+		var functionList serverlessv1alpha2.FunctionList
+		if err := reader.List(ctx, &functionList); err != nil {
+			fr.Log.Errorf("while listing functions routed to activator: %s", err)
+			return nil
+		}
+
+		var requests []reconcile.Request
+		for _, f := range functionList.Items {
+			if f.Status.ActivatorPort != 0 {
+				requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&f)})
+			}
+		}
+		return requests
+	}
+}
+
 func (fr *FunctionReconciler) sendHealthCheck() {
 	fr.Log.Debug("health check request received")
 
@@ -0,0 +1,59 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	serverlessv1alpha2 "github.com/kyma-project/serverless/components/buildless-serverless/api/v1alpha2"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/config"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestFunctionReconciler_functionsRoutedToActivator(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, serverlessv1alpha2.AddToScheme(scheme))
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&serverlessv1alpha2.Function{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "idle"},
+			Status:     serverlessv1alpha2.FunctionStatus{ActivatorPort: 20001},
+		},
+		&serverlessv1alpha2.Function{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "always-on"},
+		},
+	).Build()
+	fr := &FunctionReconciler{Log: zap.NewNop().Sugar()}
+
+	requests := fr.functionsRoutedToActivator(c)(context.Background(), &discoveryv1.EndpointSlice{})
+
+	require.Equal(t, []ctrl.Request{{NamespacedName: types.NamespacedName{Namespace: "team-a", Name: "idle"}}}, requests)
+}
+
+func TestFunctionReconciler_isActivatorEndpointSlice(t *testing.T) {
+	fr := &FunctionReconciler{Config: config.FunctionConfig{
+		ScaleToZero: config.ScaleToZeroConfig{
+			ActivatorNamespace:   "kyma-system",
+			ActivatorServiceName: "serverless-activator",
+		},
+	}}
+
+	require.True(t, fr.isActivatorEndpointSlice(fixEndpointSlice("kyma-system", "serverless-activator")))
+	require.False(t, fr.isActivatorEndpointSlice(fixEndpointSlice("kyma-system", "serverless-controller-manager")))
+	require.False(t, fr.isActivatorEndpointSlice(fixEndpointSlice("team-a", "serverless-activator")))
+}
+
+func fixEndpointSlice(namespace, serviceName string) *discoveryv1.EndpointSlice {
+	return &discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      serviceName + "-abcde",
+			Labels:    map[string]string{discoveryv1.LabelServiceName: serviceName},
+		},
+	}
+}
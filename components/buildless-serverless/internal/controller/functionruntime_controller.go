@@ -0,0 +1,148 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"strings"
+
+	serverlessv1alpha2 "github.com/kyma-project/serverless/components/buildless-serverless/api/v1alpha2"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// FunctionRuntimeReconciler keeps the list of Functions using the FunctionRuntime in its status
+type FunctionRuntimeReconciler struct {
+	client.Client
+	Log *zap.SugaredLogger
+
+	// functionReader serves the Functions indexed by their runtime
+	functionReader client.Reader
+}
+
+// +kubebuilder:rbac:groups=serverless.kyma-project.io,resources=functionruntimes,verbs=get;list;watch
+// +kubebuilder:rbac:groups=serverless.kyma-project.io,resources=functionruntimes/status,verbs=get;update;patch
+
+func (rr *FunctionRuntimeReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := rr.Log.With("request", req)
+
+	var instance serverlessv1alpha2.FunctionRuntime
+	if err := rr.Get(ctx, req.NamespacedName, &instance); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	functions, err := functionsUsingRuntime(ctx, rr.functionReader, instance.GetName())
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	status := serverlessv1alpha2.FunctionRuntimeStatus{
+		FunctionCount: int32(len(functions)),
+	}
+	for _, f := range functions {
+		status.Functions = append(status.Functions, serverlessv1alpha2.FunctionReference{
+			Namespace: f.GetNamespace(),
+			Name:      f.GetName(),
+		})
+	}
+	slices.SortFunc(status.Functions, func(a, b serverlessv1alpha2.FunctionReference) int {
+		if c := strings.Compare(a.Namespace, b.Namespace); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	if reflect.DeepEqual(instance.Status, status) {
+		return ctrl.Result{}, nil
+	}
+
+	log.Infof("updating status, %d functions use the runtime", status.FunctionCount)
+	instance.Status = status
+	return ctrl.Result{}, rr.Status().Update(ctx, &instance)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+// the Functions are listed from the manager's cache, the runtime index is registered by the FunctionReconciler
+func (rr *FunctionRuntimeReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	rr.functionReader = mgr.GetCache()
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&serverlessv1alpha2.FunctionRuntime{}).
+		Watches(&serverlessv1alpha2.Function{}, functionRuntimeEventHandler(),
+			builder.WithPredicates(runtimeChangedPredicate())).
+		Named("functionruntime").
+		Complete(rr)
+}
+
+// functionRuntimeEventHandler enqueues the runtimes used by the Function
+// both runtimes are enqueued when the Function's runtime changes
+func functionRuntimeEventHandler() handler.TypedEventHandler[client.Object, reconcile.Request] {
+	enqueue := func(q workqueue.TypedRateLimitingInterface[reconcile.Request], objs ...client.Object) {
+		for _, obj := range objs {
+			f, ok := obj.(*serverlessv1alpha2.Function)
+			if !ok || f.Spec.Runtime == "" {
+				continue
+			}
+			q.Add(reconcile.Request{NamespacedName: types.NamespacedName{Name: string(f.Spec.Runtime)}})
+		}
+	}
+
+	return handler.Funcs{
+		CreateFunc: func(_ context.Context, e event.CreateEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+			enqueue(q, e.Object)
+		},
+		UpdateFunc: func(_ context.Context, e event.UpdateEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+			enqueue(q, e.ObjectOld, e.ObjectNew)
+		},
+		DeleteFunc: func(_ context.Context, e event.DeleteEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+			enqueue(q, e.Object)
+		},
+	}
+}
+
+// runtimeChangedPredicate skips the Function updates not changing its runtime, e.g. status updates
+func runtimeChangedPredicate() predicate.Funcs {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldFn, okOld := e.ObjectOld.(*serverlessv1alpha2.Function)
+			newFn, okNew := e.ObjectNew.(*serverlessv1alpha2.Function)
+			if !okOld || !okNew {
+				return true
+			}
+			return oldFn.Spec.Runtime != newFn.Spec.Runtime
+		},
+	}
+}
+
+// functionsUsingRuntime lists the Functions using the runtime
+// the reader must serve the runtime index, so the Functions are listed from the manager's cache
+func functionsUsingRuntime(ctx context.Context, reader client.Reader, runtime string) ([]serverlessv1alpha2.Function, error) {
+	var functionList serverlessv1alpha2.FunctionList
+	if err := reader.List(ctx, &functionList, client.MatchingFields{functionRuntimeIndex: runtime}); err != nil {
+		return nil, err
+	}
+	return functionList.Items, nil
+}
@@ -0,0 +1,138 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	serverlessv1alpha2 "github.com/kyma-project/serverless/components/buildless-serverless/api/v1alpha2"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func TestFunctionRuntimeReconciler_Reconcile(t *testing.T) {
+	t.Run("should list functions using the runtime in status", func(t *testing.T) {
+		rr := fixFunctionRuntimeReconciler(t,
+			&serverlessv1alpha2.FunctionRuntime{ObjectMeta: metav1.ObjectMeta{Name: "go124"}},
+			fixRuntimeFunction("team-b", "orders", "go124"),
+			fixRuntimeFunction("team-a", "payments", "go124"),
+			fixRuntimeFunction("team-a", "invoices", "go124"),
+			fixRuntimeFunction("team-a", "reports", "nodejs24"),
+		)
+
+		result, err := rr.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "go124"}})
+
+		require.NoError(t, err)
+		require.Equal(t, ctrl.Result{}, result)
+		functionRuntime := &serverlessv1alpha2.FunctionRuntime{}
+		require.NoError(t, rr.Get(context.Background(), client.ObjectKey{Name: "go124"}, functionRuntime))
+		require.Equal(t, serverlessv1alpha2.FunctionRuntimeStatus{
+			FunctionCount: 3,
+			Functions: []serverlessv1alpha2.FunctionReference{
+				{Namespace: "team-a", Name: "invoices"},
+				{Namespace: "team-a", Name: "payments"},
+				{Namespace: "team-b", Name: "orders"},
+			},
+		}, functionRuntime.Status)
+	})
+	t.Run("should clear status when runtime is not used", func(t *testing.T) {
+		rr := fixFunctionRuntimeReconciler(t,
+			&serverlessv1alpha2.FunctionRuntime{
+				ObjectMeta: metav1.ObjectMeta{Name: "go124"},
+				Status: serverlessv1alpha2.FunctionRuntimeStatus{
+					FunctionCount: 1,
+					Functions:     []serverlessv1alpha2.FunctionReference{{Namespace: "team-a", Name: "orders"}},
+				},
+			},
+		)
+
+		_, err := rr.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "go124"}})
+
+		require.NoError(t, err)
+		functionRuntime := &serverlessv1alpha2.FunctionRuntime{}
+		require.NoError(t, rr.Get(context.Background(), client.ObjectKey{Name: "go124"}, functionRuntime))
+		require.Equal(t, serverlessv1alpha2.FunctionRuntimeStatus{}, functionRuntime.Status)
+	})
+	t.Run("should ignore not existing runtime", func(t *testing.T) {
+		rr := fixFunctionRuntimeReconciler(t)
+
+		result, err := rr.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "go124"}})
+
+		require.NoError(t, err)
+		require.Equal(t, ctrl.Result{}, result)
+	})
+}
+
+func TestFunctionReconciler_functionsForRuntime(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, serverlessv1alpha2.AddToScheme(scheme))
+	reader := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithIndex(&serverlessv1alpha2.Function{}, functionRuntimeIndex, functionRuntime).
+		WithObjects(
+			fixRuntimeFunction("team-a", "orders", "go124"),
+			fixRuntimeFunction("team-a", "reports", "nodejs24"),
+		).Build()
+	fr := &FunctionReconciler{
+		Log: zap.NewNop().Sugar(),
+	}
+
+	requests := fr.functionsForRuntime(reader)(context.Background(), &serverlessv1alpha2.FunctionRuntime{
+		ObjectMeta: metav1.ObjectMeta{Name: "go124"},
+	})
+
+	require.Equal(t, []ctrl.Request{{NamespacedName: types.NamespacedName{Namespace: "team-a", Name: "orders"}}}, requests)
+}
+
+func Test_runtimeChangedPredicate(t *testing.T) {
+	p := runtimeChangedPredicate()
+
+	t.Run("skip update not changing runtime", func(t *testing.T) {
+		oldFn := fixRuntimeFunction("team-a", "orders", "go124")
+		newFn := oldFn.DeepCopy()
+		newFn.Status.Runtime = "go124"
+
+		require.False(t, p.Update(event.UpdateEvent{ObjectOld: oldFn, ObjectNew: newFn}))
+	})
+	t.Run("pass update changing runtime", func(t *testing.T) {
+		require.True(t, p.Update(event.UpdateEvent{
+			ObjectOld: fixRuntimeFunction("team-a", "orders", "go124"),
+			ObjectNew: fixRuntimeFunction("team-a", "orders", "nodejs24"),
+		}))
+	})
+	t.Run("pass create and delete", func(t *testing.T) {
+		fn := fixRuntimeFunction("team-a", "orders", "go124")
+
+		require.True(t, p.Create(event.CreateEvent{Object: fn}))
+		require.True(t, p.Delete(event.DeleteEvent{Object: fn}))
+	})
+}
+
+func fixFunctionRuntimeReconciler(t *testing.T, objs ...client.Object) *FunctionRuntimeReconciler {
+	scheme := runtime.NewScheme()
+	require.NoError(t, serverlessv1alpha2.AddToScheme(scheme))
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(objs...).
+		WithIndex(&serverlessv1alpha2.Function{}, functionRuntimeIndex, functionRuntime).
+		WithStatusSubresource(&serverlessv1alpha2.FunctionRuntime{}).
+		Build()
+	return &FunctionRuntimeReconciler{
+		Client:         c,
+		Log:            zap.NewNop().Sugar(),
+		functionReader: c,
+	}
+}
+
+func fixRuntimeFunction(namespace, name string, runtime serverlessv1alpha2.Runtime) *serverlessv1alpha2.Function {
+	return &serverlessv1alpha2.Function{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec:       serverlessv1alpha2.FunctionSpec{Runtime: runtime},
+	}
+}
@@ -5,13 +5,20 @@ import (
 	"sync"
 	"time"
 
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/config"
+	serverlessmetrics "github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/metrics"
+	"github.com/pkg/errors"
 	"go.uber.org/zap"
 )
 
+// ErrCommitCheckTimedOut is the error of the order that exceeded the git operation timeout
+var ErrCommitCheckTimedOut = errors.New("latest commit check timed out")
+
 //go:generate mockery --name=AsyncLatestCommitChecker --output=automock --outpkg=automock --case=underscore
 type AsyncLatestCommitChecker interface {
 	PlaceOrder(string, string, string, *GitAuth)
 	CollectOrder(string) *OrderResult
+	InvalidateOrder(string)
 }
 
 type asyncLatestCommitChecker struct {
@@ -19,23 +26,42 @@ type asyncLatestCommitChecker struct {
 	cache             sync.Map
 	log               *zap.SugaredLogger
 	cacheElemLifetime time.Duration
+	// timeout limits the time of the order, the order exceeding it results in the error
+	timeout time.Duration
+	// sources keeps the repository of every order to invalidate its shared references
+	sources sync.Map
+	refs    *remoteRefsCache
 
 	// implemented to allow easier testing
-	getLatestCommit func(repo, ref string, auth *GitAuth) (string, error)
+	getLatestCommit func(ctx context.Context, repo, ref string, auth *GitAuth) (ResolvedReference, error)
+}
+
+type orderSource struct {
+	repo string
+	auth *GitAuth
 }
 
+// pendingOrder marks the order in progress
+type pendingOrder struct{}
+
 type OrderResult struct {
-	Commit    string
+	Commit string
+	// Tag is the tag the semver constraint or the pattern reference was resolved to
+	Tag       string
 	Error     error
 	timestamp time.Time
 }
 
-func NewAsyncLatestCommitChecker(ctx context.Context, log *zap.SugaredLogger) AsyncLatestCommitChecker {
+// NewAsyncLatestCommitChecker creates the checker sharing the references listed from the repository between the orders
+func NewAsyncLatestCommitChecker(ctx context.Context, log *zap.SugaredLogger, cfg config.GitRemoteConfig) AsyncLatestCommitChecker {
+	refs := newRemoteRefsCache(cfg)
 	checker := &asyncLatestCommitChecker{
 		ctx:               ctx,
 		log:               log,
-		getLatestCommit:   GetLatestCommit,
+		refs:              refs,
+		getLatestCommit:   refs.latestCommit,
 		cacheElemLifetime: 2 * time.Minute,
+		timeout:           cfg.OperationTimeout,
 	}
 
 	// start periodic cache cleanup
@@ -53,22 +79,61 @@ func (c *asyncLatestCommitChecker) PlaceOrder(orderID, repo, ref string, auth *G
 		return
 	}
 
-	// store a nil value to indicate that the order is in progress
-	c.cache.Store(orderID, nil)
+	// store a pending value to indicate that the order is in progress
+	pending := &pendingOrder{}
+	c.cache.Store(orderID, pending)
+	c.sources.Store(orderID, orderSource{repo: repo, auth: auth})
 
 	go func() {
 		c.log.Debugf("starting async latest commit check for %s %s", repo, ref)
-		commit, err := c.getLatestCommit(repo, ref, auth)
-
-		c.log.Debugf("finished async lalatestst commit check for %s %s with commit %s", repo, ref, commit)
-		c.cache.Store(orderID, &OrderResult{
-			Commit:    commit,
+		serverlessmetrics.PublishGitCommitCheckOrderStarted()
+		resolved, err := c.checkLatestCommit(repo, ref, auth)
+		serverlessmetrics.PublishGitCommitCheckOrderFinished(errors.Is(err, ErrCommitCheckTimedOut))
+
+		c.log.Debugf("finished async latest commit check for %s %s with commit %s", repo, ref, resolved.Commit)
+		// the result is dropped when the order was invalidated in the meantime
+		c.cache.CompareAndSwap(orderID, pending, &OrderResult{
+			Commit:    resolved.Commit,
+			Tag:       resolved.Tag,
 			Error:     err,
 			timestamp: time.Now(),
 		})
 	}()
 }
 
+// checkLatestCommit checks the latest commit within the timeout
+// the check is abandoned when the timeout is exceeded, even if the git server does not respond to the cancellation
+func (c *asyncLatestCommitChecker) checkLatestCommit(repo, ref string, auth *GitAuth) (ResolvedReference, error) {
+	ctx, cancel := c.ctx, context.CancelFunc(func() {})
+	if c.timeout > 0 {
+		ctx, cancel = context.WithTimeout(c.ctx, c.timeout)
+	}
+	defer cancel()
+
+	type checkResult struct {
+		resolved ResolvedReference
+		err      error
+	}
+	// buffered, so the abandoned check does not block when it finishes
+	results := make(chan checkResult, 1)
+	go func() {
+		resolved, err := c.getLatestCommit(ctx, repo, ref, auth)
+		results <- checkResult{resolved: resolved, err: err}
+	}()
+
+	var result checkResult
+	select {
+	case result = <-results:
+	case <-ctx.Done():
+		result = checkResult{err: ctx.Err()}
+	}
+
+	if result.err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return ResolvedReference{}, errors.Wrapf(ErrCommitCheckTimedOut, "while checking the latest commit of %s for %s", repo, c.timeout)
+	}
+	return result.resolved, result.err
+}
+
 // CollectOrder collects the result of the latest commit check for the given orderID
 // if the result is found or the order is still in progress, nil is returned
 // if order is older than 2 minutes, it is removed from the cache but latest order is returned
@@ -82,6 +147,18 @@ func (c *asyncLatestCommitChecker) CollectOrder(orderID string) *OrderResult {
 	return result
 }
 
+// InvalidateOrder removes the result of the latest commit check for the given orderID
+// the next order checks the latest commit again, even if the previous result is not outdated yet
+func (c *asyncLatestCommitChecker) InvalidateOrder(orderID string) {
+	c.cache.Delete(orderID)
+
+	value, ok := c.sources.Load(orderID)
+	if source, isSource := value.(orderSource); ok && isSource && c.refs != nil {
+		// the references shared with other orders are outdated too
+		c.refs.invalidate(source.repo, source.auth)
+	}
+}
+
 func (c *asyncLatestCommitChecker) load(orderID string) *OrderResult {
 	value, exists := c.cache.Load(orderID)
 	if !exists {
@@ -105,6 +182,10 @@ func (c *asyncLatestCommitChecker) clearCacheEvery(duration time.Duration) {
 			case <-time.After(duration):
 				c.log.Debug("clearing async latest commit checker cache")
 				c.cache.Clear()
+				c.sources.Clear()
+				if c.refs != nil {
+					c.refs.clear()
+				}
 			}
 		}
 	}()
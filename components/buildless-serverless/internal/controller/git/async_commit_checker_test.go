@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 )
@@ -22,8 +23,8 @@ func Test_AsyncLatestCommitChecker(t *testing.T) {
 			ctx:               context.Background(),
 			log:               zap.NewNop().Sugar(),
 			cacheElemLifetime: 0,
-			getLatestCommit: func(repo, ref string, auth *GitAuth) (string, error) {
-				return "test-commit", nil
+			getLatestCommit: func(_ context.Context, repo, ref string, auth *GitAuth) (ResolvedReference, error) {
+				return ResolvedReference{Commit: "test-commit"}, nil
 			},
 		}
 
@@ -58,8 +59,8 @@ func Test_AsyncLatestCommitChecker(t *testing.T) {
 			ctx:               context.Background(),
 			log:               zap.NewNop().Sugar(),
 			cacheElemLifetime: time.Hour,
-			getLatestCommit: func(repo, ref string, auth *GitAuth) (string, error) {
-				return "test-commit", nil
+			getLatestCommit: func(_ context.Context, repo, ref string, auth *GitAuth) (ResolvedReference, error) {
+				return ResolvedReference{Commit: "test-commit"}, nil
 			},
 		}
 
@@ -86,10 +87,10 @@ func Test_AsyncLatestCommitChecker(t *testing.T) {
 		checker := asyncLatestCommitChecker{
 			ctx: context.Background(),
 			log: zap.NewNop().Sugar(),
-			getLatestCommit: func(repo, ref string, auth *GitAuth) (string, error) {
+			getLatestCommit: func(_ context.Context, repo, ref string, auth *GitAuth) (ResolvedReference, error) {
 				ordersCount++
 				time.Sleep(time.Second)
-				return "test-commit", nil
+				return ResolvedReference{Commit: "test-commit"}, nil
 			},
 		}
 
@@ -102,6 +103,128 @@ func Test_AsyncLatestCommitChecker(t *testing.T) {
 
 		require.Equal(t, 1, ordersCount, "commit check should be ordered only once")
 	})
+
+	t.Run("return error when commit check times out", func(t *testing.T) {
+		id := "order-id"
+		stalled := make(chan struct{})
+		defer close(stalled)
+		checker := asyncLatestCommitChecker{
+			ctx:               context.Background(),
+			log:               zap.NewNop().Sugar(),
+			cacheElemLifetime: time.Hour,
+			timeout:           10 * time.Millisecond,
+			getLatestCommit: func(_ context.Context, repo, ref string, auth *GitAuth) (ResolvedReference, error) {
+				// the stalled git server ignores the cancellation
+				<-stalled
+				return ResolvedReference{Commit: "test-commit"}, nil
+			},
+		}
+
+		checker.PlaceOrder(id, "test-repo", "test-ref", nil)
+		require.Eventually(t, func() bool { return checker.CollectOrder(id) != nil }, time.Second, time.Millisecond)
+
+		result := checker.CollectOrder(id)
+		require.ErrorIs(t, result.Error, ErrCommitCheckTimedOut)
+		require.Empty(t, result.Commit)
+	})
+
+	t.Run("cancel commit check when it times out", func(t *testing.T) {
+		id := "order-id"
+		checker := asyncLatestCommitChecker{
+			ctx:               context.Background(),
+			log:               zap.NewNop().Sugar(),
+			cacheElemLifetime: time.Hour,
+			timeout:           10 * time.Millisecond,
+			getLatestCommit: func(ctx context.Context, repo, ref string, auth *GitAuth) (ResolvedReference, error) {
+				<-ctx.Done()
+				return ResolvedReference{}, ctx.Err()
+			},
+		}
+
+		checker.PlaceOrder(id, "test-repo", "test-ref", nil)
+		require.Eventually(t, func() bool { return checker.CollectOrder(id) != nil }, time.Second, time.Millisecond)
+
+		require.ErrorIs(t, checker.CollectOrder(id).Error, ErrCommitCheckTimedOut)
+	})
+}
+
+func Test_InvalidateOrder(t *testing.T) {
+	t.Run("check latest commit again after invalidation", func(t *testing.T) {
+		id := "order-id"
+		commits := make(chan string, 2)
+		commits <- "first-commit"
+		commits <- "second-commit"
+		checker := asyncLatestCommitChecker{
+			ctx:               context.Background(),
+			log:               zap.NewNop().Sugar(),
+			cacheElemLifetime: time.Hour,
+			getLatestCommit: func(_ context.Context, repo, ref string, auth *GitAuth) (ResolvedReference, error) {
+				return ResolvedReference{Commit: <-commits}, nil
+			},
+		}
+
+		checker.PlaceOrder(id, "test-repo", "test-ref", nil)
+		require.Eventually(t, func() bool { return checker.CollectOrder(id) != nil }, time.Second, time.Millisecond)
+		require.Equal(t, "first-commit", checker.CollectOrder(id).Commit)
+
+		checker.InvalidateOrder(id)
+		require.Nil(t, checker.CollectOrder(id))
+
+		checker.PlaceOrder(id, "test-repo", "test-ref", nil)
+		require.Eventually(t, func() bool { return checker.CollectOrder(id) != nil }, time.Second, time.Millisecond)
+		require.Equal(t, "second-commit", checker.CollectOrder(id).Commit)
+	})
+
+	t.Run("invalidate references shared with other orders", func(t *testing.T) {
+		lists := 0
+		refs := fixRemoteRefsCache(func(_ context.Context, url string, auth *GitAuth) ([]*plumbing.Reference, error) {
+			lists++
+			return fixRemoteRefs(), nil
+		})
+		checker := asyncLatestCommitChecker{
+			ctx:               context.Background(),
+			log:               zap.NewNop().Sugar(),
+			cacheElemLifetime: time.Hour,
+			refs:              refs,
+			getLatestCommit:   refs.latestCommit,
+		}
+
+		for _, id := range []string{"first-order", "second-order"} {
+			checker.PlaceOrder(id, "https://github.com/org/monorepo", "main", nil)
+			require.Eventually(t, func() bool { return checker.CollectOrder(id) != nil }, time.Second, time.Millisecond)
+		}
+		require.Equal(t, 1, lists)
+
+		checker.InvalidateOrder("first-order")
+		checker.InvalidateOrder("second-order")
+		checker.PlaceOrder("second-order", "https://github.com/org/monorepo", "main", nil)
+		require.Eventually(t, func() bool { return checker.CollectOrder("second-order") != nil }, time.Second, time.Millisecond)
+
+		require.Equal(t, 2, lists)
+	})
+
+	t.Run("drop result of order invalidated in progress", func(t *testing.T) {
+		id := "order-id"
+		release := make(chan struct{})
+		checker := asyncLatestCommitChecker{
+			ctx:               context.Background(),
+			log:               zap.NewNop().Sugar(),
+			cacheElemLifetime: time.Hour,
+			getLatestCommit: func(_ context.Context, repo, ref string, auth *GitAuth) (ResolvedReference, error) {
+				<-release
+				return ResolvedReference{Commit: "outdated-commit"}, nil
+			},
+		}
+
+		checker.PlaceOrder(id, "test-repo", "test-ref", nil)
+		checker.InvalidateOrder(id)
+		close(release)
+
+		require.Never(t, func() bool {
+			_, exists := checker.cache.Load(id)
+			return exists
+		}, 50*time.Millisecond, time.Millisecond)
+	})
 }
 
 func Test_clearCacheEvery(t *testing.T) {
@@ -1,10 +1,16 @@
 package git
 
 import (
+	"context"
+	"path"
 	"regexp"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
 
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/protocol/packp/capability"
 	"github.com/go-git/go-git/v5/plumbing/transport"
 	"github.com/go-git/go-git/v5/storage/memory"
@@ -21,49 +27,180 @@ func init() {
 	}
 }
 
-func GetLatestCommit(url, reference string, gitAuth *GitAuth) (string, error) {
+// ResolvedReference is the commit the Function's reference points to
+type ResolvedReference struct {
+	Commit string
+	// Tag is the tag matched by the semver constraint or the pattern reference
+	Tag string
+}
+
+func GetLatestCommit(ctx context.Context, url, reference string, gitAuth *GitAuth) (string, error) {
 	// A full 40-character SHA is already the commit — no remote listing needed.
 	if commitSHARegexp.MatchString(reference) {
 		return reference, nil
 	}
 
-	repo, err := git.Init(memory.NewStorage(), nil)
+	refs, err := ListRemoteRefs(ctx, url, gitAuth)
 	if err != nil {
 		return "", err
 	}
 
+	resolved, err := resolveReference(refs, reference)
+	return resolved.Commit, err
+}
+
+// ListRemoteRefs lists the references of the remote repository, like `git ls-remote` does
+// the listing is aborted when the context is done
+func ListRemoteRefs(ctx context.Context, url string, gitAuth *GitAuth) ([]*plumbing.Reference, error) {
+	repo, err := git.Init(memory.NewStorage(), nil)
+	if err != nil {
+		return nil, err
+	}
+
 	remote, err := repo.CreateRemote(&config.RemoteConfig{
 		Name: "origin",
 		URLs: []string{url},
 	})
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	var auth transport.AuthMethod
 	if gitAuth != nil {
 		auth, err = gitAuth.GetAuthMethod()
 		if err != nil {
-			return "", errors.Wrap(err, "while choosing authorization method")
+			return nil, errors.Wrap(err, "while choosing authorization method")
 		}
 	}
 
-	refs, err := remote.List(&git.ListOptions{
+	return remote.ListContext(ctx, &git.ListOptions{
 		Auth: auth,
+		// the annotated tags are resolved to the commits they point to
+		PeelingOption: git.AppendPeeled,
 	})
-	if err != nil {
-		return "", err
+}
+
+// resolveReference resolves the branch or tag name, or the semver constraint or pattern matched against the tags
+// the exact branch or tag name takes precedence over the constraint and the pattern
+func resolveReference(refs []*plumbing.Reference, reference string) (ResolvedReference, error) {
+	if commitSHARegexp.MatchString(reference) {
+		return ResolvedReference{Commit: reference}, nil
 	}
 
+	branches := map[string]string{}
+	tags := map[string]string{}
 	for _, rf := range refs {
 		rfName := rf.Name()
-		if !rfName.IsBranch() && !rfName.IsTag() {
+		switch {
+		case rfName.IsBranch():
+			branches[rfName.Short()] = rf.Hash().String()
+		case rfName.IsTag():
+			name, peeled := strings.CutSuffix(rfName.Short(), peeledSuffix)
+			if _, exists := tags[name]; !exists || peeled {
+				// the peeled reference points to the commit of the annotated tag
+				tags[name] = rf.Hash().String()
+			}
+		}
+	}
+
+	if commit, ok := branches[reference]; ok {
+		return ResolvedReference{Commit: commit}, nil
+	}
+	if commit, ok := tags[reference]; ok {
+		return ResolvedReference{Commit: commit}, nil
+	}
+
+	tag, ok := highestMatchingTag(tags, reference)
+	if !ok {
+		return ResolvedReference{}, errors.New("reference not found")
+	}
+	return ResolvedReference{Commit: tags[tag], Tag: tag}, nil
+}
+
+const peeledSuffix = "^{}"
+
+// highestMatchingTag returns the highest version of the tags matching the glob pattern or the semver constraint
+func highestMatchingTag(tags map[string]string, reference string) (string, bool) {
+	match, ok := tagMatcher(reference)
+	if !ok {
+		return "", false
+	}
+	return highestTag(tags, match)
+}
+
+// MatchesTag checks if the tag is the reference or it matches the reference's glob pattern or semver constraint
+func MatchesTag(reference, tag string) bool {
+	if tag == reference {
+		return true
+	}
+	match, ok := tagMatcher(reference)
+	if !ok {
+		return false
+	}
+	_, matched := match(tag)
+	return matched
+}
+
+// tagMatcher returns the function matching a tag against the reference's glob pattern or semver constraint
+// and returning the tag's version, false is returned when the reference is neither a pattern nor a constraint
+func tagMatcher(reference string) (func(tag string) (*semver.Version, bool), bool) {
+	if isPattern(reference) {
+		// the versions are compared without the pattern's prefix, so `release-*` orders `release-1.10` above `release-1.9`
+		prefix := reference[:strings.IndexAny(reference, "*?[")]
+		return func(tag string) (*semver.Version, bool) {
+			matched, err := path.Match(reference, tag)
+			if err != nil || !matched {
+				return nil, false
+			}
+			version, _ := semver.NewVersion(strings.TrimPrefix(tag, prefix))
+			return version, true
+		}, true
+	}
+
+	constraint, err := semver.NewConstraint(reference)
+	if err != nil {
+		return nil, false
+	}
+	return func(tag string) (*semver.Version, bool) {
+		version, err := semver.NewVersion(tag)
+		if err != nil || !constraint.Check(version) {
+			return nil, false
+		}
+		return version, true
+	}, true
+}
+
+// highestTag returns the matching tag with the highest version, the tags without the version are ordered by name below them
+func highestTag(tags map[string]string, match func(tag string) (*semver.Version, bool)) (string, bool) {
+	var highest string
+	var highestVersion *semver.Version
+	found := false
+	for tag := range tags {
+		version, ok := match(tag)
+		if !ok {
 			continue
 		}
-		if rfName.Short() == reference {
-			return rf.Hash().String(), nil
+		if !found || isHigherTag(tag, version, highest, highestVersion) {
+			highest, highestVersion, found = tag, version, true
 		}
 	}
+	return highest, found
+}
+
+func isHigherTag(tag string, version *semver.Version, than string, thanVersion *semver.Version) bool {
+	switch {
+	case version != nil && thanVersion != nil && !version.Equal(thanVersion):
+		return version.GreaterThan(thanVersion)
+	case version != nil && thanVersion == nil:
+		return true
+	case version == nil && thanVersion != nil:
+		return false
+	default:
+		// the same versions, like `v1.0` and `1.0`, are ordered by name to resolve the same tag every time
+		return tag > than
+	}
+}
 
-	return "", errors.New("reference not found")
+func isPattern(reference string) bool {
+	return strings.ContainsAny(reference, "*?[")
 }
@@ -1,15 +1,17 @@
 package git
 
 import (
+	"context"
 	"testing"
 
+	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/stretchr/testify/require"
 )
 
 func Test_GetLatestCommit_CommitSHA(t *testing.T) {
 	t.Run("returns commit SHA directly without remote call", func(t *testing.T) {
 		sha := "25ea1d5577a4362500fc77d4ea9a2bfeb3665c05"
-		result, err := GetLatestCommit("http://should-not-be-called", sha, nil)
+		result, err := GetLatestCommit(context.Background(), "http://should-not-be-called", sha, nil)
 		require.NoError(t, err)
 		require.Equal(t, sha, result)
 	})
@@ -17,12 +19,119 @@ func Test_GetLatestCommit_CommitSHA(t *testing.T) {
 	t.Run("does not treat short hex string as commit SHA", func(t *testing.T) {
 		// A 7-char abbreviated SHA should NOT be treated as a full commit SHA —
 		// it would fail on an unreachable URL just like a branch name would.
-		_, err := GetLatestCommit("http://unreachable.invalid", "25ea1d5", nil)
+		_, err := GetLatestCommit(context.Background(), "http://unreachable.invalid", "25ea1d5", nil)
 		require.Error(t, err)
 	})
 
 	t.Run("does not treat non-hex string as commit SHA", func(t *testing.T) {
-		_, err := GetLatestCommit("http://unreachable.invalid", "main", nil)
+		_, err := GetLatestCommit(context.Background(), "http://unreachable.invalid", "main", nil)
 		require.Error(t, err)
 	})
 }
+
+func Test_resolveReference(t *testing.T) {
+	refs := []*plumbing.Reference{
+		plumbing.NewHashReference("refs/heads/main", plumbing.NewHash("1111111111111111111111111111111111111111")),
+		plumbing.NewHashReference("refs/heads/1.x", plumbing.NewHash("2222222222222222222222222222222222222222")),
+		plumbing.NewHashReference("refs/tags/v1.3.0", plumbing.NewHash("1300000000000000000000000000000000000000")),
+		plumbing.NewHashReference("refs/tags/v1.4.2", plumbing.NewHash("1420000000000000000000000000000000000000")),
+		plumbing.NewHashReference("refs/tags/v1.10.0", plumbing.NewHash("1100000000000000000000000000000000000000")),
+		plumbing.NewHashReference("refs/tags/v2.0.0-rc.1", plumbing.NewHash("20000000000000000000000000000000000000c1")),
+		// the annotated tag is listed with the peeled reference to its commit
+		plumbing.NewHashReference("refs/tags/v2.0.1", plumbing.NewHash("aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa")),
+		plumbing.NewHashReference("refs/tags/v2.0.1^{}", plumbing.NewHash("2010000000000000000000000000000000000000")),
+		plumbing.NewHashReference("refs/tags/release-1.9", plumbing.NewHash("0019000000000000000000000000000000000000")),
+		plumbing.NewHashReference("refs/tags/release-1.10", plumbing.NewHash("0110000000000000000000000000000000000000")),
+		plumbing.NewHashReference("refs/tags/release-candidate", plumbing.NewHash("00cc000000000000000000000000000000000000")),
+	}
+
+	tests := []struct {
+		name      string
+		reference string
+		want      ResolvedReference
+	}{
+		{
+			name:      "resolve branch",
+			reference: "main",
+			want:      ResolvedReference{Commit: "1111111111111111111111111111111111111111"},
+		},
+		{
+			name:      "prefer branch over semver constraint",
+			reference: "1.x",
+			want:      ResolvedReference{Commit: "2222222222222222222222222222222222222222"},
+		},
+		{
+			name:      "resolve tag",
+			reference: "v1.3.0",
+			want:      ResolvedReference{Commit: "1300000000000000000000000000000000000000"},
+		},
+		{
+			name:      "peel annotated tag",
+			reference: "v2.0.1",
+			want:      ResolvedReference{Commit: "2010000000000000000000000000000000000000"},
+		},
+		{
+			name:      "resolve caret constraint to highest version",
+			reference: "^1.4",
+			want:      ResolvedReference{Commit: "1100000000000000000000000000000000000000", Tag: "v1.10.0"},
+		},
+		{
+			name:      "resolve tilde constraint",
+			reference: "~1.4.x",
+			want:      ResolvedReference{Commit: "1420000000000000000000000000000000000000", Tag: "v1.4.2"},
+		},
+		{
+			name:      "resolve range constraint to peeled annotated tag",
+			reference: ">=2.0.0-0 <3",
+			want:      ResolvedReference{Commit: "2010000000000000000000000000000000000000", Tag: "v2.0.1"},
+		},
+		{
+			name:      "resolve pattern to highest version",
+			reference: "release-*",
+			want:      ResolvedReference{Commit: "0110000000000000000000000000000000000000", Tag: "release-1.10"},
+		},
+		{
+			name:      "resolve pattern matching versioned tags",
+			reference: "v1.*",
+			want:      ResolvedReference{Commit: "1100000000000000000000000000000000000000", Tag: "v1.10.0"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolved, err := resolveReference(refs, tt.reference)
+
+			require.NoError(t, err)
+			require.Equal(t, tt.want, resolved)
+		})
+	}
+
+	for _, reference := range []string{"feature", "^3.0", "hotfix-*"} {
+		t.Run("return error when "+reference+" is not found", func(t *testing.T) {
+			_, err := resolveReference(refs, reference)
+
+			require.EqualError(t, err, "reference not found")
+		})
+	}
+}
+
+func Test_MatchesTag(t *testing.T) {
+	tests := []struct {
+		name      string
+		reference string
+		tag       string
+		want      bool
+	}{
+		{name: "same tag", reference: "v1.3.0", tag: "v1.3.0", want: true},
+		{name: "other tag", reference: "v1.3.0", tag: "v1.3.1", want: false},
+		{name: "tag matching semver constraint", reference: "^1.4", tag: "v1.10.0", want: true},
+		{name: "tag out of semver constraint", reference: "^1.4", tag: "v2.0.0", want: false},
+		{name: "tag matching pattern", reference: "release-*", tag: "release-1.10", want: true},
+		{name: "tag not matching pattern", reference: "release-*", tag: "v1.10.0", want: false},
+		{name: "branch reference", reference: "main", tag: "v1.10.0", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, MatchesTag(tt.reference, tt.tag))
+		})
+	}
+}
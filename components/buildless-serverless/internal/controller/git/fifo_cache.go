@@ -0,0 +1,40 @@
+package git
+
+import "sync"
+
+// fifoCache remembers the limited number of values, the oldest value is forgotten first
+type fifoCache[K comparable, V any] struct {
+	mu     sync.Mutex
+	size   int
+	values map[K]V
+	order  []K
+}
+
+func newFIFOCache[K comparable, V any](size int) *fifoCache[K, V] {
+	return &fifoCache[K, V]{
+		size:   size,
+		values: map[K]V{},
+	}
+}
+
+func (c *fifoCache[K, V]) get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	value, ok := c.values[key]
+	return value, ok
+}
+
+// add remembers the value, the value added for the key before is kept
+func (c *fifoCache[K, V]) add(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, exists := c.values[key]; exists {
+		return
+	}
+	c.values[key] = value
+	c.order = append(c.order, key)
+	if len(c.order) > c.size {
+		delete(c.values, c.order[0])
+		c.order = c.order[1:]
+	}
+}
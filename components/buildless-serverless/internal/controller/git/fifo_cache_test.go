@@ -0,0 +1,26 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_fifoCache(t *testing.T) {
+	c := newFIFOCache[string, int](2)
+	c.add("first", 1)
+	c.add("second", 2)
+	c.add("first", 10)
+
+	value, ok := c.get("first")
+	require.True(t, ok)
+	require.Equal(t, 1, value, "the value added before should be kept")
+
+	c.add("third", 3)
+
+	_, ok = c.get("first")
+	require.False(t, ok, "the oldest value should be forgotten")
+	value, ok = c.get("third")
+	require.True(t, ok)
+	require.Equal(t, 3, value)
+}
@@ -2,14 +2,16 @@ package git
 
 import (
 	"context"
+	"crypto/sha256"
 	"fmt"
+	"strings"
 
 	"github.com/go-git/go-git/v5/plumbing/transport"
 	"github.com/go-git/go-git/v5/plumbing/transport/http"
 	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
 	serverlessv1alpha2 "github.com/kyma-project/serverless/components/buildless-serverless/api/v1alpha2"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/git/hostkey"
 	"github.com/pkg/errors"
-	crypto_ssh "golang.org/x/crypto/ssh"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -19,8 +21,13 @@ type dataField[T any] struct {
 	value     T
 	fieldName string
 	envName   string
+	// secretName overrides the name of the secret the field is read from by the env
+	secretName string
 }
 
+// GitHubAppUsername is the username authenticating with the GitHub App's installation token
+const GitHubAppUsername = "x-access-token"
+
 type GitAuth struct {
 	secretName      string
 	secretNamespace string
@@ -30,14 +37,23 @@ type GitAuth struct {
 	username        *dataField[string]
 	password        *dataField[string]
 	sshKey          *dataField[[]byte]
+	token           *dataField[string]
+	gitHubApp       *gitHubApp
+	hostKeyPolicy   serverlessv1alpha2.HostKeyPolicy
+	// knownHosts are the SSH host keys from the Function, the secret and the cluster's defaults
+	knownHosts string
+	// trustedHostKeys are the SSH host keys trusted on the first connection
+	trustedHostKeys string
 }
 
-func NewGitAuth(ctx context.Context, client client.Client, f *serverlessv1alpha2.Function) (*GitAuth, error) {
+func NewGitAuth(ctx context.Context, client client.Client, f *serverlessv1alpha2.Function, defaultKnownHosts string) (*GitAuth, error) {
+	auth := f.Spec.Source.GitRepository.Auth
 	a := &GitAuth{
-		secretName:      f.Spec.Source.GitRepository.Auth.SecretName,
+		secretName:      auth.SecretName,
 		secretNamespace: f.GetNamespace(),
-		authType:        f.Spec.Source.GitRepository.Auth.Type,
+		authType:        auth.Type,
 		client:          client,
+		hostKeyPolicy:   auth.HostKeyPolicy,
 	}
 	err := a.loadSecret(ctx)
 	if err != nil {
@@ -47,9 +63,80 @@ func NewGitAuth(ctx context.Context, client client.Client, f *serverlessv1alpha2
 	if err != nil {
 		return nil, errors.Wrap(err, "while parsing git authorization secret")
 	}
+	if a.gitHubApp != nil {
+		token, err := defaultInstallationTokens.get(ctx, *a.gitHubApp)
+		if err != nil {
+			return nil, errors.Wrap(err, "while getting GitHub App installation token")
+		}
+		a.token = &dataField[string]{
+			value:      token,
+			fieldName:  InstallationTokenSecretKey,
+			envName:    tokenEnvVarName,
+			secretName: InstallationTokenSecretName(f),
+		}
+	}
+	a.knownHosts = joinKnownHosts(auth.KnownHosts, string(a.secret.Data[knownHostsFieldName]), defaultKnownHosts)
+	if a.hostKeyPolicy == serverlessv1alpha2.HostKeyPolicyTrustOnFirstUse && f.Status.GitRepository != nil {
+		a.trustedHostKeys = f.Status.GitRepository.TrustedHostKeys
+	}
 	return a, nil
 }
 
+// TrustOnFirstUse trusts the host key of the unknown SSH server when the trust on first use policy is set
+func (a *GitAuth) TrustOnFirstUse(ctx context.Context, url string) error {
+	if a.authType != serverlessv1alpha2.RepositoryAuthSSHKey || a.hostKeyPolicy != serverlessv1alpha2.HostKeyPolicyTrustOnFirstUse {
+		return nil
+	}
+
+	address, err := hostkey.Address(url)
+	if err != nil {
+		return err
+	}
+	if hostkey.Contains(a.allKnownHosts(), address) {
+		// the key is verified while connecting to the server
+		return nil
+	}
+
+	callback, err := hostkey.Callback(a.allKnownHosts())
+	if err != nil {
+		return err
+	}
+	key, remote, err := hostkey.Scan(ctx, address)
+	if err != nil {
+		return err
+	}
+
+	err = callback(address, remote, key)
+	var hostKeyErr *hostkey.Error
+	if errors.As(err, &hostKeyErr) && hostKeyErr.Unknown {
+		a.trustedHostKeys = joinKnownHosts(a.trustedHostKeys, hostkey.Line(address, key))
+		return nil
+	}
+	return err
+}
+
+// TrustedHostKeys returns the SSH host keys trusted on the first connection
+func (a *GitAuth) TrustedHostKeys() string {
+	return a.trustedHostKeys
+}
+
+func (a *GitAuth) allKnownHosts() string {
+	return joinKnownHosts(a.knownHosts, a.trustedHostKeys)
+}
+
+func joinKnownHosts(knownHosts ...string) string {
+	var lines []string
+	for _, k := range knownHosts {
+		if k = strings.TrimSpace(k); k != "" {
+			lines = append(lines, k)
+		}
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
 func (a *GitAuth) loadSecret(ctx context.Context) error {
 	s := &corev1.Secret{}
 	err := a.client.Get(ctx,
@@ -73,6 +160,10 @@ func (a *GitAuth) parseSecret() error {
 		// It is for compatibility with the previous implementation
 	default:
 		switch a.authType {
+		case serverlessv1alpha2.RepositoryAuthToken:
+			return a.parseTokenSecret()
+		case serverlessv1alpha2.RepositoryAuthGitHubApp:
+			return a.parseGitHubAppSecret()
 		case serverlessv1alpha2.RepositoryAuthSSHKey:
 			return a.parseSSHAuthOldServerlessSecret()
 		case serverlessv1alpha2.RepositoryAuthBasic:
@@ -83,17 +174,47 @@ func (a *GitAuth) parseSecret() error {
 	}
 }
 
+// identity distinguishes the credentials, the repository listed with other credentials may return other references
+func (a *GitAuth) identity() string {
+	if a == nil {
+		return ""
+	}
+	resourceVersion := ""
+	if a.secret != nil {
+		resourceVersion = a.secret.GetResourceVersion()
+	}
+	// the references listed with other known hosts may fail for other reasons
+	return fmt.Sprintf("%s/%s@%s#%x", a.secretNamespace, a.secretName, resourceVersion, sha256.Sum256([]byte(a.allKnownHosts())))
+}
+
 func (a *GitAuth) GetAuthMethod() (transport.AuthMethod, error) {
 	switch a.authType {
 	case serverlessv1alpha2.RepositoryAuthSSHKey:
 		return a.sshAuth()
 	case serverlessv1alpha2.RepositoryAuthBasic:
 		return a.basicAuth()
+	case serverlessv1alpha2.RepositoryAuthToken:
+		return a.tokenAuth()
+	case serverlessv1alpha2.RepositoryAuthGitHubApp:
+		return a.gitHubAppAuth()
 	default:
 		return nil, errors.New("unexpected authorization type")
 	}
 }
 
+// InstallationToken returns the GitHub App's installation token, which has to be stored in the secret named by InstallationTokenSecretName
+func (a *GitAuth) InstallationToken() string {
+	if a.gitHubApp == nil || a.token == nil {
+		return ""
+	}
+	return a.token.value
+}
+
+// InstallationTokenSecretName returns the name of the secret with the GitHub App's installation token used to clone the function's repository
+func InstallationTokenSecretName(f *serverlessv1alpha2.Function) string {
+	return fmt.Sprintf("%s-git-token", f.GetName())
+}
+
 func (a *GitAuth) GetAuthEnvs() []corev1.EnvVar {
 	s := a.secretName
 	var envs []corev1.EnvVar
@@ -104,6 +225,13 @@ func (a *GitAuth) GetAuthEnvs() []corev1.EnvVar {
 	envs = addEnvVar(envs, a.sshKey, s)
 	envs = addEnvVar(envs, a.username, s)
 	envs = addEnvVar(envs, a.password, s)
+	envs = addEnvVar(envs, a.token, s)
+	if knownHosts := a.allKnownHosts(); a.authType == serverlessv1alpha2.RepositoryAuthSSHKey && knownHosts != "" {
+		envs = append(envs, corev1.EnvVar{
+			Name:  knownHostsEnvVarName,
+			Value: knownHosts,
+		})
+	}
 	return envs
 }
 
@@ -114,10 +242,20 @@ const (
 	oldServerlessKeyFieldName      = "key"
 	oldServerlessUsernameFieldName = "username"
 	oldServerlessPasswordFieldName = "password"
-	repositoryAuthTypeEnvVarName   = "APP_REPOSITORY_AUTH_TYPE"
-	usernameEnvVarName             = "APP_REPOSITORY_USERNAME"
-	passwordEnvVarName             = "APP_REPOSITORY_PASSWORD"
-	sshKeyEnvVarName               = "APP_REPOSITORY_KEY"
+	knownHostsFieldName            = "known_hosts"
+	tokenFieldName                 = "token"
+	gitHubAppIDFieldName           = "app-id"
+	gitHubInstallationIDFieldName  = "installation-id"
+	gitHubPrivateKeyFieldName      = "private-key"
+	gitHubAPIURLFieldName          = "api-url"
+	// InstallationTokenSecretKey is the key of the installation token in the secret named by InstallationTokenSecretName
+	InstallationTokenSecretKey   = "token"
+	repositoryAuthTypeEnvVarName = "APP_REPOSITORY_AUTH_TYPE"
+	usernameEnvVarName           = "APP_REPOSITORY_USERNAME"
+	passwordEnvVarName           = "APP_REPOSITORY_PASSWORD"
+	sshKeyEnvVarName             = "APP_REPOSITORY_KEY"
+	knownHostsEnvVarName         = "APP_REPOSITORY_KNOWN_HOSTS"
+	tokenEnvVarName              = "APP_REPOSITORY_TOKEN"
 )
 
 func (a *GitAuth) parseSSHAuthKubernetesSecret() error {
@@ -198,6 +336,39 @@ func (a *GitAuth) parseBasicAuthOldServerlessSecret() error {
 	return nil
 }
 
+func (a *GitAuth) parseTokenSecret() error {
+	token, ok := a.secret.Data[tokenFieldName]
+	if !ok {
+		return errors.New(fmt.Sprintf("missing '%s'", tokenFieldName))
+	}
+	a.token = &dataField[string]{
+		value:     string(token),
+		fieldName: tokenFieldName,
+		envName:   tokenEnvVarName,
+	}
+	return nil
+}
+
+func (a *GitAuth) parseGitHubAppSecret() error {
+	appID, appIDFound := a.secret.Data[gitHubAppIDFieldName]
+	installationID, installationIDFound := a.secret.Data[gitHubInstallationIDFieldName]
+	privateKey, privateKeyFound := a.secret.Data[gitHubPrivateKeyFieldName]
+	if !appIDFound || !installationIDFound || !privateKeyFound {
+		return errors.New(fmt.Sprintf("missing '%s', '%s' or '%s'", gitHubAppIDFieldName, gitHubInstallationIDFieldName, gitHubPrivateKeyFieldName))
+	}
+	apiURL := defaultGitHubAPIURL
+	if value, ok := a.secret.Data[gitHubAPIURLFieldName]; ok {
+		apiURL = string(value)
+	}
+	a.gitHubApp = &gitHubApp{
+		apiURL:         apiURL,
+		appID:          strings.TrimSpace(string(appID)),
+		installationID: strings.TrimSpace(string(installationID)),
+		privateKey:     privateKey,
+	}
+	return nil
+}
+
 func (a *GitAuth) sshAuth() (transport.AuthMethod, error) {
 	password := ""
 	if a.password != nil {
@@ -208,9 +379,10 @@ func (a *GitAuth) sshAuth() (transport.AuthMethod, error) {
 		return nil, errors.Wrap(err, "unable to parse private key")
 	}
 
-	// set callback to func that always returns nil while checking known hosts
-	// this disables known hosts validation
-	auth.HostKeyCallback = crypto_ssh.InsecureIgnoreHostKey()
+	auth.HostKeyCallback, err = hostkey.Callback(a.allKnownHosts())
+	if err != nil {
+		return nil, err
+	}
 
 	return auth, nil
 }
@@ -222,10 +394,27 @@ func (a *GitAuth) basicAuth() (transport.AuthMethod, error) {
 	}, nil
 }
 
+func (a *GitAuth) tokenAuth() (transport.AuthMethod, error) {
+	return &http.TokenAuth{
+		Token: a.token.value,
+	}, nil
+}
+
+func (a *GitAuth) gitHubAppAuth() (transport.AuthMethod, error) {
+	// GitHub accepts the installation token as the password of the x-access-token user
+	return &http.BasicAuth{
+		Username: GitHubAppUsername,
+		Password: a.token.value,
+	}, nil
+}
+
 func addEnvVar[T any](envs []corev1.EnvVar, f *dataField[T], secretName string) []corev1.EnvVar {
 	if f == nil {
 		return envs
 	}
+	if f.secretName != "" {
+		secretName = f.secretName
+	}
 	envs = append(envs, corev1.EnvVar{
 		Name: f.envName,
 		ValueFrom: &corev1.EnvVarSource{
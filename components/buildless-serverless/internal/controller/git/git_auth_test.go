@@ -2,12 +2,21 @@ package git
 
 import (
 	"context"
+	"crypto/ed25519"
+	"crypto/rand"
 	"encoding/json"
+	"fmt"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
 	serverlessv1alpha2 "github.com/kyma-project/serverless/components/buildless-serverless/api/v1alpha2"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/git/hostkey"
 	"github.com/stretchr/testify/require"
+	crypto_ssh "golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"net"
+	"net/http"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"testing"
 )
@@ -496,3 +505,253 @@ func TestGitAuth_GetAuthEnvs(t *testing.T) {
 		})
 	}
 }
+
+func TestNewGitAuth_KnownHosts(t *testing.T) {
+	secret := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "git-creds", Namespace: "default"},
+		Type:       corev1.SecretTypeSSHAuth,
+		Data: map[string][]byte{
+			"ssh-privatekey": []byte("key"),
+			"known_hosts":    []byte("gitlab.com ssh-ed25519 AAAA-secret\n"),
+		},
+	}
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&secret).Build()
+	f := &serverlessv1alpha2.Function{
+		ObjectMeta: metav1.ObjectMeta{Name: "function", Namespace: "default"},
+		Spec: serverlessv1alpha2.FunctionSpec{
+			Source: serverlessv1alpha2.Source{
+				GitRepository: &serverlessv1alpha2.GitRepositorySource{
+					URL: "git@github.com:org/repo.git",
+					Auth: &serverlessv1alpha2.RepositoryAuth{
+						Type:       serverlessv1alpha2.RepositoryAuthSSHKey,
+						SecretName: "git-creds",
+						KnownHosts: "github.com ssh-ed25519 AAAA-function",
+					},
+				},
+			},
+		},
+		Status: serverlessv1alpha2.FunctionStatus{
+			GitRepository: &serverlessv1alpha2.GitRepositoryStatus{
+				TrustedHostKeys: "bitbucket.org ssh-ed25519 AAAA-trusted\n",
+			},
+		},
+	}
+
+	t.Run("join known hosts of function, secret and cluster", func(t *testing.T) {
+		a, err := NewGitAuth(context.Background(), k8sClient, f, "example.com ssh-ed25519 AAAA-cluster")
+
+		require.NoError(t, err)
+		require.Equal(t, "github.com ssh-ed25519 AAAA-function\ngitlab.com ssh-ed25519 AAAA-secret\nexample.com ssh-ed25519 AAAA-cluster\n", a.knownHosts)
+		require.Empty(t, a.TrustedHostKeys())
+	})
+	t.Run("use trusted host keys only with trust on first use policy", func(t *testing.T) {
+		tofu := f.DeepCopy()
+		tofu.Spec.Source.GitRepository.Auth.HostKeyPolicy = serverlessv1alpha2.HostKeyPolicyTrustOnFirstUse
+
+		a, err := NewGitAuth(context.Background(), k8sClient, tofu, "")
+
+		require.NoError(t, err)
+		require.Equal(t, "bitbucket.org ssh-ed25519 AAAA-trusted\n", a.TrustedHostKeys())
+		require.Contains(t, a.allKnownHosts(), "AAAA-trusted")
+	})
+}
+
+func TestGitAuth_TrustOnFirstUse(t *testing.T) {
+	t.Run("trust host key of unknown server", func(t *testing.T) {
+		key, address := fixSSHServer(t)
+		a := &GitAuth{
+			authType:      serverlessv1alpha2.RepositoryAuthSSHKey,
+			hostKeyPolicy: serverlessv1alpha2.HostKeyPolicyTrustOnFirstUse,
+		}
+
+		err := a.TrustOnFirstUse(context.Background(), "ssh://git@"+address+"/org/repo.git")
+
+		require.NoError(t, err)
+		require.Equal(t, hostkey.Line(address, key)+"\n", a.TrustedHostKeys())
+	})
+	t.Run("reject changed host key of known server", func(t *testing.T) {
+		_, address := fixSSHServer(t)
+		otherKey, _ := fixSSHServer(t)
+		a := &GitAuth{
+			authType:      serverlessv1alpha2.RepositoryAuthSSHKey,
+			hostKeyPolicy: serverlessv1alpha2.HostKeyPolicyTrustOnFirstUse,
+			// hashed line is not recognized without the scan
+			knownHosts: hashedKnownHostsLine(address, otherKey),
+		}
+
+		err := a.TrustOnFirstUse(context.Background(), "ssh://git@"+address+"/org/repo.git")
+
+		var hostKeyErr *hostkey.Error
+		require.ErrorAs(t, err, &hostKeyErr)
+		require.False(t, hostKeyErr.Unknown)
+		require.Empty(t, a.TrustedHostKeys())
+	})
+	t.Run("do not scan trusted server", func(t *testing.T) {
+		// the unresolvable host fails the scan
+		key, _ := fixSSHServer(t)
+		a := &GitAuth{
+			authType:        serverlessv1alpha2.RepositoryAuthSSHKey,
+			hostKeyPolicy:   serverlessv1alpha2.HostKeyPolicyTrustOnFirstUse,
+			trustedHostKeys: hostkey.Line("git.example.com:22", key),
+		}
+
+		err := a.TrustOnFirstUse(context.Background(), "git@git.example.com:org/repo.git")
+
+		require.NoError(t, err)
+		require.Equal(t, hostkey.Line("git.example.com:22", key), a.TrustedHostKeys())
+	})
+	t.Run("do nothing with strict policy", func(t *testing.T) {
+		a := &GitAuth{
+			authType:      serverlessv1alpha2.RepositoryAuthSSHKey,
+			hostKeyPolicy: serverlessv1alpha2.HostKeyPolicyStrict,
+		}
+
+		err := a.TrustOnFirstUse(context.Background(), "git@git.example.com:org/repo.git")
+
+		require.NoError(t, err)
+		require.Empty(t, a.TrustedHostKeys())
+	})
+}
+
+func TestGitAuth_GetAuthEnvs_KnownHosts(t *testing.T) {
+	a := &GitAuth{
+		secretName:      "git-creds",
+		authType:        serverlessv1alpha2.RepositoryAuthSSHKey,
+		knownHosts:      "github.com ssh-ed25519 AAAA\n",
+		trustedHostKeys: "gitlab.com ssh-ed25519 BBBB\n",
+	}
+
+	envs := a.GetAuthEnvs()
+
+	require.Contains(t, envs, corev1.EnvVar{
+		Name:  knownHostsEnvVarName,
+		Value: "github.com ssh-ed25519 AAAA\ngitlab.com ssh-ed25519 BBBB\n",
+	})
+}
+
+func TestGitAuth_identity(t *testing.T) {
+	a := &GitAuth{secretName: "git-creds", secretNamespace: "default"}
+	withKnownHosts := &GitAuth{secretName: "git-creds", secretNamespace: "default", knownHosts: "github.com ssh-ed25519 AAAA\n"}
+
+	require.NotEqual(t, a.identity(), withKnownHosts.identity())
+	require.Empty(t, (*GitAuth)(nil).identity())
+}
+
+// fixSSHServer starts the SSH server presenting the returned host key
+func fixSSHServer(t *testing.T) (crypto_ssh.PublicKey, string) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err := crypto_ssh.NewSignerFromKey(private)
+	require.NoError(t, err)
+
+	config := &crypto_ssh.ServerConfig{NoClientAuth: true}
+	config.AddHostKey(signer)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_, _, _, _ = crypto_ssh.NewServerConn(conn, config)
+			}()
+		}
+	}()
+
+	return signer.PublicKey(), l.Addr().String()
+}
+
+func hashedKnownHostsLine(address string, key crypto_ssh.PublicKey) string {
+	return knownhosts.HashHostname(knownhosts.Normalize(address)) + " " + string(crypto_ssh.MarshalAuthorizedKey(key))
+}
+
+func TestNewGitAuth_TokenTypes(t *testing.T) {
+	_, privateKey := fixGitHubAppKey(t)
+	server := fixGitHubAPIServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"token":"installation-token","expires_at":"2099-01-01T00:00:00Z"}`)
+	})
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "token-creds", Namespace: "default"},
+			Data:       map[string][]byte{"token": []byte("bearer-token")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "app-creds", Namespace: "default"},
+			Data: map[string][]byte{
+				"app-id":          []byte("12345"),
+				"installation-id": []byte("67890\n"),
+				"private-key":     privateKey,
+				"api-url":         []byte(server.URL),
+			},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "incomplete-app-creds", Namespace: "default"},
+			Data:       map[string][]byte{"app-id": []byte("12345")},
+		},
+	).Build()
+	fixFunction := func(authType serverlessv1alpha2.RepositoryAuthType, secretName string) *serverlessv1alpha2.Function {
+		return &serverlessv1alpha2.Function{
+			ObjectMeta: metav1.ObjectMeta{Name: "function", Namespace: "default"},
+			Spec: serverlessv1alpha2.FunctionSpec{
+				Source: serverlessv1alpha2.Source{
+					GitRepository: &serverlessv1alpha2.GitRepositorySource{
+						URL:  "https://github.com/org/repo.git",
+						Auth: &serverlessv1alpha2.RepositoryAuth{Type: authType, SecretName: secretName},
+					},
+				},
+			},
+		}
+	}
+
+	t.Run("authenticate with bearer token", func(t *testing.T) {
+		a, err := NewGitAuth(context.Background(), k8sClient, fixFunction(serverlessv1alpha2.RepositoryAuthToken, "token-creds"), "")
+		require.NoError(t, err)
+
+		auth, err := a.GetAuthMethod()
+
+		require.NoError(t, err)
+		require.Equal(t, &githttp.TokenAuth{Token: "bearer-token"}, auth)
+		require.Empty(t, a.InstallationToken())
+		require.Equal(t, []corev1.EnvVar{
+			{Name: repositoryAuthTypeEnvVarName, Value: "token"},
+			{Name: tokenEnvVarName, ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "token-creds"}, Key: "token"}}},
+		}, a.GetAuthEnvs())
+	})
+	t.Run("authenticate with GitHub App installation token", func(t *testing.T) {
+		a, err := NewGitAuth(context.Background(), k8sClient, fixFunction(serverlessv1alpha2.RepositoryAuthGitHubApp, "app-creds"), "")
+		require.NoError(t, err)
+
+		auth, err := a.GetAuthMethod()
+
+		require.NoError(t, err)
+		require.Equal(t, &githttp.BasicAuth{Username: "x-access-token", Password: "installation-token"}, auth)
+		require.Equal(t, "installation-token", a.InstallationToken())
+		require.Equal(t, []corev1.EnvVar{
+			{Name: repositoryAuthTypeEnvVarName, Value: "githubApp"},
+			{Name: tokenEnvVarName, ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "function-git-token"}, Key: "token"}}},
+		}, a.GetAuthEnvs())
+	})
+	t.Run("return error for missing GitHub App data", func(t *testing.T) {
+		_, err := NewGitAuth(context.Background(), k8sClient, fixFunction(serverlessv1alpha2.RepositoryAuthGitHubApp, "incomplete-app-creds"), "")
+
+		require.EqualError(t, err, "while parsing git authorization secret: missing 'app-id', 'installation-id' or 'private-key'")
+	})
+	t.Run("return error for missing token", func(t *testing.T) {
+		_, err := NewGitAuth(context.Background(), k8sClient, fixFunction(serverlessv1alpha2.RepositoryAuthToken, "app-creds"), "")
+
+		require.EqualError(t, err, "while parsing git authorization secret: missing 'token'")
+	})
+}
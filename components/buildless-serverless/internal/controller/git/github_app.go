@@ -0,0 +1,172 @@
+package git
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultGitHubAPIURL = "https://api.github.com"
+	// the installation token is valid for an hour, it is refreshed before
+	// so the Function's Pods starting later can still clone the repository with it
+	installationTokenRefreshBefore = 30 * time.Minute
+	// the JWT is backdated to allow the clock drift
+	appJWTClockDrift = time.Minute
+	appJWTLifetime   = 9 * time.Minute
+)
+
+type gitHubApp struct {
+	apiURL         string
+	appID          string
+	installationID string
+	privateKey     []byte
+}
+
+type installationToken struct {
+	token     string
+	expiresAt time.Time
+}
+
+type installationTokenKey struct {
+	apiURL         string
+	appID          string
+	installationID string
+	privateKey     [sha256.Size]byte
+}
+
+// installationTokens mints the GitHub App's installation tokens and shares them until they are close to expiry
+type installationTokens struct {
+	mu     sync.Mutex
+	tokens map[installationTokenKey]installationToken
+
+	// implemented to allow easier testing
+	httpClient *http.Client
+	now        func() time.Time
+}
+
+var defaultInstallationTokens = newInstallationTokens()
+
+func newInstallationTokens() *installationTokens {
+	return &installationTokens{
+		tokens:     map[installationTokenKey]installationToken{},
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		now:        time.Now,
+	}
+}
+
+func (t *installationTokens) get(ctx context.Context, app gitHubApp) (string, error) {
+	key := installationTokenKey{
+		apiURL:         app.apiURL,
+		appID:          app.appID,
+		installationID: app.installationID,
+		privateKey:     sha256.Sum256(app.privateKey),
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if token, ok := t.tokens[key]; ok && t.now().Add(installationTokenRefreshBefore).Before(token.expiresAt) {
+		return token.token, nil
+	}
+
+	token, err := t.mint(ctx, app)
+	if err != nil {
+		return "", err
+	}
+	t.tokens[key] = token
+	return token.token, nil
+}
+
+func (t *installationTokens) mint(ctx context.Context, app gitHubApp) (installationToken, error) {
+	jwt, err := appJWT(app, t.now())
+	if err != nil {
+		return installationToken{}, err
+	}
+
+	url := fmt.Sprintf("%s/app/installations/%s/access_tokens", strings.TrimSuffix(app.apiURL, "/"), app.installationID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, nil)
+	if err != nil {
+		return installationToken{}, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+jwt)
+
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return installationToken{}, errors.Wrap(err, "while requesting installation token")
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return installationToken{}, errors.Wrap(err, "while reading installation token")
+	}
+	if resp.StatusCode != http.StatusCreated {
+		return installationToken{}, fmt.Errorf("unexpected status code %d while requesting installation token: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	result := struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}{}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return installationToken{}, errors.Wrap(err, "while parsing installation token")
+	}
+	return installationToken{token: result.Token, expiresAt: result.ExpiresAt}, nil
+}
+
+// appJWT returns the JWT authenticating as the GitHub App, signed with its private key
+func appJWT(app gitHubApp, now time.Time) (string, error) {
+	key, err := parseRSAPrivateKey(app.privateKey)
+	if err != nil {
+		return "", err
+	}
+
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	claims, _ := json.Marshal(map[string]any{
+		"iat": now.Add(-appJWTClockDrift).Unix(),
+		"exp": now.Add(appJWTLifetime).Unix(),
+		"iss": app.appID,
+	})
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(nil, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", errors.Wrap(err, "while signing GitHub App JWT")
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+func parseRSAPrivateKey(privateKey []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(privateKey)
+	if block == nil {
+		return nil, errors.New("unable to decode GitHub App private key")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse GitHub App private key")
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("GitHub App private key is not an RSA key")
+	}
+	return rsaKey, nil
+}
@@ -0,0 +1,150 @@
+package git
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_appJWT(t *testing.T) {
+	key, privateKey := fixGitHubAppKey(t)
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	jwt, err := appJWT(gitHubApp{appID: "12345", privateKey: privateKey}, now)
+
+	require.NoError(t, err)
+	parts := strings.Split(jwt, ".")
+	require.Len(t, parts, 3)
+
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	require.NoError(t, err)
+	require.NoError(t, rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature))
+
+	claims, err := base64.RawURLEncoding.DecodeString(parts[1])
+	require.NoError(t, err)
+	require.JSONEq(t, fmt.Sprintf(`{"iss":"12345","iat":%d,"exp":%d}`, now.Add(-time.Minute).Unix(), now.Add(9*time.Minute).Unix()), string(claims))
+}
+
+func Test_parseRSAPrivateKey(t *testing.T) {
+	key, privateKey := fixGitHubAppKey(t)
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	t.Run("parse PKCS1 key", func(t *testing.T) {
+		parsed, err := parseRSAPrivateKey(privateKey)
+
+		require.NoError(t, err)
+		require.True(t, key.Equal(parsed))
+	})
+	t.Run("parse PKCS8 key", func(t *testing.T) {
+		parsed, err := parseRSAPrivateKey(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8}))
+
+		require.NoError(t, err)
+		require.True(t, key.Equal(parsed))
+	})
+	t.Run("return error for invalid key", func(t *testing.T) {
+		_, err := parseRSAPrivateKey([]byte("not-a-key"))
+
+		require.EqualError(t, err, "unable to decode GitHub App private key")
+	})
+}
+
+func Test_installationTokens_get(t *testing.T) {
+	_, privateKey := fixGitHubAppKey(t)
+
+	t.Run("share token until it is close to expiry", func(t *testing.T) {
+		var mints atomic.Int32
+		server := fixGitHubAPIServer(t, func(w http.ResponseWriter, r *http.Request) {
+			n := mints.Add(1)
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprintf(w, `{"token":"token-%d","expires_at":"2026-01-01T13:00:00Z"}`, n)
+		})
+		tokens := newInstallationTokens()
+		now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+		tokens.now = func() time.Time { return now }
+		app := gitHubApp{apiURL: server.URL, appID: "12345", installationID: "67890", privateKey: privateKey}
+
+		token, err := tokens.get(context.Background(), app)
+		require.NoError(t, err)
+		require.Equal(t, "token-1", token)
+
+		now = now.Add(20 * time.Minute)
+		token, err = tokens.get(context.Background(), app)
+		require.NoError(t, err)
+		require.Equal(t, "token-1", token)
+
+		now = now.Add(20 * time.Minute)
+		token, err = tokens.get(context.Background(), app)
+		require.NoError(t, err)
+		require.Equal(t, "token-2", token)
+	})
+	t.Run("do not share token of other installation", func(t *testing.T) {
+		server := fixGitHubAPIServer(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprintf(w, `{"token":"token-of-%s","expires_at":"2099-01-01T00:00:00Z"}`, strings.Split(r.URL.Path, "/")[3])
+		})
+		tokens := newInstallationTokens()
+
+		token, err := tokens.get(context.Background(), gitHubApp{apiURL: server.URL, appID: "12345", installationID: "1", privateKey: privateKey})
+		require.NoError(t, err)
+		require.Equal(t, "token-of-1", token)
+		token, err = tokens.get(context.Background(), gitHubApp{apiURL: server.URL, appID: "12345", installationID: "2", privateKey: privateKey})
+		require.NoError(t, err)
+		require.Equal(t, "token-of-2", token)
+	})
+	t.Run("return error for rejected request", func(t *testing.T) {
+		server := fixGitHubAPIServer(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message":"Not Found"}`)
+		})
+		tokens := newInstallationTokens()
+
+		_, err := tokens.get(context.Background(), gitHubApp{apiURL: server.URL, appID: "12345", installationID: "67890", privateKey: privateKey})
+
+		require.EqualError(t, err, `unexpected status code 404 while requesting installation token: {"message":"Not Found"}`)
+	})
+}
+
+func fixGitHubAppKey(t *testing.T) (*rsa.PrivateKey, []byte) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return key, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+}
+
+// fixGitHubAPIServer serves the installation token requests authenticated with the GitHub App's JWT
+func fixGitHubAPIServer(t *testing.T, handler http.HandlerFunc) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Regexp(t, `^/app/installations/[^/]+/access_tokens$`, r.URL.Path)
+		jwt, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		require.True(t, ok)
+		claims, err := base64.RawURLEncoding.DecodeString(strings.Split(jwt, ".")[1])
+		require.NoError(t, err)
+		require.Equal(t, "12345", fixJSONField(t, claims, "iss"))
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func fixJSONField(t *testing.T, data []byte, field string) any {
+	fields := map[string]any{}
+	require.NoError(t, json.Unmarshal(data, &fields))
+	return fields[field]
+}
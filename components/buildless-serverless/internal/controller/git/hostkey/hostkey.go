@@ -0,0 +1,202 @@
+package hostkey
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+const (
+	defaultSSHPort = 22
+	scanTimeout    = 10 * time.Second
+)
+
+// Error is returned when the host key of the git server is not trusted
+type Error struct {
+	Host string
+	// Unknown is true when the known hosts have no key of the host, otherwise the key of the host changed
+	Unknown bool
+}
+
+func (e *Error) Error() string {
+	if e.Unknown {
+		return fmt.Sprintf("host key of '%s' is not in known hosts", e.Host)
+	}
+	return fmt.Sprintf("host key of '%s' does not match known hosts", e.Host)
+}
+
+// Callback returns the host key callback accepting only the hosts from the known_hosts content
+// the plain, hashed and wildcard host patterns are supported, the lines marked as revoked reject the key
+func Callback(knownHosts string) (ssh.HostKeyCallback, error) {
+	lines, err := parseKnownHosts(knownHosts)
+	if err != nil {
+		return nil, fmt.Errorf("while parsing known hosts: %w", err)
+	}
+
+	return func(hostname string, _ net.Addr, key ssh.PublicKey) error {
+		host := knownhosts.Normalize(hostname)
+		known := false
+		for _, l := range lines {
+			if !l.matches(host) {
+				continue
+			}
+			if bytes.Equal(l.key.Marshal(), key.Marshal()) {
+				if l.revoked {
+					return &Error{Host: host}
+				}
+				return nil
+			}
+			known = known || !l.revoked
+		}
+		return &Error{Host: host, Unknown: !known}
+	}, nil
+}
+
+type knownHostsLine struct {
+	hosts   []string
+	key     ssh.PublicKey
+	revoked bool
+}
+
+func parseKnownHosts(knownHosts string) ([]knownHostsLine, error) {
+	var lines []knownHostsLine
+	rest := []byte(knownHosts)
+	for {
+		marker, hosts, key, _, next, err := ssh.ParseKnownHosts(rest)
+		if errors.Is(err, io.EOF) {
+			return lines, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		rest = next
+		if marker == "cert-authority" {
+			// host certificates are not supported
+			continue
+		}
+		lines = append(lines, knownHostsLine{hosts: hosts, key: key, revoked: marker == "revoked"})
+	}
+}
+
+func (l *knownHostsLine) matches(host string) bool {
+	matched := false
+	for _, pattern := range l.hosts {
+		negated := strings.HasPrefix(pattern, "!")
+		if !matchHost(strings.TrimPrefix(pattern, "!"), host) {
+			continue
+		}
+		if negated {
+			return false
+		}
+		matched = true
+	}
+	return matched
+}
+
+func matchHost(pattern, host string) bool {
+	if strings.HasPrefix(pattern, "|1|") {
+		// |1|base64(salt)|base64(hmac-sha1(salt, host))
+		parts := strings.Split(pattern[len("|1|"):], "|")
+		if len(parts) != 2 {
+			return false
+		}
+		salt, err := base64.StdEncoding.DecodeString(parts[0])
+		if err != nil {
+			return false
+		}
+		mac := hmac.New(sha1.New, salt)
+		mac.Write([]byte(host))
+		return base64.StdEncoding.EncodeToString(mac.Sum(nil)) == parts[1]
+	}
+	if !strings.ContainsAny(pattern, "*?") {
+		return pattern == host
+	}
+	// the brackets enclose the host with non-default port, they are not the character class
+	pattern = strings.NewReplacer("[", `\[`, "]", `\]`).Replace(pattern)
+	matched, err := path.Match(pattern, host)
+	return err == nil && matched
+}
+
+// Address returns the host and port of the SSH repository URL
+func Address(url string) (string, error) {
+	endpoint, err := transport.NewEndpoint(url)
+	if err != nil {
+		return "", err
+	}
+	if endpoint.Protocol != "ssh" {
+		return "", fmt.Errorf("repository '%s' is not accessed over SSH", url)
+	}
+	port := endpoint.Port
+	if port == 0 {
+		port = defaultSSHPort
+	}
+	return net.JoinHostPort(endpoint.Host, strconv.Itoa(port)), nil
+}
+
+// Scan returns the host key of the SSH server, like `ssh-keyscan` does
+func Scan(ctx context.Context, address string) (ssh.PublicKey, net.Addr, error) {
+	ctx, cancel := context.WithTimeout(ctx, scanTimeout)
+	defer cancel()
+
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	var hostKey ssh.PublicKey
+	errScanned := errors.New("host key scanned")
+	config := &ssh.ClientConfig{
+		HostKeyCallback: func(_ string, _ net.Addr, key ssh.PublicKey) error {
+			hostKey = key
+			// stop the handshake, the connection is not authenticated
+			return errScanned
+		},
+	}
+	_, _, _, err = ssh.NewClientConn(conn, address, config)
+	if hostKey == nil {
+		if err == nil {
+			err = errors.New("server did not present its host key")
+		}
+		return nil, nil, fmt.Errorf("while scanning host key of '%s': %w", address, err)
+	}
+	return hostKey, conn.RemoteAddr(), nil
+}
+
+// Line returns the known_hosts line of the host key
+func Line(address string, key ssh.PublicKey) string {
+	return knownhosts.Line([]string{knownhosts.Normalize(address)}, key)
+}
+
+// Contains checks if the known_hosts content has a plain, not hashed, line of the host
+func Contains(knownHosts, address string) bool {
+	lines, err := parseKnownHosts(knownHosts)
+	if err != nil {
+		return false
+	}
+	host := knownhosts.Normalize(address)
+	for _, l := range lines {
+		if slices.Contains(l.hosts, host) {
+			return true
+		}
+	}
+	return false
+}
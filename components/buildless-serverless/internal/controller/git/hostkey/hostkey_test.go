@@ -0,0 +1,224 @@
+package hostkey
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestCallback(t *testing.T) {
+	key := fixPublicKey(t)
+	otherKey := fixPublicKey(t)
+
+	tests := []struct {
+		name       string
+		knownHosts string
+		hostname   string
+		key        ssh.PublicKey
+		wantErr    error
+	}{
+		{
+			name:       "accept known host",
+			knownHosts: Line("github.com:22", key),
+			hostname:   "github.com:22",
+			key:        key,
+		},
+		{
+			name:       "accept known host with non-default port",
+			knownHosts: Line("git.example.com:2222", key),
+			hostname:   "git.example.com:2222",
+			key:        key,
+		},
+		{
+			name:       "accept one of the host keys",
+			knownHosts: Line("github.com:22", otherKey) + "\n" + Line("github.com:22", key),
+			hostname:   "github.com:22",
+			key:        key,
+		},
+		{
+			name:       "accept wildcard host",
+			knownHosts: fmt.Sprintf("*.example.com %s", ssh.MarshalAuthorizedKey(key)),
+			hostname:   "git.example.com:22",
+			key:        key,
+		},
+		{
+			name:       "accept hashed host",
+			knownHosts: fmt.Sprintf("%s %s", fixHashedHost("github.com"), ssh.MarshalAuthorizedKey(key)),
+			hostname:   "github.com:22",
+			key:        key,
+		},
+		{
+			name:       "reject unknown host",
+			knownHosts: Line("gitlab.com:22", key),
+			hostname:   "github.com:22",
+			key:        key,
+			wantErr:    &Error{Host: "github.com", Unknown: true},
+		},
+		{
+			name:       "reject unknown host when known hosts are empty",
+			knownHosts: "",
+			hostname:   "github.com:22",
+			key:        key,
+			wantErr:    &Error{Host: "github.com", Unknown: true},
+		},
+		{
+			name:       "reject other port of known host",
+			knownHosts: Line("github.com:22", key),
+			hostname:   "github.com:2222",
+			key:        key,
+			wantErr:    &Error{Host: "[github.com]:2222", Unknown: true},
+		},
+		{
+			name:       "reject changed key",
+			knownHosts: Line("github.com:22", otherKey),
+			hostname:   "github.com:22",
+			key:        key,
+			wantErr:    &Error{Host: "github.com"},
+		},
+		{
+			name:       "reject revoked key",
+			knownHosts: fmt.Sprintf("@revoked * %s", ssh.MarshalAuthorizedKey(key)) + Line("github.com:22", key),
+			hostname:   "github.com:22",
+			key:        key,
+			wantErr:    &Error{Host: "github.com"},
+		},
+		{
+			name:       "reject negated host",
+			knownHosts: fmt.Sprintf("*.example.com,!evil.example.com %s", ssh.MarshalAuthorizedKey(key)),
+			hostname:   "evil.example.com:22",
+			key:        key,
+			wantErr:    &Error{Host: "evil.example.com", Unknown: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			callback, err := Callback(tt.knownHosts)
+			require.NoError(t, err)
+
+			err = callback(tt.hostname, nil, tt.key)
+
+			if tt.wantErr == nil {
+				require.NoError(t, err)
+				return
+			}
+			require.Equal(t, tt.wantErr, err)
+		})
+	}
+	t.Run("return error for invalid known hosts", func(t *testing.T) {
+		_, err := Callback("github.com ssh-ed25519 not-a-key")
+
+		require.ErrorContains(t, err, "while parsing known hosts")
+	})
+}
+
+func TestError_Error(t *testing.T) {
+	require.Equal(t, "host key of 'github.com' is not in known hosts", (&Error{Host: "github.com", Unknown: true}).Error())
+	require.Equal(t, "host key of 'github.com' does not match known hosts", (&Error{Host: "github.com"}).Error())
+}
+
+func TestAddress(t *testing.T) {
+	tests := map[string]string{
+		"git@github.com:org/repo.git":           "github.com:22",
+		"ssh://git@github.com/org/repo.git":     "github.com:22",
+		"ssh://git@git.example.com:2222/org/re": "git.example.com:2222",
+	}
+	for url, want := range tests {
+		t.Run(url, func(t *testing.T) {
+			address, err := Address(url)
+
+			require.NoError(t, err)
+			require.Equal(t, want, address)
+		})
+	}
+	t.Run("return error for https url", func(t *testing.T) {
+		_, err := Address("https://github.com/org/repo.git")
+
+		require.EqualError(t, err, "repository 'https://github.com/org/repo.git' is not accessed over SSH")
+	})
+}
+
+func TestContains(t *testing.T) {
+	key := fixPublicKey(t)
+	knownHosts := Line("github.com:22", key) + "\n" + Line("git.example.com:2222", key)
+
+	require.True(t, Contains(knownHosts, "github.com:22"))
+	require.True(t, Contains(knownHosts, "git.example.com:2222"))
+	require.False(t, Contains(knownHosts, "git.example.com:22"))
+	require.False(t, Contains("", "github.com:22"))
+}
+
+func TestScan(t *testing.T) {
+	t.Run("return host key of the server", func(t *testing.T) {
+		key, address := fixServer(t)
+
+		scanned, remote, err := Scan(context.Background(), address)
+
+		require.NoError(t, err)
+		require.Equal(t, key.Marshal(), scanned.Marshal())
+		require.Equal(t, address, remote.String())
+	})
+	t.Run("return error when server is not reachable", func(t *testing.T) {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		address := l.Addr().String()
+		require.NoError(t, l.Close())
+
+		_, _, err = Scan(context.Background(), address)
+
+		require.ErrorContains(t, err, "connection refused")
+	})
+}
+
+func fixPublicKey(t *testing.T) ssh.PublicKey {
+	public, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	key, err := ssh.NewPublicKey(public)
+	require.NoError(t, err)
+	return key
+}
+
+func fixHashedHost(host string) string {
+	salt := []byte("0123456789abcdefghij")
+	mac := hmac.New(sha1.New, salt)
+	mac.Write([]byte(host))
+	return fmt.Sprintf("|1|%s|%s", base64.StdEncoding.EncodeToString(salt), base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+}
+
+// fixServer starts the SSH server presenting the returned host key
+func fixServer(t *testing.T) (ssh.PublicKey, string) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(private)
+	require.NoError(t, err)
+
+	config := &ssh.ServerConfig{NoClientAuth: true}
+	config.AddHostKey(signer)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_, _, _, _ = ssh.NewServerConn(conn, config)
+			}()
+		}
+	}()
+
+	return signer.PublicKey(), l.Addr().String()
+}
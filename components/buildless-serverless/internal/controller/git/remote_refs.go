@@ -0,0 +1,208 @@
+package git
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/config"
+	serverlessmetrics "github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/metrics"
+)
+
+type remoteKey struct {
+	url  string
+	auth string
+}
+
+type remoteRefs struct {
+	// done is closed when the listing is finished
+	done     chan struct{}
+	refs     []*plumbing.Reference
+	err      error
+	listedAt time.Time
+	// failures is the number of consecutive failed listings, the next listing is delayed exponentially
+	failures int
+	retryAt  time.Time
+}
+
+// remoteRefsCache shares the listed references of the repository between the Functions using it
+// the Functions listing the same repository at the same time wait for a single listing
+type remoteRefsCache struct {
+	mu      sync.Mutex
+	entries map[remoteKey]*remoteRefs
+	hosts   map[string]chan struct{}
+	cfg     config.GitRemoteConfig
+
+	// implemented to allow easier testing
+	listRemoteRefs func(ctx context.Context, url string, auth *GitAuth) ([]*plumbing.Reference, error)
+}
+
+func newRemoteRefsCache(cfg config.GitRemoteConfig) *remoteRefsCache {
+	return &remoteRefsCache{
+		entries:        map[remoteKey]*remoteRefs{},
+		hosts:          map[string]chan struct{}{},
+		cfg:            cfg,
+		listRemoteRefs: ListRemoteRefs,
+	}
+}
+
+// latestCommit resolves the reference using the shared references of the repository
+func (c *remoteRefsCache) latestCommit(ctx context.Context, url, reference string, auth *GitAuth) (ResolvedReference, error) {
+	// A full 40-character SHA is already the commit — no remote listing needed.
+	if commitSHARegexp.MatchString(reference) {
+		return ResolvedReference{Commit: reference}, nil
+	}
+
+	refs, err := c.get(ctx, url, auth)
+	if err != nil {
+		return ResolvedReference{}, err
+	}
+	return resolveReference(refs, reference)
+}
+
+// get returns the shared references, the listing in progress is awaited until the context is done
+func (c *remoteRefsCache) get(ctx context.Context, url string, auth *GitAuth) ([]*plumbing.Reference, error) {
+	entry := c.entry(ctx, url, auth)
+	select {
+	case <-entry.done:
+		return entry.refs, entry.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// entry returns the valid entry of the repository or starts the new listing
+// the listing is shared, so it's detached from the context of the caller starting it
+// and the caller giving up waiting neither cancels it for the others nor counts as its failure
+func (c *remoteRefsCache) entry(ctx context.Context, url string, auth *GitAuth) *remoteRefs {
+	key := remoteKey{url: url, auth: auth.identity()}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry := c.entries[key]
+	if entry != nil && c.isValid(entry) {
+		serverlessmetrics.PublishGitListRemoteCacheRequest(true)
+		return entry
+	}
+
+	next := &remoteRefs{done: make(chan struct{})}
+	if entry != nil {
+		next.failures = entry.failures
+	}
+	c.entries[key] = next
+	serverlessmetrics.PublishGitListRemoteCacheRequest(false)
+
+	go c.list(context.WithoutCancel(ctx), next, url, auth)
+	return next
+}
+
+// isValid checks if the entry is being listed, is not older than the TTL, or failed and waits for the retry
+func (c *remoteRefsCache) isValid(entry *remoteRefs) bool {
+	select {
+	case <-entry.done:
+	default:
+		// the listing is in progress
+		return true
+	}
+
+	if entry.err != nil {
+		return time.Now().Before(entry.retryAt)
+	}
+	return time.Since(entry.listedAt) < c.cfg.RefsCacheTTL
+}
+
+func (c *remoteRefsCache) list(ctx context.Context, entry *remoteRefs, url string, auth *GitAuth) {
+	defer close(entry.done)
+
+	entry.refs, entry.err = c.listHost(ctx, url, auth)
+	entry.listedAt = time.Now()
+	if entry.err == nil {
+		entry.failures = 0
+		return
+	}
+	entry.failures++
+	entry.retryAt = entry.listedAt.Add(c.failureBackoff(entry.failures))
+}
+
+// listHost lists the references within the limit of the listings from the repository's host
+func (c *remoteRefsCache) listHost(ctx context.Context, url string, auth *GitAuth) ([]*plumbing.Reference, error) {
+	host := remoteHost(url)
+	release, err := c.acquireHost(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	// the time of the listing is limited, because it's not cancelled by the callers
+	if c.cfg.OperationTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.cfg.OperationTimeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	refs, err := c.listRemoteRefs(ctx, url, auth)
+	serverlessmetrics.PublishGitListRemote(host, start, err)
+	return refs, err
+}
+
+// acquireHost waits until the number of listings from the host is below the limit or the context is done
+func (c *remoteRefsCache) acquireHost(ctx context.Context, host string) (func(), error) {
+	if c.cfg.MaxListsPerHost <= 0 {
+		return func() {}, nil
+	}
+
+	c.mu.Lock()
+	slots, ok := c.hosts[host]
+	if !ok {
+		slots = make(chan struct{}, c.cfg.MaxListsPerHost)
+		c.hosts[host] = slots
+	}
+	c.mu.Unlock()
+
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (c *remoteRefsCache) failureBackoff(failures int) time.Duration {
+	backoff := c.cfg.MinFailureBackoff
+	for i := 1; i < failures && backoff < c.cfg.MaxFailureBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, c.cfg.MaxFailureBackoff)
+}
+
+// invalidate forgets the references of the repository listed with the credentials
+// the listing in progress is not awaited by the next requests
+func (c *remoteRefsCache) invalidate(url string, auth *GitAuth) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, remoteKey{url: url, auth: auth.identity()})
+}
+
+// clear forgets all listed references
+func (c *remoteRefsCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = map[remoteKey]*remoteRefs{}
+}
+
+// remoteHost returns the host of the HTTP, SSH or SCP-like repository URL
+func remoteHost(repoURL string) string {
+	if u, err := url.Parse(repoURL); err == nil && u.Host != "" {
+		return u.Hostname()
+	}
+	// git@github.com:org/repo.git
+	host, _, _ := strings.Cut(repoURL, ":")
+	if i := strings.LastIndex(host, "@"); i != -1 {
+		host = host[i+1:]
+	}
+	return host
+}
@@ -0,0 +1,278 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/pem"
+	"fmt"
+	"hash"
+	"io"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/protocol/packp"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// the number of the fetched commits remembered, the previous commits are rarely verified again
+	signedCommitsCacheSize = 1024
+
+	pgpPublicKeyBlockHeader = "-----BEGIN PGP PUBLIC KEY BLOCK-----"
+	sshSignatureHeader      = "-----BEGIN SSH SIGNATURE-----"
+	sshSignaturePEMType     = "SSH SIGNATURE"
+	sshSignatureMagic       = "SSHSIG"
+	// git signs the commits with the SSH keys in the git namespace
+	sshSignatureNamespace = "git"
+)
+
+// VerificationError is returned when the commit is not signed by any of the trusted keys
+type VerificationError struct {
+	Commit string
+	Reason string
+}
+
+func (e *VerificationError) Error() string {
+	return fmt.Sprintf("commit %s %s", e.Commit, e.Reason)
+}
+
+// TrustedKeys are the GPG and SSH public keys trusted to sign the Functions' commits
+type TrustedKeys struct {
+	pgp openpgp.EntityList
+	ssh []ssh.PublicKey
+}
+
+// LoadTrustedKeys reads the trusted keys from all keys of the secret
+func LoadTrustedKeys(ctx context.Context, c client.Client, secretName types.NamespacedName) (*TrustedKeys, error) {
+	secret := &corev1.Secret{}
+	if err := c.Get(ctx, secretName, secret); err != nil {
+		return nil, errors.Wrapf(err, "while getting trusted keys secret %s", secretName)
+	}
+
+	keys, err := ParseTrustedKeys(secret.Data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "while parsing trusted keys secret %s", secretName)
+	}
+	return keys, nil
+}
+
+// ParseTrustedKeys parses the armored GPG public keys and the SSH public keys in the authorized_keys format
+func ParseTrustedKeys(data map[string][]byte) (*TrustedKeys, error) {
+	keys := &TrustedKeys{}
+	for name, value := range data {
+		var err error
+		if bytes.Contains(value, []byte(pgpPublicKeyBlockHeader)) {
+			err = keys.parsePGP(value)
+		} else {
+			err = keys.parseSSH(value)
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "while parsing key %s", name)
+		}
+	}
+
+	if len(keys.pgp) == 0 && len(keys.ssh) == 0 {
+		return nil, errors.New("no trusted keys found")
+	}
+	return keys, nil
+}
+
+func (k *TrustedKeys) parsePGP(value []byte) error {
+	// the armored key ring is read block by block, the keys exported separately are concatenated
+	blocks := strings.Split(string(value), pgpPublicKeyBlockHeader)
+	for _, block := range blocks[1:] {
+		entities, err := openpgp.ReadArmoredKeyRing(strings.NewReader(pgpPublicKeyBlockHeader + block))
+		if err != nil {
+			return err
+		}
+		k.pgp = append(k.pgp, entities...)
+	}
+	return nil
+}
+
+func (k *TrustedKeys) parseSSH(value []byte) error {
+	for _, line := range bytes.Split(value, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+		key, _, _, _, err := ssh.ParseAuthorizedKey(line)
+		if err != nil {
+			return err
+		}
+		k.ssh = append(k.ssh, key)
+	}
+	return nil
+}
+
+// Verify checks that the commit is signed by one of the trusted keys
+func (k *TrustedKeys) Verify(commit *object.Commit) error {
+	if commit.PGPSignature == "" {
+		return &VerificationError{Commit: commit.Hash.String(), Reason: "is not signed"}
+	}
+
+	encoded := &plumbing.MemoryObject{}
+	if err := commit.EncodeWithoutSignature(encoded); err != nil {
+		return err
+	}
+	payload, err := encoded.Reader()
+	if err != nil {
+		return err
+	}
+
+	if strings.HasPrefix(strings.TrimSpace(commit.PGPSignature), sshSignatureHeader) {
+		err = k.verifySSH(payload, commit.PGPSignature)
+	} else {
+		_, err = openpgp.CheckArmoredDetachedSignature(k.pgp, payload, strings.NewReader(commit.PGPSignature), nil)
+	}
+	if err != nil {
+		return &VerificationError{Commit: commit.Hash.String(), Reason: fmt.Sprintf("is not signed by any of the trusted keys: %s", err)}
+	}
+	return nil
+}
+
+// sshSignature is the signature blob of the SSH signature
+// https://github.com/openssh/openssh-portable/blob/master/PROTOCOL.sshsig
+type sshSignature struct {
+	Version       uint32
+	PublicKey     []byte
+	Namespace     string
+	Reserved      []byte
+	HashAlgorithm string
+	Signature     []byte
+}
+
+// sshSignedData is the data signed by the SSH signature, following the magic preamble
+type sshSignedData struct {
+	Namespace     string
+	Reserved      []byte
+	HashAlgorithm string
+	Hash          []byte
+}
+
+func (k *TrustedKeys) verifySSH(payload io.Reader, armoredSignature string) error {
+	block, _ := pem.Decode([]byte(armoredSignature))
+	if block == nil || block.Type != sshSignaturePEMType {
+		return errors.New("malformed SSH signature")
+	}
+	blob, found := bytes.CutPrefix(block.Bytes, []byte(sshSignatureMagic))
+	if !found {
+		return errors.New("malformed SSH signature")
+	}
+	sig := sshSignature{}
+	if err := ssh.Unmarshal(blob, &sig); err != nil {
+		return errors.Wrap(err, "malformed SSH signature")
+	}
+	if sig.Version != 1 {
+		return errors.Errorf("unsupported SSH signature version %d", sig.Version)
+	}
+	if sig.Namespace != sshSignatureNamespace {
+		return errors.Errorf("SSH signature namespace %q is not %q", sig.Namespace, sshSignatureNamespace)
+	}
+
+	publicKey, err := ssh.ParsePublicKey(sig.PublicKey)
+	if err != nil {
+		return errors.Wrap(err, "malformed SSH signature key")
+	}
+	if !k.trustsSSH(publicKey) {
+		return errors.Errorf("SSH key %s is not trusted", ssh.FingerprintSHA256(publicKey))
+	}
+
+	var h hash.Hash
+	switch sig.HashAlgorithm {
+	case "sha256":
+		h = sha256.New()
+	case "sha512":
+		h = sha512.New()
+	default:
+		return errors.Errorf("unsupported SSH signature hash algorithm %q", sig.HashAlgorithm)
+	}
+	if _, err := io.Copy(h, payload); err != nil {
+		return err
+	}
+
+	signature := &ssh.Signature{}
+	if err := ssh.Unmarshal(sig.Signature, signature); err != nil {
+		return errors.Wrap(err, "malformed SSH signature")
+	}
+	signed := append([]byte(sshSignatureMagic), ssh.Marshal(sshSignedData{
+		Namespace:     sig.Namespace,
+		Reserved:      sig.Reserved,
+		HashAlgorithm: sig.HashAlgorithm,
+		Hash:          h.Sum(nil),
+	})...)
+	return publicKey.Verify(signed, signature)
+}
+
+func (k *TrustedKeys) trustsSSH(key ssh.PublicKey) bool {
+	for _, trusted := range k.ssh {
+		if bytes.Equal(trusted.Marshal(), key.Marshal()) {
+			return true
+		}
+	}
+	return false
+}
+
+type signedCommitKey struct {
+	url    string
+	commit string
+}
+
+// CommitVerifier verifies the signatures of the Functions' commits before they are rolled out
+type CommitVerifier interface {
+	// Verify checks that the commit is signed by one of the trusted keys, the VerificationError is returned if not
+	Verify(ctx context.Context, url string, auth *GitAuth, commit string, keys *TrustedKeys) error
+}
+
+// commitVerifier remembers the fetched commits, so the commit is fetched once
+type commitVerifier struct {
+	commits *fifoCache[signedCommitKey, *object.Commit]
+
+	// implemented to allow easier testing
+	fetchCommit func(ctx context.Context, url string, auth *GitAuth, commit string) (*object.Commit, error)
+}
+
+// NewCommitVerifier creates the verifier fetching the commits without their trees, when the git server supports it
+func NewCommitVerifier() CommitVerifier {
+	return newCommitVerifier()
+}
+
+func newCommitVerifier() *commitVerifier {
+	return &commitVerifier{
+		commits:     newFIFOCache[signedCommitKey, *object.Commit](signedCommitsCacheSize),
+		fetchCommit: fetchSignedCommit,
+	}
+}
+
+func (v *commitVerifier) Verify(ctx context.Context, url string, auth *GitAuth, commit string, keys *TrustedKeys) error {
+	key := signedCommitKey{url: url, commit: commit}
+
+	c, ok := v.commits.get(key)
+	if !ok {
+		var err error
+		c, err = v.fetchCommit(ctx, url, auth, commit)
+		if err != nil {
+			return errors.Wrapf(err, "while fetching commit %s", commit)
+		}
+		v.commits.add(key, c)
+	}
+
+	return keys.Verify(c)
+}
+
+// fetchSignedCommit fetches the commit without its trees, or with them if the git server can't omit them
+func fetchSignedCommit(ctx context.Context, url string, auth *GitAuth, commit string) (*object.Commit, error) {
+	c, err := fetchCommit(ctx, url, auth, commit, packp.FilterTreeDepth(0))
+	if errors.Is(err, ErrPartialFetchNotSupported) {
+		return fetchCommit(ctx, url, auth, commit, "")
+	}
+	return c, err
+}
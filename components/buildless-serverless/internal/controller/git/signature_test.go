@@ -0,0 +1,209 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/pem"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestTrustedKeys_Verify(t *testing.T) {
+	pgpKey := fixPGPEntity(t)
+	sshKey := fixSSHSigner(t)
+	otherSSHKey := fixSSHSigner(t)
+	keys, err := ParseTrustedKeys(map[string][]byte{
+		"gpg": fixArmoredPublicKey(t, pgpKey),
+		"ssh": ssh.MarshalAuthorizedKey(sshKey.PublicKey()),
+	})
+	require.NoError(t, err)
+
+	t.Run("verify commit signed with trusted GPG key", func(t *testing.T) {
+		commit := fixSignedCommit(t, &git.CommitOptions{SignKey: pgpKey})
+
+		require.NoError(t, keys.Verify(commit))
+	})
+	t.Run("verify commit signed with trusted SSH key", func(t *testing.T) {
+		commit := fixSignedCommit(t, &git.CommitOptions{Signer: sshKey})
+
+		require.NoError(t, keys.Verify(commit))
+	})
+	t.Run("reject unsigned commit", func(t *testing.T) {
+		commit := fixSignedCommit(t, &git.CommitOptions{})
+
+		err := keys.Verify(commit)
+
+		var verificationErr *VerificationError
+		require.True(t, errors.As(err, &verificationErr))
+		require.Equal(t, commit.Hash.String(), verificationErr.Commit)
+		require.ErrorContains(t, err, "is not signed")
+	})
+	t.Run("reject commit signed with untrusted GPG key", func(t *testing.T) {
+		commit := fixSignedCommit(t, &git.CommitOptions{SignKey: fixPGPEntity(t)})
+
+		var verificationErr *VerificationError
+		require.True(t, errors.As(keys.Verify(commit), &verificationErr))
+	})
+	t.Run("reject commit signed with untrusted SSH key", func(t *testing.T) {
+		commit := fixSignedCommit(t, &git.CommitOptions{Signer: otherSSHKey})
+
+		err := keys.Verify(commit)
+
+		var verificationErr *VerificationError
+		require.True(t, errors.As(err, &verificationErr))
+		require.ErrorContains(t, err, "is not trusted")
+	})
+	t.Run("reject SSH signature of other namespace", func(t *testing.T) {
+		signer := fixSSHSigner(t)
+		signer.namespace = "file"
+		keys, err := ParseTrustedKeys(map[string][]byte{"ssh": ssh.MarshalAuthorizedKey(signer.PublicKey())})
+		require.NoError(t, err)
+		commit := fixSignedCommit(t, &git.CommitOptions{Signer: signer})
+
+		require.ErrorContains(t, keys.Verify(commit), "namespace")
+	})
+}
+
+func TestParseTrustedKeys(t *testing.T) {
+	t.Run("parse concatenated GPG keys and SSH keys", func(t *testing.T) {
+		first, second := fixPGPEntity(t), fixPGPEntity(t)
+		sshKey := fixSSHSigner(t)
+		authorizedKeys := "# team keys\n\n" + string(ssh.MarshalAuthorizedKey(sshKey.PublicKey())) + string(ssh.MarshalAuthorizedKey(fixSSHSigner(t).PublicKey()))
+
+		keys, err := ParseTrustedKeys(map[string][]byte{
+			"gpg": append(fixArmoredPublicKey(t, first), fixArmoredPublicKey(t, second)...),
+			"ssh": []byte(authorizedKeys),
+		})
+
+		require.NoError(t, err)
+		require.Len(t, keys.pgp, 2)
+		require.Len(t, keys.ssh, 2)
+	})
+	t.Run("return error when there are no keys", func(t *testing.T) {
+		_, err := ParseTrustedKeys(map[string][]byte{"ssh": []byte("# no keys yet\n")})
+
+		require.ErrorContains(t, err, "no trusted keys")
+	})
+	t.Run("return error for malformed key", func(t *testing.T) {
+		_, err := ParseTrustedKeys(map[string][]byte{"ssh": []byte("ssh-ed25519 not-a-key")})
+
+		require.ErrorContains(t, err, "while parsing key ssh")
+	})
+}
+
+func Test_commitVerifier_Verify(t *testing.T) {
+	sshKey := fixSSHSigner(t)
+	keys, err := ParseTrustedKeys(map[string][]byte{"ssh": ssh.MarshalAuthorizedKey(sshKey.PublicKey())})
+	require.NoError(t, err)
+	signed := fixSignedCommit(t, &git.CommitOptions{Signer: sshKey})
+
+	t.Run("fetch commit once", func(t *testing.T) {
+		v := newCommitVerifier()
+		fetches := 0
+		v.fetchCommit = func(_ context.Context, _ string, _ *GitAuth, _ string) (*object.Commit, error) {
+			fetches++
+			return signed, nil
+		}
+
+		require.NoError(t, v.Verify(context.Background(), "https://github.com/org/repo", nil, signed.Hash.String(), keys))
+		require.NoError(t, v.Verify(context.Background(), "https://github.com/org/repo", nil, signed.Hash.String(), keys))
+		require.Equal(t, 1, fetches)
+	})
+	t.Run("return fetch error", func(t *testing.T) {
+		v := newCommitVerifier()
+		v.fetchCommit = func(_ context.Context, _ string, _ *GitAuth, _ string) (*object.Commit, error) {
+			return nil, errors.New("connection refused")
+		}
+
+		err := v.Verify(context.Background(), "https://github.com/org/repo", nil, signed.Hash.String(), keys)
+
+		require.ErrorContains(t, err, "connection refused")
+		var verificationErr *VerificationError
+		require.False(t, errors.As(err, &verificationErr))
+	})
+}
+
+// fixSignedCommit creates the commit signed as configured in the options
+func fixSignedCommit(t *testing.T, opts *git.CommitOptions) *object.Commit {
+	fs := memfs.New()
+	repo, err := git.Init(memory.NewStorage(), fs)
+	require.NoError(t, err)
+	wt, err := repo.Worktree()
+	require.NoError(t, err)
+	require.NoError(t, util.WriteFile(fs, "handler.js", []byte("module.exports = {}"), 0o644))
+	_, err = wt.Add("handler.js")
+	require.NoError(t, err)
+	opts.Author = &object.Signature{Name: "test"}
+	hash, err := wt.Commit("test", opts)
+	require.NoError(t, err)
+	commit, err := repo.CommitObject(hash)
+	require.NoError(t, err)
+	return commit
+}
+
+func fixPGPEntity(t *testing.T) *openpgp.Entity {
+	entity, err := openpgp.NewEntity("test", "", "test@example.com", nil)
+	require.NoError(t, err)
+	return entity
+}
+
+func fixArmoredPublicKey(t *testing.T, entity *openpgp.Entity) []byte {
+	buf := &bytes.Buffer{}
+	w, err := armor.Encode(buf, openpgp.PublicKeyType, nil)
+	require.NoError(t, err)
+	require.NoError(t, entity.Serialize(w))
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+// sshSigner signs the commits like `git commit -S` with the SSH key does
+type sshSigner struct {
+	ssh.Signer
+	namespace string
+}
+
+func fixSSHSigner(t *testing.T) *sshSigner {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(key)
+	require.NoError(t, err)
+	return &sshSigner{Signer: signer, namespace: sshSignatureNamespace}
+}
+
+func (s *sshSigner) Sign(message io.Reader) ([]byte, error) {
+	h := sha512.New()
+	if _, err := io.Copy(h, message); err != nil {
+		return nil, err
+	}
+	signed := append([]byte(sshSignatureMagic), ssh.Marshal(sshSignedData{
+		Namespace:     s.namespace,
+		HashAlgorithm: "sha512",
+		Hash:          h.Sum(nil),
+	})...)
+	signature, err := s.Signer.Sign(rand.Reader, signed)
+	if err != nil {
+		return nil, err
+	}
+	blob := append([]byte(sshSignatureMagic), ssh.Marshal(sshSignature{
+		Version:       1,
+		PublicKey:     s.PublicKey().Marshal(),
+		Namespace:     s.namespace,
+		HashAlgorithm: "sha512",
+		Signature:     ssh.Marshal(signature),
+	})...)
+	return pem.EncodeToMemory(&pem.Block{Type: sshSignaturePEMType, Bytes: blob}), nil
+}
@@ -0,0 +1,275 @@
+package git
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/format/packfile"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/protocol/packp"
+	"github.com/go-git/go-git/v5/plumbing/protocol/packp/capability"
+	"github.com/go-git/go-git/v5/plumbing/protocol/packp/sideband"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/client"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/pkg/errors"
+)
+
+// the number of the source tree hashes remembered, the hashes of the previous commits are rarely needed again
+const sourceTreesCacheSize = 1024
+
+// ErrPartialFetchNotSupported is returned when the git server can't omit the files while fetching the commit
+var ErrPartialFetchNotSupported = errors.New("git server does not support partial fetch")
+
+// ErrDirectoryNotFound is returned when the commit doesn't contain the directory
+var ErrDirectoryNotFound = errors.New("directory not found")
+
+type sourceTreeKey struct {
+	url    string
+	commit string
+	paths  string
+}
+
+// SourceTrees hashes the Functions' source trees to find out if the new commit changed them
+type SourceTrees interface {
+	// Hash returns the hash of the paths' trees in the commit, it changes only when the files under the paths change
+	Hash(ctx context.Context, url string, auth *GitAuth, commit string, paths []string) (string, error)
+	// Files returns the names of the files in the directory of the commit, ErrDirectoryNotFound is returned if it's missing
+	Files(ctx context.Context, url string, auth *GitAuth, commit string, dir string) ([]string, error)
+}
+
+// sourceDir is the listed directory, err is ErrDirectoryNotFound when the directory is missing
+type sourceDir struct {
+	files []string
+	err   error
+}
+
+// sourceTrees remembers the hashes and the files of the Functions' source trees, so the commit is fetched once
+type sourceTrees struct {
+	hashes *fifoCache[sourceTreeKey, string]
+	dirs   *fifoCache[sourceTreeKey, sourceDir]
+
+	// implemented to allow easier testing
+	fetchTree func(ctx context.Context, url string, auth *GitAuth, commit string) (*object.Tree, error)
+}
+
+// NewSourceTrees creates the source trees hashing the commits fetched without the files,
+// so the git server must support the partial fetch
+func NewSourceTrees() SourceTrees {
+	return newSourceTrees()
+}
+
+func newSourceTrees() *sourceTrees {
+	return &sourceTrees{
+		hashes:    newFIFOCache[sourceTreeKey, string](sourceTreesCacheSize),
+		dirs:      newFIFOCache[sourceTreeKey, sourceDir](sourceTreesCacheSize),
+		fetchTree: fetchTree,
+	}
+}
+
+func (s *sourceTrees) Hash(ctx context.Context, url string, auth *GitAuth, commit string, paths []string) (string, error) {
+	paths = normalizeSourcePaths(paths)
+	key := sourceTreeKey{url: url, commit: commit, paths: strings.Join(paths, "\n")}
+
+	hash, ok := s.hashes.get(key)
+	if ok {
+		return hash, nil
+	}
+
+	tree, err := s.fetchTree(ctx, url, auth, commit)
+	if err != nil {
+		return "", err
+	}
+	hash, err = pathsTreeHash(tree, paths)
+	if err != nil {
+		return "", err
+	}
+	s.hashes.add(key, hash)
+	return hash, nil
+}
+
+func (s *sourceTrees) Files(ctx context.Context, url string, auth *GitAuth, commit string, dir string) ([]string, error) {
+	dir = normalizeSourcePaths([]string{dir})[0]
+	key := sourceTreeKey{url: url, commit: commit, paths: dir}
+
+	if d, ok := s.dirs.get(key); ok {
+		return d.files, d.err
+	}
+
+	tree, err := s.fetchTree(ctx, url, auth, commit)
+	if err != nil {
+		return nil, err
+	}
+	files, err := dirFiles(tree, dir)
+	if err != nil && !errors.Is(err, ErrDirectoryNotFound) {
+		return nil, err
+	}
+
+	s.dirs.add(key, sourceDir{files: files, err: err})
+	return files, err
+}
+
+// IsRootSourcePath checks if the path is the root of the repository, whose tree changes with every commit
+func IsRootSourcePath(path string) bool {
+	path = strings.Trim(path, "/ ")
+	return path == "" || path == "."
+}
+
+func normalizeSourcePaths(paths []string) []string {
+	result := make([]string, 0, len(paths))
+	for _, path := range paths {
+		result = append(result, strings.Trim(path, "/ "))
+	}
+	slices.Sort(result)
+	return slices.Compact(result)
+}
+
+// pathsTreeHash hashes the tree hashes of the paths, the missing path is hashed as well, so adding it changes the hash
+func pathsTreeHash(tree *object.Tree, paths []string) (string, error) {
+	h := sha256.New()
+	for _, path := range paths {
+		entryHash := plumbing.ZeroHash
+		if IsRootSourcePath(path) {
+			entryHash = tree.Hash
+		} else {
+			entry, err := tree.FindEntry(path)
+			if err != nil && !errors.Is(err, object.ErrEntryNotFound) && !errors.Is(err, object.ErrDirectoryNotFound) {
+				return "", errors.Wrapf(err, "while looking for path %s", path)
+			}
+			if entry != nil {
+				entryHash = entry.Hash
+			}
+		}
+		fmt.Fprintf(h, "%s %s\n", path, entryHash)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// dirFiles lists the files in the directory of the tree, the submodules are not listed
+func dirFiles(tree *object.Tree, dir string) ([]string, error) {
+	if !IsRootSourcePath(dir) {
+		entry, err := tree.FindEntry(dir)
+		if errors.Is(err, object.ErrEntryNotFound) || errors.Is(err, object.ErrDirectoryNotFound) {
+			return nil, ErrDirectoryNotFound
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "while looking for directory %s", dir)
+		}
+		if entry.Mode == filemode.Submodule {
+			// the submodule's files are in its own repository
+			return nil, errors.Errorf("directory %s is a submodule", dir)
+		}
+		if entry.Mode != filemode.Dir {
+			return nil, ErrDirectoryNotFound
+		}
+		tree, err = tree.Tree(dir)
+		if err != nil {
+			return nil, errors.Wrapf(err, "while reading directory %s", dir)
+		}
+	}
+
+	files := []string{}
+	for _, entry := range tree.Entries {
+		if entry.Mode != filemode.Dir && entry.Mode != filemode.Submodule {
+			files = append(files, entry.Name)
+		}
+	}
+	return files, nil
+}
+
+// fetchTree fetches the commit with its trees and without the files, like `git fetch --depth=1 --filter=blob:none` does
+func fetchTree(ctx context.Context, url string, auth *GitAuth, commit string) (*object.Tree, error) {
+	c, err := fetchCommit(ctx, url, auth, commit, packp.FilterBlobNone())
+	if err != nil {
+		return nil, err
+	}
+	return c.Tree()
+}
+
+// fetchCommit fetches the commit without its parents, the objects excluded by the filter are not fetched
+func fetchCommit(ctx context.Context, url string, auth *GitAuth, commit string, filter packp.Filter) (*object.Commit, error) {
+	endpoint, err := transport.NewEndpoint(url)
+	if err != nil {
+		return nil, err
+	}
+	cli, err := client.NewClient(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	var authMethod transport.AuthMethod
+	if auth != nil {
+		authMethod, err = auth.GetAuthMethod()
+		if err != nil {
+			return nil, errors.Wrap(err, "while choosing authorization method")
+		}
+	}
+
+	session, err := cli.NewUploadPackSession(endpoint, authMethod)
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+
+	advRefs, err := session.AdvertisedReferencesContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !advRefs.Capabilities.Supports(capability.Shallow) ||
+		(filter != "" && !advRefs.Capabilities.Supports(capability.Filter)) {
+		return nil, ErrPartialFetchNotSupported
+	}
+
+	req := packp.NewUploadPackRequestFromCapabilities(advRefs.Capabilities)
+	req.Wants = []plumbing.Hash{plumbing.NewHash(commit)}
+	req.Depth = packp.DepthCommits(1)
+	if err := req.Capabilities.Set(capability.Shallow); err != nil {
+		return nil, err
+	}
+	if filter != "" {
+		req.Filter = filter
+		if err := req.Capabilities.Set(capability.Filter); err != nil {
+			return nil, err
+		}
+	}
+	if advRefs.Capabilities.Supports(capability.NoProgress) {
+		if err := req.Capabilities.Set(capability.NoProgress); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := session.UploadPack(ctx, req)
+	if err != nil {
+		return nil, errors.Wrap(err, "while fetching commit")
+	}
+	defer resp.Close()
+
+	storage := memory.NewStorage()
+	if err := packfile.UpdateObjectStorage(storage, sidebandReader(req.Capabilities, resp)); err != nil {
+		return nil, errors.Wrap(err, "while reading fetched commit")
+	}
+
+	c, err := object.GetCommit(storage, plumbing.NewHash(commit))
+	if err != nil {
+		return nil, errors.Wrap(err, "while reading fetched commit")
+	}
+	return c, nil
+}
+
+func sidebandReader(capabilities *capability.List, reader io.Reader) io.Reader {
+	switch {
+	case capabilities.Supports(capability.Sideband64k):
+		return sideband.NewDemuxer(sideband.Sideband64k, reader)
+	case capabilities.Supports(capability.Sideband):
+		return sideband.NewDemuxer(sideband.Sideband, reader)
+	default:
+		return reader
+	}
+}
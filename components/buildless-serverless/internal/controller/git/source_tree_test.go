@@ -0,0 +1,154 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_sourceTrees_Hash(t *testing.T) {
+	base := map[string]string{
+		"functions/a/handler.js": "a",
+		"functions/b/handler.js": "b",
+		"lib/util.js":            "util",
+	}
+	otherFunctionChanged := map[string]string{
+		"functions/a/handler.js": "a",
+		"functions/b/handler.js": "b2",
+		"lib/util.js":            "util",
+	}
+	libChanged := map[string]string{
+		"functions/a/handler.js": "a",
+		"functions/b/handler.js": "b",
+		"lib/util.js":            "util2",
+	}
+	commits := map[string]*object.Tree{
+		"base":                 fixTree(t, base),
+		"otherFunctionChanged": fixTree(t, otherFunctionChanged),
+		"libChanged":           fixTree(t, libChanged),
+	}
+	s := newSourceTrees()
+	fetches := 0
+	s.fetchTree = func(_ context.Context, _ string, _ *GitAuth, commit string) (*object.Tree, error) {
+		fetches++
+		return commits[commit], nil
+	}
+	hash := func(commit string, paths ...string) string {
+		h, err := s.Hash(context.Background(), "https://github.com/org/monorepo", nil, commit, paths)
+		require.NoError(t, err)
+		return h
+	}
+
+	t.Run("keep hash when other function changed", func(t *testing.T) {
+		require.Equal(t, hash("base", "functions/a"), hash("otherFunctionChanged", "/functions/a/"))
+	})
+	t.Run("change hash when function changed", func(t *testing.T) {
+		require.NotEqual(t, hash("base", "functions/b"), hash("otherFunctionChanged", "functions/b"))
+	})
+	t.Run("change hash when watched path changed", func(t *testing.T) {
+		require.Equal(t, hash("base", "functions/a"), hash("libChanged", "functions/a"))
+		require.NotEqual(t, hash("base", "functions/a", "lib"), hash("libChanged", "functions/a", "lib"))
+	})
+	t.Run("change hash when watched paths changed", func(t *testing.T) {
+		require.NotEqual(t, hash("base", "functions/a"), hash("base", "functions/a", "lib"))
+		require.NotEqual(t, hash("base", "functions/a"), hash("base", "functions/a", "missing"))
+	})
+	t.Run("fetch commit once", func(t *testing.T) {
+		fetches = 0
+		hash("base", "functions/a")
+		hash("base", "functions/a")
+		require.Equal(t, 0, fetches)
+		hash("base", "functions/c")
+		require.Equal(t, 1, fetches)
+	})
+	t.Run("return fetch error", func(t *testing.T) {
+		s := newSourceTrees()
+		s.fetchTree = func(_ context.Context, _ string, _ *GitAuth, _ string) (*object.Tree, error) {
+			return nil, ErrPartialFetchNotSupported
+		}
+
+		_, err := s.Hash(context.Background(), "https://github.com/org/monorepo", nil, "base", []string{"functions/a"})
+
+		require.True(t, errors.Is(err, ErrPartialFetchNotSupported))
+	})
+}
+
+func Test_sourceTrees_Files(t *testing.T) {
+	s := newSourceTrees()
+	fetches := 0
+	s.fetchTree = func(_ context.Context, _ string, _ *GitAuth, _ string) (*object.Tree, error) {
+		fetches++
+		return fixTree(t, map[string]string{
+			"functions/a/handler.js":   "a",
+			"functions/a/package.json": "{}",
+			"functions/a/lib/util.js":  "util",
+			"README.md":                "readme",
+		}), nil
+	}
+	files := func(dir string) ([]string, error) {
+		return s.Files(context.Background(), "https://github.com/org/monorepo", nil, "base", dir)
+	}
+
+	t.Run("list files in directory", func(t *testing.T) {
+		result, err := files("/functions/a/")
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{"handler.js", "package.json"}, result)
+	})
+	t.Run("list files in repository root", func(t *testing.T) {
+		result, err := files("/")
+		require.NoError(t, err)
+		require.Equal(t, []string{"README.md"}, result)
+	})
+	t.Run("return error for missing directory", func(t *testing.T) {
+		_, err := files("functions/b")
+		require.True(t, errors.Is(err, ErrDirectoryNotFound))
+	})
+	t.Run("return error for file", func(t *testing.T) {
+		_, err := files("functions/a/handler.js")
+		require.True(t, errors.Is(err, ErrDirectoryNotFound))
+	})
+	t.Run("fetch commit once", func(t *testing.T) {
+		fetches = 0
+		_, _ = files("functions/b")
+		_, _ = files("functions/b")
+		_, _ = files("functions/a")
+		require.Equal(t, 0, fetches)
+		_, _ = files("functions/c")
+		require.Equal(t, 1, fetches)
+	})
+}
+
+func TestIsRootSourcePath(t *testing.T) {
+	for _, path := range []string{"", "/", ".", " / "} {
+		require.True(t, IsRootSourcePath(path), path)
+	}
+	require.False(t, IsRootSourcePath("/functions/a"))
+}
+
+// fixTree commits the files and returns the commit's tree
+func fixTree(t *testing.T, files map[string]string) *object.Tree {
+	fs := memfs.New()
+	repo, err := git.Init(memory.NewStorage(), fs)
+	require.NoError(t, err)
+	wt, err := repo.Worktree()
+	require.NoError(t, err)
+	for path, content := range files {
+		require.NoError(t, util.WriteFile(fs, path, []byte(content), 0o644))
+		_, err = wt.Add(path)
+		require.NoError(t, err)
+	}
+	hash, err := wt.Commit("test", &git.CommitOptions{Author: &object.Signature{Name: "test"}})
+	require.NoError(t, err)
+	commit, err := repo.CommitObject(hash)
+	require.NoError(t, err)
+	tree, err := commit.Tree()
+	require.NoError(t, err)
+	return tree
+}
@@ -46,6 +46,40 @@ var (
 		},
 		[]string{"runtime", "source", "state"},
 	)
+	GitListRemoteTime = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "serverless_git_ls_remote_time_seconds",
+			Help:    "Time taken for listing the references of a git repository",
+			Buckets: []float64{0.1, 0.3, 1, 3, 10, 30},
+		},
+		[]string{"host"},
+	)
+	GitListRemoteErrorsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "serverless_git_ls_remote_errors_total",
+			Help: "Total number of failed listings of the references of a git repository",
+		},
+		[]string{"host"},
+	)
+	GitListRemoteCacheRequestsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "serverless_git_ls_remote_cache_requests_total",
+			Help: "Total number of requests for the references of a git repository served from the cache (hit) or by listing them (miss)",
+		},
+		[]string{"result"},
+	)
+	GitCommitCheckOrdersInFlight = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "serverless_git_commit_check_orders_in_flight",
+			Help: "Number of latest commit checks of a git repository in progress",
+		},
+	)
+	GitCommitCheckOrdersTimedOutTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "serverless_git_commit_check_orders_timed_out_total",
+			Help: "Total number of latest commit checks of a git repository that timed out",
+		},
+	)
 	stateReachTimeInfo     = map[string]functionStateReachTimeInfo{}
 	processedFunctionsUIDs = sets.Set[string]{}
 )
@@ -56,6 +90,11 @@ func Register() {
 		ReconciliationsTotal,
 		ReconciliationTime,
 		StateReachTime,
+		GitListRemoteTime,
+		GitListRemoteErrorsTotal,
+		GitListRemoteCacheRequestsTotal,
+		GitCommitCheckOrdersInFlight,
+		GitCommitCheckOrdersTimedOutTotal,
 	)
 }
 
@@ -67,6 +106,9 @@ func sourceType(f serverlessv1alpha2.Function) string {
 	if f.HasGitSources() {
 		return "git"
 	}
+	if sourceType := f.ResolvedSourceType(); sourceType != "" {
+		return string(sourceType)
+	}
 	return "inline"
 }
 
@@ -121,6 +163,32 @@ func conditionIsGreaterThanRegistered(cond serverlessv1alpha2.ConditionType, reg
 	return order[cond] > regVal
 }
 
+func PublishGitListRemote(host string, start time.Time, err error) {
+	GitListRemoteTime.WithLabelValues(host).Observe(time.Since(start).Seconds())
+	if err != nil {
+		GitListRemoteErrorsTotal.WithLabelValues(host).Inc()
+	}
+}
+
+func PublishGitListRemoteCacheRequest(hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	GitListRemoteCacheRequestsTotal.WithLabelValues(result).Inc()
+}
+
+func PublishGitCommitCheckOrderStarted() {
+	GitCommitCheckOrdersInFlight.Inc()
+}
+
+func PublishGitCommitCheckOrderFinished(timedOut bool) {
+	GitCommitCheckOrdersInFlight.Dec()
+	if timedOut {
+		GitCommitCheckOrdersTimedOutTotal.Inc()
+	}
+}
+
 func PublishStateReachTime(f serverlessv1alpha2.Function, toState serverlessv1alpha2.ConditionType) {
 	uid := string(f.UID)
 	fi, ok := stateReachTimeInfo[uid]
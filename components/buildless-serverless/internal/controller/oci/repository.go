@@ -0,0 +1,62 @@
+package oci
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"oras.land/oras-go/v2/registry"
+	"oras.land/oras-go/v2/registry/remote"
+	"oras.land/oras-go/v2/registry/remote/auth"
+	"oras.land/oras-go/v2/registry/remote/credentials"
+	"oras.land/oras-go/v2/registry/remote/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// NewRepository returns the registry repository of the artifact reference,
+// authenticated with the credentials of the docker config, if set
+func NewRepository(reference string, dockerConfig []byte) (*remote.Repository, error) {
+	repo, err := remote.NewRepository(reference)
+	if err != nil {
+		return nil, errors.Wrapf(err, "while parsing reference %s", reference)
+	}
+
+	authClient := &auth.Client{
+		Client: retry.DefaultClient,
+		Cache:  auth.NewCache(),
+	}
+	if len(dockerConfig) != 0 {
+		store, err := credentials.NewMemoryStoreFromDockerConfig(dockerConfig)
+		if err != nil {
+			return nil, errors.Wrap(err, "while parsing docker config")
+		}
+		authClient.Credential = credentials.Credential(store)
+	}
+	repo.Client = authClient
+	return repo, nil
+}
+
+// DigestReference returns the reference of the artifact's digest in the repository of the reference
+func DigestReference(reference string, digest string) (string, error) {
+	ref, err := registry.ParseReference(reference)
+	if err != nil {
+		return "", errors.Wrapf(err, "while parsing reference %s", reference)
+	}
+	ref.Reference = digest
+	return ref.String(), nil
+}
+
+// LoadDockerConfig reads the docker config from the secret of the kubernetes.io/dockerconfigjson type
+func LoadDockerConfig(ctx context.Context, c client.Client, secretName types.NamespacedName) ([]byte, error) {
+	secret := &corev1.Secret{}
+	if err := c.Get(ctx, secretName, secret); err != nil {
+		return nil, errors.Wrapf(err, "while getting pull secret %s", secretName)
+	}
+
+	dockerConfig, ok := secret.Data[corev1.DockerConfigJsonKey]
+	if !ok {
+		return nil, errors.Errorf("pull secret %s has no %s key", secretName, corev1.DockerConfigJsonKey)
+	}
+	return dockerConfig, nil
+}
@@ -0,0 +1,55 @@
+package oci
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestNewRepository(t *testing.T) {
+	t.Run("create repository with credentials", func(t *testing.T) {
+		repo, err := NewRepository("registry.example.com/team/function:1.0.0",
+			[]byte(`{"auths":{"registry.example.com":{"username":"user","password":"pass"}}}`))
+
+		require.NoError(t, err)
+		require.Equal(t, "registry.example.com", repo.Reference.Registry)
+		require.Equal(t, "team/function", repo.Reference.Repository)
+	})
+	t.Run("return error for malformed docker config", func(t *testing.T) {
+		_, err := NewRepository("registry.example.com/team/function:1.0.0", []byte("not json"))
+
+		require.ErrorContains(t, err, "while parsing docker config")
+	})
+}
+
+func TestDigestReference(t *testing.T) {
+	reference, err := DigestReference("registry.example.com/team/function:1.0.0", testDigest)
+
+	require.NoError(t, err)
+	require.Equal(t, "registry.example.com/team/function@"+testDigest, reference)
+}
+
+func TestLoadDockerConfig(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "pull-secret", Namespace: "default"},
+		Data:       map[string][]byte{corev1.DockerConfigJsonKey: []byte(`{"auths":{}}`)},
+	}
+	c := fake.NewClientBuilder().WithObjects(secret).Build()
+
+	t.Run("read docker config", func(t *testing.T) {
+		dockerConfig, err := LoadDockerConfig(context.Background(), c, types.NamespacedName{Name: "pull-secret", Namespace: "default"})
+
+		require.NoError(t, err)
+		require.Equal(t, `{"auths":{}}`, string(dockerConfig))
+	})
+	t.Run("return error for missing secret", func(t *testing.T) {
+		_, err := LoadDockerConfig(context.Background(), c, types.NamespacedName{Name: "other", Namespace: "default"})
+
+		require.ErrorContains(t, err, "while getting pull secret")
+	})
+}
@@ -0,0 +1,94 @@
+package oci
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"oras.land/oras-go/v2/registry"
+)
+
+// Resolver resolves the references of the Functions' OCI artifacts to their digests
+type Resolver interface {
+	// Resolve returns the digest of the artifact, the reference pointing to the digest is returned without asking the registry
+	Resolve(ctx context.Context, reference string, dockerConfig []byte) (string, error)
+}
+
+type resolvedKey struct {
+	reference string
+	// the digest resolved with the credentials is not shared with the Functions without them
+	credentials string
+}
+
+type resolvedDigest struct {
+	digest     string
+	resolvedAt time.Time
+}
+
+// resolver remembers the resolved digests for the TTL, so the registry is not asked on every reconciliation
+type resolver struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	digests map[resolvedKey]resolvedDigest
+
+	// implemented to allow easier testing
+	resolveDigest func(ctx context.Context, reference string, dockerConfig []byte) (string, error)
+}
+
+// NewResolver creates the resolver remembering the resolved digests for the TTL
+func NewResolver(ttl time.Duration) Resolver {
+	return newResolver(ttl)
+}
+
+func newResolver(ttl time.Duration) *resolver {
+	return &resolver{
+		ttl:           ttl,
+		digests:       map[resolvedKey]resolvedDigest{},
+		resolveDigest: resolveDigest,
+	}
+}
+
+func (r *resolver) Resolve(ctx context.Context, reference string, dockerConfig []byte) (string, error) {
+	ref, err := registry.ParseReference(reference)
+	if err != nil {
+		return "", errors.Wrapf(err, "while parsing reference %s", reference)
+	}
+	if digest, err := ref.Digest(); err == nil {
+		return digest.String(), nil
+	}
+
+	credentials := sha256.Sum256(dockerConfig)
+	key := resolvedKey{reference: reference, credentials: hex.EncodeToString(credentials[:])}
+
+	r.mu.Lock()
+	resolved, ok := r.digests[key]
+	r.mu.Unlock()
+	if ok && time.Since(resolved.resolvedAt) < r.ttl {
+		return resolved.digest, nil
+	}
+
+	digest, err := r.resolveDigest(ctx, reference, dockerConfig)
+	if err != nil {
+		return "", err
+	}
+
+	r.mu.Lock()
+	r.digests[key] = resolvedDigest{digest: digest, resolvedAt: time.Now()}
+	r.mu.Unlock()
+	return digest, nil
+}
+
+func resolveDigest(ctx context.Context, reference string, dockerConfig []byte) (string, error) {
+	repo, err := NewRepository(reference, dockerConfig)
+	if err != nil {
+		return "", err
+	}
+	desc, err := repo.Resolve(ctx, repo.Reference.ReferenceOrDefault())
+	if err != nil {
+		return "", errors.Wrapf(err, "while resolving reference %s", reference)
+	}
+	return desc.Digest.String(), nil
+}
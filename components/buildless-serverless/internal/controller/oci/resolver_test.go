@@ -0,0 +1,87 @@
+package oci
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testDigest = "sha256:6c3c624b58dbbcd3c0dd82b4c53f04194d1247c6eebdaab7c610cf7d66709b3b"
+
+func Test_resolver_Resolve(t *testing.T) {
+	t.Run("return digest of digest reference without asking registry", func(t *testing.T) {
+		r := newResolver(time.Minute)
+		r.resolveDigest = func(_ context.Context, _ string, _ []byte) (string, error) {
+			return "", errors.New("registry should not be asked")
+		}
+
+		digest, err := r.Resolve(context.Background(), "registry.example.com/team/function@"+testDigest, nil)
+
+		require.NoError(t, err)
+		require.Equal(t, testDigest, digest)
+	})
+	t.Run("resolve tag once for TTL", func(t *testing.T) {
+		r := newResolver(time.Minute)
+		resolves := 0
+		r.resolveDigest = func(_ context.Context, reference string, _ []byte) (string, error) {
+			resolves++
+			require.Equal(t, "registry.example.com/team/function:1.0.0", reference)
+			return testDigest, nil
+		}
+
+		for range 2 {
+			digest, err := r.Resolve(context.Background(), "registry.example.com/team/function:1.0.0", nil)
+			require.NoError(t, err)
+			require.Equal(t, testDigest, digest)
+		}
+		require.Equal(t, 1, resolves)
+	})
+	t.Run("resolve tag again after TTL", func(t *testing.T) {
+		r := newResolver(0)
+		resolves := 0
+		r.resolveDigest = func(_ context.Context, _ string, _ []byte) (string, error) {
+			resolves++
+			return testDigest, nil
+		}
+
+		_, _ = r.Resolve(context.Background(), "registry.example.com/team/function:1.0.0", nil)
+		_, _ = r.Resolve(context.Background(), "registry.example.com/team/function:1.0.0", nil)
+
+		require.Equal(t, 2, resolves)
+	})
+	t.Run("do not share digest resolved with other credentials", func(t *testing.T) {
+		r := newResolver(time.Minute)
+		resolves := 0
+		r.resolveDigest = func(_ context.Context, _ string, _ []byte) (string, error) {
+			resolves++
+			return testDigest, nil
+		}
+
+		_, _ = r.Resolve(context.Background(), "registry.example.com/team/function:1.0.0", []byte(`{"auths":{}}`))
+		_, _ = r.Resolve(context.Background(), "registry.example.com/team/function:1.0.0", nil)
+
+		require.Equal(t, 2, resolves)
+	})
+	t.Run("do not remember failure", func(t *testing.T) {
+		r := newResolver(time.Minute)
+		resolves := 0
+		r.resolveDigest = func(_ context.Context, _ string, _ []byte) (string, error) {
+			resolves++
+			return "", errors.New("unauthorized")
+		}
+
+		_, err := r.Resolve(context.Background(), "registry.example.com/team/function:1.0.0", nil)
+		require.ErrorContains(t, err, "unauthorized")
+		_, _ = r.Resolve(context.Background(), "registry.example.com/team/function:1.0.0", nil)
+
+		require.Equal(t, 2, resolves)
+	})
+	t.Run("return error for invalid reference", func(t *testing.T) {
+		_, err := newResolver(time.Minute).Resolve(context.Background(), "not a reference", nil)
+
+		require.ErrorContains(t, err, "while parsing reference")
+	})
+}
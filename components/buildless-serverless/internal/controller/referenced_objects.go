@@ -0,0 +1,78 @@
+package controller
+
+import (
+	"context"
+
+	serverlessv1alpha2 "github.com/kyma-project/serverless/components/buildless-serverless/api/v1alpha2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	referencedSecretsIndex    = "spec.referencedSecrets"
+	referencedConfigMapsIndex = "spec.referencedConfigMaps"
+	functionRuntimeIndex      = "spec.runtime"
+)
+
+// indexReferencedObjects indexes the Functions by the names of the FunctionRuntime, Secrets and ConfigMaps they reference
+func indexReferencedObjects(ctx context.Context, indexer client.FieldIndexer) error {
+	err := indexer.IndexField(ctx, &serverlessv1alpha2.Function{}, referencedSecretsIndex, referencedSecrets)
+	if err != nil {
+		return err
+	}
+	err = indexer.IndexField(ctx, &serverlessv1alpha2.Function{}, referencedConfigMapsIndex, referencedConfigMaps)
+	if err != nil {
+		return err
+	}
+	return indexer.IndexField(ctx, &serverlessv1alpha2.Function{}, functionRuntimeIndex, functionRuntime)
+}
+
+func referencedSecrets(obj client.Object) []string {
+	return obj.(*serverlessv1alpha2.Function).ReferencedSecretNames()
+}
+
+func referencedConfigMaps(obj client.Object) []string {
+	return obj.(*serverlessv1alpha2.Function).ReferencedConfigMapNames()
+}
+
+func functionRuntime(obj client.Object) []string {
+	runtime := obj.(*serverlessv1alpha2.Function).Spec.Runtime
+	if runtime == "" {
+		return nil
+	}
+	return []string{string(runtime)}
+}
+
+// referencedObjectPredicate passes all events of the referenced Secrets and ConfigMaps,
+// their deletion is passed as well, so the Functions referencing them report the missing object
+func referencedObjectPredicate() predicate.Funcs {
+	return predicate.Funcs{
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return true
+		},
+	}
+}
+
+// functionsReferencing enqueues the Functions referencing the changed object
+// the reader must serve the index, so the Functions are listed from the manager's cache
+func (fr *FunctionReconciler) functionsReferencing(reader client.Reader, index string) handler.MapFunc {
+	return func(ctx context.Context, obj client.Object) []reconcile.Request {
+		var functionList serverlessv1alpha2.FunctionList
+		err := reader.List(ctx, &functionList,
+			client.InNamespace(obj.GetNamespace()),
+			client.MatchingFields{index: obj.GetName()})
+		if err != nil {
+			fr.Log.Errorf("while listing functions referencing %s/%s: %s", obj.GetNamespace(), obj.GetName(), err)
+			return nil
+		}
+
+		var requests []reconcile.Request
+		for _, f := range functionList.Items {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&f)})
+		}
+		return requests
+	}
+}
@@ -0,0 +1,106 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	serverlessv1alpha2 "github.com/kyma-project/serverless/components/buildless-serverless/api/v1alpha2"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func TestFunctionReconciler_functionsReferencing(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, serverlessv1alpha2.AddToScheme(scheme))
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithIndex(&serverlessv1alpha2.Function{}, referencedSecretsIndex, referencedSecrets).
+		WithIndex(&serverlessv1alpha2.Function{}, referencedConfigMapsIndex, referencedConfigMaps).
+		WithObjects(
+			fixReferencingFunction("team-a", "mounts", serverlessv1alpha2.FunctionSpec{
+				SecretMounts:    []serverlessv1alpha2.SecretMount{{SecretName: "credentials", MountPath: "/credentials"}},
+				ConfigMapMounts: []serverlessv1alpha2.ConfigMapMount{{ConfigMapName: "settings", MountPath: "/settings"}},
+			}),
+			fixReferencingFunction("team-a", "envs", serverlessv1alpha2.FunctionSpec{
+				EnvFrom: []corev1.EnvFromSource{
+					{SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "credentials"}}},
+				},
+				Env: []corev1.EnvVar{{
+					Name: "LEVEL",
+					ValueFrom: &corev1.EnvVarSource{ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "settings"},
+						Key:                  "level",
+					}},
+				}},
+			}),
+			fixReferencingFunction("team-a", "git", serverlessv1alpha2.FunctionSpec{
+				Source: serverlessv1alpha2.Source{GitRepository: &serverlessv1alpha2.GitRepositorySource{
+					URL:  "https://github.com/kyma-project/serverless.git",
+					Auth: &serverlessv1alpha2.RepositoryAuth{SecretName: "git-credentials"},
+				}},
+			}),
+			fixReferencingFunction("team-b", "mounts", serverlessv1alpha2.FunctionSpec{
+				SecretMounts: []serverlessv1alpha2.SecretMount{{SecretName: "credentials", MountPath: "/credentials"}},
+			}),
+		).Build()
+	fr := &FunctionReconciler{Log: zap.NewNop().Sugar()}
+
+	t.Run("should enqueue functions referencing the secret", func(t *testing.T) {
+		requests := fr.functionsReferencing(c, referencedSecretsIndex)(context.Background(), &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "credentials"},
+		})
+
+		require.ElementsMatch(t, []ctrl.Request{
+			{NamespacedName: types.NamespacedName{Namespace: "team-a", Name: "mounts"}},
+			{NamespacedName: types.NamespacedName{Namespace: "team-a", Name: "envs"}},
+		}, requests)
+	})
+	t.Run("should enqueue functions referencing the configmap", func(t *testing.T) {
+		requests := fr.functionsReferencing(c, referencedConfigMapsIndex)(context.Background(), &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "settings"},
+		})
+
+		require.ElementsMatch(t, []ctrl.Request{
+			{NamespacedName: types.NamespacedName{Namespace: "team-a", Name: "mounts"}},
+			{NamespacedName: types.NamespacedName{Namespace: "team-a", Name: "envs"}},
+		}, requests)
+	})
+	t.Run("should enqueue functions referencing the source secret", func(t *testing.T) {
+		requests := fr.functionsReferencing(c, referencedSecretsIndex)(context.Background(), &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "git-credentials"},
+		})
+
+		require.Equal(t, []ctrl.Request{{NamespacedName: types.NamespacedName{Namespace: "team-a", Name: "git"}}}, requests)
+	})
+	t.Run("should not enqueue functions when object is not referenced", func(t *testing.T) {
+		requests := fr.functionsReferencing(c, referencedConfigMapsIndex)(context.Background(), &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "team-b", Name: "settings"},
+		})
+
+		require.Empty(t, requests)
+	})
+}
+
+func fixReferencingFunction(namespace, name string, spec serverlessv1alpha2.FunctionSpec) client.Object {
+	return &serverlessv1alpha2.Function{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec:       spec,
+	}
+}
+
+func Test_referencedObjectPredicate(t *testing.T) {
+	p := referencedObjectPredicate()
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "creds", Namespace: "team-a"}}
+
+	require.True(t, p.Create(event.CreateEvent{Object: secret}))
+	require.True(t, p.Update(event.UpdateEvent{ObjectOld: secret, ObjectNew: secret}))
+	require.True(t, p.Delete(event.DeleteEvent{Object: secret}))
+}
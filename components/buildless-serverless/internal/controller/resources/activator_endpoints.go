@@ -0,0 +1,51 @@
+package resources
+
+import (
+	"fmt"
+
+	serverlessv1alpha2 "github.com/kyma-project/serverless/components/buildless-serverless/api/v1alpha2"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+// ActivatorEndpointSliceName returns the name of the endpoint slice routing the function's service to the activator
+func ActivatorEndpointSliceName(f *serverlessv1alpha2.Function) string {
+	return fmt.Sprintf("%s-activator", f.GetName())
+}
+
+// NewActivatorEndpointSlice returns the endpoint slice of the function's service pointing to the activator's addresses,
+// the function's port of the activator is the only port the activator serves the function on
+func NewActivatorEndpointSlice(f *serverlessv1alpha2.Function, addressType discoveryv1.AddressType, addresses []string) *discoveryv1.EndpointSlice {
+	endpoints := make([]discoveryv1.Endpoint, 0, len(addresses))
+	for _, address := range addresses {
+		endpoints = append(endpoints, discoveryv1.Endpoint{
+			Addresses:  []string{address},
+			Conditions: discoveryv1.EndpointConditions{Ready: ptr.To(true)},
+		})
+	}
+
+	return &discoveryv1.EndpointSlice{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "EndpointSlice",
+			APIVersion: discoveryv1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ActivatorEndpointSliceName(f),
+			Namespace: f.GetNamespace(),
+			Labels: map[string]string{
+				discoveryv1.LabelServiceName: f.GetName(),
+				discoveryv1.LabelManagedBy:   serverlessv1alpha2.FunctionControllerValue,
+			},
+		},
+		AddressType: addressType,
+		Endpoints:   endpoints,
+		Ports: []discoveryv1.EndpointPort{{
+			// the name matches the service's port
+			Name:     ptr.To("http"),
+			Port:     ptr.To(f.Status.ActivatorPort),
+			Protocol: ptr.To(corev1.ProtocolTCP),
+		}},
+	}
+}
@@ -0,0 +1,189 @@
+package resources
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	serverlessv1alpha2 "github.com/kyma-project/serverless/components/buildless-serverless/api/v1alpha2"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/config"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/git"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/utils/ptr"
+)
+
+const (
+	dependencyCacheVolumeName   = "dependency-cache"
+	dependencyCacheMountPath    = "/dependency-cache"
+	dependencyCacheHashLength   = 10
+	dependencyCacheBackoffLimit = 2
+	// job names are used as pod labels, so they can't be longer than 63 characters
+	dependencyCacheMaxFunctionNameLength = 63 - len("-deps-") - dependencyCacheHashLength
+)
+
+// HasDependencies returns true when the function's pods install dependencies on start
+func HasDependencies(f *serverlessv1alpha2.Function) bool {
+	if f.HasFetchedSources() {
+		return true
+	}
+	return f.Spec.Source.Inline != nil && f.Spec.Source.Inline.Dependencies != ""
+}
+
+// DependencyCacheHash returns the hash of the function's resolved dependencies
+func DependencyCacheHash(f *serverlessv1alpha2.Function, c *config.FunctionConfig, commit string) (string, error) {
+	rc, ok := c.RuntimeConfig(string(f.Spec.Runtime))
+	if !ok {
+		return "", unknownRuntimeError(f.Spec.Runtime)
+	}
+	parts := []string{string(f.Spec.Runtime), runtimeImage(f, rc)}
+	if f.HasGitSources() {
+		repository := f.Spec.Source.GitRepository
+		parts = append(parts, repository.URL, commit, repository.BaseDir)
+	} else if sourceType := f.ResolvedSourceType(); sourceType != "" {
+		parts = append(parts, string(sourceType), commit, sourceBaseDir(f))
+	} else if f.Spec.Source.Inline != nil {
+		parts = append(parts, f.Spec.Source.Inline.Dependencies)
+	}
+
+	hash := sha256.Sum256([]byte(strings.Join(parts, "\n")))
+	return hex.EncodeToString(hash[:])[:dependencyCacheHashLength], nil
+}
+
+// DependencyCacheName returns the name of the job and the volume claim holding the dependencies with the given hash
+func DependencyCacheName(f *serverlessv1alpha2.Function, hash string) string {
+	name := f.GetName()
+	if len(name) > dependencyCacheMaxFunctionNameLength {
+		name = strings.TrimSuffix(name[:dependencyCacheMaxFunctionNameLength], "-")
+	}
+	return fmt.Sprintf("%s-deps-%s", name, hash)
+}
+
+// DependencyCacheLabels returns labels used to find the function's dependency caches
+func DependencyCacheLabels(f *serverlessv1alpha2.Function) map[string]string {
+	return labels.Merge(f.InternalFunctionLabels(), map[string]string{
+		serverlessv1alpha2.FunctionResourceLabel: serverlessv1alpha2.FunctionResourceLabelDependencyCacheValue,
+	})
+}
+
+func dependencyCacheLabels(f *serverlessv1alpha2.Function, hash string) map[string]string {
+	return labels.Merge(DependencyCacheLabels(f), map[string]string{
+		serverlessv1alpha2.FunctionDependencyHashLabel: hash,
+	})
+}
+
+// NewDependencyCacheVolumeClaim returns the volume claim the function's dependencies are installed to
+func NewDependencyCacheVolumeClaim(f *serverlessv1alpha2.Function, c *config.FunctionConfig, hash string) *corev1.PersistentVolumeClaim {
+	claim := &corev1.PersistentVolumeClaim{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "PersistentVolumeClaim",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      DependencyCacheName(f, hash),
+			Namespace: f.GetNamespace(),
+			Labels:    dependencyCacheLabels(f, hash),
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{c.DependencyCache.AccessMode},
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: c.DependencyCache.Size.Quantity,
+				},
+			},
+		},
+	}
+	if c.DependencyCache.StorageClassName != "" {
+		claim.Spec.StorageClassName = ptr.To(c.DependencyCache.StorageClassName)
+	}
+	return claim
+}
+
+// NewDependencyCacheJob returns the job installing the function's dependencies the same way the function's pod does it
+func NewDependencyCacheJob(f *serverlessv1alpha2.Function, c *config.FunctionConfig, hash string, commit string, gitAuth *git.GitAuth, isKymaFipsModeEnabled bool, opts ...deployOptions) (*batchv1.Job, error) {
+	rc, ok := c.RuntimeConfig(string(f.Spec.Runtime))
+	if !ok {
+		return nil, unknownRuntimeError(f.Spec.Runtime)
+	}
+	opts = append(opts, DeploySetCmd([]string{
+		"sh",
+		"-c",
+		dependencyCacheCommand(f, rc),
+	}))
+	d := NewDeployment(f, c, commit, gitAuth, "", isKymaFipsModeEnabled, opts...)
+
+	podSpec := d.Spec.Template.Spec
+	podSpec.RestartPolicy = corev1.RestartPolicyNever
+	podSpec.Volumes = append(podSpec.Volumes, dependencyCacheVolume(DependencyCacheName(f, hash), false))
+	container := &podSpec.Containers[0]
+	container.Name = "dependencies"
+	container.Ports = nil
+	container.StartupProbe = nil
+	container.ReadinessProbe = nil
+	container.LivenessProbe = nil
+	container.VolumeMounts = append(container.VolumeMounts, dependencyCacheVolumeMount(false))
+
+	job := &batchv1.Job{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Job",
+			APIVersion: "batch/v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      DependencyCacheName(f, hash),
+			Namespace: f.GetNamespace(),
+			Labels:    dependencyCacheLabels(f, hash),
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: ptr.To[int32](dependencyCacheBackoffLimit),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					// the job's pods can't use the function's pod labels, otherwise the function's service would select them
+					Labels:      dependencyCacheLabels(f, hash),
+					Annotations: d.Spec.Template.GetAnnotations(),
+				},
+				Spec: podSpec,
+			},
+		},
+	}
+	if c.DependencyCache.JobTimeout > 0 {
+		// the job waiting for the volume or a stuck installation doesn't hold the function's deployment forever
+		job.Spec.ActiveDeadlineSeconds = ptr.To(int64(c.DependencyCache.JobTimeout.Seconds()))
+	}
+	return job, nil
+}
+
+func dependencyCacheVolume(claimName string, readOnly bool) corev1.Volume {
+	return corev1.Volume{
+		Name: dependencyCacheVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: claimName,
+				ReadOnly:  readOnly,
+			},
+		},
+	}
+}
+
+func dependencyCacheVolumeMount(readOnly bool) corev1.VolumeMount {
+	return corev1.VolumeMount{
+		Name:      dependencyCacheVolumeName,
+		ReadOnly:  readOnly,
+		MountPath: dependencyCacheMountPath,
+	}
+}
+
+func dependencyCacheCommand(f *serverlessv1alpha2.Function, rc config.RuntimeConfig) string {
+	result := []string{"set -e;"}
+	result = append(result, runtimeCommandSources(f, rc))
+	result = append(result, rc.InstallCommand)
+	result = append(result, rc.DependencyCache.SaveCommand)
+
+	return strings.Join(result, "\n")
+}
+
+func unknownRuntimeError(runtime serverlessv1alpha2.Runtime) error {
+	return fmt.Errorf("cannot find runtime: %s", runtime)
+}
@@ -0,0 +1,273 @@
+package resources
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	serverlessv1alpha2 "github.com/kyma-project/serverless/components/buildless-serverless/api/v1alpha2"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/config"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+func TestHasDependencies(t *testing.T) {
+	t.Run("inline function with dependencies", func(t *testing.T) {
+		require.True(t, HasDependencies(fixDependencyCacheFunction()))
+	})
+	t.Run("inline function without dependencies", func(t *testing.T) {
+		f := fixDependencyCacheFunction()
+		f.Spec.Source.Inline.Dependencies = ""
+
+		require.False(t, HasDependencies(f))
+	})
+	t.Run("git function", func(t *testing.T) {
+		f := fixDependencyCacheFunction()
+		f.Spec.Source = serverlessv1alpha2.Source{
+			GitRepository: &serverlessv1alpha2.GitRepositorySource{URL: "test-url"},
+		}
+
+		require.True(t, HasDependencies(f))
+	})
+	t.Run("OCI artifact function", func(t *testing.T) {
+		f := fixDependencyCacheFunction()
+		f.Spec.Source = serverlessv1alpha2.Source{
+			OCIArtifact: &serverlessv1alpha2.OCIArtifactSource{Reference: "registry.example.com/team/function:1.0.0"},
+		}
+
+		require.True(t, HasDependencies(f))
+	})
+}
+
+func TestDependencyCacheHash(t *testing.T) {
+	c := &config.FunctionConfig{Images: config.ImagesConfig{NodeJs24: "test-image"}}
+	t.Run("hash is stable", func(t *testing.T) {
+		hash := fixDependencyCacheHash(t, fixDependencyCacheFunction(), c, "")
+
+		require.Len(t, hash, 10)
+		require.Equal(t, hash, fixDependencyCacheHash(t, fixDependencyCacheFunction(), c, ""))
+	})
+	t.Run("hash doesn't depend on the source", func(t *testing.T) {
+		f := fixDependencyCacheFunction()
+		f.Spec.Source.Inline.Source = "other-source"
+
+		require.Equal(t, fixDependencyCacheHash(t, fixDependencyCacheFunction(), c, ""), fixDependencyCacheHash(t, f, c, ""))
+	})
+	t.Run("hash depends on dependencies and runtime image", func(t *testing.T) {
+		f := fixDependencyCacheFunction()
+		f.Spec.Source.Inline.Dependencies = "other-dependencies"
+		imageFunction := fixDependencyCacheFunction()
+		imageFunction.Spec.RuntimeImageOverride = "other-image"
+
+		hash := fixDependencyCacheHash(t, fixDependencyCacheFunction(), c, "")
+		require.NotEqual(t, hash, fixDependencyCacheHash(t, f, c, ""))
+		require.NotEqual(t, hash, fixDependencyCacheHash(t, imageFunction, c, ""))
+	})
+	t.Run("hash of git function depends on commit", func(t *testing.T) {
+		f := fixDependencyCacheFunction()
+		f.Spec.Source = serverlessv1alpha2.Source{
+			GitRepository: &serverlessv1alpha2.GitRepositorySource{URL: "test-url"},
+		}
+
+		require.NotEqual(t, fixDependencyCacheHash(t, f, c, "commit-1"), fixDependencyCacheHash(t, f, c, "commit-2"))
+	})
+	t.Run("unknown runtime", func(t *testing.T) {
+		f := fixDependencyCacheFunction()
+		f.Spec.Runtime = "unknown-runtime"
+
+		hash, err := DependencyCacheHash(f, c, "")
+
+		require.EqualError(t, err, "cannot find runtime: unknown-runtime")
+		require.Empty(t, hash)
+	})
+	t.Run("hash of ConfigMap function depends on digest", func(t *testing.T) {
+		f := fixDependencyCacheFunction()
+		f.Spec.Source = serverlessv1alpha2.Source{
+			ConfigMap: &serverlessv1alpha2.ConfigMapSource{Name: "function-files"},
+		}
+
+		require.NotEqual(t, fixDependencyCacheHash(t, f, c, "sha256:1"), fixDependencyCacheHash(t, f, c, "sha256:2"))
+	})
+}
+
+func fixDependencyCacheHash(t *testing.T, f *serverlessv1alpha2.Function, c *config.FunctionConfig, commit string) string {
+	hash, err := DependencyCacheHash(f, c, commit)
+	require.NoError(t, err)
+	return hash
+}
+
+func TestDependencyCacheName(t *testing.T) {
+	t.Run("name contains function name and hash", func(t *testing.T) {
+		require.Equal(t, "test-function-name-deps-0123456789", DependencyCacheName(fixDependencyCacheFunction(), "0123456789"))
+	})
+	t.Run("long function name is truncated", func(t *testing.T) {
+		f := fixDependencyCacheFunction()
+		f.Name = strings.Repeat("a", 63)
+
+		name := DependencyCacheName(f, "0123456789")
+
+		require.Len(t, name, 63)
+		require.True(t, strings.HasSuffix(name, "-deps-0123456789"))
+	})
+}
+
+func TestNewDependencyCacheVolumeClaim(t *testing.T) {
+	t.Run("create proper volume claim", func(t *testing.T) {
+		c := &config.FunctionConfig{DependencyCache: config.DependencyCacheConfig{
+			StorageClassName: "test-storage-class",
+			Size:             config.Quantity{Quantity: resource.MustParse("2Gi")},
+			AccessMode:       corev1.ReadWriteMany,
+		}}
+
+		claim := NewDependencyCacheVolumeClaim(fixDependencyCacheFunction(), c, "test-hash")
+
+		require.Equal(t, metav1.ObjectMeta{
+			Name:      "test-function-name-deps-test-hash",
+			Namespace: "test-function-namespace",
+			Labels: map[string]string{
+				"serverless.kyma-project.io/function-name":   "test-function-name",
+				"serverless.kyma-project.io/managed-by":      "function-controller",
+				"serverless.kyma-project.io/uuid":            "test-uid",
+				"serverless.kyma-project.io/resource":        "dependency-cache",
+				"serverless.kyma-project.io/dependency-hash": "test-hash",
+			},
+		}, claim.ObjectMeta)
+		require.Equal(t, []corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany}, claim.Spec.AccessModes)
+		require.Equal(t, resource.MustParse("2Gi"), claim.Spec.Resources.Requests[corev1.ResourceStorage])
+		require.Equal(t, ptr.To("test-storage-class"), claim.Spec.StorageClassName)
+	})
+	t.Run("use default storage class", func(t *testing.T) {
+		claim := NewDependencyCacheVolumeClaim(fixDependencyCacheFunction(), &config.FunctionConfig{}, "test-hash")
+
+		require.Nil(t, claim.Spec.StorageClassName)
+	})
+}
+
+func TestNewDependencyCacheJob(t *testing.T) {
+	t.Run("install nodejs dependencies to the cache volume", func(t *testing.T) {
+		job, err := NewDependencyCacheJob(fixDependencyCacheFunction(), &config.FunctionConfig{}, "test-hash", "", nil, false)
+
+		require.NoError(t, err)
+
+		require.Equal(t, "test-function-name-deps-test-hash", job.GetName())
+		require.Equal(t, ptr.To[int32](2), job.Spec.BackoffLimit)
+		require.Nil(t, job.Spec.ActiveDeadlineSeconds)
+		podSpec := job.Spec.Template.Spec
+		require.Equal(t, corev1.RestartPolicyNever, podSpec.RestartPolicy)
+		require.Equal(t, job.GetLabels(), job.Spec.Template.GetLabels())
+		require.Contains(t, podSpec.Volumes, corev1.Volume{
+			Name: "dependency-cache",
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: "test-function-name-deps-test-hash",
+				},
+			},
+		})
+		require.Len(t, podSpec.Containers, 1)
+		container := podSpec.Containers[0]
+		require.Nil(t, container.ReadinessProbe)
+		require.Empty(t, container.Ports)
+		require.Contains(t, container.VolumeMounts, corev1.VolumeMount{
+			Name:      "dependency-cache",
+			MountPath: "/dependency-cache",
+		})
+		require.Contains(t, container.Command[2], "npm install")
+		require.Contains(t, container.Command[2], "cp -r node_modules /dependency-cache/;")
+		require.NotContains(t, container.Command[2], "npm start")
+	})
+	t.Run("install python dependencies to the cache volume", func(t *testing.T) {
+		f := fixDependencyCacheFunction()
+		f.Spec.Runtime = serverlessv1alpha2.Python312
+
+		job, err := NewDependencyCacheJob(f, &config.FunctionConfig{}, "test-hash", "", nil, false)
+
+		require.NoError(t, err)
+		command := job.Spec.Template.Spec.Containers[0].Command[2]
+		require.Contains(t, command, "pip install")
+		require.Contains(t, command, "cp -r /kubeless/.local /dependency-cache/;")
+	})
+	t.Run("unknown runtime", func(t *testing.T) {
+		f := fixDependencyCacheFunction()
+		f.Spec.Runtime = "unknown-runtime"
+
+		job, err := NewDependencyCacheJob(f, &config.FunctionConfig{}, "test-hash", "", nil, false)
+
+		require.EqualError(t, err, "cannot find runtime: unknown-runtime")
+		require.Nil(t, job)
+	})
+}
+
+func TestNewDependencyCacheJob_timeout(t *testing.T) {
+	c := &config.FunctionConfig{DependencyCache: config.DependencyCacheConfig{JobTimeout: 10 * time.Minute}}
+
+	job, err := NewDependencyCacheJob(fixDependencyCacheFunction(), c, "test-hash", "", nil, false)
+
+	require.NoError(t, err)
+	require.Equal(t, ptr.To[int64](600), job.Spec.ActiveDeadlineSeconds)
+}
+
+func TestDeployDependencyCache(t *testing.T) {
+	t.Run("reuse cached nodejs dependencies", func(t *testing.T) {
+		d := NewDeployment(fixDependencyCacheFunction(), &config.FunctionConfig{}, "", nil, "", false,
+			DeployDependencyCache("test-claim"))
+
+		podSpec := d.Spec.Template.Spec
+		require.Contains(t, podSpec.Volumes, corev1.Volume{
+			Name: "dependency-cache",
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: "test-claim",
+					ReadOnly:  true,
+				},
+			},
+		})
+		container := podSpec.Containers[0]
+		require.Contains(t, container.VolumeMounts, corev1.VolumeMount{
+			Name:      "dependency-cache",
+			ReadOnly:  true,
+			MountPath: "/dependency-cache",
+		})
+		require.NotContains(t, container.Command[2], "npm install")
+		require.Contains(t, container.Command[2], "ln -s /dependency-cache/node_modules node_modules;")
+		require.Contains(t, container.Command[2], "npm start;")
+	})
+	t.Run("reuse cached python dependencies", func(t *testing.T) {
+		f := fixDependencyCacheFunction()
+		f.Spec.Runtime = serverlessv1alpha2.Python312
+
+		d := NewDeployment(f, &config.FunctionConfig{}, "", nil, "", false, DeployDependencyCache("test-claim"))
+
+		command := d.Spec.Template.Spec.Containers[0].Command[2]
+		require.NotContains(t, command, "pip install")
+		require.Contains(t, command, `export PYTHONPATH="/dependency-cache/.local:${PYTHONPATH}"`)
+	})
+	t.Run("install dependencies when there is no cache", func(t *testing.T) {
+		d := NewDeployment(fixDependencyCacheFunction(), &config.FunctionConfig{}, "", nil, "", false,
+			DeployDependencyCache(""))
+
+		require.Equal(t, runtimeCommand(fixDependencyCacheFunction(), fixRuntimeConfig(t, serverlessv1alpha2.NodeJs24)), d.Spec.Template.Spec.Containers[0].Command[2])
+		require.NotContains(t, d.Spec.Template.Spec.Volumes, corev1.Volume{Name: "dependency-cache"})
+	})
+}
+
+func fixDependencyCacheFunction() *serverlessv1alpha2.Function {
+	return &serverlessv1alpha2.Function{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-function-name",
+			Namespace: "test-function-namespace",
+			UID:       "test-uid",
+		},
+		Spec: serverlessv1alpha2.FunctionSpec{
+			Runtime: serverlessv1alpha2.NodeJs24,
+			Source: serverlessv1alpha2.Source{
+				Inline: &serverlessv1alpha2.InlineSource{
+					Source:       "test-source",
+					Dependencies: "test-dependencies",
+				},
+			},
+		},
+	}
+}
@@ -1,19 +1,23 @@
 package resources
 
 import (
+	"encoding/json"
 	"fmt"
+	"hash/fnv"
 	"path"
 	"strings"
 
 	serverlessv1alpha2 "github.com/kyma-project/serverless/components/buildless-serverless/api/v1alpha2"
 	"github.com/kyma-project/serverless/components/buildless-serverless/internal/config"
 	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/git"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/oci"
 	"github.com/kyma-project/serverless/components/common/fips"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/rand"
 	"k8s.io/utils/ptr"
 )
 
@@ -24,6 +28,8 @@ const (
 	istioNativeSidecarLabelKey                = "sidecar.istio.io/nativeSidecar"
 	kymaBootstraperRegistryUrlMutation        = "rt-cfg.kyma-project.io/alter-img-registry"
 	kymaBootstraperAddImagePullSecretMutation = "rt-cfg.kyma-project.io/add-img-pull-secret"
+	configMapSourceVolumeName                 = "config-map-source"
+	configMapSourceMountPath                  = "/config-map-source"
 )
 
 type deployOptions func(*Deployment)
@@ -36,9 +42,10 @@ func DeploySetName(name string) deployOptions {
 	}
 }
 
-// DeployTrimClusterInfoLabels - get rid of internal labels like managed-by, function-name or uuid
+// DeployTrimClusterInfoLabels - get rid of internal labels like managed-by, function-name or uuid, and the template hash annotation
 func DeployTrimClusterInfoLabels() deployOptions {
 	return func(d *Deployment) {
+		d.clusterInfoTrimmed = true
 		internalLabels := d.function.InternalFunctionLabels()
 		for key := range internalLabels {
 			delete(d.functionLabels, key)
@@ -61,7 +68,7 @@ func DeployAppendSelectorLabels(labels map[string]string) deployOptions {
 // DeployUseGeneralEnvs - use general envs function for the deployment
 func DeployUseGeneralEnvs() deployOptions {
 	return func(d *Deployment) {
-		d.podEnvs = generalEnvs(d.function, d.functionConfig)
+		d.podEnvs = generalEnvs(d.function, d.functionConfig, d.runtimeConfig)
 	}
 }
 
@@ -79,12 +86,50 @@ func DeploySetCmd(cmd []string) deployOptions {
 	}
 }
 
+// DeployScaleToZero - scale the deployment to zero replicas when the function is idle
+func DeployScaleToZero(scaledToZero bool) deployOptions {
+	return func(d *Deployment) {
+		d.scaledToZero = scaledToZero
+	}
+}
+
+// DeployDependencyCache - reuse the prebuilt dependencies from the cache volume instead of installing them on start
+func DeployDependencyCache(volumeClaimName string) deployOptions {
+	return func(d *Deployment) {
+		if volumeClaimName == "" {
+			return
+		}
+		d.dependencyCacheClaimName = volumeClaimName
+		d.podCmd = []string{
+			"sh",
+			"-c",
+			cachedRuntimeCommand(d.function, d.runtimeConfig),
+		}
+	}
+}
+
+// DeployResolvedTag - clone the tag the semver constraint or the pattern reference was resolved to
+func DeployResolvedTag(tag string) deployOptions {
+	return func(d *Deployment) {
+		d.resolvedTag = tag
+	}
+}
+
+// DeployReferencedContentHash - annotate the pods with the hash of the consumed Secrets and ConfigMaps, to roll them out when the content changes
+func DeployReferencedContentHash(contentHash string) deployOptions {
+	return func(d *Deployment) {
+		d.referencedContentHash = contentHash
+	}
+}
+
 type Deployment struct {
 	*appsv1.Deployment
 	functionConfig           *config.FunctionConfig
+	runtimeConfig            config.RuntimeConfig
 	function                 *serverlessv1alpha2.Function
-	clusterDeployment        *appsv1.Deployment
 	commit                   string
+	resolvedTag              string
+	referencedContentHash    string
 	gitAuth                  *git.GitAuth
 	isKymaFipsModeEnabled    bool
 	functionLabels           map[string]string
@@ -97,13 +142,19 @@ type Deployment struct {
 	podCmd                   []string
 	podSecurityContext       *corev1.PodSecurityContext
 	containerSecurityContext *corev1.SecurityContext
+	scaledToZero             bool
+	dependencyCacheClaimName string
+	clusterInfoTrimmed       bool
 }
 
-func NewDeployment(f *serverlessv1alpha2.Function, c *config.FunctionConfig, clusterDeployment *appsv1.Deployment, commit string, gitAuth *git.GitAuth, appName string, isKymaFipsModeEnabled bool, opts ...deployOptions) *Deployment {
+func NewDeployment(f *serverlessv1alpha2.Function, c *config.FunctionConfig, commit string, gitAuth *git.GitAuth, appName string, isKymaFipsModeEnabled bool, opts ...deployOptions) *Deployment {
+	// the spec's runtime is validated and the revision's runtime is checked by sFnLoadRevision before the deployment is built,
+	// the ejected function's runtime is checked by the eject endpoint
+	rc, _ := c.RuntimeConfig(string(f.Spec.Runtime))
 	d := &Deployment{
 		functionConfig:           c,
+		runtimeConfig:            rc,
 		function:                 f,
-		clusterDeployment:        clusterDeployment,
 		commit:                   commit,
 		gitAuth:                  gitAuth,
 		isKymaFipsModeEnabled:    isKymaFipsModeEnabled,
@@ -112,14 +163,14 @@ func NewDeployment(f *serverlessv1alpha2.Function, c *config.FunctionConfig, clu
 		podLabels:                f.PodLabels(),
 		deployName:               "",
 		deployGeneratedName:      fmt.Sprintf("%s-", f.Name),
-		podImage:                 runtimeImage(f, c),
-		podEnvs:                  append(generalEnvs(f, c), sourceEnvs(f)...),
+		podImage:                 runtimeImage(f, rc),
+		podEnvs:                  append(generalEnvs(f, c, rc), sourceEnvs(f, rc)...),
 		podSecurityContext:       podSecurityContext(f),
 		containerSecurityContext: containerSecurityContext(f),
 		podCmd: []string{
 			"sh",
 			"-c",
-			runtimeCommand(f),
+			runtimeCommand(f, rc),
 		},
 	}
 
@@ -135,6 +186,12 @@ func NewDeployment(f *serverlessv1alpha2.Function, c *config.FunctionConfig, clu
 	}
 
 	d.Deployment = d.construct()
+	if !d.clusterInfoTrimmed {
+		// the hash tells if the deployment in the cluster runs the same pod template without comparing its fields
+		d.Deployment.Annotations = map[string]string{
+			serverlessv1alpha2.DeploymentTemplateHashAnnotation: d.TemplateHash(),
+		}
+	}
 	return d
 }
 
@@ -179,18 +236,29 @@ func (d *Deployment) RuntimeImage() string {
 	return d.Spec.Template.Spec.Containers[0].Image
 }
 
+// TemplateHash returns the hash identifying the deployment's pod template
+func (d *Deployment) TemplateHash() string {
+	hasher := fnv.New32a()
+	// marshalling a pod template can't fail
+	template, _ := json.Marshal(d.Spec.Template)
+	_, _ = hasher.Write(template)
+	return rand.SafeEncodeString(fmt.Sprint(hasher.Sum32()))
+}
+
 func (d *Deployment) podAnnotations() map[string]string {
 	result := d.defaultAnnotations()
 	if d.function.Spec.Annotations != nil {
 		result = labels.Merge(d.function.Spec.Annotations, result)
 	}
 
-	// merge old and new annotations to allow other components to annotate functions deployment
-	// for example in case when someone use `kubectl rollout restart` on it
-	// before merge we need to remove annotations that are not present in the current function to allow removing them
-	result = labels.Merge(d.currentAnnotationsWithoutPreviousFunctionAnnotations(), result)
+	// the annotations added by other components, for example by `kubectl rollout restart`,
+	// are owned by them and kept by the server-side apply
 	result = labels.Merge(d.annotationsRequiredByIstio(), result)
 
+	if d.referencedContentHash != "" {
+		result[serverlessv1alpha2.PodReferencedContentHashAnnotation] = d.referencedContentHash
+	}
+
 	return result
 }
 
@@ -202,18 +270,6 @@ func (d *Deployment) defaultAnnotations() map[string]string {
 	}
 }
 
-func (d *Deployment) currentAnnotationsWithoutPreviousFunctionAnnotations() map[string]string {
-	previousFunctionAnnotations := d.function.Status.FunctionAnnotations
-	currentAnnotations := d.currentAnnotations()
-	result := make(map[string]string)
-	for key := range currentAnnotations {
-		if _, ok := previousFunctionAnnotations[key]; !ok {
-			result[key] = currentAnnotations[key]
-		}
-	}
-	return result
-}
-
 // allow istio to inject native sidecar (istio-proxy as init container)
 // this is required for init container of git sourced functions to fetch source from git repository
 func (d *Deployment) annotationsRequiredByIstio() map[string]string {
@@ -222,79 +278,136 @@ func (d *Deployment) annotationsRequiredByIstio() map[string]string {
 	return result
 }
 
-func (d *Deployment) currentAnnotations() map[string]string {
-	if d.clusterDeployment == nil {
-		return map[string]string{}
-	}
-
-	return d.clusterDeployment.Spec.Template.GetAnnotations()
-}
-
 func (d *Deployment) podSpec() corev1.PodSpec {
-	secretVolumes, secretVolumeMounts := d.deploymentSecretVolumes()
+	mountedVolumes, mountedVolumeMounts := d.deploymentMountedVolumes()
+	template := d.template()
 
 	return corev1.PodSpec{
-		Volumes:        append(d.volumes(), secretVolumes...),
-		InitContainers: d.initContainerForGitRepository(),
+		Volumes:        append(d.volumes(), mountedVolumes...),
+		InitContainers: d.initContainerForSources(),
 		Containers: []corev1.Container{
 			{
 				Name:         "function",
 				Image:        d.podImage,
-				WorkingDir:   workingSourcesDir(d.function),
+				WorkingDir:   d.runtimeConfig.WorkingDir,
 				Command:      d.podCmd,
 				Resources:    d.resourceConfiguration(),
 				Env:          d.podEnvs,
-				VolumeMounts: append(d.volumeMounts(), secretVolumeMounts...),
+				EnvFrom:      d.function.Spec.EnvFrom,
+				VolumeMounts: append(d.volumeMounts(), mountedVolumeMounts...),
 				Ports: []corev1.ContainerPort{
 					{
 						ContainerPort: 8080,
 						Protocol:      "TCP",
 					},
 				},
-				StartupProbe: &corev1.Probe{
-					ProbeHandler: corev1.ProbeHandler{
-						HTTPGet: &corev1.HTTPGetAction{
-							Path: "/healthz",
-							Port: svcTargetPort,
-						},
-					},
-					InitialDelaySeconds: 0,
-					PeriodSeconds:       5,
-					SuccessThreshold:    1,
-					FailureThreshold:    30, // FailureThreshold * PeriodSeconds = 150s in this case, this should be enough for any function pod to start up
-				},
-				ReadinessProbe: &corev1.Probe{
-					ProbeHandler: corev1.ProbeHandler{
-						HTTPGet: &corev1.HTTPGetAction{
-							Path: "/healthz",
-							Port: svcTargetPort,
-						},
-					},
-					InitialDelaySeconds: 0, // startup probe exists, so delaying anything here doesn't make sense
-					FailureThreshold:    1,
-					PeriodSeconds:       5,
-					TimeoutSeconds:      2,
-				},
-				LivenessProbe: &corev1.Probe{
-					ProbeHandler: corev1.ProbeHandler{
-						HTTPGet: &corev1.HTTPGetAction{
-							Path: "/healthz",
-							Port: svcTargetPort,
-						},
-					},
-					FailureThreshold: 3,
-					PeriodSeconds:    5,
-					TimeoutSeconds:   4,
-				},
+				StartupProbe:    d.startupProbe(),
+				ReadinessProbe:  d.readinessProbe(),
+				LivenessProbe:   d.livenessProbe(),
 				SecurityContext: d.containerSecurityContext,
 			},
 		},
-		SecurityContext: d.podSecurityContext,
+		SecurityContext:           d.podSecurityContext,
+		NodeSelector:              template.NodeSelector,
+		Tolerations:               template.Tolerations,
+		Affinity:                  template.Affinity,
+		TopologySpreadConstraints: d.topologySpreadConstraints(),
+		PriorityClassName:         template.PriorityClassName,
+		ServiceAccountName:        template.ServiceAccountName,
+	}
+}
+
+func (d *Deployment) template() serverlessv1alpha2.Template {
+	if d.function.Spec.Template == nil {
+		return serverlessv1alpha2.Template{}
+	}
+	return *d.function.Spec.Template
+}
+
+// topologySpreadConstraints spreads the function's pods when the constraint doesn't select the pods itself
+func (d *Deployment) topologySpreadConstraints() []corev1.TopologySpreadConstraint {
+	constraints := d.template().TopologySpreadConstraints
+	if len(constraints) == 0 {
+		return nil
+	}
+	result := make([]corev1.TopologySpreadConstraint, len(constraints))
+	for i, constraint := range constraints {
+		result[i] = *constraint.DeepCopy()
+		if result[i].LabelSelector == nil {
+			result[i].LabelSelector = &metav1.LabelSelector{
+				MatchLabels: d.selectorLabels,
+			}
+		}
+	}
+	return result
+}
+
+func (d *Deployment) startupProbe() *corev1.Probe {
+	probe := &corev1.Probe{
+		ProbeHandler:        healthzProbeHandler(),
+		InitialDelaySeconds: 0,
+		PeriodSeconds:       5,
+		SuccessThreshold:    1,
+		FailureThreshold:    30, // FailureThreshold * PeriodSeconds = 150s in this case, this should be enough for any function pod to start up
+	}
+	return overrideProbe(probe, d.probes().Startup)
+}
+
+func (d *Deployment) readinessProbe() *corev1.Probe {
+	probe := &corev1.Probe{
+		ProbeHandler:        healthzProbeHandler(),
+		InitialDelaySeconds: 0, // startup probe exists, so delaying anything here doesn't make sense
+		FailureThreshold:    1,
+		PeriodSeconds:       5,
+		TimeoutSeconds:      2,
+	}
+	return overrideProbe(probe, d.probes().Readiness)
+}
+
+func (d *Deployment) livenessProbe() *corev1.Probe {
+	probe := &corev1.Probe{
+		ProbeHandler:     healthzProbeHandler(),
+		FailureThreshold: 3,
+		PeriodSeconds:    5,
+		TimeoutSeconds:   4,
+	}
+	return overrideProbe(probe, d.probes().Liveness)
+}
+
+func (d *Deployment) probes() serverlessv1alpha2.Probes {
+	if d.function.Spec.Probes == nil {
+		return serverlessv1alpha2.Probes{}
+	}
+	return *d.function.Spec.Probes
+}
+
+func healthzProbeHandler() corev1.ProbeHandler {
+	return corev1.ProbeHandler{
+		HTTPGet: &corev1.HTTPGetAction{
+			Path: "/healthz",
+			Port: svcTargetPort,
+		},
+	}
+}
+
+// overrideProbe sets the values of the function's probe in the default one
+func overrideProbe(probe *corev1.Probe, override *serverlessv1alpha2.Probe) *corev1.Probe {
+	if override == nil {
+		return probe
+	}
+	if override.Path != "" {
+		probe.HTTPGet.Path = override.Path
 	}
+	probe.InitialDelaySeconds = ptr.Deref(override.InitialDelaySeconds, probe.InitialDelaySeconds)
+	probe.PeriodSeconds = ptr.Deref(override.PeriodSeconds, probe.PeriodSeconds)
+	probe.TimeoutSeconds = ptr.Deref(override.TimeoutSeconds, probe.TimeoutSeconds)
+	probe.SuccessThreshold = ptr.Deref(override.SuccessThreshold, probe.SuccessThreshold)
+	probe.FailureThreshold = ptr.Deref(override.FailureThreshold, probe.FailureThreshold)
+	return probe
 }
 
-func (d *Deployment) initContainerForGitRepository() []corev1.Container {
-	if !d.function.HasGitSources() {
+func (d *Deployment) initContainerForSources() []corev1.Container {
+	if !d.function.HasFetchedSources() {
 		return []corev1.Container{}
 	}
 
@@ -302,7 +415,7 @@ func (d *Deployment) initContainerForGitRepository() []corev1.Container {
 		{
 			Name:       "init",
 			Image:      d.functionConfig.Images.RepoFetcher,
-			WorkingDir: workingSourcesDir(d.function),
+			WorkingDir: d.runtimeConfig.WorkingDir,
 			Command: []string{
 				"sh",
 				"-c",
@@ -319,13 +432,7 @@ func (d *Deployment) initContainerForGitRepository() []corev1.Container {
 					corev1.ResourceMemory: resource.MustParse("512Mi"),
 				},
 			},
-			VolumeMounts: []corev1.VolumeMount{
-				{
-					Name:      "git-repository",
-					ReadOnly:  false,
-					MountPath: "/git-repository",
-				},
-			},
+			VolumeMounts: d.initContainerVolumeMounts(),
 			SecurityContext: &corev1.SecurityContext{
 				Privileged: ptr.To(false),
 				Capabilities: &corev1.Capabilities{
@@ -340,7 +447,51 @@ func (d *Deployment) initContainerForGitRepository() []corev1.Container {
 	}
 }
 
+func (d *Deployment) initContainerVolumeMounts() []corev1.VolumeMount {
+	volumeMounts := []corev1.VolumeMount{
+		{
+			Name:      "git-repository",
+			ReadOnly:  false,
+			MountPath: "/git-repository",
+		},
+	}
+	if d.function.HasConfigMapSources() {
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:      configMapSourceVolumeName,
+			ReadOnly:  true,
+			MountPath: configMapSourceMountPath,
+		})
+	}
+	return volumeMounts
+}
+
 func (d *Deployment) initContainerEnvs(isKymaFipsModeEnabled bool) []corev1.EnvVar {
+	var envs []corev1.EnvVar
+	if d.function.HasGitSources() {
+		envs = d.initContainerGitEnvs()
+	} else {
+		envs = d.initContainerResolvedSourceEnvs()
+	}
+
+	if isKymaFipsModeEnabled {
+		envs = append(envs,
+			corev1.EnvVar{Name: "APP_KYMA_FIPS_MODE_ENABLED", Value: "true"},
+			corev1.EnvVar{Name: "GODEBUG", Value: fips.GODEBUG_VALUE},
+		)
+	}
+
+	if d.gitAuth != nil {
+		envs = append(envs, d.gitAuth.GetAuthEnvs()...)
+	}
+
+	return envs
+}
+
+func (d *Deployment) initContainerGitEnvs() []corev1.EnvVar {
+	reference := d.function.Spec.Source.GitRepository.Repository.Reference
+	if d.resolvedTag != "" {
+		reference = d.resolvedTag
+	}
 	envs := []corev1.EnvVar{
 		{
 			Name:  "APP_REPOSITORY_URL",
@@ -348,7 +499,7 @@ func (d *Deployment) initContainerEnvs(isKymaFipsModeEnabled bool) []corev1.EnvV
 		},
 		{
 			Name:  "APP_REPOSITORY_REFERENCE",
-			Value: d.function.Spec.Source.GitRepository.Repository.Reference,
+			Value: reference,
 		},
 		{
 			Name:  "APP_REPOSITORY_COMMIT",
@@ -359,33 +510,135 @@ func (d *Deployment) initContainerEnvs(isKymaFipsModeEnabled bool) []corev1.EnvV
 			Value: "/git-repository/repo",
 		},
 	}
+	return append(envs, d.initContainerCheckoutEnvs()...)
+}
 
-	if isKymaFipsModeEnabled {
+// initContainerResolvedSourceEnvs returns the envs of fetching the OCI artifact, the HTTP archive or the ConfigMap
+// the digest changes the pods' template, so the new files are rolled out
+func (d *Deployment) initContainerResolvedSourceEnvs() []corev1.EnvVar {
+	source := d.function.Spec.Source
+	envs := []corev1.EnvVar{
+		{
+			Name:  "APP_SOURCE_TYPE",
+			Value: string(d.function.ResolvedSourceType()),
+		},
+		{
+			Name:  "APP_SOURCE_DIGEST",
+			Value: d.commit,
+		},
+		{
+			Name:  "APP_DESTINATION_PATH",
+			Value: "/git-repository/repo",
+		},
+	}
+
+	switch {
+	case source.OCIArtifact != nil:
+		envs = append(envs, corev1.EnvVar{
+			Name:  "APP_OCI_REFERENCE",
+			Value: ociArtifactReference(source.OCIArtifact, d.commit),
+		})
+		if source.OCIArtifact.PullSecretName != "" {
+			envs = append(envs, corev1.EnvVar{
+				Name: "APP_OCI_DOCKER_CONFIG",
+				ValueFrom: &corev1.EnvVarSource{
+					SecretKeyRef: &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: source.OCIArtifact.PullSecretName},
+						Key:                  corev1.DockerConfigJsonKey,
+					},
+				},
+			})
+		}
+	case source.HTTPArchive != nil:
 		envs = append(envs,
-			corev1.EnvVar{Name: "APP_KYMA_FIPS_MODE_ENABLED", Value: "true"},
-			corev1.EnvVar{Name: "GODEBUG", Value: fips.GODEBUG_VALUE},
+			corev1.EnvVar{Name: "APP_ARCHIVE_URL", Value: source.HTTPArchive.URL},
+			corev1.EnvVar{Name: "APP_ARCHIVE_CHECKSUM", Value: source.HTTPArchive.Checksum},
 		)
+	case source.ConfigMap != nil:
+		envs = append(envs, corev1.EnvVar{Name: "APP_CONFIG_MAP_PATH", Value: configMapSourceMountPath})
 	}
+	return envs
+}
 
-	if d.gitAuth != nil {
-		envs = append(envs, d.gitAuth.GetAuthEnvs()...)
+// configMapSourceName returns the snapshot of the ConfigMap source's files with the resolved digest,
+// so the pods run the same files even when the ConfigMap changes before they start
+func (d *Deployment) configMapSourceName() string {
+	if d.commit == "" {
+		return d.function.Spec.Source.ConfigMap.Name
+	}
+	return SourceSnapshotName(d.function, d.commit)
+}
+
+// ociArtifactReference returns the reference of the artifact's resolved digest, so the pods pull the same files
+func ociArtifactReference(artifact *serverlessv1alpha2.OCIArtifactSource, digest string) string {
+	if digest == "" {
+		return artifact.Reference
+	}
+	reference, err := oci.DigestReference(artifact.Reference, digest)
+	if err != nil {
+		return artifact.Reference
+	}
+	return reference
+}
+
+func (d *Deployment) initContainerCheckoutEnvs() []corev1.EnvVar {
+	gitRepo := d.function.Spec.Source.GitRepository
+	checkout := gitRepo.Checkout
+	if checkout == nil {
+		return nil
 	}
 
+	envs := []corev1.EnvVar{
+		{
+			Name:  "APP_REPOSITORY_BASE_DIR",
+			Value: gitRepo.BaseDir,
+		},
+	}
+	options := []struct {
+		name    string
+		enabled bool
+	}{
+		{name: "APP_REPOSITORY_SUBMODULES", enabled: checkout.Submodules},
+		{name: "APP_REPOSITORY_LFS", enabled: checkout.LFS},
+		{name: "APP_REPOSITORY_SHALLOW", enabled: checkout.Shallow},
+		{name: "APP_REPOSITORY_SPARSE", enabled: checkout.Sparse},
+	}
+	for _, o := range options {
+		if o.enabled {
+			envs = append(envs, corev1.EnvVar{Name: o.name, Value: "true"})
+		}
+	}
 	return envs
 }
 
 func (d *Deployment) initContainerCommand() string {
-	gitRepo := d.function.Spec.Source.GitRepository
 	var arr []string
 	arr = append(arr, "rm -rf /git-repository/*")
 	arr = append(arr, "/app/gitcloner")
 	arr = append(arr,
 		fmt.Sprintf("mkdir /git-repository/src;cp -r '/git-repository/repo/%s'/* /git-repository/src;",
-			strings.Trim(gitRepo.BaseDir, "/ ")))
+			strings.Trim(sourceBaseDir(d.function), "/ ")))
 	return strings.Join(arr, "\n")
 }
 
+// sourceBaseDir returns the directory of the fetched sources that contains the function's code
+func sourceBaseDir(f *serverlessv1alpha2.Function) string {
+	source := f.Spec.Source
+	switch {
+	case source.GitRepository != nil:
+		return source.GitRepository.BaseDir
+	case source.OCIArtifact != nil:
+		return source.OCIArtifact.BaseDir
+	case source.HTTPArchive != nil:
+		return source.HTTPArchive.BaseDir
+	}
+	return ""
+}
+
 func (d *Deployment) replicas() *int32 {
+	if d.scaledToZero {
+		return ptr.To[int32](0)
+	}
 	replicas := d.function.Spec.Replicas
 	if replicas != nil {
 		return replicas
@@ -419,7 +672,8 @@ func (d *Deployment) volumes() []corev1.Volume {
 			},
 		},
 	}
-	if d.function.HasGitSources() {
+	if d.function.HasFetchedSources() {
+		// the volume keeps its name for all fetched sources, so the git-sourced functions' pods are not restarted
 		volumes = append(volumes, corev1.Volume{
 			Name: "git-repository",
 			VolumeSource: corev1.VolumeSource{
@@ -427,15 +681,27 @@ func (d *Deployment) volumes() []corev1.Volume {
 			},
 		})
 	}
-	if d.function.HasPythonRuntime() {
+	if d.function.HasConfigMapSources() {
+		volumes = append(volumes, corev1.Volume{
+			Name: configMapSourceVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{Name: d.configMapSourceName()},
+				},
+			},
+		})
+	}
+	for _, emptyDir := range d.runtimeConfig.EmptyDirs {
 		volumes = append(volumes, corev1.Volume{
-			// required by pip to save deps to .local dir
-			Name: "local",
+			Name: emptyDir.Name,
 			VolumeSource: corev1.VolumeSource{
 				EmptyDir: &corev1.EmptyDirVolumeSource{},
 			},
 		})
 	}
+	if d.dependencyCacheClaimName != "" {
+		volumes = append(volumes, dependencyCacheVolume(d.dependencyCacheClaimName, true))
+	}
 	return volumes
 }
 
@@ -443,7 +709,7 @@ func (d *Deployment) volumeMounts() []corev1.VolumeMount {
 	volumeMounts := []corev1.VolumeMount{
 		{
 			Name:      "sources",
-			MountPath: workingSourcesDir(d.function),
+			MountPath: d.runtimeConfig.WorkingDir,
 		},
 		{
 			Name:      "tmp",
@@ -451,30 +717,27 @@ func (d *Deployment) volumeMounts() []corev1.VolumeMount {
 			MountPath: "/tmp",
 		},
 	}
-	if d.function.HasGitSources() {
+	if d.function.HasFetchedSources() {
 		volumeMounts = append(volumeMounts, corev1.VolumeMount{
 			Name:      "git-repository",
 			MountPath: "/git-repository",
 		})
 	}
-	if d.function.HasNodejsRuntime() {
+	for _, emptyDir := range d.runtimeConfig.EmptyDirs {
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:      emptyDir.Name,
+			MountPath: emptyDir.MountPath,
+		})
+	}
+	if registryConfigFile := d.runtimeConfig.PackageRegistryConfigFile; registryConfigFile != "" {
 		volumeMounts = append(volumeMounts, corev1.VolumeMount{
 			Name:      "package-registry-config",
-			MountPath: path.Join(workingSourcesDir(d.function), "package-registry-config/.npmrc"),
-			SubPath:   ".npmrc",
+			MountPath: path.Join(d.runtimeConfig.WorkingDir, "package-registry-config", registryConfigFile),
+			SubPath:   registryConfigFile,
 		})
 	}
-	if d.function.HasPythonRuntime() {
-		volumeMounts = append(volumeMounts,
-			corev1.VolumeMount{
-				Name:      "local",
-				MountPath: "/.local",
-			},
-			corev1.VolumeMount{
-				Name:      "package-registry-config",
-				MountPath: path.Join(workingSourcesDir(d.function), "package-registry-config/pip.conf"),
-				SubPath:   "pip.conf",
-			})
+	if d.dependencyCacheClaimName != "" {
+		volumeMounts = append(volumeMounts, dependencyCacheVolumeMount(true))
 	}
 	return volumeMounts
 }
@@ -548,109 +811,63 @@ func getSliceOrDefault[T any](field []T, defaultValue []T) []T {
 	return field
 }
 
-func runtimeImage(f *serverlessv1alpha2.Function, c *config.FunctionConfig) string {
+func runtimeImage(f *serverlessv1alpha2.Function, rc config.RuntimeConfig) string {
 	runtimeOverride := f.Spec.RuntimeImageOverride
 	if runtimeOverride != "" {
 		return runtimeOverride
 	}
-
-	switch f.Spec.Runtime {
-	case serverlessv1alpha2.NodeJs20:
-		return c.Images.NodeJs20
-	case serverlessv1alpha2.NodeJs22:
-		return c.Images.NodeJs22
-	case serverlessv1alpha2.NodeJs24:
-		return c.Images.NodeJs24
-	case serverlessv1alpha2.Python312:
-		return c.Images.Python312
-	default:
-		return ""
-	}
+	return rc.Image
 }
 
-func workingSourcesDir(f *serverlessv1alpha2.Function) string {
-	if f.HasNodejsRuntime() {
-		return "/usr/src/app/function"
-	} else if f.HasPythonRuntime() {
-		return "/kubeless"
-	}
-	return ""
+func runtimeCommand(f *serverlessv1alpha2.Function, rc config.RuntimeConfig) string {
+	result := []string{"set -e;"}
+	result = append(result, runtimeCommandSources(f, rc))
+	result = append(result, rc.InstallCommand)
+	result = append(result, rc.StartCommand)
+
+	return strings.Join(result, "\n")
 }
 
-func runtimeCommand(f *serverlessv1alpha2.Function) string {
+func cachedRuntimeCommand(f *serverlessv1alpha2.Function, rc config.RuntimeConfig) string {
 	result := []string{"set -e;"}
-	result = append(result, runtimeCommandSources(f))
-	result = append(result, runtimeCommandInstall(f))
-	result = append(result, runtimeCommandStart(f))
+	result = append(result, runtimeCommandSources(f, rc))
+	result = append(result, rc.DependencyCache.RestoreCommand)
+	result = append(result, rc.StartCommand)
 
 	return strings.Join(result, "\n")
 }
 
-func runtimeCommandSources(f *serverlessv1alpha2.Function) string {
-	spec := &f.Spec
-	if spec.Source.GitRepository != nil {
-		return runtimeCommandGitSources(f)
+func runtimeCommandSources(f *serverlessv1alpha2.Function, rc config.RuntimeConfig) string {
+	if f.HasFetchedSources() {
+		return runtimeCommandFetchedSources(rc)
 	}
-	return runtimeCommandInlineSources(f)
+	return runtimeCommandInlineSources(f, rc)
 }
 
-func runtimeCommandGitSources(f *serverlessv1alpha2.Function) string {
+func runtimeCommandFetchedSources(rc config.RuntimeConfig) string {
 	var result []string
-	if f.HasNodejsRuntime() {
-		result = append(result, `echo "{}" > package.json;`)
+	if rc.EmptyDependencies != "" {
+		// the fetched sources may not contain the dependencies file
+		result = append(result, fmt.Sprintf(`echo "%s" > %s;`, rc.EmptyDependencies, rc.DependenciesFile))
 	}
 	result = append(result, `cp -r /git-repository/src/* .;`)
 	return strings.Join(result, "\n")
 }
 
-func runtimeCommandInlineSources(f *serverlessv1alpha2.Function) string {
+func runtimeCommandInlineSources(f *serverlessv1alpha2.Function, rc config.RuntimeConfig) string {
 	var result []string
 	spec := &f.Spec
 	dependencies := spec.Source.Inline.Dependencies
 
-	handlerName, dependenciesName := "", ""
-	if f.HasNodejsRuntime() {
-		handlerName, dependenciesName = "handler.js", "package.json"
-		result = append(result, `echo "{}" > package.json;`)
-	} else if f.HasPythonRuntime() {
-		handlerName, dependenciesName = "handler.py", "requirements.txt"
-		result = append(result, `echo "" > requirements.txt;`)
-	}
-
-	result = append(result, fmt.Sprintf(`echo "${FUNC_HANDLER_SOURCE}" > %s;`, handlerName))
+	result = append(result, fmt.Sprintf(`echo "%s" > %s;`, rc.EmptyDependencies, rc.DependenciesFile))
+	result = append(result, fmt.Sprintf(`echo "${FUNC_HANDLER_SOURCE}" > %s;`, rc.HandlerFile))
 	if dependencies != "" {
-		result = append(result, fmt.Sprintf(`echo "${FUNC_HANDLER_DEPENDENCIES}" > %s;`, dependenciesName))
+		result = append(result, fmt.Sprintf(`echo "${FUNC_HANDLER_DEPENDENCIES}" > %s;`, rc.DependenciesFile))
 	}
 	return strings.Join(result, "\n")
 }
 
-func runtimeCommandInstall(f *serverlessv1alpha2.Function) string {
-	if f.HasNodejsRuntime() {
-		return `NPM_CONFIG_USERCONFIG=package-registry-config/.npmrc npm install --prefer-offline --no-audit --progress=false;`
-	} else if f.HasPythonRuntime() {
-		return `export PYTHONPATH="/kubeless/.local:${PYTHONPATH}"
-PIP_CONFIG_FILE=package-registry-config/pip.conf pip install --target=/kubeless/.local --no-cache-dir -r requirements.txt;`
-	}
-	return ""
-}
-
-func runtimeCommandStart(f *serverlessv1alpha2.Function) string {
-	if f.HasNodejsRuntime() {
-		return `cd ..;
-npm start;`
-	} else if f.HasPythonRuntime() {
-		return `cd ..;
-if [ -f "./kubeless.py" ]; then
-  # old file location support
-  python kubeless.py;
-else
-  python server.py;
-fi`
-	}
-	return ""
-}
-
-func generalEnvs(f *serverlessv1alpha2.Function, c *config.FunctionConfig) []corev1.EnvVar {
+func generalEnvs(f *serverlessv1alpha2.Function, c *config.FunctionConfig, rc config.RuntimeConfig) []corev1.EnvVar {
 	spec := &f.Spec
 	envs := []corev1.EnvVar{
 		{
@@ -675,27 +892,12 @@ func generalEnvs(f *serverlessv1alpha2.Function, c *config.FunctionConfig) []cor
 		},
 	}
 
-	if f.HasPythonRuntime() {
-		envs = append(envs, []corev1.EnvVar{
-			{
-				Name:  "PYTHONUNBUFFERED",
-				Value: "TRUE",
-			},
-			{
-				Name:  "MOD_NAME",
-				Value: "handler",
-			},
-			{
-				Name:  "FUNC_HANDLER",
-				Value: "main",
-			},
-		}...)
-	}
+	envs = append(envs, runtimeEnvs(rc.Env)...)
 	envs = append(envs, spec.Env...) //TODO: this order is critical, should we provide option for users to override envs?
 	return envs
 }
 
-func sourceEnvs(f *serverlessv1alpha2.Function) []corev1.EnvVar {
+func sourceEnvs(f *serverlessv1alpha2.Function, rc config.RuntimeConfig) []corev1.EnvVar {
 	spec := &f.Spec
 	envs := []corev1.EnvVar{}
 	if f.HasInlineSources() {
@@ -710,21 +912,17 @@ func sourceEnvs(f *serverlessv1alpha2.Function) []corev1.EnvVar {
 			},
 		}...)
 	}
-	if f.HasNodejsRuntime() {
-		envs = append(envs, []corev1.EnvVar{
-			{
-				Name:  "HANDLER_PATH",
-				Value: "./function/handler.js",
-			},
-		}...)
-	}
-	if f.HasPythonRuntime() {
-		envs = append(envs, []corev1.EnvVar{
-			{
-				Name:  "FUNCTION_PATH",
-				Value: "/kubeless",
-			},
-		}...)
+	envs = append(envs, runtimeEnvs(rc.SourceEnv)...)
+	return envs
+}
+
+func runtimeEnvs(runtimeEnvs []config.RuntimeEnvVar) []corev1.EnvVar {
+	envs := make([]corev1.EnvVar, 0, len(runtimeEnvs))
+	for _, env := range runtimeEnvs {
+		envs = append(envs, corev1.EnvVar{
+			Name:  env.Name,
+			Value: env.Value,
+		})
 	}
 	return envs
 }
@@ -759,30 +957,76 @@ func (d *Deployment) resourceConfigurationAndProfile() (corev1.ResourceRequireme
 	return corev1.ResourceRequirements{}, "custom"
 }
 
-func (d *Deployment) deploymentSecretVolumes() (volumes []corev1.Volume, volumeMounts []corev1.VolumeMount) {
-	volumes = []corev1.Volume{}
-	volumeMounts = []corev1.VolumeMount{}
+// deploymentMountedVolumes builds the volumes mounted into the function's container from the Function's mounts
+func (d *Deployment) deploymentMountedVolumes() (volumes []corev1.Volume, volumeMounts []corev1.VolumeMount) {
+	b := &volumeBuilder{
+		volumes:      []corev1.Volume{},
+		volumeMounts: []corev1.VolumeMount{},
+	}
 	for _, secretMount := range d.function.Spec.SecretMounts {
-		volumeName := secretMount.SecretName
-
-		volume := corev1.Volume{
-			Name: volumeName,
-			VolumeSource: corev1.VolumeSource{
-				Secret: &corev1.SecretVolumeSource{
-					SecretName:  secretMount.SecretName,
-					DefaultMode: ptr.To[int32](0666), //read and write only for everybody
-					Optional:    ptr.To(false),
-				},
+		b.add(secretMount.SecretName, secretMount.MountPath, corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName:  secretMount.SecretName,
+				Items:       secretMount.Items,
+				DefaultMode: ptr.To[int32](0666), //read and write only for everybody
+				Optional:    ptr.To(false),
 			},
+		})
+	}
+	for _, configMapMount := range d.function.Spec.ConfigMapMounts {
+		b.add(configMapMount.ConfigMapName, configMapMount.MountPath, corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: configMapMount.ConfigMapName},
+				Items:                configMapMount.Items,
+				DefaultMode:          ptr.To[int32](0666),
+				Optional:             ptr.To(false),
+			},
+		})
+	}
+	for i, tokenMount := range d.function.Spec.ServiceAccountTokenMounts {
+		path := tokenMount.Path
+		if path == "" {
+			path = defaultTokenPath
 		}
-		volumes = append(volumes, volume)
-
-		volumeMount := corev1.VolumeMount{
-			Name:      volumeName,
-			ReadOnly:  true,
-			MountPath: secretMount.MountPath,
-		}
-		volumeMounts = append(volumeMounts, volumeMount)
+		b.add(serviceAccountTokenVolumeName(i), tokenMount.MountPath, corev1.VolumeSource{
+			Projected: &corev1.ProjectedVolumeSource{
+				Sources: []corev1.VolumeProjection{{
+					ServiceAccountToken: &corev1.ServiceAccountTokenProjection{
+						Audience:          tokenMount.Audience,
+						ExpirationSeconds: ptr.To(ptr.Deref(tokenMount.ExpirationSeconds, defaultTokenExpirationSeconds)),
+						Path:              path,
+					},
+				}},
+			},
+		})
 	}
-	return volumes, volumeMounts
+	return b.volumes, b.volumeMounts
+}
+
+const (
+	defaultTokenExpirationSeconds int64 = 3600
+	defaultTokenPath                    = "token"
+)
+
+// serviceAccountTokenVolumeName returns the name of the volume with the Function's i-th projected token
+func serviceAccountTokenVolumeName(i int) string {
+	return fmt.Sprintf("serviceaccount-token-%d", i)
+}
+
+// volumeBuilder collects the volumes together with their read-only mounts
+type volumeBuilder struct {
+	volumes      []corev1.Volume
+	volumeMounts []corev1.VolumeMount
+}
+
+func (b *volumeBuilder) add(name, mountPath string, source corev1.VolumeSource) {
+	b.volumes = append(b.volumes, corev1.Volume{
+		Name:         name,
+		VolumeSource: source,
+	})
+	b.volumeMounts = append(b.volumeMounts, corev1.VolumeMount{
+		Name:      name,
+		ReadOnly:  true,
+		MountPath: mountPath,
+	})
 }
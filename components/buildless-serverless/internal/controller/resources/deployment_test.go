@@ -19,7 +19,7 @@ func TestNewDeployment(t *testing.T) {
 		f := minimalFunction()
 		c := minimalFunctionConfig()
 
-		r := NewDeployment(f, c, nil, "test-commit", nil, "", true)
+		r := NewDeployment(f, c, "test-commit", nil, "", true)
 
 		require.NotNil(t, r)
 		d := r.Deployment
@@ -79,13 +79,22 @@ func TestDeployment_construct(t *testing.T) {
 		require.NotNil(t, r)
 		require.Equal(t, int32(78), *r.Spec.Replicas)
 	})
+	t.Run("use zero replicas when function is scaled to zero", func(t *testing.T) {
+		f := minimalFunction()
+		f.Spec.Replicas = ptr.To[int32](3)
+
+		r := NewDeployment(f, minimalFunctionConfig(), "", nil, "", true, DeployScaleToZero(true))
+
+		require.NotNil(t, r)
+		require.Equal(t, int32(0), *r.Spec.Replicas)
+	})
 	t.Run("create labels based on function", func(t *testing.T) {
 		f := minimalFunction()
 		f.Spec.Labels = map[string]string{
 			"shtern": "stoic",
 			"boyd":   "vigilant",
 		}
-		d := NewDeployment(f, minimalFunctionConfig(), nil, "", nil, "", true)
+		d := NewDeployment(f, minimalFunctionConfig(), "", nil, "", true)
 
 		r := d.construct()
 
@@ -111,28 +120,12 @@ func TestDeployment_construct(t *testing.T) {
 			"boyd":                                     "vigilant",
 		}, r.Spec.Template.ObjectMeta.Labels)
 	})
-	t.Run("create annotations based on function and current deployment", func(t *testing.T) {
+	t.Run("create annotations based on function", func(t *testing.T) {
 		d := minimalDeployment()
 		d.function.Spec.Annotations = map[string]string{
 			"leavitt": "hopeful",
 			"pike":    "tender",
 		}
-		d.function.Status.FunctionAnnotations = map[string]string{
-			"dewdney": "intelligent", // this should be removed from deployment
-			"leavitt": "hopeful",
-		}
-		d.clusterDeployment = &appsv1.Deployment{
-			Spec: appsv1.DeploymentSpec{
-				Template: corev1.PodTemplateSpec{
-					ObjectMeta: metav1.ObjectMeta{
-						Annotations: map[string]string{
-							"thompson": "exciting",
-							"dewdney":  "zealous", // this should be removed
-						},
-					},
-				},
-			},
-		}
 
 		r := d.construct()
 
@@ -143,10 +136,27 @@ func TestDeployment_construct(t *testing.T) {
 			"rt-cfg.kyma-project.io/add-img-pull-secret": "true",
 			"leavitt":                        "hopeful",
 			"pike":                           "tender",
-			"thompson":                       "exciting",
 			"sidecar.istio.io/nativeSidecar": "true",
 		}, r.Spec.Template.ObjectMeta.Annotations)
 	})
+	t.Run("annotate deployment with pod template hash", func(t *testing.T) {
+		d := NewDeployment(minimalFunction(), minimalFunctionConfig(), "", nil, "", true)
+
+		require.Equal(t, map[string]string{
+			"serverless.kyma-project.io/template-hash": d.TemplateHash(),
+		}, d.Annotations)
+	})
+	t.Run("annotate pods with referenced content hash", func(t *testing.T) {
+		d := NewDeployment(minimalFunction(), minimalFunctionConfig(), "", nil, "", true, DeployReferencedContentHash("sha256:content"))
+
+		require.Equal(t, "sha256:content", d.Spec.Template.Annotations["serverless.kyma-project.io/referenced-content-hash"])
+	})
+	t.Run("change pod template hash when referenced content changes", func(t *testing.T) {
+		d := NewDeployment(minimalFunction(), minimalFunctionConfig(), "", nil, "", true, DeployReferencedContentHash("sha256:content"))
+		changed := NewDeployment(minimalFunction(), minimalFunctionConfig(), "", nil, "", true, DeployReferencedContentHash("sha256:changed"))
+
+		require.NotEqual(t, d.TemplateHash(), changed.TemplateHash())
+	})
 	t.Run("enable native sidecar", func(t *testing.T) {
 		d := minimalDeployment()
 
@@ -160,6 +170,157 @@ func TestDeployment_construct(t *testing.T) {
 			"sidecar.istio.io/nativeSidecar":             "true",
 		}, r.Spec.Template.ObjectMeta.Annotations)
 	})
+	t.Run("use default probes", func(t *testing.T) {
+		d := minimalDeployment()
+
+		r := d.construct()
+
+		container := r.Spec.Template.Spec.Containers[0]
+		require.Equal(t, &corev1.Probe{
+			ProbeHandler: corev1.ProbeHandler{
+				HTTPGet: &corev1.HTTPGetAction{Path: "/healthz", Port: svcTargetPort}},
+			PeriodSeconds:    5,
+			SuccessThreshold: 1,
+			FailureThreshold: 30,
+		}, container.StartupProbe)
+		require.Equal(t, &corev1.Probe{
+			ProbeHandler: corev1.ProbeHandler{
+				HTTPGet: &corev1.HTTPGetAction{Path: "/healthz", Port: svcTargetPort}},
+			PeriodSeconds:    5,
+			TimeoutSeconds:   2,
+			FailureThreshold: 1,
+		}, container.ReadinessProbe)
+		require.Equal(t, &corev1.Probe{
+			ProbeHandler: corev1.ProbeHandler{
+				HTTPGet: &corev1.HTTPGetAction{Path: "/healthz", Port: svcTargetPort}},
+			PeriodSeconds:    5,
+			TimeoutSeconds:   4,
+			FailureThreshold: 3,
+		}, container.LivenessProbe)
+	})
+	t.Run("override probes with function's probes", func(t *testing.T) {
+		d := minimalDeployment()
+		d.function.Spec.Probes = &serverlessv1alpha2.Probes{
+			Startup: &serverlessv1alpha2.Probe{
+				PeriodSeconds:    ptr.To[int32](10),
+				FailureThreshold: ptr.To[int32](60)},
+			Readiness: &serverlessv1alpha2.Probe{
+				Path:             "/ready",
+				FailureThreshold: ptr.To[int32](3)},
+			Liveness: &serverlessv1alpha2.Probe{
+				Path:                "/alive",
+				InitialDelaySeconds: ptr.To[int32](15),
+				TimeoutSeconds:      ptr.To[int32](10),
+				SuccessThreshold:    ptr.To[int32](1)},
+		}
+
+		r := d.construct()
+
+		container := r.Spec.Template.Spec.Containers[0]
+		require.Equal(t, &corev1.Probe{
+			ProbeHandler: corev1.ProbeHandler{
+				HTTPGet: &corev1.HTTPGetAction{Path: "/healthz", Port: svcTargetPort}},
+			PeriodSeconds:    10,
+			SuccessThreshold: 1,
+			FailureThreshold: 60,
+		}, container.StartupProbe)
+		require.Equal(t, &corev1.Probe{
+			ProbeHandler: corev1.ProbeHandler{
+				HTTPGet: &corev1.HTTPGetAction{Path: "/ready", Port: svcTargetPort}},
+			PeriodSeconds:    5,
+			TimeoutSeconds:   2,
+			FailureThreshold: 3,
+		}, container.ReadinessProbe)
+		require.Equal(t, &corev1.Probe{
+			ProbeHandler: corev1.ProbeHandler{
+				HTTPGet: &corev1.HTTPGetAction{Path: "/alive", Port: svcTargetPort}},
+			InitialDelaySeconds: 15,
+			PeriodSeconds:       5,
+			TimeoutSeconds:      10,
+			SuccessThreshold:    1,
+			FailureThreshold:    3,
+		}, container.LivenessProbe)
+	})
+	t.Run("schedule pods with function's template", func(t *testing.T) {
+		d := minimalDeployment()
+		affinity := &corev1.Affinity{
+			PodAntiAffinity: &corev1.PodAntiAffinity{
+				PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{
+					{Weight: 100, PodAffinityTerm: corev1.PodAffinityTerm{TopologyKey: "kubernetes.io/hostname"}},
+				},
+			},
+		}
+		d.function.Spec.Template = &serverlessv1alpha2.Template{
+			NodeSelector: map[string]string{"pool": "functions"},
+			Tolerations: []corev1.Toleration{
+				{Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "functions", Effect: corev1.TaintEffectNoSchedule},
+			},
+			Affinity: affinity,
+			TopologySpreadConstraints: []corev1.TopologySpreadConstraint{
+				{
+					MaxSkew:           1,
+					TopologyKey:       "topology.kubernetes.io/zone",
+					WhenUnsatisfiable: corev1.ScheduleAnyway,
+				},
+				{
+					MaxSkew:           2,
+					TopologyKey:       "kubernetes.io/hostname",
+					WhenUnsatisfiable: corev1.DoNotSchedule,
+					LabelSelector:     &metav1.LabelSelector{MatchLabels: map[string]string{"app": "custom"}},
+				},
+			},
+			PriorityClassName:  "high-priority",
+			ServiceAccountName: "function-sa",
+		}
+
+		r := d.construct()
+
+		podSpec := r.Spec.Template.Spec
+		require.Equal(t, map[string]string{"pool": "functions"}, podSpec.NodeSelector)
+		require.Equal(t, d.function.Spec.Template.Tolerations, podSpec.Tolerations)
+		require.Equal(t, affinity, podSpec.Affinity)
+		require.Equal(t, []corev1.TopologySpreadConstraint{
+			{
+				MaxSkew:           1,
+				TopologyKey:       "topology.kubernetes.io/zone",
+				WhenUnsatisfiable: corev1.ScheduleAnyway,
+				LabelSelector:     &metav1.LabelSelector{MatchLabels: r.Spec.Selector.MatchLabels},
+			},
+			{
+				MaxSkew:           2,
+				TopologyKey:       "kubernetes.io/hostname",
+				WhenUnsatisfiable: corev1.DoNotSchedule,
+				LabelSelector:     &metav1.LabelSelector{MatchLabels: map[string]string{"app": "custom"}},
+			},
+		}, podSpec.TopologySpreadConstraints)
+		require.Equal(t, "high-priority", podSpec.PriorityClassName)
+		require.Equal(t, "function-sa", podSpec.ServiceAccountName)
+		require.Nil(t, d.function.Spec.Template.TopologySpreadConstraints[0].LabelSelector)
+	})
+	t.Run("use default scheduling without function's template", func(t *testing.T) {
+		d := minimalDeployment()
+
+		r := d.construct()
+
+		podSpec := r.Spec.Template.Spec
+		require.Nil(t, podSpec.NodeSelector)
+		require.Nil(t, podSpec.Tolerations)
+		require.Nil(t, podSpec.Affinity)
+		require.Nil(t, podSpec.TopologySpreadConstraints)
+		require.Empty(t, podSpec.PriorityClassName)
+		require.Empty(t, podSpec.ServiceAccountName)
+	})
+	t.Run("use function's envFrom", func(t *testing.T) {
+		d := minimalDeployment()
+		d.function.Spec.EnvFrom = []corev1.EnvFromSource{
+			{ConfigMapRef: &corev1.ConfigMapEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "config"}}},
+			{Prefix: "DB_", SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "db"}}},
+		}
+
+		r := d.construct()
+
+		require.Equal(t, d.function.Spec.EnvFrom, r.Spec.Template.Spec.Containers[0].EnvFrom)
+	})
 	t.Run("use fixed container name", func(t *testing.T) {
 		d := minimalDeployment()
 
@@ -171,7 +332,7 @@ func TestDeployment_construct(t *testing.T) {
 	t.Run("use container image based on function and function configuration", func(t *testing.T) {
 		d := NewDeployment(minimalFunction(), &config.FunctionConfig{
 			Images: config.ImagesConfig{Python312: "special-test-image"},
-		}, nil, "", nil, "", true)
+		}, "", nil, "", true)
 
 		r := d.construct()
 
@@ -388,6 +549,118 @@ mkdir /git-repository/src;cp -r '/git-repository/repo/recursing-mcnulty'/* /git-
 mkdir /git-repository/src;cp -r '/git-repository/repo/git functions/nodejs12'/* /git-repository/src;`}
 		require.Equal(t, expectedCommand, c.Command)
 	})
+	t.Run("create init container cloning the resolved tag", func(t *testing.T) {
+		d := minimalDeployment()
+		d.commit = "test-commit"
+		d.function.Spec.Source = serverlessv1alpha2.Source{
+			GitRepository: &serverlessv1alpha2.GitRepositorySource{
+				URL: "wonderful-germain",
+				Repository: serverlessv1alpha2.Repository{
+					BaseDir:   "recursing-mcnulty",
+					Reference: "^1.4"}}}
+		DeployResolvedTag("v1.4.2")(d)
+
+		r := d.construct()
+
+		require.NotNil(t, r)
+		require.Len(t, r.Spec.Template.Spec.InitContainers, 1)
+		require.Contains(t, r.Spec.Template.Spec.InitContainers[0].Env, corev1.EnvVar{Name: "APP_REPOSITORY_REFERENCE", Value: "v1.4.2"})
+	})
+	t.Run("create init container for git function with checkout options", func(t *testing.T) {
+		d := minimalDeployment()
+		d.commit = "test-commit"
+		d.function.Spec.Source = serverlessv1alpha2.Source{
+			GitRepository: &serverlessv1alpha2.GitRepositorySource{
+				URL: "wonderful-germain",
+				Checkout: &serverlessv1alpha2.RepositoryCheckout{
+					Submodules: true,
+					Shallow:    true,
+					Sparse:     true,
+				},
+				Repository: serverlessv1alpha2.Repository{
+					BaseDir:   "recursing-mcnulty",
+					Reference: "main"}}}
+
+		r := d.construct()
+
+		require.NotNil(t, r)
+		require.Len(t, r.Spec.Template.Spec.InitContainers, 1)
+		c := r.Spec.Template.Spec.InitContainers[0]
+		require.Contains(t, c.Env, corev1.EnvVar{Name: "APP_REPOSITORY_BASE_DIR", Value: "recursing-mcnulty"})
+		require.Contains(t, c.Env, corev1.EnvVar{Name: "APP_REPOSITORY_SUBMODULES", Value: "true"})
+		require.Contains(t, c.Env, corev1.EnvVar{Name: "APP_REPOSITORY_SHALLOW", Value: "true"})
+		require.Contains(t, c.Env, corev1.EnvVar{Name: "APP_REPOSITORY_SPARSE", Value: "true"})
+		require.NotContains(t, c.Env, corev1.EnvVar{Name: "APP_REPOSITORY_LFS", Value: "true"})
+	})
+	t.Run("create init container pulling the resolved digest of OCI artifact", func(t *testing.T) {
+		d := minimalDeployment()
+		d.commit = "sha256:6c3c624b58dbbcd3c0dd82b4c53f04194d1247c6eebdaab7c610cf7d66709b3b"
+		d.function.Spec.Source = serverlessv1alpha2.Source{
+			OCIArtifact: &serverlessv1alpha2.OCIArtifactSource{
+				Reference:      "registry.example.com/team/function:1.0.0",
+				BaseDir:        "src",
+				PullSecretName: "pull-secret"}}
+
+		r := d.construct()
+
+		require.NotNil(t, r)
+		require.Len(t, r.Spec.Template.Spec.InitContainers, 1)
+		c := r.Spec.Template.Spec.InitContainers[0]
+		require.Contains(t, c.Env, corev1.EnvVar{Name: "APP_SOURCE_TYPE", Value: "ociArtifact"})
+		require.Contains(t, c.Env, corev1.EnvVar{Name: "APP_OCI_REFERENCE",
+			Value: "registry.example.com/team/function@sha256:6c3c624b58dbbcd3c0dd82b4c53f04194d1247c6eebdaab7c610cf7d66709b3b"})
+		require.Contains(t, c.Env, corev1.EnvVar{Name: "APP_OCI_DOCKER_CONFIG", ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "pull-secret"},
+				Key:                  ".dockerconfigjson"}}})
+		expectedCommand := []string{"sh", "-c",
+			`rm -rf /git-repository/*
+/app/gitcloner
+mkdir /git-repository/src;cp -r '/git-repository/repo/src'/* /git-repository/src;`}
+		require.Equal(t, expectedCommand, c.Command)
+		require.Contains(t, r.Spec.Template.Spec.Containers[0].VolumeMounts, corev1.VolumeMount{Name: "git-repository", MountPath: "/git-repository"})
+	})
+	t.Run("create init container downloading HTTP archive", func(t *testing.T) {
+		d := minimalDeployment()
+		d.commit = "sha256:6c3c624b58dbbcd3c0dd82b4c53f04194d1247c6eebdaab7c610cf7d66709b3b"
+		d.function.Spec.Source = serverlessv1alpha2.Source{
+			HTTPArchive: &serverlessv1alpha2.HTTPArchiveSource{
+				URL:      "https://example.com/function.tar.gz",
+				Checksum: "sha256:6c3c624b58dbbcd3c0dd82b4c53f04194d1247c6eebdaab7c610cf7d66709b3b"}}
+
+		r := d.construct()
+
+		require.NotNil(t, r)
+		require.Len(t, r.Spec.Template.Spec.InitContainers, 1)
+		c := r.Spec.Template.Spec.InitContainers[0]
+		require.Contains(t, c.Env, corev1.EnvVar{Name: "APP_SOURCE_TYPE", Value: "httpArchive"})
+		require.Contains(t, c.Env, corev1.EnvVar{Name: "APP_ARCHIVE_URL", Value: "https://example.com/function.tar.gz"})
+		require.Contains(t, c.Env, corev1.EnvVar{Name: "APP_ARCHIVE_CHECKSUM",
+			Value: "sha256:6c3c624b58dbbcd3c0dd82b4c53f04194d1247c6eebdaab7c610cf7d66709b3b"})
+	})
+	t.Run("create init container copying ConfigMap files", func(t *testing.T) {
+		d := minimalDeployment()
+		d.commit = "sha256:6c3c624b58dbbcd3c0dd82b4c53f04194d1247c6eebdaab7c610cf7d66709b3b"
+		d.function.Spec.Source = serverlessv1alpha2.Source{
+			ConfigMap: &serverlessv1alpha2.ConfigMapSource{Name: "function-files"}}
+
+		r := d.construct()
+
+		require.NotNil(t, r)
+		require.Len(t, r.Spec.Template.Spec.InitContainers, 1)
+		c := r.Spec.Template.Spec.InitContainers[0]
+		require.Contains(t, c.Env, corev1.EnvVar{Name: "APP_SOURCE_TYPE", Value: "configMap"})
+		require.Contains(t, c.Env, corev1.EnvVar{Name: "APP_SOURCE_DIGEST",
+			Value: "sha256:6c3c624b58dbbcd3c0dd82b4c53f04194d1247c6eebdaab7c610cf7d66709b3b"})
+		require.Contains(t, c.Env, corev1.EnvVar{Name: "APP_CONFIG_MAP_PATH", Value: "/config-map-source"})
+		require.Contains(t, c.VolumeMounts, corev1.VolumeMount{Name: "config-map-source", ReadOnly: true, MountPath: "/config-map-source"})
+		require.Contains(t, r.Spec.Template.Spec.Volumes, corev1.Volume{
+			Name: "config-map-source",
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "test-function-name-source-6c3c624b58db"}}}})
+		require.NotContains(t, r.Spec.Template.Spec.Containers[0].VolumeMounts, corev1.VolumeMount{Name: "config-map-source", ReadOnly: true, MountPath: "/config-map-source"})
+	})
 }
 
 func TestDeployment_replicas(t *testing.T) {
@@ -417,7 +690,7 @@ func TestDeployment_replicas(t *testing.T) {
 	})
 }
 
-func TestDeployment_workingSourcesDir(t *testing.T) {
+func TestDeployment_workingDir(t *testing.T) {
 	tests := []struct {
 		name    string
 		runtime serverlessv1alpha2.Runtime
@@ -446,13 +719,18 @@ func TestDeployment_workingSourcesDir(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			r := workingSourcesDir(&serverlessv1alpha2.Function{
+			d := NewDeployment(&serverlessv1alpha2.Function{
 				Spec: serverlessv1alpha2.FunctionSpec{
 					Runtime: tt.runtime,
+					Source: serverlessv1alpha2.Source{
+						Inline: &serverlessv1alpha2.InlineSource{
+							Source: "function-source",
+						},
+					},
 				},
-			})
+			}, &config.FunctionConfig{}, "", nil, "", false)
 
-			assert.Equal(t, tt.want, r)
+			assert.Equal(t, tt.want, d.Spec.Template.Spec.Containers[0].WorkingDir)
 		})
 	}
 }
@@ -463,7 +741,12 @@ func TestDeployment_runtimeImage(t *testing.T) {
 			NodeJs20:  "image-for-nodejs20",
 			NodeJs22:  "image-for-nodejs22",
 			NodeJs24:  "image-for-nodejs24",
+			NodeJs26:  "image-for-nodejs26",
 			Python312: "image-for-python312",
+			Python314: "image-for-python314",
+		},
+		Runtimes: map[string]config.RuntimeConfig{
+			"custom": {Image: "image-for-custom"},
 		},
 	}
 	type fields struct {
@@ -507,6 +790,30 @@ func TestDeployment_runtimeImage(t *testing.T) {
 			},
 			want: "image-for-nodejs24",
 		},
+		{
+			name: "get nodejs26 image from function config",
+			fields: fields{
+				runtime:              serverlessv1alpha2.NodeJs26,
+				runtimeImageOverride: "",
+			},
+			want: "image-for-nodejs26",
+		},
+		{
+			name: "get python314 image from function config",
+			fields: fields{
+				runtime:              serverlessv1alpha2.Python314,
+				runtimeImageOverride: "",
+			},
+			want: "image-for-python314",
+		},
+		{
+			name: "get custom runtime image from function config",
+			fields: fields{
+				runtime:              "custom",
+				runtimeImageOverride: "",
+			},
+			want: "image-for-custom",
+		},
 		{
 			name: "get overridden image name from function",
 			fields: fields{
@@ -534,12 +841,13 @@ func TestDeployment_runtimeImage(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			rc, _ := c.RuntimeConfig(string(tt.fields.runtime))
 			r := runtimeImage(&serverlessv1alpha2.Function{
 				Spec: serverlessv1alpha2.FunctionSpec{
 					Runtime:              tt.fields.runtime,
 					RuntimeImageOverride: tt.fields.runtimeImageOverride,
 				},
-			}, c)
+			}, rc)
 
 			assert.Equal(t, tt.want, r)
 		})
@@ -840,6 +1148,7 @@ func TestDeployment_volumeMounts(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			d := &Deployment{
+				runtimeConfig: fixRuntimeConfig(t, tt.runtime),
 				function: &serverlessv1alpha2.Function{
 					Spec: serverlessv1alpha2.FunctionSpec{
 						Runtime: tt.runtime,
@@ -1099,6 +1408,7 @@ func TestDeployment_volumes(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			d := &Deployment{
 				functionConfig: c,
+				runtimeConfig:  fixRuntimeConfig(t, tt.runtime),
 				function: &serverlessv1alpha2.Function{
 					Spec: serverlessv1alpha2.FunctionSpec{
 						Runtime: tt.runtime,
@@ -1114,23 +1424,25 @@ func TestDeployment_volumes(t *testing.T) {
 	}
 }
 
-func TestDeployment_deploymentSecretVolumes(t *testing.T) {
+func TestDeployment_deploymentMountedVolumes(t *testing.T) {
 	tests := []struct {
 		name             string
-		secretMounts     []serverlessv1alpha2.SecretMount
+		spec             serverlessv1alpha2.FunctionSpec
 		wantVolumes      []corev1.Volume
 		wantVolumeMounts []corev1.VolumeMount
 	}{
 		{
 			name: "build secret volumes based on function",
-			secretMounts: []serverlessv1alpha2.SecretMount{
-				{
-					SecretName: "secret-name-1",
-					MountPath:  "mount-path-1",
-				},
-				{
-					SecretName: "secret-name-2",
-					MountPath:  "mount-path-2",
+			spec: serverlessv1alpha2.FunctionSpec{
+				SecretMounts: []serverlessv1alpha2.SecretMount{
+					{
+						SecretName: "secret-name-1",
+						MountPath:  "mount-path-1",
+					},
+					{
+						SecretName: "secret-name-2",
+						MountPath:  "mount-path-2",
+					},
 				},
 			},
 			wantVolumes: []corev1.Volume{
@@ -1169,8 +1481,130 @@ func TestDeployment_deploymentSecretVolumes(t *testing.T) {
 			},
 		},
 		{
-			name:             "build empty secret volumes based on function",
-			secretMounts:     []serverlessv1alpha2.SecretMount{},
+			name: "build secret and config map volumes with items based on function",
+			spec: serverlessv1alpha2.FunctionSpec{
+				SecretMounts: []serverlessv1alpha2.SecretMount{
+					{
+						SecretName: "secret-name",
+						MountPath:  "secret-path",
+						Items: []corev1.KeyToPath{
+							{Key: "tls.key", Path: "keys/tls.key", Mode: ptr.To[int32](0400)},
+						},
+					},
+				},
+				ConfigMapMounts: []serverlessv1alpha2.ConfigMapMount{
+					{
+						ConfigMapName: "config-map-name",
+						MountPath:     "config-map-path",
+						Items: []corev1.KeyToPath{
+							{Key: "config.yaml", Path: "config.yaml"},
+						},
+					},
+				},
+			},
+			wantVolumes: []corev1.Volume{
+				{
+					Name: "secret-name",
+					VolumeSource: corev1.VolumeSource{
+						Secret: &corev1.SecretVolumeSource{
+							SecretName: "secret-name",
+							Items: []corev1.KeyToPath{
+								{Key: "tls.key", Path: "keys/tls.key", Mode: ptr.To[int32](0400)},
+							},
+							DefaultMode: ptr.To[int32](0666),
+							Optional:    ptr.To[bool](false),
+						},
+					},
+				},
+				{
+					Name: "config-map-name",
+					VolumeSource: corev1.VolumeSource{
+						ConfigMap: &corev1.ConfigMapVolumeSource{
+							LocalObjectReference: corev1.LocalObjectReference{Name: "config-map-name"},
+							Items: []corev1.KeyToPath{
+								{Key: "config.yaml", Path: "config.yaml"},
+							},
+							DefaultMode: ptr.To[int32](0666),
+							Optional:    ptr.To[bool](false),
+						},
+					},
+				},
+			},
+			wantVolumeMounts: []corev1.VolumeMount{
+				{
+					Name:      "secret-name",
+					ReadOnly:  true,
+					MountPath: "secret-path",
+				},
+				{
+					Name:      "config-map-name",
+					ReadOnly:  true,
+					MountPath: "config-map-path",
+				},
+			},
+		},
+		{
+			name: "build projected service account token volumes based on function",
+			spec: serverlessv1alpha2.FunctionSpec{
+				ServiceAccountTokenMounts: []serverlessv1alpha2.ServiceAccountTokenMount{
+					{
+						Audience:  "sts.amazonaws.com",
+						MountPath: "/var/run/secrets/aws",
+					},
+					{
+						Audience:          "api://AzureADTokenExchange",
+						ExpirationSeconds: ptr.To[int64](600),
+						MountPath:         "/var/run/secrets/azure",
+						Path:              "azure-identity-token",
+					},
+				},
+			},
+			wantVolumes: []corev1.Volume{
+				{
+					Name: "serviceaccount-token-0",
+					VolumeSource: corev1.VolumeSource{
+						Projected: &corev1.ProjectedVolumeSource{
+							Sources: []corev1.VolumeProjection{{
+								ServiceAccountToken: &corev1.ServiceAccountTokenProjection{
+									Audience:          "sts.amazonaws.com",
+									ExpirationSeconds: ptr.To[int64](3600),
+									Path:              "token",
+								},
+							}},
+						},
+					},
+				},
+				{
+					Name: "serviceaccount-token-1",
+					VolumeSource: corev1.VolumeSource{
+						Projected: &corev1.ProjectedVolumeSource{
+							Sources: []corev1.VolumeProjection{{
+								ServiceAccountToken: &corev1.ServiceAccountTokenProjection{
+									Audience:          "api://AzureADTokenExchange",
+									ExpirationSeconds: ptr.To[int64](600),
+									Path:              "azure-identity-token",
+								},
+							}},
+						},
+					},
+				},
+			},
+			wantVolumeMounts: []corev1.VolumeMount{
+				{
+					Name:      "serviceaccount-token-0",
+					ReadOnly:  true,
+					MountPath: "/var/run/secrets/aws",
+				},
+				{
+					Name:      "serviceaccount-token-1",
+					ReadOnly:  true,
+					MountPath: "/var/run/secrets/azure",
+				},
+			},
+		},
+		{
+			name:             "build empty volumes based on function",
+			spec:             serverlessv1alpha2.FunctionSpec{},
 			wantVolumes:      []corev1.Volume{},
 			wantVolumeMounts: []corev1.VolumeMount{},
 		},
@@ -1179,12 +1613,10 @@ func TestDeployment_deploymentSecretVolumes(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			d := &Deployment{
 				function: &serverlessv1alpha2.Function{
-					Spec: serverlessv1alpha2.FunctionSpec{
-						SecretMounts: tt.secretMounts,
-					},
+					Spec: tt.spec,
 				},
 			}
-			rV, rVM := d.deploymentSecretVolumes()
+			rV, rVM := d.deploymentMountedVolumes()
 			assert.Equal(t, tt.wantVolumes, rV)
 			assert.Equal(t, tt.wantVolumeMounts, rVM)
 		})
@@ -1465,13 +1897,69 @@ func TestDeployment_envs(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "build envs based on inline python314 function",
+			function: &serverlessv1alpha2.Function{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "function-name",
+					Namespace: "function-namespace",
+				},
+				Spec: serverlessv1alpha2.FunctionSpec{
+					Runtime: serverlessv1alpha2.Python314,
+					Source: serverlessv1alpha2.Source{
+						Inline: &serverlessv1alpha2.InlineSource{
+							Source:       "function-source-py",
+							Dependencies: "function-dependencies-py",
+						},
+					},
+				},
+			},
+			want: []corev1.EnvVar{
+				{
+					Name:  "FUNC_NAME",
+					Value: "function-name",
+				},
+				{
+					Name:  "FUNC_RUNTIME",
+					Value: "python314",
+				},
+				{
+					Name:  "SERVICE_NAMESPACE",
+					Value: "function-namespace",
+				},
+				{
+					Name:  "FUNC_HANDLER_SOURCE",
+					Value: "function-source-py",
+				},
+				{
+					Name:  "HANDLER_PATH",
+					Value: "/kubeless",
+				},
+				{
+					Name:  "FUNC_HANDLER_DEPENDENCIES",
+					Value: "function-dependencies-py",
+				},
+				{
+					Name:  "TRACE_COLLECTOR_ENDPOINT",
+					Value: "test-trace-collector-endpoint",
+				},
+				{
+					Name:  "PUBLISHER_PROXY_ADDRESS",
+					Value: "test-proxy-address",
+				},
+				{
+					Name:  "PYTHONUNBUFFERED",
+					Value: "TRUE",
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			d := NewDeployment(tt.function, &config.FunctionConfig{
 				FunctionPublisherProxyAddress:  "test-proxy-address",
 				FunctionTraceCollectorEndpoint: "test-trace-collector-endpoint",
-			}, nil, "", nil, "", true)
+			}, "", nil, "", true)
 
 			assert.ElementsMatch(t, tt.want, d.podEnvs)
 		})
@@ -1563,6 +2051,49 @@ if [ -f "./kubeless.py" ]; then
 else
   python server.py;
 fi`,
+		},
+		{
+			name: "build runtime command for inline python314 with dependencies",
+			function: &serverlessv1alpha2.Function{
+				Spec: serverlessv1alpha2.FunctionSpec{
+					Runtime: serverlessv1alpha2.Python314,
+					Source: serverlessv1alpha2.Source{
+						Inline: &serverlessv1alpha2.InlineSource{
+							Source:       "function-source",
+							Dependencies: "function-dependencies",
+						},
+					},
+				},
+			},
+			want: `set -e;
+echo "" > requirements.txt;
+echo "${FUNC_HANDLER_SOURCE}" > handler.py;
+echo "${FUNC_HANDLER_DEPENDENCIES}" > requirements.txt;
+export PYTHONPATH="/kubeless/.local:${PYTHONPATH}"
+PIP_CONFIG_FILE=package-registry-config/pip.conf pip install --target=/kubeless/.local --no-cache-dir -r requirements.txt;
+cd /usr/src/app;
+python server.py;`,
+		},
+		{
+			name: "build runtime command for inline nodejs26 with dependencies",
+			function: &serverlessv1alpha2.Function{
+				Spec: serverlessv1alpha2.FunctionSpec{
+					Runtime: serverlessv1alpha2.NodeJs26,
+					Source: serverlessv1alpha2.Source{
+						Inline: &serverlessv1alpha2.InlineSource{
+							Source:       "function-source",
+							Dependencies: "function-dependencies",
+						},
+					},
+				},
+			},
+			want: `set -e;
+echo "{}" > package.json;
+echo "${FUNC_HANDLER_SOURCE}" > handler.js;
+echo "${FUNC_HANDLER_DEPENDENCIES}" > package.json;
+NPM_CONFIG_USERCONFIG=package-registry-config/.npmrc npm install --prefer-offline --no-audit --progress=false;
+cd ..;
+npm start;`,
 		},
 		{
 			name: "build runtime command for inline nodejs20 without dependencies",
@@ -1756,13 +2287,81 @@ npm start;`,
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			r := runtimeCommand(tt.function)
+			r := runtimeCommand(tt.function, fixRuntimeConfig(t, tt.function.Spec.Runtime))
 
 			assert.Equal(t, tt.want, r)
 		})
 	}
 }
 
+func TestNewDeployment_customRuntime(t *testing.T) {
+	c := &config.FunctionConfig{
+		Runtimes: map[string]config.RuntimeConfig{
+			"go124": {
+				Image:            "image-for-go124",
+				WorkingDir:       "/app/function",
+				HandlerFile:      "handler.go",
+				DependenciesFile: "go.mod",
+				InstallCommand:   "go build -o /tmp/function .;",
+				StartCommand:     "/tmp/function;",
+				EmptyDirs: []config.RuntimeEmptyDir{
+					{Name: "go-cache", MountPath: "/.cache"},
+				},
+				Env: []config.RuntimeEnvVar{
+					{Name: "GOCACHE", Value: "/.cache"},
+				},
+				SourceEnv: []config.RuntimeEnvVar{
+					{Name: "HANDLER_PATH", Value: "/app/function"},
+				},
+			},
+		},
+	}
+	f := &serverlessv1alpha2.Function{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-function-name",
+			Namespace: "test-function-namespace",
+		},
+		Spec: serverlessv1alpha2.FunctionSpec{
+			Runtime: "go124",
+			Source: serverlessv1alpha2.Source{
+				Inline: &serverlessv1alpha2.InlineSource{
+					Source: "function-source",
+				},
+			},
+		},
+	}
+
+	d := NewDeployment(f, c, "", nil, "", false)
+
+	podSpec := d.Spec.Template.Spec
+	container := podSpec.Containers[0]
+	require.Equal(t, "image-for-go124", container.Image)
+	require.Equal(t, "/app/function", container.WorkingDir)
+	require.Equal(t, `set -e;
+echo "" > go.mod;
+echo "${FUNC_HANDLER_SOURCE}" > handler.go;
+go build -o /tmp/function .;
+/tmp/function;`, container.Command[2])
+	require.Contains(t, container.Env, corev1.EnvVar{Name: "GOCACHE", Value: "/.cache"})
+	require.Contains(t, container.Env, corev1.EnvVar{Name: "HANDLER_PATH", Value: "/app/function"})
+	require.Contains(t, podSpec.Volumes, corev1.Volume{
+		Name: "go-cache",
+		VolumeSource: corev1.VolumeSource{
+			EmptyDir: &corev1.EmptyDirVolumeSource{},
+		},
+	})
+	require.Contains(t, container.VolumeMounts, corev1.VolumeMount{Name: "go-cache", MountPath: "/.cache"})
+	for _, mount := range container.VolumeMounts {
+		require.NotEqual(t, "package-registry-config", mount.Name)
+	}
+}
+
+func fixRuntimeConfig(t *testing.T, runtime serverlessv1alpha2.Runtime) config.RuntimeConfig {
+	rc, ok := (&config.FunctionConfig{}).RuntimeConfig(string(runtime))
+	require.True(t, ok)
+	return rc
+}
+
 func minimalFunction() *serverlessv1alpha2.Function {
 	return &serverlessv1alpha2.Function{
 		ObjectMeta: metav1.ObjectMeta{
@@ -1790,7 +2389,7 @@ func minimalFunctionConfig() *config.FunctionConfig {
 }
 
 func minimalDeploymentForFunction(f *serverlessv1alpha2.Function) *Deployment {
-	return NewDeployment(f, minimalFunctionConfig(), nil, "", nil, "", true)
+	return NewDeployment(f, minimalFunctionConfig(), "", nil, "", true)
 }
 
 func minimalDeployment() *Deployment {
@@ -0,0 +1,30 @@
+package resources
+
+import (
+	serverlessv1alpha2 "github.com/kyma-project/serverless/components/buildless-serverless/api/v1alpha2"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/git"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// NewGitTokenSecret returns the secret with the GitHub App's installation token the function's pods clone the repository with
+func NewGitTokenSecret(f *serverlessv1alpha2.Function, token string) *corev1.Secret {
+	return &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Secret",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      git.InstallationTokenSecretName(f),
+			Namespace: f.GetNamespace(),
+			Labels: labels.Merge(f.InternalFunctionLabels(), map[string]string{
+				serverlessv1alpha2.FunctionResourceLabel: serverlessv1alpha2.FunctionResourceLabelGitTokenValue,
+			}),
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			git.InstallationTokenSecretKey: []byte(token),
+		},
+	}
+}
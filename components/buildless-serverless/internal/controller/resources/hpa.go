@@ -0,0 +1,81 @@
+package resources
+
+import (
+	serverlessv1alpha2 "github.com/kyma-project/serverless/components/buildless-serverless/api/v1alpha2"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/config"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+type HPA struct {
+	*autoscalingv2.HorizontalPodAutoscaler
+	function       *serverlessv1alpha2.Function
+	functionConfig *config.FunctionConfig
+}
+
+func NewHPA(f *serverlessv1alpha2.Function, c *config.FunctionConfig) *HPA {
+	h := &HPA{
+		function:       f,
+		functionConfig: c,
+	}
+
+	h.HorizontalPodAutoscaler = h.construct()
+	return h
+}
+
+func (h *HPA) construct() *autoscalingv2.HorizontalPodAutoscaler {
+	return &autoscalingv2.HorizontalPodAutoscaler{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "HorizontalPodAutoscaler",
+			APIVersion: "autoscaling/v2",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      h.function.GetName(),
+			Namespace: h.function.GetNamespace(),
+			Labels:    h.function.FunctionLabels(),
+		},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			// HPA scales the Function through its scale subresource,
+			// Function's replicas are then propagated to the Deployment
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{
+				Kind:       "Function",
+				Name:       h.function.GetName(),
+				APIVersion: serverlessv1alpha2.GroupVersion.String(),
+			},
+			MinReplicas: h.minReplicas(),
+			MaxReplicas: h.maxReplicas(),
+			Metrics: []autoscalingv2.MetricSpec{
+				{
+					Type: autoscalingv2.ResourceMetricSourceType,
+					Resource: &autoscalingv2.ResourceMetricSource{
+						Name: corev1.ResourceCPU,
+						Target: autoscalingv2.MetricTarget{
+							Type:               autoscalingv2.UtilizationMetricType,
+							AverageUtilization: ptr.To(h.functionConfig.TargetCPUUtilizationPercentage),
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (h *HPA) minReplicas() *int32 {
+	// HPA can't scale to zero, scale-to-zero functions are scaled down by the controller
+	// and scaled back up by the activator
+	if h.function.IsScaleToZeroEnabled() {
+		return ptr.To[int32](1)
+	}
+	return h.function.Spec.ScaleConfig.MinReplicas
+}
+
+func (h *HPA) maxReplicas() int32 {
+	maxReplicas := h.function.Spec.ScaleConfig.MaxReplicas
+	if maxReplicas == nil {
+		// HPA rejects maxReplicas lower than minReplicas
+		return max(ptr.Deref(h.minReplicas(), DefaultDeploymentReplicas), 1)
+	}
+	return *maxReplicas
+}
@@ -0,0 +1,116 @@
+package resources
+
+import (
+	"testing"
+
+	serverlessv1alpha2 "github.com/kyma-project/serverless/components/buildless-serverless/api/v1alpha2"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/config"
+	"github.com/stretchr/testify/require"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+func TestNewHPA(t *testing.T) {
+	t.Run("create proper hpa", func(t *testing.T) {
+		f := &serverlessv1alpha2.Function{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-function-name",
+				Namespace: "test-function-namespace",
+				UID:       "test-uid",
+			},
+			Spec: serverlessv1alpha2.FunctionSpec{
+				ScaleConfig: &serverlessv1alpha2.ScaleConfig{
+					MinReplicas: ptr.To[int32](2),
+					MaxReplicas: ptr.To[int32](4),
+				},
+			},
+		}
+		c := &config.FunctionConfig{
+			TargetCPUUtilizationPercentage: 70,
+		}
+		expectedHPA := &autoscalingv2.HorizontalPodAutoscaler{
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "HorizontalPodAutoscaler",
+				APIVersion: "autoscaling/v2",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-function-name",
+				Namespace: "test-function-namespace",
+				Labels: map[string]string{
+					"serverless.kyma-project.io/function-name": "test-function-name",
+					"serverless.kyma-project.io/managed-by":    "function-controller",
+					"serverless.kyma-project.io/uuid":          "test-uid",
+				},
+			},
+			Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+				ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{
+					Kind:       "Function",
+					Name:       "test-function-name",
+					APIVersion: "serverless.kyma-project.io/v1alpha2",
+				},
+				MinReplicas: ptr.To[int32](2),
+				MaxReplicas: 4,
+				Metrics: []autoscalingv2.MetricSpec{
+					{
+						Type: autoscalingv2.ResourceMetricSourceType,
+						Resource: &autoscalingv2.ResourceMetricSource{
+							Name: corev1.ResourceCPU,
+							Target: autoscalingv2.MetricTarget{
+								Type:               autoscalingv2.UtilizationMetricType,
+								AverageUtilization: ptr.To[int32](70),
+							},
+						},
+					},
+				},
+			},
+		}
+
+		r := NewHPA(f, c)
+
+		require.NotNil(t, r)
+		require.Equal(t, expectedHPA, r.HorizontalPodAutoscaler)
+	})
+	t.Run("use default max replicas when not set", func(t *testing.T) {
+		f := &serverlessv1alpha2.Function{
+			Spec: serverlessv1alpha2.FunctionSpec{
+				ScaleConfig: &serverlessv1alpha2.ScaleConfig{},
+			},
+		}
+
+		r := NewHPA(f, &config.FunctionConfig{})
+
+		require.Nil(t, r.Spec.MinReplicas)
+		require.Equal(t, DefaultDeploymentReplicas, r.Spec.MaxReplicas)
+	})
+	t.Run("use min replicas as max replicas when not set", func(t *testing.T) {
+		f := &serverlessv1alpha2.Function{
+			Spec: serverlessv1alpha2.FunctionSpec{
+				ScaleConfig: &serverlessv1alpha2.ScaleConfig{
+					MinReplicas: ptr.To[int32](4),
+				},
+			},
+		}
+
+		r := NewHPA(f, &config.FunctionConfig{})
+
+		require.Equal(t, ptr.To[int32](4), r.Spec.MinReplicas)
+		require.Equal(t, int32(4), r.Spec.MaxReplicas)
+	})
+	t.Run("use one min replica for scale-to-zero function", func(t *testing.T) {
+		f := &serverlessv1alpha2.Function{
+			Spec: serverlessv1alpha2.FunctionSpec{
+				ScaleConfig: &serverlessv1alpha2.ScaleConfig{
+					MinReplicas: ptr.To[int32](0),
+					MaxReplicas: ptr.To[int32](3),
+				},
+			},
+		}
+
+		r := NewHPA(f, &config.FunctionConfig{})
+
+		require.Equal(t, ptr.To[int32](1), r.Spec.MinReplicas)
+		require.Equal(t, int32(3), r.Spec.MaxReplicas)
+	})
+}
@@ -0,0 +1,138 @@
+package resources
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	serverlessv1alpha2 "github.com/kyma-project/serverless/components/buildless-serverless/api/v1alpha2"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	revisionSpecKey         = "spec"
+	revisionCommitKey       = "commit"
+	revisionTemplateHashKey = "templateHash"
+)
+
+// Revision is the snapshot of the function's version stored in a config map
+type Revision struct {
+	*corev1.ConfigMap
+	number int64
+	spec   serverlessv1alpha2.FunctionSpec
+}
+
+func NewRevision(f *serverlessv1alpha2.Function, number int64, commit string, templateHash string) *Revision {
+	r := &Revision{
+		number: number,
+		spec:   revisionSpec(f),
+	}
+
+	r.ConfigMap = &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "ConfigMap",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-rev-%d", f.GetName(), number),
+			Namespace: f.GetNamespace(),
+			Labels: labels.Merge(RevisionLabels(f), map[string]string{
+				serverlessv1alpha2.FunctionRevisionLabel: strconv.FormatInt(number, 10),
+			}),
+		},
+		Data: map[string]string{
+			revisionSpecKey:         marshalRevisionSpec(r.spec),
+			revisionCommitKey:       commit,
+			revisionTemplateHashKey: templateHash,
+		},
+	}
+	return r
+}
+
+// ParseRevision reads the function's snapshot from the revision's config map
+func ParseRevision(cm *corev1.ConfigMap) (*Revision, error) {
+	number, err := strconv.ParseInt(cm.GetLabels()[serverlessv1alpha2.FunctionRevisionLabel], 10, 64)
+	if err != nil {
+		return nil, errors.Wrapf(err, "while parsing revision number of %s", cm.GetName())
+	}
+
+	spec := serverlessv1alpha2.FunctionSpec{}
+	if err := json.Unmarshal([]byte(cm.Data[revisionSpecKey]), &spec); err != nil {
+		return nil, errors.Wrapf(err, "while parsing spec of revision %s", cm.GetName())
+	}
+
+	return &Revision{
+		ConfigMap: cm,
+		number:    number,
+		spec:      spec,
+	}, nil
+}
+
+// RevisionLabels returns labels used to find the function's revisions
+func RevisionLabels(f *serverlessv1alpha2.Function) map[string]string {
+	return labels.Merge(f.InternalFunctionLabels(), map[string]string{
+		serverlessv1alpha2.FunctionResourceLabel: serverlessv1alpha2.FunctionResourceLabelRevisionValue,
+	})
+}
+
+func (r *Revision) Number() int64 {
+	return r.number
+}
+
+func (r *Revision) Commit() string {
+	return r.Data[revisionCommitKey]
+}
+
+func (r *Revision) TemplateHash() string {
+	return r.Data[revisionTemplateHashKey]
+}
+
+// Function returns a copy of the function running the revision's spec
+// the scaling, the rollout strategy and the revision settings are kept from the function
+func (r *Revision) Function(f *serverlessv1alpha2.Function) *serverlessv1alpha2.Function {
+	result := f.DeepCopy()
+	spec := r.spec.DeepCopy()
+	spec.ScaleConfig = result.Spec.ScaleConfig
+	spec.Replicas = result.Spec.Replicas
+	spec.Rollout = result.Spec.Rollout
+	spec.Revision = result.Spec.Revision
+	spec.RevisionHistoryLimit = result.Spec.RevisionHistoryLimit
+	result.Spec = *spec
+	return result
+}
+
+// Matches checks if the revision records the function's spec running the commit
+func (r *Revision) Matches(f *serverlessv1alpha2.Function, commit string) bool {
+	return r.Commit() == commit && r.Data[revisionSpecKey] == marshalRevisionSpec(revisionSpec(f))
+}
+
+// Status returns the revision's summary exposed in the function's status
+func (r *Revision) Status() serverlessv1alpha2.FunctionRevision {
+	return serverlessv1alpha2.FunctionRevision{
+		Name:              r.GetName(),
+		Runtime:           r.spec.Runtime,
+		Commit:            r.Commit(),
+		TemplateHash:      r.TemplateHash(),
+		CreationTimestamp: r.GetCreationTimestamp(),
+	}
+}
+
+// revisionSpec returns the function's spec without the fields not affecting the function's pods
+func revisionSpec(f *serverlessv1alpha2.Function) serverlessv1alpha2.FunctionSpec {
+	spec := f.Spec.DeepCopy()
+	spec.ScaleConfig = nil
+	spec.Replicas = nil
+	spec.Rollout = nil
+	spec.Revision = ""
+	spec.RevisionHistoryLimit = nil
+	return *spec
+}
+
+func marshalRevisionSpec(spec serverlessv1alpha2.FunctionSpec) string {
+	// marshalling the function's spec can't fail
+	data, _ := json.Marshal(spec)
+	return string(data)
+}
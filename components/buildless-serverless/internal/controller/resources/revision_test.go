@@ -0,0 +1,166 @@
+package resources
+
+import (
+	"testing"
+
+	serverlessv1alpha2 "github.com/kyma-project/serverless/components/buildless-serverless/api/v1alpha2"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+func TestNewRevision(t *testing.T) {
+	t.Run("create proper revision", func(t *testing.T) {
+		f := fixRevisionFunction()
+
+		r := NewRevision(f, 3, "test-commit", "test-hash")
+
+		require.Equal(t, &corev1.ConfigMap{
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "ConfigMap",
+				APIVersion: "v1",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-function-name-rev-3",
+				Namespace: "test-function-namespace",
+				Labels: map[string]string{
+					"serverless.kyma-project.io/function-name": "test-function-name",
+					"serverless.kyma-project.io/managed-by":    "function-controller",
+					"serverless.kyma-project.io/uuid":          "test-uid",
+					"serverless.kyma-project.io/resource":      "revision",
+					"serverless.kyma-project.io/revision":      "3",
+				},
+			},
+			Data: map[string]string{
+				"spec":         `{"runtime":"nodejs24","source":{"inline":{"source":"test-source","dependencies":"test-dependencies"}},"env":[{"name":"LOG_LEVEL","value":"info"}]}`,
+				"commit":       "test-commit",
+				"templateHash": "test-hash",
+			},
+		}, r.ConfigMap)
+		require.Equal(t, int64(3), r.Number())
+		require.Equal(t, "test-commit", r.Commit())
+		require.Equal(t, "test-hash", r.TemplateHash())
+	})
+}
+
+func TestParseRevision(t *testing.T) {
+	t.Run("parse revision created from function", func(t *testing.T) {
+		f := fixRevisionFunction()
+		cm := NewRevision(f, 3, "test-commit", "test-hash").ConfigMap
+
+		r, err := ParseRevision(cm)
+
+		require.NoError(t, err)
+		require.Equal(t, int64(3), r.Number())
+		require.Equal(t, serverlessv1alpha2.FunctionRevision{
+			Name:         "test-function-name-rev-3",
+			Runtime:      serverlessv1alpha2.NodeJs24,
+			Commit:       "test-commit",
+			TemplateHash: "test-hash",
+		}, r.Status())
+	})
+	t.Run("invalid revision number", func(t *testing.T) {
+		cm := NewRevision(fixRevisionFunction(), 3, "", "").ConfigMap
+		cm.Labels[serverlessv1alpha2.FunctionRevisionLabel] = "three"
+
+		r, err := ParseRevision(cm)
+
+		require.ErrorContains(t, err, "while parsing revision number of test-function-name-rev-3")
+		require.Nil(t, r)
+	})
+	t.Run("invalid spec", func(t *testing.T) {
+		cm := NewRevision(fixRevisionFunction(), 3, "", "").ConfigMap
+		cm.Data["spec"] = "{"
+
+		r, err := ParseRevision(cm)
+
+		require.ErrorContains(t, err, "while parsing spec of revision test-function-name-rev-3")
+		require.Nil(t, r)
+	})
+}
+
+func TestRevision_Function(t *testing.T) {
+	t.Run("apply revision's spec", func(t *testing.T) {
+		r := NewRevision(fixRevisionFunction(), 1, "", "")
+		f := fixRevisionFunction()
+		f.Spec.Runtime = serverlessv1alpha2.Python312
+		f.Spec.Source.Inline.Source = "new-source"
+		f.Spec.Env = []corev1.EnvVar{{Name: "LOG_LEVEL", Value: "debug"}}
+
+		result := r.Function(f)
+
+		require.Equal(t, serverlessv1alpha2.NodeJs24, result.Spec.Runtime)
+		require.Equal(t, "test-source", result.Spec.Source.Inline.Source)
+		require.Equal(t, "test-dependencies", result.Spec.Source.Inline.Dependencies)
+		require.Equal(t, []corev1.EnvVar{{Name: "LOG_LEVEL", Value: "info"}}, result.Spec.Env)
+		// original function is not changed
+		require.Equal(t, serverlessv1alpha2.Python312, f.Spec.Runtime)
+		require.Equal(t, "new-source", f.Spec.Source.Inline.Source)
+	})
+	t.Run("keep function's scaling and revision settings", func(t *testing.T) {
+		old := fixRevisionFunction()
+		old.Spec.Replicas = ptr.To[int32](1)
+		r := NewRevision(old, 1, "", "")
+		f := fixRevisionFunction()
+		f.Spec.Replicas = ptr.To[int32](3)
+		f.Spec.Revision = "test-function-name-rev-1"
+		f.Spec.RevisionHistoryLimit = ptr.To[int32](5)
+
+		result := r.Function(f)
+
+		require.Equal(t, ptr.To[int32](3), result.Spec.Replicas)
+		require.Equal(t, "test-function-name-rev-1", result.Spec.Revision)
+		require.Equal(t, ptr.To[int32](5), result.Spec.RevisionHistoryLimit)
+	})
+}
+
+func TestRevision_Matches(t *testing.T) {
+	r := NewRevision(fixRevisionFunction(), 1, "test-commit", "test-hash")
+
+	t.Run("match the same spec and commit", func(t *testing.T) {
+		require.True(t, r.Matches(fixRevisionFunction(), "test-commit"))
+	})
+	t.Run("match function with other scaling", func(t *testing.T) {
+		f := fixRevisionFunction()
+		f.Spec.Replicas = ptr.To[int32](3)
+		f.Spec.ScaleConfig = &serverlessv1alpha2.ScaleConfig{MinReplicas: ptr.To[int32](0)}
+
+		require.True(t, r.Matches(f, "test-commit"))
+	})
+	t.Run("match parsed revision", func(t *testing.T) {
+		parsed, err := ParseRevision(r.ConfigMap)
+
+		require.NoError(t, err)
+		require.True(t, parsed.Matches(fixRevisionFunction(), "test-commit"))
+	})
+	t.Run("not match other commit", func(t *testing.T) {
+		require.False(t, r.Matches(fixRevisionFunction(), "other-commit"))
+	})
+	t.Run("not match other pod settings", func(t *testing.T) {
+		f := fixRevisionFunction()
+		f.Spec.ResourceConfiguration = &serverlessv1alpha2.ResourceConfiguration{Function: &serverlessv1alpha2.ResourceRequirements{Profile: "L"}}
+
+		require.False(t, r.Matches(f, "test-commit"))
+	})
+}
+
+func fixRevisionFunction() *serverlessv1alpha2.Function {
+	return &serverlessv1alpha2.Function{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-function-name",
+			Namespace: "test-function-namespace",
+			UID:       "test-uid",
+		},
+		Spec: serverlessv1alpha2.FunctionSpec{
+			Runtime: serverlessv1alpha2.NodeJs24,
+			Source: serverlessv1alpha2.Source{
+				Inline: &serverlessv1alpha2.InlineSource{
+					Source:       "test-source",
+					Dependencies: "test-dependencies",
+				},
+			},
+			Env: []corev1.EnvVar{{Name: "LOG_LEVEL", Value: "info"}},
+		},
+	}
+}
@@ -1,6 +1,8 @@
 package resources
 
 import (
+	"strconv"
+
 	serverlessv1alpha2 "github.com/kyma-project/serverless/components/buildless-serverless/api/v1alpha2"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -40,12 +42,21 @@ func ServiceTrimClusterInfoLabels() serviceOptions {
 	}
 }
 
+// ServiceActivatorPort - route the service to the activator's port instead of the function's pods,
+// the endpoints of the service are managed by the controller
+func ServiceActivatorPort(port int32) serviceOptions {
+	return func(s *Service) {
+		s.activatorPort = port
+	}
+}
+
 type Service struct {
 	*corev1.Service
 	function       *serverlessv1alpha2.Function
 	functionLabels map[string]string
 	selectorLabels map[string]string
 	svcName        string
+	activatorPort  int32
 }
 
 func NewService(f *serverlessv1alpha2.Function, opts ...serviceOptions) *Service {
@@ -85,8 +96,21 @@ func (s *Service) construct() *corev1.Service {
 				Protocol:   corev1.ProtocolTCP,
 			}},
 			Selector: s.selectorLabels,
+			// the type is set explicitly to migrate the services of scale-to-zero functions
+			// that pointed to the activator with the external name before
+			Type: corev1.ServiceTypeClusterIP,
 		},
 	}
 
+	if s.activatorPort != 0 {
+		// the service without a selector keeps its cluster IP, but it's routed to the activator's endpoints,
+		// the activator recognizes the called function by the port
+		service.Spec.Ports[0].TargetPort = intstr.FromInt32(s.activatorPort)
+		service.Spec.Selector = nil
+		service.Annotations = map[string]string{
+			serverlessv1alpha2.ServiceActivatorPortAnnotation: strconv.Itoa(int(s.activatorPort)),
+		}
+	}
+
 	return service
 }
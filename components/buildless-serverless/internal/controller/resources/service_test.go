@@ -49,6 +49,7 @@ func TestNewService(t *testing.T) {
 					"serverless.kyma-project.io/resource":      "deployment",
 					"serverless.kyma-project.io/uuid":          "test-uid",
 				},
+				Type: corev1.ServiceTypeClusterIP,
 			},
 		}
 
@@ -60,4 +61,26 @@ func TestNewService(t *testing.T) {
 		require.IsType(t, &corev1.Service{}, s)
 		require.Equal(t, expectedSvc, s)
 	})
+	t.Run("create service routed to activator", func(t *testing.T) {
+		f := &serverlessv1alpha2.Function{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-function-name",
+				Namespace: "test-function-namespace",
+				UID:       "test-uid",
+			},
+		}
+
+		r := NewService(f, ServiceActivatorPort(20001))
+
+		require.NotNil(t, r)
+		require.Equal(t, corev1.ServiceTypeClusterIP, r.Spec.Type)
+		require.Nil(t, r.Spec.Selector)
+		require.Equal(t, map[string]string{"serverless.kyma-project.io/activator-port": "20001"}, r.Annotations)
+		require.Equal(t, []corev1.ServicePort{{
+			Name:       "http",
+			TargetPort: intstr.FromInt32(20001),
+			Port:       80,
+			Protocol:   corev1.ProtocolTCP,
+		}}, r.Spec.Ports)
+	})
 }
@@ -0,0 +1,42 @@
+package resources
+
+import (
+	"fmt"
+	"strings"
+
+	serverlessv1alpha2 "github.com/kyma-project/serverless/components/buildless-serverless/api/v1alpha2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/utils/ptr"
+)
+
+const sourceSnapshotDigestLength = 12
+
+// SourceSnapshotName returns the name of the ConfigMap keeping the files of the ConfigMap source with the given digest
+func SourceSnapshotName(f *serverlessv1alpha2.Function, digest string) string {
+	hash := strings.TrimPrefix(digest, "sha256:")
+	return fmt.Sprintf("%s-source-%s", f.GetName(), hash[:min(len(hash), sourceSnapshotDigestLength)])
+}
+
+// SourceSnapshotLabels returns the labels selecting the function's source snapshots
+func SourceSnapshotLabels(f *serverlessv1alpha2.Function) map[string]string {
+	return labels.Merge(f.InternalFunctionLabels(), map[string]string{
+		serverlessv1alpha2.FunctionResourceLabel: serverlessv1alpha2.FunctionResourceLabelSourceValue,
+	})
+}
+
+// NewSourceSnapshot copies the files of the ConfigMap source, so the function's pods run the files with the resolved digest
+// the snapshot is immutable, the changed source is copied to the snapshot of its new digest
+func NewSourceSnapshot(f *serverlessv1alpha2.Function, source *corev1.ConfigMap, digest string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      SourceSnapshotName(f, digest),
+			Namespace: f.GetNamespace(),
+			Labels:    SourceSnapshotLabels(f),
+		},
+		Data:       source.Data,
+		BinaryData: source.BinaryData,
+		Immutable:  ptr.To(true),
+	}
+}
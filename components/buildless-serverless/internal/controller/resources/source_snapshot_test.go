@@ -0,0 +1,41 @@
+package resources
+
+import (
+	"testing"
+
+	serverlessv1alpha2 "github.com/kyma-project/serverless/components/buildless-serverless/api/v1alpha2"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSourceSnapshotName(t *testing.T) {
+	f := &serverlessv1alpha2.Function{ObjectMeta: metav1.ObjectMeta{Name: "test-function"}}
+
+	require.Equal(t, "test-function-source-6c3c624b58db",
+		SourceSnapshotName(f, "sha256:6c3c624b58dbbcd3c0dd82b4c53f04194d1247c6eebdaab7c610cf7d66709b3b"))
+	require.Equal(t, "test-function-source-6c3c", SourceSnapshotName(f, "sha256:6c3c"))
+}
+
+func TestNewSourceSnapshot(t *testing.T) {
+	f := &serverlessv1alpha2.Function{ObjectMeta: metav1.ObjectMeta{
+		Name:      "test-function",
+		Namespace: "test-namespace",
+		UID:       "test-uid",
+	}}
+	source := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "function-files", Namespace: "test-namespace"},
+		Data:       map[string]string{"handler.js": "module.exports = {}"},
+		BinaryData: map[string][]byte{"logo.png": {0x89, 0x50}},
+	}
+
+	snapshot := NewSourceSnapshot(f, source, "sha256:6c3c624b58dbbcd3c0dd82b4c53f04194d1247c6eebdaab7c610cf7d66709b3b")
+
+	require.Equal(t, "test-function-source-6c3c624b58db", snapshot.GetName())
+	require.Equal(t, "test-namespace", snapshot.GetNamespace())
+	require.Equal(t, "source", snapshot.GetLabels()[serverlessv1alpha2.FunctionResourceLabel])
+	require.Equal(t, "test-function", snapshot.GetLabels()[serverlessv1alpha2.FunctionNameLabel])
+	require.Equal(t, source.Data, snapshot.Data)
+	require.Equal(t, source.BinaryData, snapshot.BinaryData)
+	require.True(t, *snapshot.Immutable)
+}
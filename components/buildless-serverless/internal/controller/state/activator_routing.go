@@ -0,0 +1,190 @@
+package state
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"slices"
+
+	serverlessv1alpha2 "github.com/kyma-project/serverless/components/buildless-serverless/api/v1alpha2"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/activator"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/fsm"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/resources"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	discoveryv1ac "k8s.io/client-go/applyconfigurations/discovery/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// endpointSliceControllerName manages the endpoint slices of the services with a selector
+const endpointSliceControllerName = "endpointslice-controller.k8s.io"
+
+// isRoutedToActivator returns true when the scale-to-zero function has no ready pods,
+// its requests are received by the activator, that wakes the function up and holds them until the function is ready
+func isRoutedToActivator(m *fsm.StateMachine) bool {
+	if !m.State.Function.IsScaleToZeroEnabled() {
+		return false
+	}
+	deployment := m.State.ClusterDeployment
+	return m.State.ScaledToZero || deployment == nil || deployment.Status.ReadyReplicas == 0
+}
+
+// handleActivatorPort assigns the scale-to-zero function its own port of the activator,
+// the activator recognizes the called function by the port the request was sent to
+func handleActivatorPort(ctx context.Context, m *fsm.StateMachine) error {
+	f := &m.State.Function
+	ports := m.FunctionConfig.ScaleToZero.ActivatorPorts
+	if !f.IsScaleToZeroEnabled() {
+		f.Status.ActivatorPort = 0
+		return nil
+	}
+	if ports.Contains(f.Status.ActivatorPort) {
+		conflicting, err := hasPrecedingFunctionOnPort(ctx, m, f.Status.ActivatorPort)
+		if err != nil || !conflicting {
+			return err
+		}
+		// the functions got the same port from the stale cache, the one assigned later moves to another port
+		m.Log.Warnf("activator port %d is used by another function, assigning another port", f.Status.ActivatorPort)
+	}
+
+	port, err := freeActivatorPort(ctx, m)
+	if err != nil {
+		return err
+	}
+	f.Status.ActivatorPort = port
+	return nil
+}
+
+// freeActivatorPort returns the first port not used by other functions,
+// the search starts from the port derived from the function's UID to avoid checking the same ports for each function
+func freeActivatorPort(ctx context.Context, m *fsm.StateMachine) (int32, error) {
+	ports := m.FunctionConfig.ScaleToZero.ActivatorPorts
+	h := fnv.New32a()
+	h.Write([]byte(m.State.Function.GetUID()))
+	offset := int32(h.Sum32() % uint32(max(ports.Size(), 1)))
+	for i := range ports.Size() {
+		port := ports.Min + (offset+i)%ports.Size()
+		functions, err := activator.FunctionsReachedThroughPort(ctx, m.Cache, port)
+		if err != nil {
+			return 0, err
+		}
+		if !slices.ContainsFunc(functions, isOtherFunction(m)) {
+			return port, nil
+		}
+	}
+	return 0, fmt.Errorf("all activator ports from %d to %d are used", ports.Min, ports.Max)
+}
+
+// hasPrecedingFunctionOnPort returns true when the port is used by another function created before the function
+func hasPrecedingFunctionOnPort(ctx context.Context, m *fsm.StateMachine, port int32) (bool, error) {
+	functions, err := activator.FunctionsReachedThroughPort(ctx, m.Cache, port)
+	if err != nil {
+		return false, err
+	}
+	f := &m.State.Function
+	return slices.ContainsFunc(functions, func(other serverlessv1alpha2.Function) bool {
+		if !isOtherFunction(m)(other) {
+			return false
+		}
+		if !other.CreationTimestamp.Equal(&f.CreationTimestamp) {
+			return other.CreationTimestamp.Before(&f.CreationTimestamp)
+		}
+		return other.GetUID() < f.GetUID()
+	}), nil
+}
+
+func isOtherFunction(m *fsm.StateMachine) func(serverlessv1alpha2.Function) bool {
+	return func(f serverlessv1alpha2.Function) bool {
+		return f.GetUID() != m.State.Function.GetUID()
+	}
+}
+
+// routeServiceEndpoints keeps the service's endpoints in line with its routing,
+// the service without a selector is routed to the activator's endpoints
+// and the endpoints of the function's pods are removed, otherwise the requests would be sent to both
+func routeServiceEndpoints(ctx context.Context, m *fsm.StateMachine, clusterService, builtService *corev1.Service) error {
+	wasRoutedToActivator := clusterService != nil && clusterService.GetAnnotations()[serverlessv1alpha2.ServiceActivatorPortAnnotation] != ""
+	if builtService.GetAnnotations()[serverlessv1alpha2.ServiceActivatorPortAnnotation] == "" {
+		if wasRoutedToActivator {
+			return deleteActivatorEndpoints(ctx, m)
+		}
+		return nil
+	}
+
+	if clusterService != nil && !wasRoutedToActivator {
+		// the endpoints of the service with the selector are not removed when the selector is removed
+		err := m.Client.DeleteAllOf(ctx, &discoveryv1.EndpointSlice{},
+			client.InNamespace(clusterService.GetNamespace()),
+			client.MatchingLabels{
+				discoveryv1.LabelServiceName: clusterService.GetName(),
+				discoveryv1.LabelManagedBy:   endpointSliceControllerName,
+			})
+		if err != nil {
+			return errors.Wrap(err, "while deleting endpoints of function's pods")
+		}
+	}
+	return applyActivatorEndpoints(ctx, m)
+}
+
+func applyActivatorEndpoints(ctx context.Context, m *fsm.StateMachine) error {
+	addressType, addresses, err := activatorAddresses(ctx, m)
+	if err != nil {
+		return err
+	}
+	if len(addresses) == 0 {
+		m.Log.Warnf("activator service %s/%s has no ready endpoints",
+			m.FunctionConfig.ScaleToZero.ActivatorNamespace, m.FunctionConfig.ScaleToZero.ActivatorServiceName)
+	}
+
+	endpointSlice := resources.NewActivatorEndpointSlice(&m.State.Function, addressType, addresses)
+	if err := controllerutil.SetControllerReference(&m.State.Function, endpointSlice, m.Scheme); err != nil {
+		return errors.Wrap(err, "while setting controller reference of activator endpoints")
+	}
+
+	endpointSliceApplyConfig := &discoveryv1ac.EndpointSliceApplyConfiguration{}
+	if err := convertObject(endpointSlice, endpointSliceApplyConfig); err != nil {
+		return err
+	}
+	err = m.Client.Apply(ctx, endpointSliceApplyConfig, client.FieldOwner(fieldManager), client.ForceOwnership)
+	return errors.Wrap(err, "while applying activator endpoints")
+}
+
+func deleteActivatorEndpoints(ctx context.Context, m *fsm.StateMachine) error {
+	endpointSlice := &discoveryv1.EndpointSlice{}
+	endpointSlice.SetNamespace(m.State.Function.GetNamespace())
+	endpointSlice.SetName(resources.ActivatorEndpointSliceName(&m.State.Function))
+	err := client.IgnoreNotFound(m.Client.Delete(ctx, endpointSlice))
+	return errors.Wrap(err, "while deleting activator endpoints")
+}
+
+// activatorAddresses returns the addresses of the activator's ready endpoints
+func activatorAddresses(ctx context.Context, m *fsm.StateMachine) (discoveryv1.AddressType, []string, error) {
+	endpointSlices := &discoveryv1.EndpointSliceList{}
+	err := m.Client.List(ctx, endpointSlices,
+		client.InNamespace(m.FunctionConfig.ScaleToZero.ActivatorNamespace),
+		client.MatchingLabels{discoveryv1.LabelServiceName: m.FunctionConfig.ScaleToZero.ActivatorServiceName})
+	if err != nil {
+		return "", nil, errors.Wrap(err, "while listing activator endpoints")
+	}
+
+	addressType := discoveryv1.AddressTypeIPv4
+	if len(endpointSlices.Items) != 0 && endpointSlices.Items[0].AddressType == discoveryv1.AddressTypeIPv6 {
+		addressType = discoveryv1.AddressTypeIPv6
+	}
+
+	addresses := []string{}
+	for _, endpointSlice := range endpointSlices.Items {
+		if endpointSlice.AddressType != addressType {
+			continue
+		}
+		for _, endpoint := range endpointSlice.Endpoints {
+			if endpoint.Conditions.Ready == nil || *endpoint.Conditions.Ready {
+				addresses = append(addresses, endpoint.Addresses...)
+			}
+		}
+	}
+	slices.Sort(addresses)
+	return addressType, slices.Compact(addresses), nil
+}
@@ -12,7 +12,7 @@ import (
 
 func sFnAdjustStatus(_ context.Context, m *fsm.StateMachine) (fsm.StateFn, *ctrl.Result, error) {
 	s := &m.State.Function.Status
-	f := m.State.Function
+	f := *deployedFunction(m)
 	s.Runtime = f.Spec.Runtime
 	s.RuntimeImage = m.State.BuiltDeployment.RuntimeImage()
 	s.Replicas = m.State.ClusterDeployment.Status.Replicas
@@ -36,7 +36,18 @@ func sFnAdjustStatus(_ context.Context, m *fsm.StateMachine) (fsm.StateFn, *ctrl
 				BaseDir:   f.Spec.Source.GitRepository.BaseDir,
 				Reference: f.Spec.Source.GitRepository.Reference,
 			},
-			Commit: m.State.Commit,
+			Commit:          m.State.Commit,
+			ResolvedTag:     m.State.ResolvedTag,
+			AvailableCommit: m.State.AvailableCommit,
+			TreeHash:        m.State.TreeHash,
+		}
+		if latest := m.State.LatestCommit; latest != "" && latest != m.State.Commit {
+			// the newer commits did not change the function's files, so they are not rolled out
+			s.GitRepository.Commit = latest
+			s.GitRepository.DeployedCommit = m.State.Commit
+		}
+		if m.State.GitAuth != nil {
+			s.GitRepository.TrustedHostKeys = m.State.GitAuth.TrustedHostKeys()
 		}
 		s.Repository.BaseDir = f.Spec.Source.GitRepository.BaseDir
 		s.Repository.Reference = f.Spec.Source.GitRepository.Reference
@@ -47,5 +58,14 @@ func sFnAdjustStatus(_ context.Context, m *fsm.StateMachine) (fsm.StateFn, *ctrl
 		s.Commit = ""
 	}
 
-	return requeueAfter(m.FunctionConfig.FunctionReadyRequeueDuration)
+	if sourceType := f.ResolvedSourceType(); sourceType != "" {
+		s.Source = &serverlessv1alpha2.SourceStatus{
+			Type:   sourceType,
+			Digest: m.State.Commit,
+		}
+	} else {
+		s.Source = nil
+	}
+
+	return requeueAfter(readyRequeueDuration(m))
 }
@@ -41,7 +41,7 @@ func Test_sFnAdjustStatus(t *testing.T) {
 		m := fsm.StateMachine{
 			State: fsm.SystemState{
 				Function:        f,
-				BuiltDeployment: resources.NewDeployment(&f, &fc, nil, "test-commit", nil, "", true),
+				BuiltDeployment: resources.NewDeployment(&f, &fc, "test-commit", nil, "", true),
 				ClusterDeployment: &appsv1.Deployment{
 					Status: appsv1.DeploymentStatus{
 						Replicas: int32(686)}}},
@@ -106,7 +106,7 @@ func Test_sFnAdjustStatus(t *testing.T) {
 			State: fsm.SystemState{
 				Function:        f,
 				Commit:          "test-commit",
-				BuiltDeployment: resources.NewDeployment(&f, &fc, nil, "test-commit", nil, "", true),
+				BuiltDeployment: resources.NewDeployment(&f, &fc, "test-commit", nil, "", true),
 				ClusterDeployment: &appsv1.Deployment{
 					Status: appsv1.DeploymentStatus{
 						Replicas: int32(686)}}},
@@ -145,6 +145,77 @@ func Test_sFnAdjustStatus(t *testing.T) {
 		require.Equal(t, m.State.Function.Status.Repository.Reference, "test-reference")
 		require.Equal(t, m.State.Function.Status.Commit, "test-commit")
 	})
+	t.Run("newest commit is recorded next to the deployed commit when function's files did not change", func(t *testing.T) {
+		// Arrange
+		f := serverlessv1alpha2.Function{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "keen-meitner"},
+			Spec: serverlessv1alpha2.FunctionSpec{
+				Runtime: "practical-panini",
+				Source: serverlessv1alpha2.Source{
+					GitRepository: &serverlessv1alpha2.GitRepositorySource{
+						URL: "gracious-robinson",
+						Repository: serverlessv1alpha2.Repository{
+							BaseDir:   "test-base-dir",
+							Reference: "test-reference",
+						},
+					}}}}
+		fc := config.FunctionConfig{}
+		m := fsm.StateMachine{
+			State: fsm.SystemState{
+				Function:          f,
+				Commit:            "test-commit",
+				LatestCommit:      "latest-commit",
+				TreeHash:          "test-tree",
+				BuiltDeployment:   resources.NewDeployment(&f, &fc, "test-commit", nil, "", false),
+				ClusterDeployment: &appsv1.Deployment{}},
+			FunctionConfig: fc,
+		}
+
+		// Act
+		_, _, err := sFnAdjustStatus(context.Background(), &m)
+
+		// Assert
+		require.Nil(t, err)
+		require.Equal(t, "latest-commit", m.State.Function.Status.GitRepository.Commit)
+		require.Equal(t, "test-commit", m.State.Function.Status.GitRepository.DeployedCommit)
+		require.Equal(t, "test-tree", m.State.Function.Status.GitRepository.TreeHash)
+		require.Equal(t, "test-commit", m.State.Function.Status.GitRepository.RunningCommit())
+		// the legacy field keeps the commit the function is built from
+		require.Equal(t, "test-commit", m.State.Function.Status.Commit)
+	})
+	t.Run("resolved digest is set for OCI artifact function", func(t *testing.T) {
+		// Arrange
+		f := serverlessv1alpha2.Function{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "keen-meitner"},
+			Spec: serverlessv1alpha2.FunctionSpec{
+				Runtime: "practical-panini",
+				Source: serverlessv1alpha2.Source{
+					OCIArtifact: &serverlessv1alpha2.OCIArtifactSource{
+						Reference: "registry.example.com/team/function:1.0.0"}}}}
+		fc := config.FunctionConfig{}
+		m := fsm.StateMachine{
+			State: fsm.SystemState{
+				Function:          f,
+				Commit:            "sha256:test-digest",
+				BuiltDeployment:   resources.NewDeployment(&f, &fc, "sha256:test-digest", nil, "", false),
+				ClusterDeployment: &appsv1.Deployment{}},
+			FunctionConfig: fc,
+		}
+
+		// Act
+		_, _, err := sFnAdjustStatus(context.Background(), &m)
+
+		// Assert
+		require.Nil(t, err)
+		require.Equal(t, &serverlessv1alpha2.SourceStatus{
+			Type:   serverlessv1alpha2.SourceTypeOCIArtifact,
+			Digest: "sha256:test-digest",
+		}, m.State.Function.Status.Source)
+		require.Nil(t, m.State.Function.Status.GitRepository)
+		require.Empty(t, m.State.Function.Status.Commit)
+	})
 	t.Run("function resource profile is set to custom when there is resource definition", func(t *testing.T) {
 		// Arrange
 		// machine with our function and previously created/calculated deployment
@@ -181,7 +252,7 @@ func Test_sFnAdjustStatus(t *testing.T) {
 		m := fsm.StateMachine{
 			State: fsm.SystemState{
 				Function:        f,
-				BuiltDeployment: resources.NewDeployment(&f, &fc, nil, "test-commit", nil, "", true),
+				BuiltDeployment: resources.NewDeployment(&f, &fc, "test-commit", nil, "", true),
 				ClusterDeployment: &appsv1.Deployment{
 					Status: appsv1.DeploymentStatus{
 						Replicas: int32(686)}}},
@@ -230,7 +301,7 @@ func Test_sFnAdjustStatus(t *testing.T) {
 		m := fsm.StateMachine{
 			State: fsm.SystemState{
 				Function:        f,
-				BuiltDeployment: resources.NewDeployment(&f, &fc, nil, "test-commit", nil, "", true),
+				BuiltDeployment: resources.NewDeployment(&f, &fc, "test-commit", nil, "", true),
 				ClusterDeployment: &appsv1.Deployment{
 					Status: appsv1.DeploymentStatus{
 						Replicas: int32(686)}}},
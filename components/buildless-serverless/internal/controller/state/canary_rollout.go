@@ -0,0 +1,285 @@
+package state
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	serverlessv1alpha2 "github.com/kyma-project/serverless/components/buildless-serverless/api/v1alpha2"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/fsm"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/resources"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const defaultCanaryProgressDeadline = 10 * time.Minute
+
+// sFnHandleCanaryRollout shifts the traffic from the stable deployment to the canary one step by step.
+// Both deployments are selected by the function's service, so the traffic is split proportionally to their replicas.
+func sFnHandleCanaryRollout(ctx context.Context, m *fsm.StateMachine) (fsm.StateFn, *ctrl.Result, error) {
+	f := &m.State.Function
+	stable := m.State.ClusterDeployment
+	canary := m.State.CanaryDeployment
+
+	// the desired deployment is built from the stable one, the same way as when the rollout was started
+	m.State.BuiltDeployment = resources.NewDeployment(deployedFunction(m), &m.FunctionConfig, m.State.Commit, m.State.GitAuth, "", m.IsKymaFipsModeEnabled, resources.DeployDependencyCache(m.State.DependencyCache), resources.DeployResolvedTag(m.State.ResolvedTag), resources.DeployReferencedContentHash(m.State.ReferencedContentHash))
+	builtDeployment := m.State.BuiltDeployment.Deployment
+
+	// the canary has passed all steps or the canary strategy has been removed, finish the rollout
+	if !f.IsCanaryRolloutEnabled() || int(f.Status.Rollout.Step) >= len(f.Spec.Rollout.Canary.Steps) {
+		return promoteCanary(ctx, m)
+	}
+
+	rollout := f.Status.Rollout
+	steps := f.Spec.Rollout.Canary.Steps
+
+	if templateChanged(canary, builtDeployment) {
+		// the function has changed during the rollout, start it again with the new version
+		canary.Spec.Template = builtDeployment.Spec.Template
+		metav1.SetMetaDataAnnotation(&canary.ObjectMeta, serverlessv1alpha2.DeploymentTemplateHashAnnotation, builtDeployment.GetAnnotations()[serverlessv1alpha2.DeploymentTemplateHashAnnotation])
+		resetCanaryStep(rollout, 0)
+		if _, err := updateDeployment(ctx, m, canary); err != nil {
+			return stopWithError(err)
+		}
+		updateCanaryCondition(m, metav1.ConditionUnknown, serverlessv1alpha2.ConditionReasonCanaryProgressing,
+			fmt.Sprintf("Canary Deployment %s updated, rollout restarted with step 1/%d", canary.GetName(), len(steps)))
+		return requeueAfter(time.Second)
+	}
+
+	step := steps[rollout.Step]
+	stableReplicas, canaryReplicas := splitReplicas(ptr.Deref(builtDeployment.Spec.Replicas, resources.DefaultDeploymentReplicas), step.Weight)
+	requeueNeeded, err := scaleDeploymentIfNeeded(ctx, m, canary, canaryReplicas)
+	if err != nil {
+		return stopWithError(err)
+	}
+	stableRequeueNeeded, err := scaleDeploymentIfNeeded(ctx, m, stable, stableReplicas)
+	if err != nil {
+		return stopWithError(err)
+	}
+	if requeueNeeded || stableRequeueNeeded {
+		return requeueAfter(time.Second)
+	}
+
+	if !isCanaryReady(*canary) {
+		progressDeadline := canaryProgressDeadline(f)
+		if time.Since(rollout.StepStartTime.Time) > progressDeadline {
+			return rollbackCanary(ctx, m, progressDeadline)
+		}
+
+		updateCanaryCondition(m, metav1.ConditionUnknown, serverlessv1alpha2.ConditionReasonCanaryProgressing,
+			fmt.Sprintf("Canary Deployment %s is not ready yet at step %d/%d with %d%% weight", canary.GetName(), rollout.Step+1, len(steps), step.Weight))
+		return requeueAfter(time.Second)
+	}
+
+	if rollout.StepReadyTime == nil {
+		rollout.StepReadyTime = ptr.To(metav1.Now())
+	}
+	pauseLeft := pauseDuration(step) - time.Since(rollout.StepReadyTime.Time)
+	if pauseLeft > 0 {
+		updateCanaryCondition(m, metav1.ConditionUnknown, serverlessv1alpha2.ConditionReasonCanaryPaused,
+			fmt.Sprintf("Canary Deployment %s is ready at step %d/%d with %d%% weight, next step in %s", canary.GetName(), rollout.Step+1, len(steps), step.Weight, pauseLeft.Round(time.Second)))
+		return requeueAfter(pauseLeft)
+	}
+
+	resetCanaryStep(rollout, rollout.Step+1)
+	if int(rollout.Step) >= len(steps) {
+		updateCanaryCondition(m, metav1.ConditionUnknown, serverlessv1alpha2.ConditionReasonCanaryProgressing,
+			fmt.Sprintf("Canary Deployment %s passed all %d steps and is being promoted", canary.GetName(), len(steps)))
+		return requeueAfter(time.Second)
+	}
+	updateCanaryCondition(m, metav1.ConditionUnknown, serverlessv1alpha2.ConditionReasonCanaryProgressing,
+		fmt.Sprintf("Canary Deployment %s moved to step %d/%d with %d%% weight", canary.GetName(), rollout.Step+1, len(steps), steps[rollout.Step].Weight))
+	return requeueAfter(time.Second)
+}
+
+// startCanaryRollout creates the canary deployment next to the stable one
+func startCanaryRollout(ctx context.Context, m *fsm.StateMachine) (fsm.StateFn, *ctrl.Result, error) {
+	f := &m.State.Function
+	stable := m.State.ClusterDeployment
+	steps := f.Spec.Rollout.Canary.Steps
+
+	canary := m.State.BuiltDeployment.Deployment.DeepCopy()
+	_, canaryReplicas := splitReplicas(ptr.Deref(canary.Spec.Replicas, resources.DefaultDeploymentReplicas), steps[0].Weight)
+	canary.Spec.Replicas = ptr.To(canaryReplicas)
+
+	result, err := createDeployment(ctx, m, canary)
+	if err != nil {
+		return nil, result, err
+	}
+
+	f.Status.Rollout = &serverlessv1alpha2.RolloutStatus{
+		StableDeployment: stable.GetName(),
+		CanaryDeployment: canary.GetName(),
+	}
+	resetCanaryStep(f.Status.Rollout, 0)
+	updateCanaryCondition(m, metav1.ConditionUnknown, serverlessv1alpha2.ConditionReasonCanaryProgressing,
+		fmt.Sprintf("Canary Deployment %s created, rollout started with step 1/%d with %d%% weight", canary.GetName(), len(steps), steps[0].Weight))
+	return nil, result, nil
+}
+
+// promoteCanary moves the whole traffic to the canary deployment and deletes the stable one
+func promoteCanary(ctx context.Context, m *fsm.StateMachine) (fsm.StateFn, *ctrl.Result, error) {
+	f := &m.State.Function
+	stable := m.State.ClusterDeployment
+	canary := m.State.CanaryDeployment
+
+	requeueNeeded, err := scaleDeploymentIfNeeded(ctx, m, canary, ptr.Deref(m.State.BuiltDeployment.Spec.Replicas, resources.DefaultDeploymentReplicas))
+	if err != nil {
+		return stopWithError(err)
+	}
+	if requeueNeeded {
+		return requeueAfter(time.Second)
+	}
+	// keep the stable deployment until the canary one is able to serve the whole traffic
+	if !isCanaryReady(*canary) {
+		updateCanaryCondition(m, metav1.ConditionUnknown, serverlessv1alpha2.ConditionReasonCanaryProgressing,
+			fmt.Sprintf("Canary Deployment %s is being scaled up before promotion", canary.GetName()))
+		return requeueAfter(time.Second)
+	}
+
+	if err := deleteDeployment(ctx, m, stable); err != nil {
+		return stopWithError(err)
+	}
+
+	f.Status.Rollout = nil
+	f.CopyAnnotationsToStatus()
+	updateCanaryCondition(m, metav1.ConditionTrue, serverlessv1alpha2.ConditionReasonCanaryPromoted,
+		fmt.Sprintf("Canary Deployment %s promoted, Deployment %s deleted", canary.GetName(), stable.GetName()))
+	return requeueAfter(time.Second)
+}
+
+// rollbackCanary moves the whole traffic back to the stable deployment and deletes the canary one
+func rollbackCanary(ctx context.Context, m *fsm.StateMachine, progressDeadline time.Duration) (fsm.StateFn, *ctrl.Result, error) {
+	f := &m.State.Function
+	stable := m.State.ClusterDeployment
+	canary := m.State.CanaryDeployment
+	m.Log.Info(fmt.Sprintf("canary deployment %s not ready within %s, rolling back", canary.GetName(), progressDeadline))
+
+	_, err := scaleDeploymentIfNeeded(ctx, m, stable, ptr.Deref(m.State.BuiltDeployment.Spec.Replicas, resources.DefaultDeploymentReplicas))
+	if err != nil {
+		return stopWithError(err)
+	}
+	if err := deleteDeployment(ctx, m, canary); err != nil {
+		return stopWithError(err)
+	}
+
+	step := f.Status.Rollout.Step
+	f.Status.Rollout = &serverlessv1alpha2.RolloutStatus{
+		FailedTemplateHash: m.State.BuiltDeployment.TemplateHash(),
+	}
+	updateCanaryCondition(m, metav1.ConditionFalse, serverlessv1alpha2.ConditionReasonCanaryRolledBack,
+		fmt.Sprintf("Canary Deployment %s is not ready within %s at step %d/%d, rolled back to Deployment %s", canary.GetName(), progressDeadline, step+1, len(f.Spec.Rollout.Canary.Steps), stable.GetName()))
+	return requeueAfter(time.Second)
+}
+
+// rolloutDeployments returns the stable and canary deployments of the function's rollout in progress
+func rolloutDeployments(m *fsm.StateMachine, deployments *appsv1.DeploymentList) (stable *appsv1.Deployment, canary *appsv1.Deployment) {
+	f := m.State.Function
+	if !f.IsCanaryRolloutInProgress() || len(deployments.Items) != 2 {
+		return nil, nil
+	}
+	for i := range deployments.Items {
+		switch deployments.Items[i].GetName() {
+		case f.Status.Rollout.StableDeployment:
+			stable = &deployments.Items[i]
+		case f.Status.Rollout.CanaryDeployment:
+			canary = &deployments.Items[i]
+		}
+	}
+	return stable, canary
+}
+
+// isRolledBack returns true when the built deployment has already been rolled back by the canary rollout
+func isRolledBack(m *fsm.StateMachine) bool {
+	rollout := m.State.Function.Status.Rollout
+	return rollout != nil && rollout.FailedTemplateHash == m.State.BuiltDeployment.TemplateHash()
+}
+
+// templateChanged returns true when the deployment in the cluster runs another pod template than the built one.
+// The templates of the deployments created before their template hash was recorded are compared directly,
+// ignoring the fields defaulted by the API server and the annotations added by other components.
+func templateChanged(clusterDeployment *appsv1.Deployment, builtDeployment *appsv1.Deployment) bool {
+	hash, ok := clusterDeployment.GetAnnotations()[serverlessv1alpha2.DeploymentTemplateHashAnnotation]
+	if !ok {
+		return !equality.Semantic.DeepDerivative(builtDeployment.Spec.Template, clusterDeployment.Spec.Template)
+	}
+	return hash != builtDeployment.GetAnnotations()[serverlessv1alpha2.DeploymentTemplateHashAnnotation]
+}
+
+// splitReplicas splits the function's replicas between the stable and canary deployments according to the canary weight.
+// The stable deployment keeps at least one replica until the canary weight reaches 100%,
+// so the split follows the weight only for at least 100/weight replicas, and a single replica is always split 50/50.
+func splitReplicas(replicas int32, weight int32) (stable int32, canary int32) {
+	if replicas == 0 {
+		return 0, 0
+	}
+	canary = max((replicas*weight+99)/100, 1)
+	stable = replicas - canary
+	if weight < 100 {
+		stable = max(stable, 1)
+	}
+	return stable, canary
+}
+
+func isCanaryReady(deployment appsv1.Deployment) bool {
+	return deployment.Status.ObservedGeneration >= deployment.GetGeneration() &&
+		isDeploymentReady(deployment)
+}
+
+func canaryProgressDeadline(f *serverlessv1alpha2.Function) time.Duration {
+	if f.Spec.Rollout.Canary.ProgressDeadline == nil {
+		return defaultCanaryProgressDeadline
+	}
+	return f.Spec.Rollout.Canary.ProgressDeadline.Duration
+}
+
+func pauseDuration(step serverlessv1alpha2.CanaryStep) time.Duration {
+	if step.Pause == nil {
+		return 0
+	}
+	return step.Pause.Duration
+}
+
+func resetCanaryStep(rollout *serverlessv1alpha2.RolloutStatus, step int32) {
+	rollout.Step = step
+	rollout.StepStartTime = ptr.To(metav1.Now())
+	rollout.StepReadyTime = nil
+}
+
+func updateCanaryCondition(m *fsm.StateMachine, status metav1.ConditionStatus, reason serverlessv1alpha2.ConditionReason, msg string) {
+	m.State.Function.UpdateCondition(serverlessv1alpha2.ConditionRolloutComplete, status, reason, msg)
+}
+
+func scaleDeploymentIfNeeded(ctx context.Context, m *fsm.StateMachine, deployment *appsv1.Deployment, replicas int32) (requeueNeeded bool, err error) {
+	if ptr.Equal(deployment.Spec.Replicas, &replicas) {
+		return false, nil
+	}
+
+	deployment.Spec.Replicas = ptr.To(replicas)
+	return updateDeployment(ctx, m, deployment)
+}
+
+func deleteDeployment(ctx context.Context, m *fsm.StateMachine, deployment *appsv1.Deployment) error {
+	m.Log.Info("deleting Deployment", "Deployment.Namespace", deployment.GetNamespace(), "Deployment.Name", deployment.GetName())
+
+	err := m.Client.Delete(ctx, deployment, client.PropagationPolicy(metav1.DeletePropagationBackground))
+	if client.IgnoreNotFound(err) != nil {
+		m.Log.Error(err, "Failed to delete Deployment", "Deployment.Namespace", deployment.GetNamespace(), "Deployment.Name", deployment.GetName())
+		m.State.Function.UpdateCondition(
+			serverlessv1alpha2.ConditionRunning,
+			metav1.ConditionFalse,
+			serverlessv1alpha2.ConditionReasonDeploymentDeletionFailed,
+			fmt.Sprintf("Deployment %s delete failed: %s", deployment.GetName(), err.Error()))
+		return err
+	}
+	m.State.Function.UpdateCondition(
+		serverlessv1alpha2.ConditionRunning,
+		metav1.ConditionUnknown,
+		serverlessv1alpha2.ConditionReasonDeploymentDeleted,
+		fmt.Sprintf("Deployment %s deleted", deployment.GetName()))
+	return nil
+}
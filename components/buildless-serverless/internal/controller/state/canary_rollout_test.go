@@ -0,0 +1,377 @@
+package state
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	serverlessv1alpha2 "github.com/kyma-project/serverless/components/buildless-serverless/api/v1alpha2"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/config"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/fsm"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/resources"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func Test_sFnHandleDeployment_canaryRollout(t *testing.T) {
+	t.Run("when function changes should create canary deployment next to the stable one", func(t *testing.T) {
+		f := fixCanaryFunction("new-source")
+		stable := fixStableDeployment("stable", 4)
+		m := fixCanaryStateMachine(t, f, stable)
+
+		next, result, err := sFnHandleDeployment(context.Background(), m)
+
+		require.NoError(t, err)
+		require.Nil(t, next)
+		require.Equal(t, &ctrl.Result{RequeueAfter: time.Second}, result)
+		deployments := &appsv1.DeploymentList{}
+		require.NoError(t, m.Client.List(context.Background(), deployments))
+		require.Len(t, deployments.Items, 2)
+		rollout := m.State.Function.Status.Rollout
+		require.NotNil(t, rollout)
+		require.Equal(t, "stable", rollout.StableDeployment)
+		require.Regexp(t, "^canary-function-\\w+$", rollout.CanaryDeployment)
+		require.Equal(t, int32(0), rollout.Step)
+		require.NotNil(t, rollout.StepStartTime)
+		canary := &appsv1.Deployment{}
+		require.NoError(t, m.Client.Get(context.Background(), client.ObjectKey{Namespace: f.Namespace, Name: rollout.CanaryDeployment}, canary))
+		require.Equal(t, ptr.To[int32](1), canary.Spec.Replicas)
+		requireContainsCondition(t, m.State.Function.Status,
+			serverlessv1alpha2.ConditionRolloutComplete,
+			metav1.ConditionUnknown,
+			serverlessv1alpha2.ConditionReasonCanaryProgressing,
+			"Canary Deployment "+rollout.CanaryDeployment+" created, rollout started with step 1/2 with 25% weight")
+	})
+	t.Run("when only replicas change should update stable deployment in place", func(t *testing.T) {
+		f := fixCanaryFunction("old-source")
+		f.Spec.Replicas = ptr.To[int32](2)
+		stable := fixStableDeployment("stable", 4)
+		m := fixCanaryStateMachine(t, f, stable)
+
+		next, result, err := sFnHandleDeployment(context.Background(), m)
+
+		require.NoError(t, err)
+		require.Nil(t, next)
+		require.Equal(t, &ctrl.Result{RequeueAfter: time.Second}, result)
+		require.Nil(t, m.State.Function.Status.Rollout)
+		deployments := &appsv1.DeploymentList{}
+		require.NoError(t, m.Client.List(context.Background(), deployments))
+		require.Len(t, deployments.Items, 1)
+		require.Equal(t, ptr.To[int32](2), deployments.Items[0].Spec.Replicas)
+	})
+	t.Run("when function has been rolled back should keep stable deployment", func(t *testing.T) {
+		f := fixCanaryFunction("new-source")
+		stable := fixStableDeployment("stable", 4)
+		m := fixCanaryStateMachine(t, f, stable)
+		failed := resources.NewDeployment(&f, &m.FunctionConfig, "", nil, "", false)
+		m.State.Function.Status.Rollout = &serverlessv1alpha2.RolloutStatus{FailedTemplateHash: failed.TemplateHash()}
+
+		next, result, err := sFnHandleDeployment(context.Background(), m)
+
+		require.NoError(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnHandleService, next)
+		deployments := &appsv1.DeploymentList{}
+		require.NoError(t, m.Client.List(context.Background(), deployments))
+		require.Len(t, deployments.Items, 1)
+		require.Equal(t, "stable", deployments.Items[0].GetName())
+	})
+	t.Run("when deployment has no template hash and function changes should create canary deployment", func(t *testing.T) {
+		f := fixCanaryFunction("new-source")
+		stable := fixStableDeployment("stable", 4)
+		stable.SetAnnotations(nil)
+		m := fixCanaryStateMachine(t, f, stable)
+
+		next, result, err := sFnHandleDeployment(context.Background(), m)
+
+		require.NoError(t, err)
+		require.Nil(t, next)
+		require.Equal(t, &ctrl.Result{RequeueAfter: time.Second}, result)
+		require.NotNil(t, m.State.Function.Status.Rollout)
+		require.Equal(t, "stable", m.State.Function.Status.Rollout.StableDeployment)
+		deployments := &appsv1.DeploymentList{}
+		require.NoError(t, m.Client.List(context.Background(), deployments))
+		require.Len(t, deployments.Items, 2)
+	})
+	t.Run("when deployment has no template hash and function is unchanged should apply the function in place", func(t *testing.T) {
+		f := fixCanaryFunction("old-source")
+		stable := fixStableDeployment("stable", 4)
+		stable.SetAnnotations(nil)
+		// fields defaulted by the API server
+		stable.Spec.Template.Spec.Containers[0].TerminationMessagePath = corev1.TerminationMessagePathDefault
+		stable.Spec.Template.Spec.SchedulerName = corev1.DefaultSchedulerName
+		m := fixCanaryStateMachine(t, f, stable)
+
+		next, result, err := sFnHandleDeployment(context.Background(), m)
+
+		require.NoError(t, err)
+		require.Nil(t, next)
+		require.Equal(t, &ctrl.Result{RequeueAfter: time.Second}, result)
+		require.Nil(t, m.State.Function.Status.Rollout)
+		deployments := &appsv1.DeploymentList{}
+		require.NoError(t, m.Client.List(context.Background(), deployments))
+		require.Len(t, deployments.Items, 1)
+		require.Equal(t, m.State.BuiltDeployment.TemplateHash(),
+			deployments.Items[0].GetAnnotations()[serverlessv1alpha2.DeploymentTemplateHashAnnotation])
+	})
+	t.Run("when rollout is in progress should go to canary rollout state", func(t *testing.T) {
+		f := fixCanaryFunction("new-source")
+		stable := fixStableDeployment("stable", 3)
+		canary := fixStableDeployment("canary", 1)
+		f.Status.Rollout = &serverlessv1alpha2.RolloutStatus{StableDeployment: "stable", CanaryDeployment: "canary"}
+		m := fixCanaryStateMachine(t, f, stable, canary)
+
+		next, result, err := sFnHandleDeployment(context.Background(), m)
+
+		require.NoError(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnHandleCanaryRollout, next)
+		require.Equal(t, "stable", m.State.ClusterDeployment.GetName())
+		require.Equal(t, "canary", m.State.CanaryDeployment.GetName())
+	})
+	t.Run("when deployments are not part of the rollout should delete them", func(t *testing.T) {
+		f := fixCanaryFunction("new-source")
+		f.Status.Rollout = &serverlessv1alpha2.RolloutStatus{StableDeployment: "stable", CanaryDeployment: "canary"}
+		m := fixCanaryStateMachine(t, f, fixStableDeployment("stable", 1), fixStableDeployment("other", 1))
+
+		next, result, err := sFnHandleDeployment(context.Background(), m)
+
+		require.NoError(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnDeleteDeployments, next)
+	})
+}
+
+func Test_sFnHandleCanaryRollout(t *testing.T) {
+	t.Run("should split replicas between stable and canary deployments", func(t *testing.T) {
+		m := fixCanaryRolloutStateMachine(t, 0, time.Now(), false)
+
+		next, result, err := sFnHandleCanaryRollout(context.Background(), m)
+
+		require.NoError(t, err)
+		require.Nil(t, next)
+		require.Equal(t, &ctrl.Result{RequeueAfter: time.Second}, result)
+		require.Equal(t, int32(3), getDeploymentReplicas(t, m, "stable"))
+		require.Equal(t, int32(1), getDeploymentReplicas(t, m, "canary"))
+	})
+	t.Run("should wait for canary deployment", func(t *testing.T) {
+		m := fixCanaryRolloutStateMachine(t, 0, time.Now(), false)
+		scaleFixDeployments(t, m, 3, 1)
+
+		next, result, err := sFnHandleCanaryRollout(context.Background(), m)
+
+		require.NoError(t, err)
+		require.Nil(t, next)
+		require.Equal(t, &ctrl.Result{RequeueAfter: time.Second}, result)
+		requireContainsCondition(t, m.State.Function.Status,
+			serverlessv1alpha2.ConditionRolloutComplete,
+			metav1.ConditionUnknown,
+			serverlessv1alpha2.ConditionReasonCanaryProgressing,
+			"Canary Deployment canary is not ready yet at step 1/2 with 25% weight")
+	})
+	t.Run("should pause when canary deployment is ready", func(t *testing.T) {
+		m := fixCanaryRolloutStateMachine(t, 0, time.Now(), true)
+		scaleFixDeployments(t, m, 3, 1)
+
+		next, result, err := sFnHandleCanaryRollout(context.Background(), m)
+
+		require.NoError(t, err)
+		require.Nil(t, next)
+		require.NotNil(t, result)
+		require.InDelta(t, time.Minute, result.RequeueAfter, float64(time.Second))
+		require.NotNil(t, m.State.Function.Status.Rollout.StepReadyTime)
+		requireContainsCondition(t, m.State.Function.Status,
+			serverlessv1alpha2.ConditionRolloutComplete,
+			metav1.ConditionUnknown,
+			serverlessv1alpha2.ConditionReasonCanaryPaused,
+			"Canary Deployment canary is ready at step 1/2 with 25% weight, next step in 1m0s")
+	})
+	t.Run("should move to the next step after pause", func(t *testing.T) {
+		m := fixCanaryRolloutStateMachine(t, 0, time.Now().Add(-2*time.Minute), true)
+		scaleFixDeployments(t, m, 3, 1)
+		m.State.Function.Status.Rollout.StepReadyTime = ptr.To(metav1.NewTime(time.Now().Add(-time.Minute)))
+
+		next, result, err := sFnHandleCanaryRollout(context.Background(), m)
+
+		require.NoError(t, err)
+		require.Nil(t, next)
+		require.Equal(t, &ctrl.Result{RequeueAfter: time.Second}, result)
+		require.Equal(t, int32(1), m.State.Function.Status.Rollout.Step)
+		require.Nil(t, m.State.Function.Status.Rollout.StepReadyTime)
+		requireContainsCondition(t, m.State.Function.Status,
+			serverlessv1alpha2.ConditionRolloutComplete,
+			metav1.ConditionUnknown,
+			serverlessv1alpha2.ConditionReasonCanaryProgressing,
+			"Canary Deployment canary moved to step 2/2 with 100% weight")
+	})
+	t.Run("should promote canary deployment after the last step", func(t *testing.T) {
+		m := fixCanaryRolloutStateMachine(t, 2, time.Now(), true)
+		scaleFixDeployments(t, m, 0, 4)
+
+		next, result, err := sFnHandleCanaryRollout(context.Background(), m)
+
+		require.NoError(t, err)
+		require.Nil(t, next)
+		require.Equal(t, &ctrl.Result{RequeueAfter: time.Second}, result)
+		require.Nil(t, m.State.Function.Status.Rollout)
+		deployments := &appsv1.DeploymentList{}
+		require.NoError(t, m.Client.List(context.Background(), deployments))
+		require.Len(t, deployments.Items, 1)
+		require.Equal(t, "canary", deployments.Items[0].GetName())
+		requireContainsCondition(t, m.State.Function.Status,
+			serverlessv1alpha2.ConditionRolloutComplete,
+			metav1.ConditionTrue,
+			serverlessv1alpha2.ConditionReasonCanaryPromoted,
+			"Canary Deployment canary promoted, Deployment stable deleted")
+	})
+	t.Run("should roll back when canary deployment is not ready within progress deadline", func(t *testing.T) {
+		m := fixCanaryRolloutStateMachine(t, 0, time.Now().Add(-time.Hour), false)
+		scaleFixDeployments(t, m, 3, 1)
+
+		next, result, err := sFnHandleCanaryRollout(context.Background(), m)
+
+		require.NoError(t, err)
+		require.Nil(t, next)
+		require.Equal(t, &ctrl.Result{RequeueAfter: time.Second}, result)
+		deployments := &appsv1.DeploymentList{}
+		require.NoError(t, m.Client.List(context.Background(), deployments))
+		require.Len(t, deployments.Items, 1)
+		require.Equal(t, "stable", deployments.Items[0].GetName())
+		require.Equal(t, ptr.To[int32](4), deployments.Items[0].Spec.Replicas)
+		require.Equal(t, &serverlessv1alpha2.RolloutStatus{
+			FailedTemplateHash: m.State.BuiltDeployment.TemplateHash(),
+		}, m.State.Function.Status.Rollout)
+		requireContainsCondition(t, m.State.Function.Status,
+			serverlessv1alpha2.ConditionRolloutComplete,
+			metav1.ConditionFalse,
+			serverlessv1alpha2.ConditionReasonCanaryRolledBack,
+			"Canary Deployment canary is not ready within 10m0s at step 1/2, rolled back to Deployment stable")
+	})
+}
+
+func Test_splitReplicas(t *testing.T) {
+	tests := []struct {
+		name       string
+		replicas   int32
+		weight     int32
+		wantStable int32
+		wantCanary int32
+	}{
+		{name: "no replicas", replicas: 0, weight: 50, wantStable: 0, wantCanary: 0},
+		{name: "proportional split", replicas: 4, weight: 25, wantStable: 3, wantCanary: 1},
+		{name: "canary rounded up", replicas: 4, weight: 30, wantStable: 2, wantCanary: 2},
+		{name: "stable keeps one replica", replicas: 1, weight: 10, wantStable: 1, wantCanary: 1},
+		{name: "single replica split in half", replicas: 1, weight: 90, wantStable: 1, wantCanary: 1},
+		{name: "full weight", replicas: 3, weight: 100, wantStable: 0, wantCanary: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stable, canary := splitReplicas(tt.replicas, tt.weight)
+
+			require.Equal(t, tt.wantStable, stable)
+			require.Equal(t, tt.wantCanary, canary)
+		})
+	}
+}
+
+func fixCanaryFunction(source string) serverlessv1alpha2.Function {
+	return serverlessv1alpha2.Function{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "canary-function",
+			Namespace: "canary-namespace",
+			UID:       "canary-uid",
+		},
+		Spec: serverlessv1alpha2.FunctionSpec{
+			Runtime: serverlessv1alpha2.NodeJs24,
+			Source: serverlessv1alpha2.Source{
+				Inline: &serverlessv1alpha2.InlineSource{
+					Source: source,
+				},
+			},
+			Replicas: ptr.To[int32](4),
+			Rollout: &serverlessv1alpha2.Rollout{
+				Canary: &serverlessv1alpha2.CanaryStrategy{
+					Steps: []serverlessv1alpha2.CanaryStep{
+						{Weight: 25},
+						{Weight: 100},
+					},
+				},
+			},
+		},
+	}
+}
+
+// fixStableDeployment returns deployment built from the previous version of the function
+func fixStableDeployment(name string, replicas int32) *appsv1.Deployment {
+	f := fixCanaryFunction("old-source")
+	f.Spec.Replicas = ptr.To(replicas)
+	return resources.NewDeployment(&f, &config.FunctionConfig{}, "", nil, "", false,
+		resources.DeploySetName(name)).Deployment
+}
+
+func fixCanaryStateMachine(t *testing.T, f serverlessv1alpha2.Function, deployments ...*appsv1.Deployment) *fsm.StateMachine {
+	scheme := runtime.NewScheme()
+	require.NoError(t, serverlessv1alpha2.AddToScheme(scheme))
+	require.NoError(t, appsv1.AddToScheme(scheme))
+	builder := fake.NewClientBuilder().WithScheme(scheme)
+	for _, d := range deployments {
+		builder = builder.WithObjects(d)
+	}
+	return &fsm.StateMachine{
+		State:  fsm.SystemState{Function: f},
+		Log:    zap.NewNop().Sugar(),
+		Client: builder.Build(),
+		Scheme: scheme,
+	}
+}
+
+// fixCanaryRolloutStateMachine returns state machine with the rollout in progress at the given step
+func fixCanaryRolloutStateMachine(t *testing.T, step int32, stepStartTime time.Time, canaryReady bool) *fsm.StateMachine {
+	f := fixCanaryFunction("new-source")
+	f.Spec.Rollout.Canary.Steps[0].Pause = &metav1.Duration{Duration: time.Minute}
+	f.Status.Rollout = &serverlessv1alpha2.RolloutStatus{
+		StableDeployment: "stable",
+		CanaryDeployment: "canary",
+		Step:             step,
+		StepStartTime:    ptr.To(metav1.NewTime(stepStartTime)),
+	}
+	stable := fixStableDeployment("stable", 4)
+	canary := resources.NewDeployment(&f, &config.FunctionConfig{}, "", nil, "", false,
+		resources.DeploySetName("canary")).Deployment
+	if canaryReady {
+		canary.Status.Conditions = []appsv1.DeploymentCondition{
+			{Type: appsv1.DeploymentAvailable, Status: corev1.ConditionTrue, Reason: MinimumReplicasAvailable},
+			{Type: appsv1.DeploymentProgressing, Status: corev1.ConditionTrue, Reason: NewRSAvailableReason},
+		}
+	}
+	m := fixCanaryStateMachine(t, f, stable, canary)
+	m.State.ClusterDeployment = getDeployment(t, m, "stable")
+	m.State.CanaryDeployment = getDeployment(t, m, "canary")
+	return m
+}
+
+// scaleFixDeployments sets replicas the stable and canary deployments are expected to have
+func scaleFixDeployments(t *testing.T, m *fsm.StateMachine, stableReplicas int32, canaryReplicas int32) {
+	m.State.ClusterDeployment.Spec.Replicas = ptr.To(stableReplicas)
+	require.NoError(t, m.Client.Update(context.Background(), m.State.ClusterDeployment))
+	m.State.CanaryDeployment.Spec.Replicas = ptr.To(canaryReplicas)
+	require.NoError(t, m.Client.Update(context.Background(), m.State.CanaryDeployment))
+}
+
+func getDeployment(t *testing.T, m *fsm.StateMachine, name string) *appsv1.Deployment {
+	d := &appsv1.Deployment{}
+	require.NoError(t, m.Client.Get(context.Background(), client.ObjectKey{Namespace: "canary-namespace", Name: name}, d))
+	return d
+}
+
+func getDeploymentReplicas(t *testing.T, m *fsm.StateMachine, name string) int32 {
+	return ptr.Deref(getDeployment(t, m, name).Spec.Replicas, 0)
+}
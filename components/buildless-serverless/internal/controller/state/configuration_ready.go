@@ -13,17 +13,34 @@ import (
 )
 
 const (
-	configurationReadyMessage      = "Function configured"
-	warningRuntimeDeprecatedFormat = "Warning: function configured, runtime %s is deprecated and will be removed in the future"
+	configurationReadyMessage       = "Function configured"
+	warningRuntimeDeprecatedFormat  = "Warning: function configured, runtime %s is deprecated and will be removed in the future"
+	revisionConfiguredMessageFormat = "Function configured with revision %s"
+	// the revision is kept in the message when its runtime is deprecated
+	warningRevisionRuntimeDeprecatedFormat = "Warning: function configured with revision %s, runtime %s is deprecated and will be removed in the future"
+	sourceUpdatePendingFormat              = "Function configured, commit %s is waiting for approval, annotate the Function with %s=%s to roll it out"
 )
 
 func sFnConfigurationReady(_ context.Context, m *fsm.StateMachine) (fsm.StateFn, *ctrl.Result, error) {
 	msg := configurationReadyMessage
 	reason := serverlessv1alpha2.ConditionReasonFunctionSpecValidated
 
-	if m.State.Function.Spec.Runtime.IsRuntimeDeprecated() {
+	if m.State.Revision != nil {
+		msg = fmt.Sprintf(revisionConfiguredMessageFormat, m.State.Revision.GetName())
+	}
+
+	runtime := deployedFunction(m).Spec.Runtime
+	if runtimeConfig, _ := m.FunctionConfig.RuntimeConfig(string(runtime)); runtimeConfig.Deprecated {
 		// warn users when runtime is deprecated
-		msg = fmt.Sprintf(warningRuntimeDeprecatedFormat, m.State.Function.Spec.Runtime)
+		msg = fmt.Sprintf(warningRuntimeDeprecatedFormat, runtime)
+		if m.State.Revision != nil {
+			msg = fmt.Sprintf(warningRevisionRuntimeDeprecatedFormat, m.State.Revision.GetName(), runtime)
+		}
+	}
+
+	if commit := m.State.AvailableCommit; commit != "" {
+		msg = fmt.Sprintf(sourceUpdatePendingFormat, commit, serverlessv1alpha2.FunctionApprovedCommitAnnotation, commit)
+		reason = serverlessv1alpha2.ConditionReasonSourceUpdatePending
 	}
 
 	m.State.Function.UpdateCondition(
@@ -33,5 +50,5 @@ func sFnConfigurationReady(_ context.Context, m *fsm.StateMachine) (fsm.StateFn,
 		msg)
 	metrics.PublishStateReachTime(m.State.Function, serverlessv1alpha2.ConditionConfigurationReady)
 
-	return nextState(sFnHandleDeployment)
+	return nextState(sFnReferencedContent)
 }
@@ -6,6 +6,7 @@ import (
 
 	serverlessv1alpha2 "github.com/kyma-project/serverless/components/buildless-serverless/api/v1alpha2"
 	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/fsm"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/resources"
 	"github.com/stretchr/testify/require"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -26,7 +27,7 @@ func Test_sFnConfigurationReady(t *testing.T) {
 		require.Nil(t, result)
 		// with expected next state
 		require.NotNil(t, next)
-		requireEqualFunc(t, sFnHandleDeployment, next)
+		requireEqualFunc(t, sFnReferencedContent, next)
 		// function has proper condition
 		requireContainsCondition(t, m.State.Function.Status,
 			serverlessv1alpha2.ConditionConfigurationReady,
@@ -56,7 +57,7 @@ func Test_sFnConfigurationReady(t *testing.T) {
 		require.Nil(t, result)
 		// with expected next state
 		require.NotNil(t, next)
-		requireEqualFunc(t, sFnHandleDeployment, next)
+		requireEqualFunc(t, sFnReferencedContent, next)
 		// function has proper condition
 		requireContainsCondition(t, m.State.Function.Status,
 			serverlessv1alpha2.ConditionConfigurationReady,
@@ -64,4 +65,51 @@ func Test_sFnConfigurationReady(t *testing.T) {
 			serverlessv1alpha2.ConditionReasonFunctionSpecValidated,
 			"Warning: function configured, runtime nodejs20 is deprecated and will be removed in the future")
 	})
+
+	t.Run("should keep revision in warning on deprecated runtime of revision", func(t *testing.T) {
+		// Arrange
+		// machine with function rolled back to revision using deprecated runtime
+		f := serverlessv1alpha2.Function{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-function"},
+			Spec: serverlessv1alpha2.FunctionSpec{
+				Runtime: serverlessv1alpha2.NodeJs20,
+			},
+		}
+		m := fsm.StateMachine{State: fsm.SystemState{
+			Function: f,
+			Revision: resources.NewRevision(&f, 2, "", "test-hash"),
+		}}
+		m.State.Function.Spec.Runtime = serverlessv1alpha2.NodeJs24
+
+		// Act
+		next, result, err := sFnConfigurationReady(context.Background(), &m)
+
+		// Assert
+		require.Nil(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnReferencedContent, next)
+		requireContainsCondition(t, m.State.Function.Status,
+			serverlessv1alpha2.ConditionConfigurationReady,
+			metav1.ConditionTrue,
+			serverlessv1alpha2.ConditionReasonFunctionSpecValidated,
+			"Warning: function configured with revision test-function-rev-2, runtime nodejs20 is deprecated and will be removed in the future")
+	})
+
+	t.Run("should set pending condition when new commit waits for approval", func(t *testing.T) {
+		// Arrange
+		m := fsm.StateMachine{State: fsm.SystemState{AvailableCommit: "new-commit"}}
+
+		// Act
+		next, result, err := sFnConfigurationReady(context.Background(), &m)
+
+		// Assert
+		require.Nil(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnReferencedContent, next)
+		requireContainsCondition(t, m.State.Function.Status,
+			serverlessv1alpha2.ConditionConfigurationReady,
+			metav1.ConditionTrue,
+			serverlessv1alpha2.ConditionReasonSourceUpdatePending,
+			"Function configured, commit new-commit is waiting for approval, annotate the Function with serverless.kyma-project.io/approved-commit=new-commit to roll it out")
+	})
 }
@@ -0,0 +1,205 @@
+package state
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	serverlessv1alpha2 "github.com/kyma-project/serverless/components/buildless-serverless/api/v1alpha2"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/fsm"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/resources"
+	"github.com/pkg/errors"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	dependencyCachePopulatingRequeueTime = 5 * time.Second
+	dependencyCacheMissMessageFormat     = "Job %s failed to install dependencies, the Function's Pods install them on start"
+	dependencyCacheTimeoutMessageFormat  = "Job %s didn't install dependencies in %s, the Function's Pods install them on start"
+	dependencyCacheUnboundMessageFormat  = "Volume claim %s is not bound, the Function's Pods install the dependencies on start"
+)
+
+// sFnHandleDependencyCache installs the function's dependencies once to the volume reused by the function's pods
+func sFnHandleDependencyCache(ctx context.Context, m *fsm.StateMachine) (fsm.StateFn, *ctrl.Result, error) {
+	m.State.DependencyCache = ""
+	if !m.FunctionConfig.DependencyCache.Enabled {
+		if m.State.Function.Status.DependencyCache != nil {
+			// the cache was disabled since the function used it
+			if err := deleteStaleDependencyCaches(ctx, m, ""); err != nil {
+				return stopWithError(errors.Wrap(err, "while deleting dependency caches"))
+			}
+		}
+		m.State.Function.Status.DependencyCache = nil
+		return nextState(sFnHandleDeployment)
+	}
+
+	f := deployedFunction(m)
+	if !resources.HasDependencies(f) {
+		m.State.Function.Status.DependencyCache = nil
+		if err := deleteStaleDependencyCaches(ctx, m, ""); err != nil {
+			return stopWithError(errors.Wrap(err, "while deleting dependency caches"))
+		}
+		return nextState(sFnHandleDeployment)
+	}
+
+	hash, err := resources.DependencyCacheHash(f, &m.FunctionConfig, m.State.Commit)
+	if err != nil {
+		return stopWithError(errors.Wrap(err, "while computing dependency cache hash"))
+	}
+	builtJob, err := resources.NewDependencyCacheJob(f, &m.FunctionConfig, hash, m.State.Commit, m.State.GitAuth, m.IsKymaFipsModeEnabled, resources.DeployResolvedTag(m.State.ResolvedTag))
+	if err != nil {
+		return stopWithError(errors.Wrap(err, "while building dependency cache job"))
+	}
+	claim, err := getOrCreateDependencyCacheVolumeClaim(ctx, m, resources.NewDependencyCacheVolumeClaim(f, &m.FunctionConfig, hash))
+	if err != nil {
+		return stopWithError(errors.Wrap(err, "while handling dependency cache volume claim"))
+	}
+	job, err := getOrCreateDependencyCacheJob(ctx, m, builtJob)
+	if err != nil {
+		return stopWithError(errors.Wrap(err, "while handling dependency cache job"))
+	}
+
+	status := &serverlessv1alpha2.DependencyCacheStatus{
+		Hash:            hash,
+		VolumeClaimName: claim.GetName(),
+	}
+	m.State.Function.Status.DependencyCache = status
+	switch {
+	case isJobFinished(job, batchv1.JobComplete):
+		status.State = serverlessv1alpha2.DependencyCacheHit
+		m.State.DependencyCache = claim.GetName()
+	case isJobFinished(job, batchv1.JobFailed):
+		status.State = serverlessv1alpha2.DependencyCacheMiss
+		status.Message = fmt.Sprintf(dependencyCacheMissMessageFormat, job.GetName())
+	case claim.Status.Phase == corev1.ClaimLost:
+		status.State = serverlessv1alpha2.DependencyCacheMiss
+		status.Message = fmt.Sprintf(dependencyCacheUnboundMessageFormat, claim.GetName())
+	case isJobTimedOut(job, m.FunctionConfig.DependencyCache.JobTimeout):
+		// the job controller may not have failed the job yet, or the job's pod waits for the volume which can't be provisioned
+		status.State = serverlessv1alpha2.DependencyCacheMiss
+		status.Message = fmt.Sprintf(dependencyCacheTimeoutMessageFormat, job.GetName(), m.FunctionConfig.DependencyCache.JobTimeout)
+		if claim.Status.Phase != corev1.ClaimBound {
+			status.Message = fmt.Sprintf(dependencyCacheUnboundMessageFormat, claim.GetName())
+		}
+	default:
+		// the function is deployed once its dependencies are installed
+		status.State = serverlessv1alpha2.DependencyCachePopulating
+		return requeueAfter(dependencyCachePopulatingRequeueTime)
+	}
+
+	if err := deleteStaleDependencyCaches(ctx, m, hash); err != nil {
+		return stopWithError(errors.Wrap(err, "while deleting stale dependency caches"))
+	}
+	return nextState(sFnHandleDeployment)
+}
+
+func getOrCreateDependencyCacheVolumeClaim(ctx context.Context, m *fsm.StateMachine, claim *corev1.PersistentVolumeClaim) (*corev1.PersistentVolumeClaim, error) {
+	clusterClaim := &corev1.PersistentVolumeClaim{}
+	err := m.Client.Get(ctx, client.ObjectKeyFromObject(claim), clusterClaim)
+	if err == nil {
+		return clusterClaim, nil
+	}
+	if !k8serrors.IsNotFound(err) {
+		return nil, err
+	}
+
+	m.Log.Info("creating a new dependency cache volume claim", "PersistentVolumeClaim.Namespace", claim.GetNamespace(), "PersistentVolumeClaim.Name", claim.GetName())
+	if err := createOwnedObject(ctx, m, claim); err != nil {
+		m.Log.Error(err, "failed to create new dependency cache volume claim", "PersistentVolumeClaim.Namespace", claim.GetNamespace(), "PersistentVolumeClaim.Name", claim.GetName())
+		return nil, err
+	}
+	return claim, nil
+}
+
+func getOrCreateDependencyCacheJob(ctx context.Context, m *fsm.StateMachine, job *batchv1.Job) (*batchv1.Job, error) {
+	clusterJob := &batchv1.Job{}
+	err := m.Client.Get(ctx, client.ObjectKeyFromObject(job), clusterJob)
+	if err == nil {
+		return clusterJob, nil
+	}
+	if !k8serrors.IsNotFound(err) {
+		return nil, err
+	}
+
+	m.Log.Info("creating a new dependency cache job", "Job.Namespace", job.GetNamespace(), "Job.Name", job.GetName())
+	if err := createOwnedObject(ctx, m, job); err != nil {
+		m.Log.Error(err, "failed to create new dependency cache job", "Job.Namespace", job.GetNamespace(), "Job.Name", job.GetName())
+		return nil, err
+	}
+	return job, nil
+}
+
+func createOwnedObject(ctx context.Context, m *fsm.StateMachine, obj client.Object) error {
+	// Set the ownerRef for the object, ensuring that it
+	// will be deleted when the Function CR is deleted.
+	if err := controllerutil.SetControllerReference(&m.State.Function, obj, m.Scheme); err != nil {
+		return err
+	}
+	return m.Client.Create(ctx, obj)
+}
+
+// deleteStaleDependencyCaches deletes the function's dependency caches other than the one with the given hash
+func deleteStaleDependencyCaches(ctx context.Context, m *fsm.StateMachine, hash string) error {
+	if m.State.Function.IsCanaryRolloutInProgress() {
+		// the previous version of the function may still need its dependencies
+		return nil
+	}
+
+	f := m.State.Function
+	listOpts := []client.ListOption{
+		client.InNamespace(f.GetNamespace()),
+		client.MatchingLabels(resources.DependencyCacheLabels(&f)),
+	}
+	jobs := &batchv1.JobList{}
+	if err := m.Client.List(ctx, jobs, listOpts...); err != nil {
+		return err
+	}
+	claims := &corev1.PersistentVolumeClaimList{}
+	if err := m.Client.List(ctx, claims, listOpts...); err != nil {
+		return err
+	}
+
+	var stale []client.Object
+	for i := range jobs.Items {
+		stale = append(stale, &jobs.Items[i])
+	}
+	for i := range claims.Items {
+		stale = append(stale, &claims.Items[i])
+	}
+	for _, obj := range stale {
+		if obj.GetLabels()[serverlessv1alpha2.FunctionDependencyHashLabel] == hash {
+			continue
+		}
+		m.Log.Info("deleting stale dependency cache", "Namespace", obj.GetNamespace(), "Name", obj.GetName())
+		// the volume claim is removed once no pod uses it
+		err := m.Client.Delete(ctx, obj, client.PropagationPolicy(metav1.DeletePropagationBackground))
+		if client.IgnoreNotFound(err) != nil {
+			m.Log.Error(err, "failed to delete stale dependency cache", "Namespace", obj.GetNamespace(), "Name", obj.GetName())
+			return err
+		}
+	}
+	return nil
+}
+
+// isJobTimedOut returns true when the job runs longer than the timeout, the job created by this reconciliation has no creation time yet
+func isJobTimedOut(job *batchv1.Job, timeout time.Duration) bool {
+	if timeout <= 0 || job.CreationTimestamp.IsZero() {
+		return false
+	}
+	return time.Since(job.CreationTimestamp.Time) > timeout
+}
+
+func isJobFinished(job *batchv1.Job, conditionType batchv1.JobConditionType) bool {
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == conditionType && condition.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
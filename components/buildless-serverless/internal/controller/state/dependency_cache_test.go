@@ -0,0 +1,265 @@
+package state
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	serverlessv1alpha2 "github.com/kyma-project/serverless/components/buildless-serverless/api/v1alpha2"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/config"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/fsm"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/resources"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func Test_sFnHandleDependencyCache(t *testing.T) {
+	t.Run("when cache is disabled should delete caches and go to the next state", func(t *testing.T) {
+		f := fixDependencyCacheFunction("test-dependencies")
+		m := fixDependencyCacheStateMachine(t, f)
+		staleJob := fixDependencyCacheJob(t, m, &f, "old-hash")
+		staleClaim := resources.NewDependencyCacheVolumeClaim(&f, &m.FunctionConfig, "old-hash")
+		for _, obj := range []client.Object{staleJob, staleClaim} {
+			require.NoError(t, m.Client.Create(context.Background(), obj))
+		}
+		m.FunctionConfig.DependencyCache.Enabled = false
+		m.State.Function.Status.DependencyCache = &serverlessv1alpha2.DependencyCacheStatus{Hash: "old-hash"}
+
+		next, result, err := sFnHandleDependencyCache(context.Background(), m)
+
+		require.NoError(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnHandleDeployment, next)
+		require.Empty(t, m.State.DependencyCache)
+		require.Nil(t, m.State.Function.Status.DependencyCache)
+		jobs := &batchv1.JobList{}
+		require.NoError(t, m.Client.List(context.Background(), jobs))
+		require.Empty(t, jobs.Items)
+		claims := &corev1.PersistentVolumeClaimList{}
+		require.NoError(t, m.Client.List(context.Background(), claims))
+		require.Empty(t, claims.Items)
+	})
+	t.Run("when runtime is unknown should stop processing", func(t *testing.T) {
+		f := fixDependencyCacheFunction("test-dependencies")
+		f.Spec.Runtime = "unknown-runtime"
+		m := fixDependencyCacheStateMachine(t, f)
+
+		next, result, err := sFnHandleDependencyCache(context.Background(), m)
+
+		require.ErrorContains(t, err, "cannot find runtime: unknown-runtime")
+		require.Nil(t, result)
+		require.Nil(t, next)
+		claims := &corev1.PersistentVolumeClaimList{}
+		require.NoError(t, m.Client.List(context.Background(), claims))
+		require.Empty(t, claims.Items)
+	})
+	t.Run("should populate cache and wait for it", func(t *testing.T) {
+		m := fixDependencyCacheStateMachine(t, fixDependencyCacheFunction("test-dependencies"))
+
+		next, result, err := sFnHandleDependencyCache(context.Background(), m)
+
+		require.NoError(t, err)
+		require.Equal(t, dependencyCachePopulatingRequeueTime, result.RequeueAfter)
+		require.Nil(t, next)
+		require.Empty(t, m.State.DependencyCache)
+		status := m.State.Function.Status.DependencyCache
+		require.NotNil(t, status)
+		require.Equal(t, serverlessv1alpha2.DependencyCachePopulating, status.State)
+
+		name := resources.DependencyCacheName(&m.State.Function, status.Hash)
+		require.Equal(t, name, status.VolumeClaimName)
+		job := &batchv1.Job{}
+		require.NoError(t, m.Client.Get(context.Background(), client.ObjectKey{Namespace: "cache-namespace", Name: name}, job))
+		require.Equal(t, "Function", job.OwnerReferences[0].Kind)
+		claim := &corev1.PersistentVolumeClaim{}
+		require.NoError(t, m.Client.Get(context.Background(), client.ObjectKey{Namespace: "cache-namespace", Name: name}, claim))
+		require.Equal(t, "Function", claim.OwnerReferences[0].Kind)
+	})
+	t.Run("should reuse populated cache and delete stale ones", func(t *testing.T) {
+		f := fixDependencyCacheFunction("test-dependencies")
+		m := fixDependencyCacheStateMachine(t, f)
+		hash := fixDependencyCacheHash(t, m, &f)
+		job := fixDependencyCacheJob(t, m, &f, hash)
+		job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}}
+		staleJob := fixDependencyCacheJob(t, m, &f, "stale-hash")
+		staleClaim := resources.NewDependencyCacheVolumeClaim(&f, &m.FunctionConfig, "stale-hash")
+		for _, obj := range []client.Object{job, staleJob, staleClaim} {
+			require.NoError(t, m.Client.Create(context.Background(), obj))
+		}
+
+		next, result, err := sFnHandleDependencyCache(context.Background(), m)
+
+		require.NoError(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnHandleDeployment, next)
+		name := resources.DependencyCacheName(&f, hash)
+		require.Equal(t, name, m.State.DependencyCache)
+		require.Equal(t, &serverlessv1alpha2.DependencyCacheStatus{
+			Hash:            hash,
+			State:           serverlessv1alpha2.DependencyCacheHit,
+			VolumeClaimName: name,
+		}, m.State.Function.Status.DependencyCache)
+
+		jobs := &batchv1.JobList{}
+		require.NoError(t, m.Client.List(context.Background(), jobs))
+		require.Len(t, jobs.Items, 1)
+		require.Equal(t, name, jobs.Items[0].GetName())
+		claims := &corev1.PersistentVolumeClaimList{}
+		require.NoError(t, m.Client.List(context.Background(), claims))
+		require.Len(t, claims.Items, 1)
+		require.Equal(t, name, claims.Items[0].GetName())
+	})
+	t.Run("when job failed should fall back to installing dependencies on start", func(t *testing.T) {
+		f := fixDependencyCacheFunction("test-dependencies")
+		m := fixDependencyCacheStateMachine(t, f)
+		hash := fixDependencyCacheHash(t, m, &f)
+		job := fixDependencyCacheJob(t, m, &f, hash)
+		job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue}}
+		require.NoError(t, m.Client.Create(context.Background(), job))
+
+		next, result, err := sFnHandleDependencyCache(context.Background(), m)
+
+		require.NoError(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnHandleDeployment, next)
+		require.Empty(t, m.State.DependencyCache)
+		status := m.State.Function.Status.DependencyCache
+		require.Equal(t, serverlessv1alpha2.DependencyCacheMiss, status.State)
+		require.Equal(t, "Job "+job.GetName()+" failed to install dependencies, the Function's Pods install them on start", status.Message)
+	})
+	t.Run("when job runs longer than timeout should fall back to installing dependencies on start", func(t *testing.T) {
+		f := fixDependencyCacheFunction("test-dependencies")
+		m := fixDependencyCacheStateMachine(t, f)
+		hash := fixDependencyCacheHash(t, m, &f)
+		job := fixDependencyCacheJob(t, m, &f, hash)
+		job.CreationTimestamp = metav1.NewTime(time.Now().Add(-time.Hour))
+		claim := resources.NewDependencyCacheVolumeClaim(&f, &m.FunctionConfig, hash)
+		claim.Status.Phase = corev1.ClaimBound
+		for _, obj := range []client.Object{job, claim} {
+			require.NoError(t, m.Client.Create(context.Background(), obj))
+		}
+
+		next, result, err := sFnHandleDependencyCache(context.Background(), m)
+
+		require.NoError(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnHandleDeployment, next)
+		require.Empty(t, m.State.DependencyCache)
+		status := m.State.Function.Status.DependencyCache
+		require.Equal(t, serverlessv1alpha2.DependencyCacheMiss, status.State)
+		require.Equal(t, "Job "+job.GetName()+" didn't install dependencies in 10m0s, the Function's Pods install them on start", status.Message)
+	})
+	t.Run("when volume claim is not bound until timeout should fall back to installing dependencies on start", func(t *testing.T) {
+		f := fixDependencyCacheFunction("test-dependencies")
+		m := fixDependencyCacheStateMachine(t, f)
+		hash := fixDependencyCacheHash(t, m, &f)
+		job := fixDependencyCacheJob(t, m, &f, hash)
+		job.CreationTimestamp = metav1.NewTime(time.Now().Add(-time.Hour))
+		claim := resources.NewDependencyCacheVolumeClaim(&f, &m.FunctionConfig, hash)
+		claim.Status.Phase = corev1.ClaimPending
+		for _, obj := range []client.Object{job, claim} {
+			require.NoError(t, m.Client.Create(context.Background(), obj))
+		}
+
+		next, result, err := sFnHandleDependencyCache(context.Background(), m)
+
+		require.NoError(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnHandleDeployment, next)
+		status := m.State.Function.Status.DependencyCache
+		require.Equal(t, serverlessv1alpha2.DependencyCacheMiss, status.State)
+		require.Equal(t, "Volume claim "+claim.GetName()+" is not bound, the Function's Pods install the dependencies on start", status.Message)
+	})
+	t.Run("when job runs within timeout should wait for it", func(t *testing.T) {
+		f := fixDependencyCacheFunction("test-dependencies")
+		m := fixDependencyCacheStateMachine(t, f)
+		hash := fixDependencyCacheHash(t, m, &f)
+		job := fixDependencyCacheJob(t, m, &f, hash)
+		job.CreationTimestamp = metav1.NewTime(time.Now().Add(-time.Minute))
+		require.NoError(t, m.Client.Create(context.Background(), job))
+
+		next, result, err := sFnHandleDependencyCache(context.Background(), m)
+
+		require.NoError(t, err)
+		require.Equal(t, dependencyCachePopulatingRequeueTime, result.RequeueAfter)
+		require.Nil(t, next)
+		require.Equal(t, serverlessv1alpha2.DependencyCachePopulating, m.State.Function.Status.DependencyCache.State)
+	})
+	t.Run("when function has no dependencies should delete caches", func(t *testing.T) {
+		f := fixDependencyCacheFunction("")
+		m := fixDependencyCacheStateMachine(t, f)
+		require.NoError(t, m.Client.Create(context.Background(),
+			fixDependencyCacheJob(t, m, &f, "stale-hash")))
+
+		next, result, err := sFnHandleDependencyCache(context.Background(), m)
+
+		require.NoError(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnHandleDeployment, next)
+		require.Nil(t, m.State.Function.Status.DependencyCache)
+		jobs := &batchv1.JobList{}
+		require.NoError(t, m.Client.List(context.Background(), jobs))
+		require.Empty(t, jobs.Items)
+	})
+}
+
+func fixDependencyCacheHash(t *testing.T, m *fsm.StateMachine, f *serverlessv1alpha2.Function) string {
+	hash, err := resources.DependencyCacheHash(f, &m.FunctionConfig, "")
+	require.NoError(t, err)
+	return hash
+}
+
+func fixDependencyCacheJob(t *testing.T, m *fsm.StateMachine, f *serverlessv1alpha2.Function, hash string) *batchv1.Job {
+	job, err := resources.NewDependencyCacheJob(f, &m.FunctionConfig, hash, "", nil, false)
+	require.NoError(t, err)
+	return job
+}
+
+func fixDependencyCacheFunction(dependencies string) serverlessv1alpha2.Function {
+	return serverlessv1alpha2.Function{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "cache-function",
+			Namespace: "cache-namespace",
+			UID:       "cache-uid",
+		},
+		Spec: serverlessv1alpha2.FunctionSpec{
+			Runtime: serverlessv1alpha2.NodeJs24,
+			Source: serverlessv1alpha2.Source{
+				Inline: &serverlessv1alpha2.InlineSource{
+					Source:       "test-source",
+					Dependencies: dependencies,
+				},
+			},
+		},
+	}
+}
+
+func fixDependencyCacheStateMachine(t *testing.T, f serverlessv1alpha2.Function) *fsm.StateMachine {
+	scheme := runtime.NewScheme()
+	require.NoError(t, serverlessv1alpha2.AddToScheme(scheme))
+	require.NoError(t, corev1.AddToScheme(scheme))
+	require.NoError(t, batchv1.AddToScheme(scheme))
+	return &fsm.StateMachine{
+		State: fsm.SystemState{
+			Function: f,
+		},
+		Log:    zap.NewNop().Sugar(),
+		Client: fake.NewClientBuilder().WithScheme(scheme).Build(),
+		Scheme: scheme,
+		FunctionConfig: config.FunctionConfig{
+			DependencyCache: config.DependencyCacheConfig{
+				Enabled:    true,
+				Size:       config.Quantity{Quantity: resource.MustParse("1Gi")},
+				AccessMode: corev1.ReadWriteMany,
+				JobTimeout: 10 * time.Minute,
+			},
+		},
+	}
+}
@@ -31,6 +31,19 @@ func sFnDeploymentStatus(ctx context.Context, m *fsm.StateMachine) (fsm.StateFn,
 	deploymentName := deployment.GetName()
 	m.State.ClusterDeployment = &deployment
 
+	// idle deployment
+	if m.State.ScaledToZero && isDeploymentReady(deployment) {
+		m.Log.Info(fmt.Sprintf("deployment %s scaled to zero", deploymentName))
+
+		m.State.Function.UpdateCondition(
+			serverlessv1alpha2.ConditionRunning,
+			metav1.ConditionTrue,
+			serverlessv1alpha2.ConditionReasonScaledToZero,
+			fmt.Sprintf("Deployment %s is scaled to zero after being idle for %s", deploymentName, m.FunctionConfig.ScaleToZero.IdleWindow))
+
+		return nextState(sFnAdjustStatus)
+	}
+
 	// ready deployment
 	if isDeploymentReady(deployment) {
 
@@ -43,7 +56,7 @@ func sFnDeploymentStatus(ctx context.Context, m *fsm.StateMachine) (fsm.StateFn,
 			fmt.Sprintf("Deployment %s is ready", deploymentName))
 		metrics.PublishStateReachTime(m.State.Function, serverlessv1alpha2.ConditionRunning)
 
-		return nextState(sFnAdjustStatus)
+		return nextState(sFnRecordRevision)
 	}
 
 	// unhealthy deployment
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	serverlessv1alpha2 "github.com/kyma-project/serverless/components/buildless-serverless/api/v1alpha2"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/config"
 	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/fsm"
 	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/resources"
 	"github.com/stretchr/testify/require"
@@ -16,6 +17,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
@@ -71,7 +73,7 @@ func Test_sFnDeploymentStatus(t *testing.T) {
 		require.Nil(t, result)
 		// with expected next state
 		require.NotNil(t, next)
-		requireEqualFunc(t, sFnAdjustStatus, next)
+		requireEqualFunc(t, sFnRecordRevision, next)
 		// function has proper condition
 		requireContainsCondition(t, m.State.Function.Status,
 			serverlessv1alpha2.ConditionRunning,
@@ -81,6 +83,64 @@ func Test_sFnDeploymentStatus(t *testing.T) {
 		// observed generation is set to the function generation
 		require.Equal(t, int64(22), m.State.Function.Status.ObservedGeneration)
 	})
+	t.Run("when deployment is scaled to zero should go to the next state", func(t *testing.T) {
+		// Arrange
+		// our function
+		f := serverlessv1alpha2.Function{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "sleepy-shannon-name",
+				Namespace: "silent-sinoussi-ns"},
+			Spec: serverlessv1alpha2.FunctionSpec{
+				Runtime: serverlessv1alpha2.NodeJs24,
+				ScaleConfig: &serverlessv1alpha2.ScaleConfig{
+					MinReplicas: ptr.To[int32](0),
+				}}}
+		// deployment which will be returned from kubernetes
+		deployment := appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "sleepy-shannon-name",
+				Namespace: "silent-sinoussi-ns",
+				Labels:    f.InternalFunctionLabels()},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: ptr.To[int32](0)},
+			Status: appsv1.DeploymentStatus{
+				Conditions: []appsv1.DeploymentCondition{
+					{Type: appsv1.DeploymentAvailable, Status: corev1.ConditionTrue, Reason: MinimumReplicasAvailable},
+					{Type: appsv1.DeploymentProgressing, Status: corev1.ConditionTrue, Reason: NewRSAvailableReason}}}}
+		// scheme and fake client
+		scheme := runtime.NewScheme()
+		require.NoError(t, serverlessv1alpha2.AddToScheme(scheme))
+		require.NoError(t, appsv1.AddToScheme(scheme))
+		k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&deployment).Build()
+		// machine with our function
+		m := fsm.StateMachine{
+			State: fsm.SystemState{
+				Function:     f,
+				ScaledToZero: true},
+			Log:    zap.NewNop().Sugar(),
+			Client: k8sClient,
+			FunctionConfig: config.FunctionConfig{
+				ScaleToZero: config.ScaleToZeroConfig{
+					IdleWindow: 15 * time.Minute}},
+			Scheme: scheme}
+
+		// Act
+		next, result, err := sFnDeploymentStatus(context.Background(), &m)
+
+		// Assert
+		// no errors
+		require.Nil(t, err)
+		// without stopping processing
+		require.Nil(t, result)
+		// with expected next state
+		requireEqualFunc(t, sFnAdjustStatus, next)
+		// function has proper condition
+		requireContainsCondition(t, m.State.Function.Status,
+			serverlessv1alpha2.ConditionRunning,
+			metav1.ConditionTrue,
+			serverlessv1alpha2.ConditionReasonScaledToZero,
+			"Deployment sleepy-shannon-name is scaled to zero after being idle for 15m0s")
+	})
 	t.Run("when deployment is unhealthy should requeue", func(t *testing.T) {
 		// Arrange
 		// our function
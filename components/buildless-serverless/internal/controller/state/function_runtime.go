@@ -0,0 +1,35 @@
+package state
+
+import (
+	"context"
+	"fmt"
+
+	serverlessv1alpha2 "github.com/kyma-project/serverless/components/buildless-serverless/api/v1alpha2"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/fsm"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/functionruntime"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// sFnLoadFunctionRuntime adds the FunctionRuntime referenced by the function to the runtimes of the reconciliation
+func sFnLoadFunctionRuntime(ctx context.Context, m *fsm.StateMachine) (fsm.StateFn, *ctrl.Result, error) {
+	runtime := m.State.Function.Spec.Runtime
+	err := loadFunctionRuntime(ctx, m, runtime)
+	if err != nil {
+		m.State.Function.UpdateCondition(
+			serverlessv1alpha2.ConditionConfigurationReady,
+			metav1.ConditionFalse,
+			serverlessv1alpha2.ConditionReasonFunctionRuntimeFailed,
+			fmt.Sprintf("FunctionRuntime %s can't be loaded: %s", runtime, err.Error()))
+		return stopWithError(errors.Wrap(err, "while loading function runtime"))
+	}
+
+	return nextState(sFnValidateFunction)
+}
+
+// loadFunctionRuntime registers the FunctionRuntime when the runtime is not one of the configured runtimes
+// the missing FunctionRuntime is not an error, the runtime is reported as unknown by the validator
+func loadFunctionRuntime(ctx context.Context, m *fsm.StateMachine, runtime serverlessv1alpha2.Runtime) error {
+	return functionruntime.Load(ctx, m.Client, &m.FunctionConfig, string(runtime))
+}
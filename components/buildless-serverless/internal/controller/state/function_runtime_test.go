@@ -0,0 +1,132 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	serverlessv1alpha2 "github.com/kyma-project/serverless/components/buildless-serverless/api/v1alpha2"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/config"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/fsm"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func Test_sFnLoadFunctionRuntime(t *testing.T) {
+	t.Run("should not look up FunctionRuntime for built-in runtime", func(t *testing.T) {
+		m := &fsm.StateMachine{
+			State: fsm.SystemState{Function: fixFunctionWithRuntime("nodejs24")},
+		}
+
+		next, result, err := sFnLoadFunctionRuntime(context.Background(), m)
+
+		require.NoError(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnValidateFunction, next)
+		require.Empty(t, m.FunctionConfig.Runtimes)
+	})
+	t.Run("should add FunctionRuntime to the runtimes", func(t *testing.T) {
+		configRuntimes := map[string]config.RuntimeConfig{"java21": {Image: "java21-image"}}
+		m := fixFunctionRuntimeStateMachine(t, fixFunctionWithRuntime("go124"), fixFunctionRuntime("go124"))
+		m.FunctionConfig.Runtimes = configRuntimes
+
+		next, result, err := sFnLoadFunctionRuntime(context.Background(), m)
+
+		require.NoError(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnValidateFunction, next)
+		rc, ok := m.FunctionConfig.RuntimeConfig("go124")
+		require.True(t, ok)
+		require.Equal(t, config.RuntimeConfig{
+			Image:              "go124-image",
+			Deprecated:         true,
+			WorkingDir:         "/app/function",
+			HandlerFile:        "handler.go",
+			DependenciesFile:   "go.mod",
+			DependenciesFormat: config.DependenciesFormatText,
+			InstallCommand:     "go build -o /tmp/function .;",
+			StartCommand:       "/tmp/function;",
+			DependencyCache: config.RuntimeDependencyCacheConfig{
+				SaveCommand: "cp /tmp/function /dependency-cache/;",
+			},
+			EmptyDirs: []config.RuntimeEmptyDir{{Name: "go-cache", MountPath: "/.cache"}},
+			Env:       []config.RuntimeEnvVar{{Name: "GOCACHE", Value: "/.cache"}},
+		}, rc)
+		// runtimes of the controller's config are not modified
+		require.Len(t, configRuntimes, 1)
+	})
+	t.Run("should leave unknown runtime to the validator", func(t *testing.T) {
+		m := fixFunctionRuntimeStateMachine(t, fixFunctionWithRuntime("go124"))
+
+		next, result, err := sFnLoadFunctionRuntime(context.Background(), m)
+
+		require.NoError(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnValidateFunction, next)
+		_, ok := m.FunctionConfig.RuntimeConfig("go124")
+		require.False(t, ok)
+	})
+	t.Run("when FunctionRuntime can't be fetched should stop processing", func(t *testing.T) {
+		m := fixFunctionRuntimeStateMachine(t, fixFunctionWithRuntime("go124"))
+		m.Client = fake.NewClientBuilder().WithScheme(m.Scheme).WithInterceptorFuncs(interceptor.Funcs{
+			Get: func(ctx context.Context, client client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				return errors.New("connection refused")
+			},
+		}).Build()
+
+		next, result, err := sFnLoadFunctionRuntime(context.Background(), m)
+
+		require.ErrorContains(t, err, "while loading function runtime")
+		require.Nil(t, result)
+		require.Nil(t, next)
+		requireContainsCondition(t, m.State.Function.Status,
+			serverlessv1alpha2.ConditionConfigurationReady,
+			metav1.ConditionFalse,
+			serverlessv1alpha2.ConditionReasonFunctionRuntimeFailed,
+			"FunctionRuntime go124 can't be loaded: connection refused")
+	})
+}
+
+func fixFunctionWithRuntime(runtime serverlessv1alpha2.Runtime) serverlessv1alpha2.Function {
+	return serverlessv1alpha2.Function{
+		ObjectMeta: metav1.ObjectMeta{Name: "function", Namespace: "default"},
+		Spec:       serverlessv1alpha2.FunctionSpec{Runtime: runtime},
+	}
+}
+
+func fixFunctionRuntime(name string) *serverlessv1alpha2.FunctionRuntime {
+	return &serverlessv1alpha2.FunctionRuntime{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: serverlessv1alpha2.FunctionRuntimeSpec{
+			Image:              "go124-image",
+			Deprecated:         true,
+			WorkingDir:         "/app/function",
+			HandlerFile:        "handler.go",
+			DependenciesFile:   "go.mod",
+			DependenciesFormat: serverlessv1alpha2.DependenciesFormatText,
+			InstallCommand:     "go build -o /tmp/function .;",
+			StartCommand:       "/tmp/function;",
+			DependencyCache: &serverlessv1alpha2.FunctionRuntimeDependencyCache{
+				SaveCommand: "cp /tmp/function /dependency-cache/;",
+			},
+			EmptyDirs: []serverlessv1alpha2.FunctionRuntimeEmptyDir{{Name: "go-cache", MountPath: "/.cache"}},
+			Env:       []serverlessv1alpha2.FunctionRuntimeEnvVar{{Name: "GOCACHE", Value: "/.cache"}},
+		},
+	}
+}
+
+func fixFunctionRuntimeStateMachine(t *testing.T, f serverlessv1alpha2.Function, objs ...client.Object) *fsm.StateMachine {
+	scheme := runtime.NewScheme()
+	require.NoError(t, serverlessv1alpha2.AddToScheme(scheme))
+	return &fsm.StateMachine{
+		State:  fsm.SystemState{Function: f},
+		Log:    zap.NewNop().Sugar(),
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build(),
+		Scheme: scheme,
+	}
+}
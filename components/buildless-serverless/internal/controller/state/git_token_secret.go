@@ -0,0 +1,73 @@
+package state
+
+import (
+	"bytes"
+	"context"
+
+	serverlessv1alpha2 "github.com/kyma-project/serverless/components/buildless-serverless/api/v1alpha2"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/fsm"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/git"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// applyGitTokenSecret stores the GitHub App's installation token in the secret the function's pods clone the repository with
+func applyGitTokenSecret(ctx context.Context, m *fsm.StateMachine, secret *corev1.Secret) error {
+	clusterSecret := &corev1.Secret{}
+	err := m.Client.Get(ctx, client.ObjectKeyFromObject(secret), clusterSecret)
+	if k8serrors.IsNotFound(err) {
+		m.Log.Info("creating a new git token secret", "Secret.Namespace", secret.GetNamespace(), "Secret.Name", secret.GetName())
+		return createOwnedObject(ctx, m, secret)
+	}
+	if err != nil {
+		return err
+	}
+
+	if !metav1.IsControlledBy(clusterSecret, &m.State.Function) {
+		// secret with the same name is managed by someone else, do not overwrite it with the token
+		return errors.Errorf("Secret %s already exists and is not controlled by the Function, delete or rename it", clusterSecret.GetName())
+	}
+
+	if bytes.Equal(clusterSecret.Data[git.InstallationTokenSecretKey], secret.Data[git.InstallationTokenSecretKey]) {
+		return nil
+	}
+
+	m.Log.Info("updating git token secret", "Secret.Namespace", secret.GetNamespace(), "Secret.Name", secret.GetName())
+	clusterSecret.Data = secret.Data
+	return m.Client.Update(ctx, clusterSecret)
+}
+
+// sFnHandleGitTokenSecret deletes the installation token secret when the function stops using the GitHub App
+func sFnHandleGitTokenSecret(ctx context.Context, m *fsm.StateMachine) (fsm.StateFn, *ctrl.Result, error) {
+	f := deployedFunction(m)
+	if f.HasGitAuth() && f.Spec.Source.GitRepository.Auth.Type == serverlessv1alpha2.RepositoryAuthGitHubApp {
+		// the secret is applied with the new installation token by sFnHandleGitSources
+		return nextState(sFnHandleGitSources)
+	}
+
+	if err := deleteGitTokenSecret(ctx, m); err != nil {
+		return stopWithError(errors.Wrap(err, "while deleting git token secret"))
+	}
+	return nextState(sFnHandleGitSources)
+}
+
+// deleteGitTokenSecret deletes the installation token secret of the function which doesn't use the GitHub App anymore
+// only the metadata is fetched, so the token is not read
+func deleteGitTokenSecret(ctx context.Context, m *fsm.StateMachine) error {
+	clusterSecret := &metav1.PartialObjectMetadata{}
+	clusterSecret.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Secret"))
+	err := m.Client.Get(ctx, client.ObjectKey{Namespace: m.State.Function.GetNamespace(), Name: git.InstallationTokenSecretName(&m.State.Function)}, clusterSecret)
+	if err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if !metav1.IsControlledBy(clusterSecret, &m.State.Function) {
+		return nil
+	}
+
+	m.Log.Info("deleting git token secret", "Secret.Namespace", clusterSecret.GetNamespace(), "Secret.Name", clusterSecret.GetName())
+	return client.IgnoreNotFound(m.Client.Delete(ctx, clusterSecret))
+}
@@ -0,0 +1,149 @@
+package state
+
+import (
+	"context"
+	"testing"
+
+	serverlessv1alpha2 "github.com/kyma-project/serverless/components/buildless-serverless/api/v1alpha2"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/fsm"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/resources"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+func Test_applyGitTokenSecret(t *testing.T) {
+	f := serverlessv1alpha2.Function{
+		ObjectMeta: metav1.ObjectMeta{Name: "app-function", Namespace: "default", UID: "app-uid"},
+	}
+	scheme := runtime.NewScheme()
+	require.NoError(t, serverlessv1alpha2.AddToScheme(scheme))
+	require.NoError(t, corev1.AddToScheme(scheme))
+	m := &fsm.StateMachine{
+		State:  fsm.SystemState{Function: f},
+		Log:    zap.NewNop().Sugar(),
+		Client: fake.NewClientBuilder().WithScheme(scheme).Build(),
+		Scheme: scheme,
+	}
+
+	t.Run("create secret owned by function", func(t *testing.T) {
+		err := applyGitTokenSecret(context.Background(), m, resources.NewGitTokenSecret(&f, "first-token"))
+		require.NoError(t, err)
+
+		secret := corev1.Secret{}
+		require.NoError(t, m.Client.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "app-function-git-token"}, &secret))
+		require.Equal(t, "first-token", string(secret.Data["token"]))
+		require.Equal(t, "git-token", secret.GetLabels()[serverlessv1alpha2.FunctionResourceLabel])
+		require.Len(t, secret.GetOwnerReferences(), 1)
+		require.Equal(t, "app-function", secret.GetOwnerReferences()[0].Name)
+	})
+	t.Run("update secret with new token", func(t *testing.T) {
+		err := applyGitTokenSecret(context.Background(), m, resources.NewGitTokenSecret(&f, "second-token"))
+		require.NoError(t, err)
+
+		secret := corev1.Secret{}
+		require.NoError(t, m.Client.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "app-function-git-token"}, &secret))
+		require.Equal(t, "second-token", string(secret.Data["token"]))
+	})
+	t.Run("do not overwrite secret not controlled by function", func(t *testing.T) {
+		other := serverlessv1alpha2.Function{
+			ObjectMeta: metav1.ObjectMeta{Name: "other-function", Namespace: "default", UID: "other-uid"},
+		}
+		userSecret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "other-function-git-token", Namespace: "default"},
+			Data:       map[string][]byte{"token": []byte("user-data")},
+		}
+		require.NoError(t, m.Client.Create(context.Background(), userSecret))
+
+		err := applyGitTokenSecret(context.Background(), fixGitTokenStateMachine(m, other), resources.NewGitTokenSecret(&other, "third-token"))
+		require.EqualError(t, err, "Secret other-function-git-token already exists and is not controlled by the Function, delete or rename it")
+
+		secret := corev1.Secret{}
+		require.NoError(t, m.Client.Get(context.Background(), client.ObjectKeyFromObject(userSecret), &secret))
+		require.Equal(t, "user-data", string(secret.Data["token"]))
+	})
+}
+
+func Test_sFnHandleGitTokenSecret(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, serverlessv1alpha2.AddToScheme(scheme))
+	require.NoError(t, corev1.AddToScheme(scheme))
+	fixFunction := func(authType serverlessv1alpha2.RepositoryAuthType) serverlessv1alpha2.Function {
+		return serverlessv1alpha2.Function{
+			ObjectMeta: metav1.ObjectMeta{Name: "app-function", Namespace: "default", UID: "app-uid"},
+			Spec: serverlessv1alpha2.FunctionSpec{
+				Source: serverlessv1alpha2.Source{
+					GitRepository: &serverlessv1alpha2.GitRepositorySource{
+						URL:  "https://github.com/org/repo",
+						Auth: &serverlessv1alpha2.RepositoryAuth{Type: authType, SecretName: "auth"},
+					},
+				},
+			},
+		}
+	}
+	fixStateMachine := func(f serverlessv1alpha2.Function, secret *corev1.Secret) *fsm.StateMachine {
+		return &fsm.StateMachine{
+			State:  fsm.SystemState{Function: f},
+			Log:    zap.NewNop().Sugar(),
+			Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build(),
+			Scheme: scheme,
+		}
+	}
+
+	t.Run("keep secret of function using GitHub App", func(t *testing.T) {
+		f := fixFunction(serverlessv1alpha2.RepositoryAuthGitHubApp)
+		m := fixStateMachine(f, fixOwnedGitTokenSecret(t, scheme, &f))
+
+		next, result, err := sFnHandleGitTokenSecret(context.Background(), m)
+
+		require.NoError(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnHandleGitSources, next)
+		require.NoError(t, m.Client.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "app-function-git-token"}, &corev1.Secret{}))
+	})
+	t.Run("delete secret when function stops using GitHub App", func(t *testing.T) {
+		f := fixFunction(serverlessv1alpha2.RepositoryAuthBasic)
+		m := fixStateMachine(f, fixOwnedGitTokenSecret(t, scheme, &f))
+
+		next, result, err := sFnHandleGitTokenSecret(context.Background(), m)
+
+		require.NoError(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnHandleGitSources, next)
+		err = m.Client.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "app-function-git-token"}, &corev1.Secret{})
+		require.True(t, k8serrors.IsNotFound(err))
+	})
+	t.Run("keep secret not controlled by function", func(t *testing.T) {
+		f := fixFunction(serverlessv1alpha2.RepositoryAuthBasic)
+		userSecret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "app-function-git-token", Namespace: "default"}}
+		m := fixStateMachine(f, userSecret)
+
+		next, result, err := sFnHandleGitTokenSecret(context.Background(), m)
+
+		require.NoError(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnHandleGitSources, next)
+		require.NoError(t, m.Client.Get(context.Background(), client.ObjectKeyFromObject(userSecret), &corev1.Secret{}))
+	})
+}
+
+func fixGitTokenStateMachine(m *fsm.StateMachine, f serverlessv1alpha2.Function) *fsm.StateMachine {
+	return &fsm.StateMachine{
+		State:  fsm.SystemState{Function: f},
+		Log:    m.Log,
+		Client: m.Client,
+		Scheme: m.Scheme,
+	}
+}
+
+func fixOwnedGitTokenSecret(t *testing.T, scheme *runtime.Scheme, f *serverlessv1alpha2.Function) *corev1.Secret {
+	secret := resources.NewGitTokenSecret(f, "token")
+	require.NoError(t, controllerutil.SetControllerReference(f, secret, scheme))
+	return secret
+}
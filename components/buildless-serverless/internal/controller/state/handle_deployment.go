@@ -3,15 +3,16 @@ package state
 import (
 	"context"
 	"fmt"
-	"reflect"
 	"time"
 
 	serverlessv1alpha2 "github.com/kyma-project/serverless/components/buildless-serverless/api/v1alpha2"
 	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/fsm"
 	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/resources"
 	appsv1 "k8s.io/api/apps/v1"
-	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	appsv1ac "k8s.io/client-go/applyconfigurations/apps/v1"
+	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -24,8 +25,19 @@ func sFnHandleDeployment(ctx context.Context, m *fsm.StateMachine) (fsm.StateFn,
 	}
 
 	// If there are multiple deployments, delete them because we only want one
+	// unless the function is being rolled out with the canary strategy
 	if len(clusterDeployments.Items) > 1 {
-		return nextState(sFnDeleteDeployments)
+		stable, canary := rolloutDeployments(m, clusterDeployments)
+		if stable == nil || canary == nil {
+			return nextState(sFnDeleteDeployments)
+		}
+		m.State.ClusterDeployment = stable
+		m.State.CanaryDeployment = canary
+		return nextState(sFnHandleCanaryRollout)
+	}
+	if m.State.Function.IsCanaryRolloutInProgress() {
+		// one of the rollout's deployments is gone, the rollout can't be continued
+		m.State.Function.Status.Rollout = nil
 	}
 
 	var clusterDeployment *appsv1.Deployment
@@ -34,7 +46,8 @@ func sFnHandleDeployment(ctx context.Context, m *fsm.StateMachine) (fsm.StateFn,
 	}
 	m.State.ClusterDeployment = clusterDeployment
 
-	m.State.BuiltDeployment = resources.NewDeployment(&m.State.Function, &m.FunctionConfig, clusterDeployment, m.State.Commit, m.State.GitAuth, "", m.IsKymaFipsModeEnabled)
+	m.State.ScaledToZero = isIdle(m)
+	m.State.BuiltDeployment = resources.NewDeployment(deployedFunction(m), &m.FunctionConfig, m.State.Commit, m.State.GitAuth, "", m.IsKymaFipsModeEnabled, resources.DeployScaleToZero(m.State.ScaledToZero), resources.DeployDependencyCache(m.State.DependencyCache), resources.DeployResolvedTag(m.State.ResolvedTag), resources.DeployReferencedContentHash(m.State.ReferencedContentHash))
 	builtDeployment := m.State.BuiltDeployment.Deployment
 
 	if m.State.ClusterDeployment == nil {
@@ -45,9 +58,24 @@ func sFnHandleDeployment(ctx context.Context, m *fsm.StateMachine) (fsm.StateFn,
 		return nil, result, errCreate
 	}
 
-	requeueNeeded, errUpdate := updateDeploymentIfNeeded(ctx, m, clusterDeployment, builtDeployment)
-	if errUpdate != nil {
-		return stopWithError(errUpdate)
+	if m.State.Function.IsCanaryRolloutEnabled() && !m.State.ScaledToZero && templateChanged(clusterDeployment, builtDeployment) {
+		if !isRolledBack(m) {
+			return startCanaryRollout(ctx, m)
+		}
+		// keep the previous version running until the function changes
+		requeueNeeded, errScale := scaleDeploymentIfNeeded(ctx, m, clusterDeployment, ptr.Deref(builtDeployment.Spec.Replicas, resources.DefaultDeploymentReplicas))
+		if errScale != nil {
+			return stopWithError(errScale)
+		}
+		if requeueNeeded {
+			return requeueAfter(time.Second)
+		}
+		return nextState(sFnHandleService)
+	}
+
+	requeueNeeded, errApply := applyDeployment(ctx, m, clusterDeployment, builtDeployment)
+	if errApply != nil {
+		return stopWithError(errApply)
 	}
 	m.State.Function.CopyAnnotationsToStatus()
 	if requeueNeeded {
@@ -87,7 +115,7 @@ func createDeployment(ctx context.Context, m *fsm.StateMachine, deployment *apps
 		return nil, err
 	}
 
-	if err := m.Client.Create(ctx, deployment); err != nil {
+	if err := m.Client.Create(ctx, deployment, client.FieldOwner(fieldManager)); err != nil {
 		m.Log.Error(err, "failed to create new Deployment", "Deployment.Namespace", deployment.GetNamespace(), "Deployment.Name", name)
 		m.State.Function.UpdateCondition(
 			serverlessv1alpha2.ConditionRunning,
@@ -105,105 +133,73 @@ func createDeployment(ctx context.Context, m *fsm.StateMachine, deployment *apps
 	return &ctrl.Result{RequeueAfter: time.Second}, nil
 }
 
-func updateDeploymentIfNeeded(ctx context.Context, m *fsm.StateMachine, clusterDeployment *appsv1.Deployment, builtDeployment *appsv1.Deployment) (requeueNeeded bool, err error) {
-	// Ensure the Deployment data matches the desired state
-	if !deploymentChanged(clusterDeployment, builtDeployment) {
-		return false, nil
+// applyDeployment applies the built deployment with the server-side apply.
+// The fields set by the controller and changed by someone else are reverted,
+// the fields set by others, like the ones injected by admission webhooks, are left untouched.
+func applyDeployment(ctx context.Context, m *fsm.StateMachine, clusterDeployment *appsv1.Deployment, builtDeployment *appsv1.Deployment) (requeueNeeded bool, err error) {
+	if err := upgradeManagedFields(ctx, m, clusterDeployment); err != nil {
+		return false, deploymentUpdateFailed(m, clusterDeployment, err)
 	}
 
-	//TODO: think if it's better to update only some fields
-	clusterDeployment.Spec.Template = builtDeployment.Spec.Template
-	clusterDeployment.Spec.Replicas = builtDeployment.Spec.Replicas
-	return updateDeployment(ctx, m, clusterDeployment)
-}
+	// the built deployment may have the generated name only
+	desired := builtDeployment.DeepCopy()
+	desired.SetName(clusterDeployment.GetName())
+	desired.SetGenerateName("")
+	if err := controllerutil.SetControllerReference(&m.State.Function, desired, m.Scheme); err != nil {
+		return false, deploymentUpdateFailed(m, clusterDeployment, err)
+	}
 
-func deploymentChanged(a *appsv1.Deployment, b *appsv1.Deployment) bool {
-	if len(a.Spec.Template.Spec.Containers) != 1 ||
-		len(b.Spec.Template.Spec.Containers) != 1 {
-		return true
-	}
-
-	aContainer := a.Spec.Template.Spec.Containers[0]
-	bContainer := b.Spec.Template.Spec.Containers[0]
-
-	imageChanged := aContainer.Image != bContainer.Image
-	labelsChanged := !reflect.DeepEqual(a.Spec.Template.ObjectMeta.Labels, b.Spec.Template.ObjectMeta.Labels)
-	annotationsChanged := !reflect.DeepEqual(a.Spec.Template.ObjectMeta.Annotations, b.Spec.Template.ObjectMeta.Annotations)
-	replicasChanged := (a.Spec.Replicas == nil && b.Spec.Replicas != nil) ||
-		(a.Spec.Replicas != nil && b.Spec.Replicas == nil) ||
-		(a.Spec.Replicas != nil && b.Spec.Replicas != nil && *a.Spec.Replicas != *b.Spec.Replicas)
-	workingDirChanged := !reflect.DeepEqual(aContainer.WorkingDir, bContainer.WorkingDir)
-	commandChanged := !reflect.DeepEqual(aContainer.Command, bContainer.Command)
-	resourcesChanged := !equalResources(aContainer.Resources, bContainer.Resources)
-	envChanged := !reflect.DeepEqual(aContainer.Env, bContainer.Env)
-	volumeMountsChanged := !reflect.DeepEqual(aContainer.VolumeMounts, bContainer.VolumeMounts)
-	portsChanged := !reflect.DeepEqual(aContainer.Ports, bContainer.Ports)
-	podSecurityContextChanged := !reflect.DeepEqual(a.Spec.Template.Spec.SecurityContext, b.Spec.Template.Spec.SecurityContext)
-	containerSecurityContextChanged := !reflect.DeepEqual(aContainer.SecurityContext, bContainer.SecurityContext)
-
-	return imageChanged ||
-		labelsChanged ||
-		annotationsChanged ||
-		replicasChanged ||
-		workingDirChanged ||
-		commandChanged ||
-		resourcesChanged ||
-		envChanged ||
-		volumeMountsChanged ||
-		portsChanged ||
-		podSecurityContextChanged ||
-		containerSecurityContextChanged ||
-		initContainerChanged(a, b)
+	deploymentApplyConfig := &appsv1ac.DeploymentApplyConfiguration{}
+	if err := convertObject(desired, deploymentApplyConfig); err != nil {
+		return false, deploymentUpdateFailed(m, clusterDeployment, err)
+	}
+	deploymentApplyConfig.Status = nil
+	if err := m.Client.Apply(ctx, deploymentApplyConfig, client.FieldOwner(fieldManager), client.ForceOwnership); err != nil {
+		return false, deploymentUpdateFailed(m, clusterDeployment, err)
+	}
+
+	appliedDeployment := &appsv1.Deployment{}
+	if err := convertObject(deploymentApplyConfig, appliedDeployment); err != nil {
+		return false, deploymentUpdateFailed(m, clusterDeployment, err)
+	}
+	if !deploymentChanged(clusterDeployment, appliedDeployment) {
+		return false, nil
+	}
+	deploymentUpdated(m, clusterDeployment)
+	// Requeue the request to ensure the Deployment is updated
+	return true, nil
 }
 
-func initContainerChanged(a *appsv1.Deployment, b *appsv1.Deployment) bool {
-	// there are no init containers for inline function and one init container for git function
-	// when count of init containers is not equal function type has been changed
-	if len(a.Spec.Template.Spec.InitContainers) > 1 ||
-		len(b.Spec.Template.Spec.InitContainers) > 1 ||
-		len(a.Spec.Template.Spec.InitContainers) != len(b.Spec.Template.Spec.InitContainers) {
-		return true
-	}
-	if len(a.Spec.Template.Spec.InitContainers) == 0 {
-		return false
-	}
-	aContainer := a.Spec.Template.Spec.InitContainers[0]
-	bContainer := b.Spec.Template.Spec.InitContainers[0]
-
-	imageChanged := aContainer.Image != bContainer.Image
-	workingDirChanged := !reflect.DeepEqual(aContainer.WorkingDir, bContainer.WorkingDir)
-	commandChanged := !reflect.DeepEqual(aContainer.Command, bContainer.Command)
-	envChanged := !reflect.DeepEqual(aContainer.Env, bContainer.Env)
-	volumeMountsChanged := !reflect.DeepEqual(aContainer.VolumeMounts, bContainer.VolumeMounts)
-	return imageChanged ||
-		workingDirChanged ||
-		commandChanged ||
-		envChanged ||
-		volumeMountsChanged
+// deploymentChanged returns true when the deployment in the cluster has been changed by the apply
+func deploymentChanged(before *appsv1.Deployment, after *appsv1.Deployment) bool {
+	return !equality.Semantic.DeepEqual(before.Spec, after.Spec) ||
+		!equality.Semantic.DeepEqual(before.GetLabels(), after.GetLabels()) ||
+		!equality.Semantic.DeepEqual(before.GetAnnotations(), after.GetAnnotations())
 }
 
 func updateDeployment(ctx context.Context, m *fsm.StateMachine, clusterDeployment *appsv1.Deployment) (requeueNeeded bool, err error) {
-	if errUpdate := m.Client.Update(ctx, clusterDeployment); errUpdate != nil {
-		m.Log.Error(errUpdate, "Failed to update Deployment", "Deployment.Namespace", clusterDeployment.GetNamespace(), "Deployment.Name", clusterDeployment.GetName())
-		m.State.Function.UpdateCondition(
-			serverlessv1alpha2.ConditionRunning,
-			metav1.ConditionFalse,
-			serverlessv1alpha2.ConditionReasonDeploymentFailed,
-			fmt.Sprintf("Deployment %s update failed: %s", clusterDeployment.GetName(), errUpdate.Error()))
-		return false, errUpdate
+	if errUpdate := m.Client.Update(ctx, clusterDeployment, client.FieldOwner(fieldManager)); errUpdate != nil {
+		return false, deploymentUpdateFailed(m, clusterDeployment, errUpdate)
 	}
+	deploymentUpdated(m, clusterDeployment)
+	// Requeue the request to ensure the Deployment is updated
+	return true, nil
+}
+
+func deploymentUpdated(m *fsm.StateMachine, clusterDeployment *appsv1.Deployment) {
 	m.State.Function.UpdateCondition(
 		serverlessv1alpha2.ConditionRunning,
 		metav1.ConditionUnknown,
 		serverlessv1alpha2.ConditionReasonDeploymentUpdated,
 		fmt.Sprintf("Deployment %s updated", clusterDeployment.GetName()))
-	// Requeue the request to ensure the Deployment is updated
-	return true, nil
 }
 
-func equalResources(a, b corev1.ResourceRequirements) bool {
-	return a.Requests.Memory().Equal(*b.Requests.Memory()) &&
-		a.Requests.Cpu().Equal(*b.Requests.Cpu()) &&
-		a.Limits.Memory().Equal(*b.Limits.Memory()) &&
-		a.Limits.Cpu().Equal(*b.Limits.Cpu())
+func deploymentUpdateFailed(m *fsm.StateMachine, clusterDeployment *appsv1.Deployment, err error) error {
+	m.Log.Error(err, "Failed to update Deployment", "Deployment.Namespace", clusterDeployment.GetNamespace(), "Deployment.Name", clusterDeployment.GetName())
+	m.State.Function.UpdateCondition(
+		serverlessv1alpha2.ConditionRunning,
+		metav1.ConditionFalse,
+		serverlessv1alpha2.ConditionReasonDeploymentFailed,
+		fmt.Sprintf("Deployment %s update failed: %s", clusterDeployment.GetName(), err.Error()))
+	return err
 }
@@ -206,9 +206,8 @@ func Test_sFnHandleDeployment(t *testing.T) {
 		fc := config.FunctionConfig{
 			Images: config.ImagesConfig{NodeJs24: "boring-bartik"},
 		}
-		cd := appsv1.Deployment{}
 		// identical deployment will be generated inside sFnHandleDeployment
-		deployment := resources.NewDeployment(&f, &fc, &cd, "test-commit", nil, "", true).Deployment
+		deployment := resources.NewDeployment(&f, &fc, "test-commit", nil, "", true).Deployment
 		// scheme and fake client
 		scheme := runtime.NewScheme()
 		require.NoError(t, serverlessv1alpha2.AddToScheme(scheme))
@@ -349,7 +348,7 @@ func Test_sFnHandleDeployment(t *testing.T) {
 		require.NoError(t, serverlessv1alpha2.AddToScheme(scheme))
 		require.NoError(t, appsv1.AddToScheme(scheme))
 		k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&deployment).WithInterceptorFuncs(interceptor.Funcs{
-			Update: func(ctx context.Context, client client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+			Apply: func(ctx context.Context, client client.WithWatch, obj runtime.ApplyConfiguration, opts ...client.ApplyOption) error {
 				return errors.New("happy-pare error message")
 			},
 		}).Build()
@@ -382,6 +381,105 @@ func Test_sFnHandleDeployment(t *testing.T) {
 	})
 }
 
+func Test_applyDeployment(t *testing.T) {
+	f := serverlessv1alpha2.Function{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "pensive-lamarr-name",
+			Namespace: "jolly-mestorf-ns",
+			UID:       "pensive-lamarr-uid"},
+		Spec: serverlessv1alpha2.FunctionSpec{
+			Runtime: serverlessv1alpha2.NodeJs24,
+			Source: serverlessv1alpha2.Source{
+				Inline: &serverlessv1alpha2.InlineSource{
+					Source: "modest-hawking"}}}}
+	fc := config.FunctionConfig{
+		Images: config.ImagesConfig{NodeJs24: "vibrant-wilson"},
+	}
+	fixMachine := func(t *testing.T, manager string, deployment *appsv1.Deployment) (*fsm.StateMachine, *appsv1.Deployment) {
+		scheme := runtime.NewScheme()
+		require.NoError(t, serverlessv1alpha2.AddToScheme(scheme))
+		require.NoError(t, appsv1.AddToScheme(scheme))
+		k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithReturnManagedFields().Build()
+		require.NoError(t, k8sClient.Create(context.Background(), deployment, client.FieldOwner(manager)))
+		m := &fsm.StateMachine{
+			State:          fsm.SystemState{Function: f},
+			FunctionConfig: fc,
+			Log:            zap.NewNop().Sugar(),
+			Client:         k8sClient,
+			Scheme:         scheme}
+		return m, deployment
+	}
+	fixDeployment := func() (*appsv1.Deployment, *appsv1.Deployment) {
+		built := resources.NewDeployment(&f, &fc, "", nil, "", false).Deployment
+		cluster := built.DeepCopy()
+		cluster.SetName("pensive-lamarr-name-abcde")
+		cluster.SetGenerateName("")
+		return cluster, built
+	}
+	getDeployment := func(t *testing.T, m *fsm.StateMachine) *appsv1.Deployment {
+		deployment := &appsv1.Deployment{}
+		require.NoError(t, m.Client.Get(context.Background(), client.ObjectKey{
+			Name:      "pensive-lamarr-name-abcde",
+			Namespace: "jolly-mestorf-ns",
+		}, deployment))
+		return deployment
+	}
+
+	t.Run("keep deployment without changes", func(t *testing.T) {
+		cluster, built := fixDeployment()
+		m, cluster := fixMachine(t, fieldManager, cluster)
+
+		requeueNeeded, err := applyDeployment(context.Background(), m, cluster, built)
+
+		require.NoError(t, err)
+		require.False(t, requeueNeeded)
+		require.Empty(t, m.State.Function.Status.Conditions)
+	})
+	t.Run("revert field set by the controller and changed by someone else", func(t *testing.T) {
+		cluster, built := fixDeployment()
+		m, cluster := fixMachine(t, fieldManager, cluster)
+		cluster.Spec.Template.Spec.Containers[0].Image = "sleepy-tesla"
+		require.NoError(t, m.Client.Update(context.Background(), cluster, client.FieldOwner("kubectl-edit")))
+
+		requeueNeeded, err := applyDeployment(context.Background(), m, cluster, built)
+
+		require.NoError(t, err)
+		require.True(t, requeueNeeded)
+		requireContainsCondition(t, m.State.Function.Status,
+			serverlessv1alpha2.ConditionRunning,
+			metav1.ConditionUnknown,
+			serverlessv1alpha2.ConditionReasonDeploymentUpdated,
+			"Deployment pensive-lamarr-name-abcde updated")
+		require.Equal(t, "vibrant-wilson", getDeployment(t, m).Spec.Template.Spec.Containers[0].Image)
+	})
+	t.Run("keep fields set by someone else", func(t *testing.T) {
+		cluster, built := fixDeployment()
+		m, cluster := fixMachine(t, fieldManager, cluster)
+		metav1.SetMetaDataAnnotation(&cluster.Spec.Template.ObjectMeta, "kubectl.kubernetes.io/restartedAt", "2026-10-18T10:00:00Z")
+		require.NoError(t, m.Client.Update(context.Background(), cluster, client.FieldOwner("kubectl-rollout")))
+
+		requeueNeeded, err := applyDeployment(context.Background(), m, cluster, built)
+
+		require.NoError(t, err)
+		require.False(t, requeueNeeded)
+		require.Equal(t, "2026-10-18T10:00:00Z",
+			getDeployment(t, m).Spec.Template.Annotations["kubectl.kubernetes.io/restartedAt"])
+	})
+	t.Run("remove field set by the controller before it applied the deployment", func(t *testing.T) {
+		cluster, built := fixDeployment()
+		cluster.Spec.Template.Spec.Containers[0].Env = append(cluster.Spec.Template.Spec.Containers[0].Env,
+			corev1.EnvVar{Name: "REMOVED_ENV", Value: "zealous-euler"})
+		m, cluster := fixMachine(t, legacyFieldManager, cluster)
+
+		requeueNeeded, err := applyDeployment(context.Background(), m, cluster, built)
+
+		require.NoError(t, err)
+		require.True(t, requeueNeeded)
+		require.NotContains(t, getDeployment(t, m).Spec.Template.Spec.Containers[0].Env,
+			corev1.EnvVar{Name: "REMOVED_ENV", Value: "zealous-euler"})
+	})
+}
+
 func Test_deploymentChanged(t *testing.T) {
 	type args struct {
 		a *appsv1.Deployment
@@ -493,7 +591,7 @@ func Test_deploymentChanged(t *testing.T) {
 			want: false,
 		},
 		{
-			name: "when there are no containers should return true",
+			name: "when there are no containers should return false",
 			args: args{
 				a: &appsv1.Deployment{
 					Spec: appsv1.DeploymentSpec{
@@ -506,7 +604,7 @@ func Test_deploymentChanged(t *testing.T) {
 							Spec: corev1.PodSpec{
 								Containers: []corev1.Container{}}}}},
 			},
-			want: true,
+			want: false,
 		},
 		{
 			name: "when securityContexts are different should return true",
@@ -793,7 +891,36 @@ func Test_deploymentChanged(t *testing.T) {
 			want: true,
 		},
 		{
-			name: "when (some) not compared fields are different should return false",
+			name: "when scheduling is different should return true",
+			args: args{
+				a: &appsv1.Deployment{
+					Spec: appsv1.DeploymentSpec{
+						Template: corev1.PodTemplateSpec{
+							Spec: corev1.PodSpec{
+								NodeSelector: map[string]string{"pool": "eager-mirzakhani"},
+								Tolerations: []corev1.Toleration{{
+									Key:      "eager-mirzakhani",
+									Operator: corev1.TolerationOpExists}},
+								PriorityClassName:  "eager-mirzakhani",
+								ServiceAccountName: "eager-mirzakhani"}}}},
+				b: &appsv1.Deployment{
+					Spec: appsv1.DeploymentSpec{
+						Template: corev1.PodTemplateSpec{
+							Spec: corev1.PodSpec{
+								NodeSelector: map[string]string{"pool": "eager-mirzakhani"},
+								Tolerations: []corev1.Toleration{{
+									Key:      "eager-mirzakhani",
+									Operator: corev1.TolerationOpExists}},
+								TopologySpreadConstraints: []corev1.TopologySpreadConstraint{{
+									MaxSkew:     1,
+									TopologyKey: "eager-mirzakhani"}},
+								PriorityClassName:  "eager-mirzakhani",
+								ServiceAccountName: "eager-mirzakhani"}}}},
+			},
+			want: true,
+		},
+		{
+			name: "when other fields of spec are different should return true",
 			args: args{
 				a: &appsv1.Deployment{
 					TypeMeta: metav1.TypeMeta{
@@ -892,7 +1019,7 @@ func Test_deploymentChanged(t *testing.T) {
 							Status: "thirsty-jemison",
 							Reason: "thirsty-jemison"}}}},
 			},
-			want: false,
+			want: true,
 		},
 		{
 			name: "when there are no init containers should return false",
@@ -1046,6 +1173,29 @@ func Test_deploymentChanged(t *testing.T) {
 			},
 			want: true,
 		},
+		{
+			name: "when only status and metadata other than labels and annotations are different should return false",
+			args: args{
+				a: &appsv1.Deployment{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:            "eloquent-hodgkin",
+						ResourceVersion: "1",
+						Generation:      1,
+						Labels:          map[string]string{"hodgkin": "eloquent"}},
+					Status: appsv1.DeploymentStatus{
+						Replicas: 1}},
+				b: &appsv1.Deployment{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:            "eloquent-hodgkin",
+						ResourceVersion: "2",
+						Generation:      2,
+						Labels:          map[string]string{"hodgkin": "eloquent"},
+						ManagedFields:   []metav1.ManagedFieldsEntry{{Manager: "eloquent-hodgkin"}}},
+					Status: appsv1.DeploymentStatus{
+						Replicas: 2}},
+			},
+			want: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
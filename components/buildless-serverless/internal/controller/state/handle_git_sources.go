@@ -4,25 +4,34 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/go-git/go-git/v5/plumbing/transport"
 	serverlessv1alpha2 "github.com/kyma-project/serverless/components/buildless-serverless/api/v1alpha2"
 	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/fsm"
 	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/git"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/git/hostkey"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/resources"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 )
 
 func sFnHandleGitSources(ctx context.Context, m *fsm.StateMachine) (fsm.StateFn, *ctrl.Result, error) {
-	if !m.State.Function.HasGitSources() {
+	f := deployedFunction(m)
+	if f.ResolvedSourceType() != "" {
+		return nextState(sFnResolveSources)
+	}
+	if !f.HasGitSources() {
 		return nextState(sFnConfigurationReady)
 	}
 
-	gitRepository := m.State.Function.Spec.Source.GitRepository
+	gitRepository := f.Spec.Source.GitRepository
 
-	if m.State.Function.HasGitAuth() {
-		gitAuth, err := git.NewGitAuth(ctx, m.Client, &m.State.Function)
+	if f.HasGitAuth() {
+		gitAuth, err := git.NewGitAuth(ctx, m.Client, f, m.FunctionConfig.GitRemote.KnownHosts)
 		if err != nil {
 			m.State.Function.UpdateCondition(
 				serverlessv1alpha2.ConditionConfigurationReady,
@@ -31,9 +40,48 @@ func sFnHandleGitSources(ctx context.Context, m *fsm.StateMachine) (fsm.StateFn,
 				fmt.Sprintf("Getting git authorization data failed: %s", err.Error()))
 			return stopWithError(err)
 		}
+		err = gitAuth.TrustOnFirstUse(ctx, gitRepository.URL)
+		if err != nil {
+			m.State.Function.UpdateCondition(
+				serverlessv1alpha2.ConditionConfigurationReady,
+				metav1.ConditionFalse,
+				serverlessv1alpha2.ConditionReasonSourceUpdateFailed,
+				prepareErrorMessage(gitRepository.URL, err))
+			return stopWithError(err)
+		}
+		if token := gitAuth.InstallationToken(); token != "" {
+			err = applyGitTokenSecret(ctx, m, resources.NewGitTokenSecret(&m.State.Function, token))
+			if err != nil {
+				m.State.Function.UpdateCondition(
+					serverlessv1alpha2.ConditionConfigurationReady,
+					metav1.ConditionFalse,
+					serverlessv1alpha2.ConditionReasonSourceUpdateFailed,
+					fmt.Sprintf("Storing git installation token failed: %s", err.Error()))
+				return stopWithError(err)
+			}
+		}
 		m.State.GitAuth = gitAuth
 	}
 
+	if m.State.Revision != nil {
+		// the function rolled back to the revision runs its recorded commit
+		commit := m.State.Revision.Commit()
+		if err := verifyGitSource(ctx, m, gitRepository.URL, commit); err != nil {
+			return stopWithVerificationError(m, gitRepository.URL, err)
+		}
+		m.State.Commit = commit
+		return nextState(sFnConfigurationReady)
+	}
+
+	current := currentGitSource(m)
+	if gitRepository.UpdatePolicy == serverlessv1alpha2.UpdatePolicyPinned && current != nil {
+		// the pinned function keeps running its commit, the new commits are not checked
+		if err := keepCurrentGitSource(ctx, m, current); err != nil {
+			return stopWithVerificationError(m, gitRepository.URL, err)
+		}
+		return nextState(sFnConfigurationReady)
+	}
+
 	orderID := string(m.State.Function.GetUID())
 	m.GitChecker.PlaceOrder(orderID, gitRepository.URL, gitRepository.Reference, m.State.GitAuth)
 
@@ -52,23 +100,199 @@ func sFnHandleGitSources(ctx context.Context, m *fsm.StateMachine) (fsm.StateFn,
 		return stopWithError(result.Error)
 	}
 
+	treeHash, changed := gitSourceChanged(ctx, m, current, result.Commit)
+	if !changed {
+		// the function's files did not change, the newest commit is recorded without rolling it out
+		if err := keepCurrentGitSource(ctx, m, current); err != nil {
+			return stopWithVerificationError(m, gitRepository.URL, err)
+		}
+		m.State.LatestCommit = result.Commit
+		return nextState(sFnConfigurationReady)
+	}
+
+	if gitRepository.UpdatePolicy == serverlessv1alpha2.UpdatePolicyManual && current != nil &&
+		current.RunningCommit() != result.Commit && m.State.Function.GetAnnotations()[serverlessv1alpha2.FunctionApprovedCommitAnnotation] != result.Commit {
+		// the new commit waits for the approval, the function keeps running its commit
+		if err := keepCurrentGitSource(ctx, m, current); err != nil {
+			return stopWithVerificationError(m, gitRepository.URL, err)
+		}
+		m.State.AvailableCommit = result.Commit
+		return nextState(sFnConfigurationReady)
+	}
+
+	if err := verifyGitSource(ctx, m, gitRepository.URL, result.Commit); err != nil {
+		return stopWithVerificationError(m, gitRepository.URL, err)
+	}
+
+	if err := validateGitSource(ctx, m, f, result.Commit); err != nil {
+		m.State.Function.UpdateCondition(
+			serverlessv1alpha2.ConditionConfigurationReady,
+			metav1.ConditionFalse,
+			serverlessv1alpha2.ConditionReasonSourceUpdateFailed,
+			fmt.Sprintf("Git repository: %s %s", gitRepository.URL, err.Error()))
+		return stopWithError(err)
+	}
+
 	if m.State.Function.Status.GitRepository == nil || m.State.Function.Status.GitRepository.Commit != result.Commit {
 		m.State.Function.UpdateCondition(
 			serverlessv1alpha2.ConditionConfigurationReady,
 			metav1.ConditionTrue,
 			serverlessv1alpha2.ConditionReasonSourceUpdated,
-			"Function source updated")
+			sourceUpdatedMessage(result))
 	}
 
 	m.State.Commit = result.Commit
+	m.State.ResolvedTag = result.Tag
+	m.State.TreeHash = treeHash
 
 	return nextState(sFnConfigurationReady)
 }
 
+// keepCurrentGitSource keeps the function running the commit it runs now
+// the running commit is verified again, the trusted keys may have changed since it was rolled out
+func keepCurrentGitSource(ctx context.Context, m *fsm.StateMachine, current *serverlessv1alpha2.GitRepositoryStatus) error {
+	if err := verifyGitSource(ctx, m, current.URL, current.RunningCommit()); err != nil {
+		return err
+	}
+	m.State.Commit = current.RunningCommit()
+	m.State.ResolvedTag = current.ResolvedTag
+	m.State.TreeHash = current.TreeHash
+	m.State.LatestCommit = current.Commit
+	return nil
+}
+
+// gitSourceChanged compares the trees of the function's base directory and watched paths in the running and the new commit
+// the function is rolled out whenever the trees can't be compared, e.g. the base directory is the repository's root
+func gitSourceChanged(ctx context.Context, m *fsm.StateMachine, current *serverlessv1alpha2.GitRepositoryStatus, commit string) (string, bool) {
+	gitRepository := m.State.Function.Spec.Source.GitRepository
+	paths := append([]string{gitRepository.BaseDir}, gitRepository.WatchPaths...)
+	if m.SourceTrees == nil || slices.ContainsFunc(paths, git.IsRootSourcePath) {
+		return "", true
+	}
+
+	ctx, cancel := gitOperationContext(ctx, m)
+	defer cancel()
+	treeHash, err := m.SourceTrees.Hash(ctx, gitRepository.URL, m.State.GitAuth, commit, paths)
+	if err != nil {
+		m.Log.Warnf("failed to compare source trees of commit %s: %s", commit, err)
+		return "", true
+	}
+
+	changed := current == nil || current.TreeHash != treeHash || current.RunningCommit() == commit
+	return treeHash, changed
+}
+
+// validateGitSource checks that the base directory of the commit contains the runtime's handler and dependencies files
+// the commit is rolled out when its tree can't be fetched, the missing files fail the function's pods then
+func validateGitSource(ctx context.Context, m *fsm.StateMachine, f *serverlessv1alpha2.Function, commit string) error {
+	runtimeConfig, ok := m.FunctionConfig.RuntimeConfig(string(f.Spec.Runtime))
+	if m.SourceTrees == nil || !ok {
+		return nil
+	}
+
+	gitRepository := f.Spec.Source.GitRepository
+	ctx, cancel := gitOperationContext(ctx, m)
+	defer cancel()
+	files, err := m.SourceTrees.Files(ctx, gitRepository.URL, m.State.GitAuth, commit, gitRepository.BaseDir)
+	if errors.Is(err, git.ErrDirectoryNotFound) {
+		return fmt.Errorf("base directory %s does not exist in commit %s", gitRepository.BaseDir, commit)
+	}
+	if err != nil {
+		m.Log.Warnf("failed to list base directory files of commit %s: %s", commit, err)
+		return nil
+	}
+
+	missing := []string{}
+	for _, file := range []string{runtimeConfig.HandlerFile, runtimeConfig.DependenciesFile} {
+		if file != "" && !slices.Contains(files, file) {
+			missing = append(missing, file)
+		}
+	}
+	if len(missing) != 0 {
+		return fmt.Errorf("base directory %s in commit %s does not contain %s", gitRepository.BaseDir, commit, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// verifyGitSource checks that the commit is signed by the keys trusted by the function or by the cluster's defaults
+// the keys trusted by the current function are used, even when it is rolled back to the revision
+func verifyGitSource(ctx context.Context, m *fsm.StateMachine, url, commit string) error {
+	secretName, ok := trustedKeysSecretName(m)
+	if !ok {
+		return nil
+	}
+	if m.CommitVerifier == nil {
+		return errors.New("commit verifier is not configured")
+	}
+
+	ctx, cancel := gitOperationContext(ctx, m)
+	defer cancel()
+	keys, err := git.LoadTrustedKeys(ctx, m.Client, secretName)
+	if err != nil {
+		return err
+	}
+	return m.CommitVerifier.Verify(ctx, url, m.State.GitAuth, commit, keys)
+}
+
+// trustedKeysSecretName returns the secret with the keys trusted by the function, or by the cluster's defaults if it doesn't define them
+func trustedKeysSecretName(m *fsm.StateMachine) (types.NamespacedName, bool) {
+	if verification := m.State.Function.Spec.Source.GitRepository.Verification; verification != nil {
+		return types.NamespacedName{Namespace: m.State.Function.GetNamespace(), Name: verification.SecretName}, true
+	}
+	defaults := m.FunctionConfig.GitRemote.Verification
+	if defaults.SecretName != "" {
+		return types.NamespacedName{Namespace: defaults.SecretNamespace, Name: defaults.SecretName}, true
+	}
+	return types.NamespacedName{}, false
+}
+
+func stopWithVerificationError(m *fsm.StateMachine, url string, err error) (fsm.StateFn, *ctrl.Result, error) {
+	m.State.Function.UpdateCondition(
+		serverlessv1alpha2.ConditionConfigurationReady,
+		metav1.ConditionFalse,
+		serverlessv1alpha2.ConditionReasonSourceVerificationFailed,
+		fmt.Sprintf("Git repository: %s source verification failed: %s", url, err.Error()))
+	return stopWithError(err)
+}
+
+// gitOperationContext limits the time of the operation on the git server
+func gitOperationContext(ctx context.Context, m *fsm.StateMachine) (context.Context, context.CancelFunc) {
+	if timeout := m.FunctionConfig.GitRemote.OperationTimeout; timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return ctx, func() {}
+}
+
+// currentGitSource returns the source the function is running, unless its URL or reference was changed since
+func currentGitSource(m *fsm.StateMachine) *serverlessv1alpha2.GitRepositoryStatus {
+	current := m.State.Function.Status.GitRepository
+	gitRepository := m.State.Function.Spec.Source.GitRepository
+	if current == nil || current.Commit == "" ||
+		current.URL != gitRepository.URL || current.Reference != gitRepository.Reference {
+		return nil
+	}
+	return current
+}
+
+func sourceUpdatedMessage(result *git.OrderResult) string {
+	if result.Tag != "" {
+		return fmt.Sprintf("Function source updated to tag %s", result.Tag)
+	}
+	return "Function source updated"
+}
+
 func prepareErrorMessage(repoUrl string, err error) string {
 	if errors.Is(err, transport.ErrAuthenticationRequired) {
 		return fmt.Sprintf("Authentication required for Git repository: %s ", repoUrl)
 	}
 
+	var hostKeyErr *hostkey.Error
+	if errors.As(err, &hostKeyErr) {
+		if hostKeyErr.Unknown {
+			return fmt.Sprintf("SSH host key of Git repository: %s is unknown, add it to the known hosts", repoUrl)
+		}
+		return fmt.Sprintf("SSH host key of Git repository: %s does not match the known hosts, the server may be impersonated or its key was changed", repoUrl)
+	}
+
 	return fmt.Sprintf("Git repository: %s source check failed: %s", repoUrl, err.Error())
 }
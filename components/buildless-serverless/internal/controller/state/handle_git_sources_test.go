@@ -4,13 +4,16 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
 	serverlessv1alpha2 "github.com/kyma-project/serverless/components/buildless-serverless/api/v1alpha2"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/config"
 	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/fsm"
 	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/git"
 	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/git/automock"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/git/hostkey"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
@@ -153,6 +156,54 @@ func Test_sFnHandleGitSources(t *testing.T) {
 		// commit change, it should be changed only for git functions
 		require.Equal(t, "latest-test-commit", m.State.Commit)
 	})
+	t.Run("for git function with semver reference resolved to the tag", func(t *testing.T) {
+		// Arrange
+		gitMock := new(automock.AsyncLatestCommitChecker)
+		gitMock.On("PlaceOrder", "any-UID", "test-url", "^1.4", mock.Anything).Return()
+		gitMock.On("CollectOrder", "any-UID").Return(&git.OrderResult{
+			Commit: "latest-test-commit",
+			Tag:    "v1.4.2",
+		})
+		m := fsm.StateMachine{
+			State: fsm.SystemState{
+				Function: serverlessv1alpha2.Function{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "nice-matsumoto-name",
+						Namespace: "festive-dewdney-ns",
+						UID:       "any-UID"},
+					Spec: serverlessv1alpha2.FunctionSpec{
+						Runtime: serverlessv1alpha2.NodeJs24,
+						Source: serverlessv1alpha2.Source{
+							GitRepository: &serverlessv1alpha2.GitRepositorySource{
+								URL: "test-url",
+								Repository: serverlessv1alpha2.Repository{
+									BaseDir:   "main",
+									Reference: "^1.4",
+								},
+							}}},
+					Status: serverlessv1alpha2.FunctionStatus{
+						GitRepository: &serverlessv1alpha2.GitRepositoryStatus{
+							Commit:      "test-commit",
+							ResolvedTag: "v1.4.1"}}}},
+			Log:        zap.NewNop().Sugar(),
+			GitChecker: gitMock,
+		}
+
+		// Act
+		next, result, err := sFnHandleGitSources(context.Background(), &m)
+
+		// Assert
+		require.Nil(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnConfigurationReady, next)
+		requireContainsCondition(t, m.State.Function.Status,
+			serverlessv1alpha2.ConditionConfigurationReady,
+			metav1.ConditionTrue,
+			serverlessv1alpha2.ConditionReasonSourceUpdated,
+			"Function source updated to tag v1.4.2")
+		require.Equal(t, "latest-test-commit", m.State.Commit)
+		require.Equal(t, "v1.4.2", m.State.ResolvedTag)
+	})
 	t.Run("for git function where the commit should be empty and stop with condition", func(t *testing.T) {
 		// Arrange
 		// machine with our function
@@ -348,3 +399,496 @@ func Test_sFnHandleGitSources(t *testing.T) {
 		require.Contains(t, string(authEnvs), "frosty-morse")
 	})
 }
+
+func Test_sFnHandleGitSources_UpdatePolicy(t *testing.T) {
+	fixMachine := func(policy serverlessv1alpha2.UpdatePolicy, annotations map[string]string, reference string, gitChecker git.AsyncLatestCommitChecker) *fsm.StateMachine {
+		return &fsm.StateMachine{
+			State: fsm.SystemState{
+				Function: serverlessv1alpha2.Function{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        "nice-matsumoto-name",
+						Namespace:   "festive-dewdney-ns",
+						UID:         "any-UID",
+						Annotations: annotations},
+					Spec: serverlessv1alpha2.FunctionSpec{
+						Runtime: serverlessv1alpha2.NodeJs24,
+						Source: serverlessv1alpha2.Source{
+							GitRepository: &serverlessv1alpha2.GitRepositorySource{
+								URL:          "test-url",
+								UpdatePolicy: policy,
+								Repository: serverlessv1alpha2.Repository{
+									BaseDir:   "main",
+									Reference: reference,
+								},
+							}}},
+					Status: serverlessv1alpha2.FunctionStatus{
+						GitRepository: &serverlessv1alpha2.GitRepositoryStatus{
+							URL: "test-url",
+							Repository: serverlessv1alpha2.Repository{
+								BaseDir:   "main",
+								Reference: "test-reference",
+							},
+							Commit:      "current-commit",
+							ResolvedTag: "v1.0.0"}}}},
+			Log:        zap.NewNop().Sugar(),
+			GitChecker: gitChecker,
+		}
+	}
+	fixGitChecker := func(reference string) *automock.AsyncLatestCommitChecker {
+		gitMock := new(automock.AsyncLatestCommitChecker)
+		gitMock.On("PlaceOrder", "any-UID", "test-url", reference, mock.Anything).Return()
+		gitMock.On("CollectOrder", "any-UID").Return(&git.OrderResult{Commit: "new-commit", Tag: "v1.1.0"})
+		return gitMock
+	}
+
+	t.Run("pinned function keeps running its commit without checking new commits", func(t *testing.T) {
+		gitMock := new(automock.AsyncLatestCommitChecker)
+		m := fixMachine(serverlessv1alpha2.UpdatePolicyPinned, nil, "test-reference", gitMock)
+
+		next, result, err := sFnHandleGitSources(context.Background(), m)
+
+		require.Nil(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnConfigurationReady, next)
+		require.Equal(t, "current-commit", m.State.Commit)
+		require.Equal(t, "v1.0.0", m.State.ResolvedTag)
+		gitMock.AssertNotCalled(t, "PlaceOrder", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+	t.Run("pinned function rolls out new commit when reference changes", func(t *testing.T) {
+		gitMock := fixGitChecker("other-reference")
+		m := fixMachine(serverlessv1alpha2.UpdatePolicyPinned, nil, "other-reference", gitMock)
+
+		next, _, err := sFnHandleGitSources(context.Background(), m)
+
+		require.Nil(t, err)
+		requireEqualFunc(t, sFnConfigurationReady, next)
+		require.Equal(t, "new-commit", m.State.Commit)
+	})
+	t.Run("manual function keeps running its commit until new commit is approved", func(t *testing.T) {
+		gitMock := fixGitChecker("test-reference")
+		m := fixMachine(serverlessv1alpha2.UpdatePolicyManual, map[string]string{
+			serverlessv1alpha2.FunctionApprovedCommitAnnotation: "current-commit",
+		}, "test-reference", gitMock)
+
+		next, _, err := sFnHandleGitSources(context.Background(), m)
+
+		require.Nil(t, err)
+		requireEqualFunc(t, sFnConfigurationReady, next)
+		require.Equal(t, "current-commit", m.State.Commit)
+		require.Equal(t, "v1.0.0", m.State.ResolvedTag)
+		require.Equal(t, "new-commit", m.State.AvailableCommit)
+	})
+	t.Run("manual function rolls out approved commit", func(t *testing.T) {
+		gitMock := fixGitChecker("test-reference")
+		m := fixMachine(serverlessv1alpha2.UpdatePolicyManual, map[string]string{
+			serverlessv1alpha2.FunctionApprovedCommitAnnotation: "new-commit",
+		}, "test-reference", gitMock)
+
+		next, _, err := sFnHandleGitSources(context.Background(), m)
+
+		require.Nil(t, err)
+		requireEqualFunc(t, sFnConfigurationReady, next)
+		require.Equal(t, "new-commit", m.State.Commit)
+		require.Equal(t, "v1.1.0", m.State.ResolvedTag)
+		require.Empty(t, m.State.AvailableCommit)
+	})
+	t.Run("manual function rolls out first commit without approval", func(t *testing.T) {
+		gitMock := fixGitChecker("test-reference")
+		m := fixMachine(serverlessv1alpha2.UpdatePolicyManual, nil, "test-reference", gitMock)
+		m.State.Function.Status.GitRepository = nil
+
+		next, _, err := sFnHandleGitSources(context.Background(), m)
+
+		require.Nil(t, err)
+		requireEqualFunc(t, sFnConfigurationReady, next)
+		require.Equal(t, "new-commit", m.State.Commit)
+		require.Empty(t, m.State.AvailableCommit)
+	})
+}
+
+func Test_sFnHandleGitSources_SourceTrees(t *testing.T) {
+	fixMachine := func(baseDir string, sourceTrees git.SourceTrees) *fsm.StateMachine {
+		gitMock := new(automock.AsyncLatestCommitChecker)
+		gitMock.On("PlaceOrder", "any-UID", "test-url", "main", mock.Anything).Return()
+		gitMock.On("CollectOrder", "any-UID").Return(&git.OrderResult{Commit: "new-commit"})
+		return &fsm.StateMachine{
+			State: fsm.SystemState{
+				Function: serverlessv1alpha2.Function{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "nice-matsumoto-name",
+						Namespace: "festive-dewdney-ns",
+						UID:       "any-UID"},
+					Spec: serverlessv1alpha2.FunctionSpec{
+						Runtime: serverlessv1alpha2.NodeJs24,
+						Source: serverlessv1alpha2.Source{
+							GitRepository: &serverlessv1alpha2.GitRepositorySource{
+								URL:        "test-url",
+								WatchPaths: []string{"lib"},
+								Repository: serverlessv1alpha2.Repository{
+									BaseDir:   baseDir,
+									Reference: "main",
+								},
+							}}},
+					Status: serverlessv1alpha2.FunctionStatus{
+						GitRepository: &serverlessv1alpha2.GitRepositoryStatus{
+							URL: "test-url",
+							Repository: serverlessv1alpha2.Repository{
+								BaseDir:   baseDir,
+								Reference: "main",
+							},
+							Commit:         "skipped-commit",
+							DeployedCommit: "running-commit",
+							TreeHash:       "running-tree"}}}},
+			Log:         zap.NewNop().Sugar(),
+			GitChecker:  gitMock,
+			SourceTrees: sourceTrees,
+		}
+	}
+
+	t.Run("keep running commit when function's files did not change", func(t *testing.T) {
+		trees := &fakeSourceTrees{hashes: map[string]string{"new-commit": "running-tree"}}
+		m := fixMachine("functions/a", trees)
+
+		next, _, err := sFnHandleGitSources(context.Background(), m)
+
+		require.Nil(t, err)
+		requireEqualFunc(t, sFnConfigurationReady, next)
+		require.Equal(t, "running-commit", m.State.Commit)
+		require.Equal(t, "new-commit", m.State.LatestCommit)
+		require.Equal(t, "running-tree", m.State.TreeHash)
+		require.Equal(t, []string{"functions/a", "lib"}, trees.paths)
+	})
+	t.Run("roll out new commit when function's files changed", func(t *testing.T) {
+		m := fixMachine("functions/a", &fakeSourceTrees{hashes: map[string]string{"new-commit": "new-tree"}})
+
+		next, _, err := sFnHandleGitSources(context.Background(), m)
+
+		require.Nil(t, err)
+		requireEqualFunc(t, sFnConfigurationReady, next)
+		require.Equal(t, "new-commit", m.State.Commit)
+		require.Empty(t, m.State.LatestCommit)
+		require.Equal(t, "new-tree", m.State.TreeHash)
+	})
+	t.Run("roll out new commit when trees can't be compared", func(t *testing.T) {
+		m := fixMachine("functions/a", &fakeSourceTrees{err: git.ErrPartialFetchNotSupported})
+
+		next, _, err := sFnHandleGitSources(context.Background(), m)
+
+		require.Nil(t, err)
+		requireEqualFunc(t, sFnConfigurationReady, next)
+		require.Equal(t, "new-commit", m.State.Commit)
+		require.Empty(t, m.State.TreeHash)
+	})
+	t.Run("roll out new commit of function in repository root", func(t *testing.T) {
+		trees := &fakeSourceTrees{}
+		m := fixMachine("/", trees)
+
+		next, _, err := sFnHandleGitSources(context.Background(), m)
+
+		require.Nil(t, err)
+		requireEqualFunc(t, sFnConfigurationReady, next)
+		require.Equal(t, "new-commit", m.State.Commit)
+		require.Nil(t, trees.paths)
+	})
+}
+
+func Test_sFnHandleGitSources_BaseDir(t *testing.T) {
+	fixMachine := func(runtime serverlessv1alpha2.Runtime, sourceTrees git.SourceTrees) *fsm.StateMachine {
+		gitMock := new(automock.AsyncLatestCommitChecker)
+		gitMock.On("PlaceOrder", "any-UID", "test-url", "main", mock.Anything).Return()
+		gitMock.On("CollectOrder", "any-UID").Return(&git.OrderResult{Commit: "new-commit"})
+		return &fsm.StateMachine{
+			State: fsm.SystemState{
+				Function: serverlessv1alpha2.Function{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "nice-matsumoto-name",
+						Namespace: "festive-dewdney-ns",
+						UID:       "any-UID"},
+					Spec: serverlessv1alpha2.FunctionSpec{
+						Runtime: runtime,
+						Source: serverlessv1alpha2.Source{
+							GitRepository: &serverlessv1alpha2.GitRepositorySource{
+								URL: "test-url",
+								Repository: serverlessv1alpha2.Repository{
+									BaseDir:   "functions/orders",
+									Reference: "main",
+								},
+							}}}}},
+			Log:         zap.NewNop().Sugar(),
+			GitChecker:  gitMock,
+			SourceTrees: sourceTrees,
+		}
+	}
+
+	t.Run("roll out commit with handler and dependencies", func(t *testing.T) {
+		m := fixMachine(serverlessv1alpha2.Python312, &fakeSourceTrees{files: []string{"handler.py", "requirements.txt", "README.md"}})
+
+		next, _, err := sFnHandleGitSources(context.Background(), m)
+
+		require.Nil(t, err)
+		requireEqualFunc(t, sFnConfigurationReady, next)
+		require.Equal(t, "new-commit", m.State.Commit)
+	})
+	t.Run("stop when base directory does not exist", func(t *testing.T) {
+		m := fixMachine(serverlessv1alpha2.NodeJs24, &fakeSourceTrees{missingDir: true})
+
+		next, _, err := sFnHandleGitSources(context.Background(), m)
+
+		require.ErrorContains(t, err, "base directory functions/orders does not exist in commit new-commit")
+		require.Nil(t, next)
+		require.Empty(t, m.State.Commit)
+		requireContainsCondition(t, m.State.Function.Status,
+			serverlessv1alpha2.ConditionConfigurationReady,
+			metav1.ConditionFalse,
+			serverlessv1alpha2.ConditionReasonSourceUpdateFailed,
+			"Git repository: test-url base directory functions/orders does not exist in commit new-commit")
+	})
+	t.Run("stop when runtime's files are missing", func(t *testing.T) {
+		m := fixMachine(serverlessv1alpha2.NodeJs24, &fakeSourceTrees{files: []string{"index.js", "package.json"}})
+
+		next, _, err := sFnHandleGitSources(context.Background(), m)
+
+		require.Nil(t, next)
+		require.EqualError(t, err, "base directory functions/orders in commit new-commit does not contain handler.js")
+		requireContainsCondition(t, m.State.Function.Status,
+			serverlessv1alpha2.ConditionConfigurationReady,
+			metav1.ConditionFalse,
+			serverlessv1alpha2.ConditionReasonSourceUpdateFailed,
+			"Git repository: test-url base directory functions/orders in commit new-commit does not contain handler.js")
+	})
+	t.Run("roll out commit when files can't be listed", func(t *testing.T) {
+		m := fixMachine(serverlessv1alpha2.NodeJs24, &fakeSourceTrees{})
+
+		next, _, err := sFnHandleGitSources(context.Background(), m)
+
+		require.Nil(t, err)
+		requireEqualFunc(t, sFnConfigurationReady, next)
+		require.Equal(t, "new-commit", m.State.Commit)
+	})
+}
+
+type fakeSourceTrees struct {
+	hashes map[string]string
+	err    error
+	paths  []string
+	// files are the base directory's files, they can't be listed if nil
+	files      []string
+	missingDir bool
+}
+
+func (f *fakeSourceTrees) Hash(_ context.Context, _ string, _ *git.GitAuth, commit string, paths []string) (string, error) {
+	f.paths = paths
+	return f.hashes[commit], f.err
+}
+
+func (f *fakeSourceTrees) Files(_ context.Context, _ string, _ *git.GitAuth, _ string, _ string) ([]string, error) {
+	if f.missingDir {
+		return nil, git.ErrDirectoryNotFound
+	}
+	if f.files == nil {
+		return nil, git.ErrPartialFetchNotSupported
+	}
+	return f.files, nil
+}
+
+func Test_sFnHandleGitSources_Verification(t *testing.T) {
+	const trustedKey = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAII9Tnl5UCl+o3x0RocyNFHBx4HAHF5rQ9GcGM67KUWLf"
+	fixMachine := func(verification *serverlessv1alpha2.RepositoryVerification, verifier git.CommitVerifier) *fsm.StateMachine {
+		scheme := runtime.NewScheme()
+		require.NoError(t, corev1.AddToScheme(scheme))
+		k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "trusted-keys", Namespace: "festive-dewdney-ns"},
+				Data:       map[string][]byte{"ssh": []byte(trustedKey)},
+			},
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "default-trusted-keys", Namespace: "kyma-system"},
+				Data:       map[string][]byte{"ssh": []byte(trustedKey)},
+			},
+		).Build()
+		gitMock := new(automock.AsyncLatestCommitChecker)
+		gitMock.On("PlaceOrder", "any-UID", "test-url", "main", mock.Anything).Return()
+		gitMock.On("CollectOrder", "any-UID").Return(&git.OrderResult{Commit: "new-commit"})
+		return &fsm.StateMachine{
+			State: fsm.SystemState{
+				Function: serverlessv1alpha2.Function{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "nice-matsumoto-name",
+						Namespace: "festive-dewdney-ns",
+						UID:       "any-UID"},
+					Spec: serverlessv1alpha2.FunctionSpec{
+						Runtime: serverlessv1alpha2.NodeJs24,
+						Source: serverlessv1alpha2.Source{
+							GitRepository: &serverlessv1alpha2.GitRepositorySource{
+								URL:          "test-url",
+								Verification: verification,
+								Repository: serverlessv1alpha2.Repository{
+									BaseDir:   "/",
+									Reference: "main",
+								},
+							}}}}},
+			Log:            zap.NewNop().Sugar(),
+			Client:         k8sClient,
+			GitChecker:     gitMock,
+			CommitVerifier: verifier,
+		}
+	}
+
+	t.Run("roll out commit signed by trusted key", func(t *testing.T) {
+		verifier := &fakeCommitVerifier{}
+		m := fixMachine(&serverlessv1alpha2.RepositoryVerification{SecretName: "trusted-keys"}, verifier)
+
+		next, _, err := sFnHandleGitSources(context.Background(), m)
+
+		require.Nil(t, err)
+		requireEqualFunc(t, sFnConfigurationReady, next)
+		require.Equal(t, "new-commit", m.State.Commit)
+		require.Equal(t, []string{"new-commit"}, verifier.commits)
+	})
+	t.Run("stop when commit is not signed by trusted key", func(t *testing.T) {
+		verifier := &fakeCommitVerifier{err: &git.VerificationError{Commit: "new-commit", Reason: "is not signed"}}
+		m := fixMachine(&serverlessv1alpha2.RepositoryVerification{SecretName: "trusted-keys"}, verifier)
+
+		next, result, err := sFnHandleGitSources(context.Background(), m)
+
+		require.ErrorContains(t, err, "commit new-commit is not signed")
+		require.Nil(t, next)
+		require.Nil(t, result)
+		require.Empty(t, m.State.Commit)
+		requireContainsCondition(t, m.State.Function.Status,
+			serverlessv1alpha2.ConditionConfigurationReady,
+			metav1.ConditionFalse,
+			serverlessv1alpha2.ConditionReasonSourceVerificationFailed,
+			"Git repository: test-url source verification failed: commit new-commit is not signed")
+	})
+	t.Run("stop when trusted keys secret is missing", func(t *testing.T) {
+		verifier := &fakeCommitVerifier{}
+		m := fixMachine(&serverlessv1alpha2.RepositoryVerification{SecretName: "missing-keys"}, verifier)
+
+		_, _, err := sFnHandleGitSources(context.Background(), m)
+
+		require.ErrorContains(t, err, "while getting trusted keys secret festive-dewdney-ns/missing-keys")
+		require.Empty(t, verifier.commits)
+		require.Empty(t, m.State.Commit)
+	})
+	t.Run("verify commit with cluster's default trusted keys", func(t *testing.T) {
+		verifier := &fakeCommitVerifier{}
+		m := fixMachine(nil, verifier)
+		m.FunctionConfig.GitRemote.Verification = config.GitVerificationConfig{
+			SecretName:      "default-trusted-keys",
+			SecretNamespace: "kyma-system",
+		}
+
+		_, _, err := sFnHandleGitSources(context.Background(), m)
+
+		require.Nil(t, err)
+		require.Equal(t, []string{"new-commit"}, verifier.commits)
+	})
+	t.Run("verify running commit of pinned function", func(t *testing.T) {
+		verifier := &fakeCommitVerifier{}
+		m := fixMachine(&serverlessv1alpha2.RepositoryVerification{SecretName: "trusted-keys"}, verifier)
+		m.State.Function.Spec.Source.GitRepository.UpdatePolicy = serverlessv1alpha2.UpdatePolicyPinned
+		m.State.Function.Status.GitRepository = &serverlessv1alpha2.GitRepositoryStatus{
+			URL:        "test-url",
+			Repository: serverlessv1alpha2.Repository{BaseDir: "/", Reference: "main"},
+			Commit:     "running-commit",
+		}
+
+		next, _, err := sFnHandleGitSources(context.Background(), m)
+
+		require.Nil(t, err)
+		requireEqualFunc(t, sFnConfigurationReady, next)
+		require.Equal(t, "running-commit", m.State.Commit)
+		require.Equal(t, []string{"running-commit"}, verifier.commits)
+	})
+	t.Run("stop when running commit of pinned function is not signed by trusted key", func(t *testing.T) {
+		verifier := &fakeCommitVerifier{err: &git.VerificationError{Commit: "running-commit", Reason: "is not signed"}}
+		m := fixMachine(&serverlessv1alpha2.RepositoryVerification{SecretName: "trusted-keys"}, verifier)
+		m.State.Function.Spec.Source.GitRepository.UpdatePolicy = serverlessv1alpha2.UpdatePolicyPinned
+		m.State.Function.Status.GitRepository = &serverlessv1alpha2.GitRepositoryStatus{
+			URL:        "test-url",
+			Repository: serverlessv1alpha2.Repository{BaseDir: "/", Reference: "main"},
+			Commit:     "running-commit",
+		}
+
+		next, result, err := sFnHandleGitSources(context.Background(), m)
+
+		require.ErrorContains(t, err, "commit running-commit is not signed")
+		require.Nil(t, next)
+		require.Nil(t, result)
+		require.Empty(t, m.State.Commit)
+		requireContainsCondition(t, m.State.Function.Status,
+			serverlessv1alpha2.ConditionConfigurationReady,
+			metav1.ConditionFalse,
+			serverlessv1alpha2.ConditionReasonSourceVerificationFailed,
+			"Git repository: test-url source verification failed: commit running-commit is not signed")
+	})
+	t.Run("verify running commit when new commit waits for approval", func(t *testing.T) {
+		verifier := &fakeCommitVerifier{}
+		m := fixMachine(&serverlessv1alpha2.RepositoryVerification{SecretName: "trusted-keys"}, verifier)
+		m.State.Function.Spec.Source.GitRepository.UpdatePolicy = serverlessv1alpha2.UpdatePolicyManual
+		m.State.Function.Status.GitRepository = &serverlessv1alpha2.GitRepositoryStatus{
+			URL:        "test-url",
+			Repository: serverlessv1alpha2.Repository{BaseDir: "/", Reference: "main"},
+			Commit:     "running-commit",
+		}
+		m.SourceTrees = &fakeSourceTrees{hashes: map[string]string{"running-commit": "old-tree", "new-commit": "new-tree"}}
+
+		next, _, err := sFnHandleGitSources(context.Background(), m)
+
+		require.Nil(t, err)
+		requireEqualFunc(t, sFnConfigurationReady, next)
+		require.Equal(t, "running-commit", m.State.Commit)
+		require.Equal(t, "new-commit", m.State.AvailableCommit)
+		require.Equal(t, []string{"running-commit"}, verifier.commits)
+	})
+	t.Run("do not verify commit when no keys are trusted", func(t *testing.T) {
+		verifier := &fakeCommitVerifier{}
+		m := fixMachine(nil, verifier)
+
+		_, _, err := sFnHandleGitSources(context.Background(), m)
+
+		require.Nil(t, err)
+		require.Equal(t, "new-commit", m.State.Commit)
+		require.Empty(t, verifier.commits)
+	})
+}
+
+type fakeCommitVerifier struct {
+	err     error
+	commits []string
+}
+
+func (f *fakeCommitVerifier) Verify(_ context.Context, _ string, _ *git.GitAuth, commit string, _ *git.TrustedKeys) error {
+	f.commits = append(f.commits, commit)
+	return f.err
+}
+
+func Test_prepareErrorMessage(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{
+			name: "unknown host key",
+			err:  fmt.Errorf("ssh: handshake failed: %w", &hostkey.Error{Host: "github.com", Unknown: true}),
+			want: "SSH host key of Git repository: git@github.com:org/repo.git is unknown, add it to the known hosts",
+		},
+		{
+			name: "changed host key",
+			err:  fmt.Errorf("ssh: handshake failed: %w", &hostkey.Error{Host: "github.com"}),
+			want: "SSH host key of Git repository: git@github.com:org/repo.git does not match the known hosts, the server may be impersonated or its key was changed",
+		},
+		{
+			name: "other error",
+			err:  errors.New("connection refused"),
+			want: "Git repository: git@github.com:org/repo.git source check failed: connection refused",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, prepareErrorMessage("git@github.com:org/repo.git", tt.err))
+		})
+	}
+}
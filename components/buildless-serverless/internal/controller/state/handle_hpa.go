@@ -0,0 +1,188 @@
+package state
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	serverlessv1alpha2 "github.com/kyma-project/serverless/components/buildless-serverless/api/v1alpha2"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/fsm"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/resources"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+func sFnHandleHPA(ctx context.Context, m *fsm.StateMachine) (fsm.StateFn, *ctrl.Result, error) {
+	clusterHPA, errGet := getHPA(ctx, m)
+	if errGet != nil {
+		return stopWithError(errGet)
+	}
+
+	// idle function is scaled to zero and will be scaled up by the activator, not by the HPA
+	if m.State.Function.Spec.ScaleConfig == nil || m.State.ScaledToZero {
+		if clusterHPA == nil || !metav1.IsControlledBy(clusterHPA, &m.State.Function) {
+			// the failure of the HPA the function doesn't need anymore is not reported
+			if condition := m.State.Function.Status.Condition(serverlessv1alpha2.ConditionScalingReady); condition != nil && condition.Status == metav1.ConditionFalse {
+				m.State.Function.RemoveCondition(serverlessv1alpha2.ConditionScalingReady)
+			}
+			return nextState(sFnDeploymentStatus)
+		}
+		// the function is not scaled by the HPA anymore, garbage-collect it
+		result, errDelete := deleteHPA(ctx, m, clusterHPA)
+		return nil, result, errDelete
+	}
+
+	if clusterHPA != nil && !metav1.IsControlledBy(clusterHPA, &m.State.Function) {
+		// HPA with the same name is managed by someone else, do not touch it
+		m.Log.Infof("HorizontalPodAutoscaler %s is not controlled by Function", clusterHPA.GetName())
+		m.State.Function.UpdateCondition(
+			serverlessv1alpha2.ConditionScalingReady,
+			metav1.ConditionFalse,
+			serverlessv1alpha2.ConditionReasonHPAFailed,
+			fmt.Sprintf("HorizontalPodAutoscaler %s already exists and is not controlled by the Function, delete or rename it", clusterHPA.GetName()))
+		// the function runs without scaling, its deployment is still reported by the Running condition
+		return nextState(sFnDeploymentStatus)
+	}
+
+	builtHPA := resources.NewHPA(&m.State.Function, &m.FunctionConfig).HorizontalPodAutoscaler
+
+	if clusterHPA == nil {
+		result, errCreate := createHPA(ctx, m, builtHPA)
+		return nil, result, errCreate
+	}
+
+	requeueNeeded, errUpdate := updateHPAIfNeeded(ctx, m, clusterHPA, builtHPA)
+	if errUpdate != nil {
+		return stopWithError(errUpdate)
+	}
+	if requeueNeeded {
+		return requeueAfter(time.Second)
+	}
+	if condition := m.State.Function.Status.Condition(serverlessv1alpha2.ConditionScalingReady); condition == nil || condition.Status != metav1.ConditionTrue {
+		m.State.Function.UpdateCondition(
+			serverlessv1alpha2.ConditionScalingReady,
+			metav1.ConditionTrue,
+			serverlessv1alpha2.ConditionReasonHPAReady,
+			fmt.Sprintf("HorizontalPodAutoscaler %s is ready", clusterHPA.GetName()))
+	}
+	return nextState(sFnDeploymentStatus)
+}
+
+// getHPA returns the HPA with the function's name or nil if it does not exist
+func getHPA(ctx context.Context, m *fsm.StateMachine) (*autoscalingv2.HorizontalPodAutoscaler, error) {
+	hpa := &autoscalingv2.HorizontalPodAutoscaler{}
+	f := m.State.Function
+	err := m.Client.Get(ctx, client.ObjectKey{
+		Namespace: f.GetNamespace(),
+		Name:      f.GetName(),
+	}, hpa)
+
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		m.Log.Error(err, "unable to fetch HorizontalPodAutoscaler for Function")
+		return nil, err
+	}
+	return hpa, nil
+}
+
+func createHPA(ctx context.Context, m *fsm.StateMachine, hpa *autoscalingv2.HorizontalPodAutoscaler) (*ctrl.Result, error) {
+	m.Log.Info("creating a new HorizontalPodAutoscaler", "HorizontalPodAutoscaler.Namespace", hpa.GetNamespace(), "HorizontalPodAutoscaler.Name", hpa.GetName())
+
+	// Set the ownerRef for the HPA, ensuring that the HPA
+	// will be deleted when the Function CR is deleted.
+	if err := controllerutil.SetControllerReference(&m.State.Function, hpa, m.Scheme); err != nil {
+		m.Log.Error(err, "failed to set controller reference for new HorizontalPodAutoscaler", "HorizontalPodAutoscaler.Namespace", hpa.GetNamespace(), "HorizontalPodAutoscaler.Name", hpa.GetName())
+		m.State.Function.UpdateCondition(
+			serverlessv1alpha2.ConditionScalingReady,
+			metav1.ConditionFalse,
+			serverlessv1alpha2.ConditionReasonHPAFailed,
+			fmt.Sprintf("HorizontalPodAutoscaler %s create failed: %s", hpa.GetName(), err.Error()))
+		return nil, err
+	}
+
+	if err := m.Client.Create(ctx, hpa); err != nil {
+		m.Log.Error(err, "failed to create new HorizontalPodAutoscaler", "HorizontalPodAutoscaler.Namespace", hpa.GetNamespace(), "HorizontalPodAutoscaler.Name", hpa.GetName())
+		m.State.Function.UpdateCondition(
+			serverlessv1alpha2.ConditionScalingReady,
+			metav1.ConditionFalse,
+			serverlessv1alpha2.ConditionReasonHPAFailed,
+			fmt.Sprintf("HorizontalPodAutoscaler %s create failed: %s", hpa.GetName(), err.Error()))
+		return nil, err
+	}
+	m.State.Function.UpdateCondition(
+		serverlessv1alpha2.ConditionScalingReady,
+		metav1.ConditionTrue,
+		serverlessv1alpha2.ConditionReasonHPACreated,
+		fmt.Sprintf("HorizontalPodAutoscaler %s created", hpa.GetName()))
+
+	return &ctrl.Result{RequeueAfter: time.Second}, nil
+}
+
+func updateHPAIfNeeded(ctx context.Context, m *fsm.StateMachine, clusterHPA *autoscalingv2.HorizontalPodAutoscaler, builtHPA *autoscalingv2.HorizontalPodAutoscaler) (requeueNeeded bool, err error) {
+	// Ensure the HPA data matches the desired state
+	if !hpaChanged(clusterHPA, builtHPA) {
+		return false, nil
+	}
+
+	clusterHPA.Spec.ScaleTargetRef = builtHPA.Spec.ScaleTargetRef
+	clusterHPA.Spec.MinReplicas = builtHPA.Spec.MinReplicas
+	clusterHPA.Spec.MaxReplicas = builtHPA.Spec.MaxReplicas
+	clusterHPA.Spec.Metrics = builtHPA.Spec.Metrics
+	clusterHPA.ObjectMeta.Labels = builtHPA.GetLabels()
+	return updateHPA(ctx, m, clusterHPA)
+}
+
+func hpaChanged(a *autoscalingv2.HorizontalPodAutoscaler, b *autoscalingv2.HorizontalPodAutoscaler) bool {
+	return !mapsEqual(a.Labels, b.Labels) ||
+		!equality.Semantic.DeepEqual(a.Spec.ScaleTargetRef, b.Spec.ScaleTargetRef) ||
+		!equality.Semantic.DeepEqual(a.Spec.MinReplicas, b.Spec.MinReplicas) ||
+		a.Spec.MaxReplicas != b.Spec.MaxReplicas ||
+		!equality.Semantic.DeepEqual(a.Spec.Metrics, b.Spec.Metrics)
+}
+
+func updateHPA(ctx context.Context, m *fsm.StateMachine, clusterHPA *autoscalingv2.HorizontalPodAutoscaler) (requeueNeeded bool, err error) {
+	if err := m.Client.Update(ctx, clusterHPA); err != nil {
+		m.Log.Error(err, "Failed to update HorizontalPodAutoscaler", "HorizontalPodAutoscaler.Namespace", clusterHPA.GetNamespace(), "HorizontalPodAutoscaler.Name", clusterHPA.GetName())
+		m.State.Function.UpdateCondition(
+			serverlessv1alpha2.ConditionScalingReady,
+			metav1.ConditionFalse,
+			serverlessv1alpha2.ConditionReasonHPAFailed,
+			fmt.Sprintf("HorizontalPodAutoscaler %s update failed: %s", clusterHPA.GetName(), err.Error()))
+		return false, err
+	}
+	m.State.Function.UpdateCondition(
+		serverlessv1alpha2.ConditionScalingReady,
+		metav1.ConditionTrue,
+		serverlessv1alpha2.ConditionReasonHPAUpdated,
+		fmt.Sprintf("HorizontalPodAutoscaler %s updated", clusterHPA.GetName()))
+	// Requeue the request to ensure the HPA is updated
+	return true, nil
+}
+
+func deleteHPA(ctx context.Context, m *fsm.StateMachine, clusterHPA *autoscalingv2.HorizontalPodAutoscaler) (*ctrl.Result, error) {
+	m.Log.Info("deleting HorizontalPodAutoscaler", "HorizontalPodAutoscaler.Namespace", clusterHPA.GetNamespace(), "HorizontalPodAutoscaler.Name", clusterHPA.GetName())
+
+	if err := m.Client.Delete(ctx, clusterHPA); client.IgnoreNotFound(err) != nil {
+		m.Log.Error(err, "Failed to delete HorizontalPodAutoscaler", "HorizontalPodAutoscaler.Namespace", clusterHPA.GetNamespace(), "HorizontalPodAutoscaler.Name", clusterHPA.GetName())
+		m.State.Function.UpdateCondition(
+			serverlessv1alpha2.ConditionScalingReady,
+			metav1.ConditionFalse,
+			serverlessv1alpha2.ConditionReasonHPAFailed,
+			fmt.Sprintf("HorizontalPodAutoscaler %s delete failed: %s", clusterHPA.GetName(), err.Error()))
+		return nil, err
+	}
+	m.State.Function.UpdateCondition(
+		serverlessv1alpha2.ConditionScalingReady,
+		metav1.ConditionTrue,
+		serverlessv1alpha2.ConditionReasonHPADeleted,
+		fmt.Sprintf("HorizontalPodAutoscaler %s deleted", clusterHPA.GetName()))
+
+	return &ctrl.Result{RequeueAfter: time.Second}, nil
+}
@@ -0,0 +1,434 @@
+package state
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	serverlessv1alpha2 "github.com/kyma-project/serverless/components/buildless-serverless/api/v1alpha2"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/config"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/fsm"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/resources"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+func Test_sFnHandleHPA(t *testing.T) {
+	t.Run("when scale config is not set and hpa does not exist should go to the next state", func(t *testing.T) {
+		// Arrange
+		scheme := minimalHPAScheme(t)
+		k8sClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+		m := fsm.StateMachine{
+			State: fsm.SystemState{
+				Function: serverlessv1alpha2.Function{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "amazing-allen-name",
+						Namespace: "awesome-archimedes-ns",
+						UID:       "amazing-allen-uid"}}},
+			Log:    zap.NewNop().Sugar(),
+			Client: k8sClient,
+			Scheme: scheme}
+
+		// Act
+		next, result, err := sFnHandleHPA(context.Background(), &m)
+
+		// Assert
+		require.Nil(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnDeploymentStatus, next)
+		require.Empty(t, m.State.Function.Status.Conditions)
+	})
+	t.Run("when scale config is set and hpa does not exist should create hpa and requeue", func(t *testing.T) {
+		// Arrange
+		scheme := minimalHPAScheme(t)
+		k8sClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+		m := fsm.StateMachine{
+			State: fsm.SystemState{
+				Function: serverlessv1alpha2.Function{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "bold-bell-name",
+						Namespace: "blissful-bohr-ns",
+						UID:       "bold-bell-uid"},
+					Spec: serverlessv1alpha2.FunctionSpec{
+						ScaleConfig: &serverlessv1alpha2.ScaleConfig{
+							MinReplicas: ptr.To[int32](2),
+							MaxReplicas: ptr.To[int32](5),
+						}}}},
+			Log:            zap.NewNop().Sugar(),
+			Client:         k8sClient,
+			Scheme:         scheme,
+			FunctionConfig: config.FunctionConfig{TargetCPUUtilizationPercentage: 63}}
+
+		// Act
+		next, result, err := sFnHandleHPA(context.Background(), &m)
+
+		// Assert
+		require.Nil(t, err)
+		require.Nil(t, next)
+		require.NotNil(t, result)
+		require.Equal(t, ctrl.Result{RequeueAfter: time.Second}, *result)
+		requireContainsCondition(t, m.State.Function.Status,
+			serverlessv1alpha2.ConditionScalingReady,
+			metav1.ConditionTrue,
+			serverlessv1alpha2.ConditionReasonHPACreated,
+			"HorizontalPodAutoscaler bold-bell-name created")
+		appliedHPA := &autoscalingv2.HorizontalPodAutoscaler{}
+		getErr := k8sClient.Get(context.Background(), client.ObjectKey{
+			Name:      "bold-bell-name",
+			Namespace: "blissful-bohr-ns",
+		}, appliedHPA)
+		require.NoError(t, getErr)
+		require.Equal(t, ptr.To[int32](2), appliedHPA.Spec.MinReplicas)
+		require.Equal(t, int32(5), appliedHPA.Spec.MaxReplicas)
+		require.Equal(t, ptr.To[int32](63), appliedHPA.Spec.Metrics[0].Resource.Target.AverageUtilization)
+		require.NotEmpty(t, appliedHPA.OwnerReferences)
+		require.Equal(t, "Function", appliedHPA.OwnerReferences[0].Kind)
+		require.Equal(t, "bold-bell-name", appliedHPA.OwnerReferences[0].Name)
+	})
+	t.Run("when hpa create fails should stop processing", func(t *testing.T) {
+		// Arrange
+		scheme := minimalHPAScheme(t)
+		k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithInterceptorFuncs(interceptor.Funcs{
+			Create: func(ctx context.Context, client client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+				return errors.New("clever-curie error message")
+			},
+		}).Build()
+		m := fsm.StateMachine{
+			State: fsm.SystemState{
+				Function: serverlessv1alpha2.Function{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "cool-cray-name",
+						Namespace: "charming-chaum-ns",
+						UID:       "cool-cray-uid"},
+					Spec: serverlessv1alpha2.FunctionSpec{
+						ScaleConfig: &serverlessv1alpha2.ScaleConfig{
+							MinReplicas: ptr.To[int32](1),
+							MaxReplicas: ptr.To[int32](3),
+						}}}},
+			Log:    zap.NewNop().Sugar(),
+			Client: k8sClient,
+			Scheme: scheme}
+
+		// Act
+		next, result, err := sFnHandleHPA(context.Background(), &m)
+
+		// Assert
+		require.ErrorContains(t, err, "clever-curie error message")
+		require.Nil(t, result)
+		require.Nil(t, next)
+		requireContainsCondition(t, m.State.Function.Status,
+			serverlessv1alpha2.ConditionScalingReady,
+			metav1.ConditionFalse,
+			serverlessv1alpha2.ConditionReasonHPAFailed,
+			"HorizontalPodAutoscaler cool-cray-name create failed: clever-curie error message")
+	})
+	t.Run("when hpa exists and we do not need changes should go to the next state", func(t *testing.T) {
+		// Arrange
+		scheme := minimalHPAScheme(t)
+		f := serverlessv1alpha2.Function{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "dazzling-darwin-name",
+				Namespace: "determined-dijkstra-ns",
+				UID:       "dazzling-darwin-uid"},
+			Spec: serverlessv1alpha2.FunctionSpec{
+				ScaleConfig: &serverlessv1alpha2.ScaleConfig{
+					MinReplicas: ptr.To[int32](1),
+					MaxReplicas: ptr.To[int32](3),
+				}}}
+		fnConfig := config.FunctionConfig{TargetCPUUtilizationPercentage: 50}
+		hpa := resources.NewHPA(&f, &fnConfig).HorizontalPodAutoscaler
+		require.NoError(t, controllerutil.SetControllerReference(&f, hpa, scheme))
+		updateWasCalled := false
+		k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(hpa).WithInterceptorFuncs(interceptor.Funcs{
+			Update: func(ctx context.Context, client client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+				updateWasCalled = true
+				return nil
+			},
+		}).Build()
+		m := fsm.StateMachine{
+			State: fsm.SystemState{
+				Function: f},
+			Log:            zap.NewNop().Sugar(),
+			Client:         k8sClient,
+			Scheme:         scheme,
+			FunctionConfig: fnConfig}
+
+		// Act
+		next, result, err := sFnHandleHPA(context.Background(), &m)
+
+		// Assert
+		require.Nil(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnDeploymentStatus, next)
+		require.False(t, updateWasCalled)
+		requireContainsCondition(t, m.State.Function.Status,
+			serverlessv1alpha2.ConditionScalingReady,
+			metav1.ConditionTrue,
+			serverlessv1alpha2.ConditionReasonHPAReady,
+			"HorizontalPodAutoscaler dazzling-darwin-name is ready")
+	})
+	t.Run("when hpa exists and we need changes should update it and requeue", func(t *testing.T) {
+		// Arrange
+		scheme := minimalHPAScheme(t)
+		f := serverlessv1alpha2.Function{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "eager-euler-name",
+				Namespace: "elastic-einstein-ns",
+				UID:       "eager-euler-uid"},
+			Spec: serverlessv1alpha2.FunctionSpec{
+				ScaleConfig: &serverlessv1alpha2.ScaleConfig{
+					MinReplicas: ptr.To[int32](1),
+					MaxReplicas: ptr.To[int32](3),
+				}}}
+		fnConfig := config.FunctionConfig{TargetCPUUtilizationPercentage: 50}
+		hpa := resources.NewHPA(&f, &fnConfig).HorizontalPodAutoscaler
+		require.NoError(t, controllerutil.SetControllerReference(&f, hpa, scheme))
+		k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(hpa).Build()
+		f.Spec.ScaleConfig.MaxReplicas = ptr.To[int32](7)
+		m := fsm.StateMachine{
+			State: fsm.SystemState{
+				Function: f},
+			Log:            zap.NewNop().Sugar(),
+			Client:         k8sClient,
+			Scheme:         scheme,
+			FunctionConfig: fnConfig}
+
+		// Act
+		next, result, err := sFnHandleHPA(context.Background(), &m)
+
+		// Assert
+		require.Nil(t, err)
+		require.Nil(t, next)
+		require.NotNil(t, result)
+		require.Equal(t, ctrl.Result{RequeueAfter: time.Second}, *result)
+		requireContainsCondition(t, m.State.Function.Status,
+			serverlessv1alpha2.ConditionScalingReady,
+			metav1.ConditionTrue,
+			serverlessv1alpha2.ConditionReasonHPAUpdated,
+			"HorizontalPodAutoscaler eager-euler-name updated")
+		updatedHPA := &autoscalingv2.HorizontalPodAutoscaler{}
+		getErr := k8sClient.Get(context.Background(), client.ObjectKey{
+			Name:      "eager-euler-name",
+			Namespace: "elastic-einstein-ns",
+		}, updatedHPA)
+		require.NoError(t, getErr)
+		require.Equal(t, int32(7), updatedHPA.Spec.MaxReplicas)
+	})
+	t.Run("when scale config is removed should delete owned hpa and requeue", func(t *testing.T) {
+		// Arrange
+		scheme := minimalHPAScheme(t)
+		f := serverlessv1alpha2.Function{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "festive-feynman-name",
+				Namespace: "friendly-fermi-ns",
+				UID:       "festive-feynman-uid"},
+			Spec: serverlessv1alpha2.FunctionSpec{
+				ScaleConfig: &serverlessv1alpha2.ScaleConfig{
+					MinReplicas: ptr.To[int32](1),
+					MaxReplicas: ptr.To[int32](3),
+				}}}
+		hpa := resources.NewHPA(&f, &config.FunctionConfig{}).HorizontalPodAutoscaler
+		require.NoError(t, controllerutil.SetControllerReference(&f, hpa, scheme))
+		k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(hpa).Build()
+		f.Spec.ScaleConfig = nil
+		m := fsm.StateMachine{
+			State: fsm.SystemState{
+				Function: f},
+			Log:    zap.NewNop().Sugar(),
+			Client: k8sClient,
+			Scheme: scheme}
+
+		// Act
+		next, result, err := sFnHandleHPA(context.Background(), &m)
+
+		// Assert
+		require.Nil(t, err)
+		require.Nil(t, next)
+		require.NotNil(t, result)
+		require.Equal(t, ctrl.Result{RequeueAfter: time.Second}, *result)
+		requireContainsCondition(t, m.State.Function.Status,
+			serverlessv1alpha2.ConditionScalingReady,
+			metav1.ConditionTrue,
+			serverlessv1alpha2.ConditionReasonHPADeleted,
+			"HorizontalPodAutoscaler festive-feynman-name deleted")
+		getErr := k8sClient.Get(context.Background(), client.ObjectKey{
+			Name:      "festive-feynman-name",
+			Namespace: "friendly-fermi-ns",
+		}, &autoscalingv2.HorizontalPodAutoscaler{})
+		require.True(t, k8serrors.IsNotFound(getErr))
+	})
+	t.Run("when function is scaled to zero should delete owned hpa and requeue", func(t *testing.T) {
+		// Arrange
+		scheme := minimalHPAScheme(t)
+		f := serverlessv1alpha2.Function{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "goofy-gauss-name",
+				Namespace: "gallant-galois-ns",
+				UID:       "goofy-gauss-uid"},
+			Spec: serverlessv1alpha2.FunctionSpec{
+				ScaleConfig: &serverlessv1alpha2.ScaleConfig{
+					MinReplicas: ptr.To[int32](0),
+					MaxReplicas: ptr.To[int32](3),
+				}}}
+		hpa := resources.NewHPA(&f, &config.FunctionConfig{}).HorizontalPodAutoscaler
+		require.NoError(t, controllerutil.SetControllerReference(&f, hpa, scheme))
+		k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(hpa).Build()
+		m := fsm.StateMachine{
+			State: fsm.SystemState{
+				Function:     f,
+				ScaledToZero: true},
+			Log:    zap.NewNop().Sugar(),
+			Client: k8sClient,
+			Scheme: scheme}
+
+		// Act
+		next, result, err := sFnHandleHPA(context.Background(), &m)
+
+		// Assert
+		require.Nil(t, err)
+		require.Nil(t, next)
+		require.NotNil(t, result)
+		require.Equal(t, ctrl.Result{RequeueAfter: time.Second}, *result)
+		requireContainsCondition(t, m.State.Function.Status,
+			serverlessv1alpha2.ConditionScalingReady,
+			metav1.ConditionTrue,
+			serverlessv1alpha2.ConditionReasonHPADeleted,
+			"HorizontalPodAutoscaler goofy-gauss-name deleted")
+		getErr := k8sClient.Get(context.Background(), client.ObjectKey{
+			Name:      "goofy-gauss-name",
+			Namespace: "gallant-galois-ns",
+		}, &autoscalingv2.HorizontalPodAutoscaler{})
+		require.True(t, k8serrors.IsNotFound(getErr))
+	})
+	t.Run("when scale config is not set should keep hpa not owned by function and forget its failure", func(t *testing.T) {
+		// Arrange
+		scheme := minimalHPAScheme(t)
+		hpa := &autoscalingv2.HorizontalPodAutoscaler{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "gallant-galileo-name",
+				Namespace: "gifted-goldberg-ns"}}
+		deleteWasCalled := false
+		k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(hpa).WithInterceptorFuncs(interceptor.Funcs{
+			Delete: func(ctx context.Context, client client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+				deleteWasCalled = true
+				return nil
+			},
+		}).Build()
+		m := fsm.StateMachine{
+			State: fsm.SystemState{
+				Function: serverlessv1alpha2.Function{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "gallant-galileo-name",
+						Namespace: "gifted-goldberg-ns",
+						UID:       "gallant-galileo-uid"},
+					Status: serverlessv1alpha2.FunctionStatus{
+						Conditions: []metav1.Condition{{
+							Type:   string(serverlessv1alpha2.ConditionScalingReady),
+							Status: metav1.ConditionFalse,
+							Reason: string(serverlessv1alpha2.ConditionReasonHPAFailed)}}}}},
+			Log:    zap.NewNop().Sugar(),
+			Client: k8sClient,
+			Scheme: scheme}
+
+		// Act
+		next, result, err := sFnHandleHPA(context.Background(), &m)
+
+		// Assert
+		require.Nil(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnDeploymentStatus, next)
+		require.False(t, deleteWasCalled)
+		require.Nil(t, m.State.Function.Status.Condition(serverlessv1alpha2.ConditionScalingReady))
+	})
+	t.Run("when scale config is set and hpa is not owned by function should report failed condition and go to the next state", func(t *testing.T) {
+		// Arrange
+		scheme := minimalHPAScheme(t)
+		hpa := &autoscalingv2.HorizontalPodAutoscaler{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "gracious-gates-name",
+				Namespace: "gifted-goldberg-ns"}}
+		writeWasCalled := false
+		k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(hpa).WithInterceptorFuncs(interceptor.Funcs{
+			Create: func(ctx context.Context, client client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+				writeWasCalled = true
+				return nil
+			},
+			Update: func(ctx context.Context, client client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+				writeWasCalled = true
+				return nil
+			},
+		}).Build()
+		m := fsm.StateMachine{
+			State: fsm.SystemState{
+				Function: serverlessv1alpha2.Function{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "gracious-gates-name",
+						Namespace: "gifted-goldberg-ns",
+						UID:       "gracious-gates-uid"},
+					Spec: serverlessv1alpha2.FunctionSpec{
+						ScaleConfig: &serverlessv1alpha2.ScaleConfig{
+							MinReplicas: ptr.To[int32](1),
+							MaxReplicas: ptr.To[int32](3)}}}},
+			Log:    zap.NewNop().Sugar(),
+			Client: k8sClient,
+			Scheme: scheme}
+
+		// Act
+		next, result, err := sFnHandleHPA(context.Background(), &m)
+
+		// Assert
+		require.Nil(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnDeploymentStatus, next)
+		require.False(t, writeWasCalled)
+		requireContainsCondition(t, m.State.Function.Status,
+			serverlessv1alpha2.ConditionScalingReady,
+			metav1.ConditionFalse,
+			serverlessv1alpha2.ConditionReasonHPAFailed,
+			"HorizontalPodAutoscaler gracious-gates-name already exists and is not controlled by the Function, delete or rename it")
+	})
+	t.Run("when cannot get hpa from kubernetes should stop processing", func(t *testing.T) {
+		// Arrange
+		scheme := minimalHPAScheme(t)
+		k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithInterceptorFuncs(interceptor.Funcs{
+			Get: func(ctx context.Context, client client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				return errors.New("happy-hopper error message")
+			},
+		}).Build()
+		m := fsm.StateMachine{
+			State: fsm.SystemState{
+				Function: serverlessv1alpha2.Function{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "hungry-hawking-name",
+						Namespace: "hopeful-hoover-ns"}}},
+			Log:    zap.NewNop().Sugar(),
+			Client: k8sClient,
+			Scheme: scheme}
+
+		// Act
+		next, result, err := sFnHandleHPA(context.Background(), &m)
+
+		// Assert
+		require.ErrorContains(t, err, "happy-hopper error message")
+		require.Nil(t, result)
+		require.Nil(t, next)
+	})
+}
+
+func minimalHPAScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	require.NoError(t, serverlessv1alpha2.AddToScheme(scheme))
+	require.NoError(t, autoscalingv2.AddToScheme(scheme))
+	return scheme
+}
@@ -9,15 +9,20 @@ import (
 	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/fsm"
 	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/resources"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1ac "k8s.io/client-go/applyconfigurations/core/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
 func sFnHandleService(ctx context.Context, m *fsm.StateMachine) (fsm.StateFn, *ctrl.Result, error) {
-	builtService := resources.NewService(&m.State.Function).Service
+	if err := handleActivatorPort(ctx, m); err != nil {
+		return stopWithError(serviceRoutingFailed(m, err))
+	}
+	builtService := buildService(m)
 
 	clusterService, errGet := getService(ctx, m)
 	if errGet != nil {
@@ -25,17 +30,34 @@ func sFnHandleService(ctx context.Context, m *fsm.StateMachine) (fsm.StateFn, *c
 	}
 	if clusterService == nil {
 		result, errCreate := createService(ctx, m, builtService)
-		return nil, result, errCreate
+		if errCreate != nil {
+			return nil, result, errCreate
+		}
+		if err := routeServiceEndpoints(ctx, m, nil, builtService); err != nil {
+			return stopWithError(serviceRoutingFailed(m, err))
+		}
+		return nil, result, nil
 	}
 
-	requeueNeeded, errUpdate := updateServiceIfNeeded(ctx, m, clusterService, builtService)
-	if errUpdate != nil {
-		return stopWithError(errUpdate)
+	requeueNeeded, errApply := applyService(ctx, m, clusterService, builtService)
+	if errApply != nil {
+		return stopWithError(errApply)
+	}
+	if err := routeServiceEndpoints(ctx, m, clusterService, builtService); err != nil {
+		return stopWithError(serviceRoutingFailed(m, err))
 	}
 	if requeueNeeded {
 		return requeueAfter(time.Second)
 	}
-	return nextState(sFnDeploymentStatus)
+	return nextState(sFnHandleHPA)
+}
+
+func buildService(m *fsm.StateMachine) *corev1.Service {
+	f := &m.State.Function
+	if isRoutedToActivator(m) {
+		return resources.NewService(f, resources.ServiceActivatorPort(f.Status.ActivatorPort)).Service
+	}
+	return resources.NewService(f).Service
 }
 
 func getService(ctx context.Context, m *fsm.StateMachine) (*corev1.Service, error) {
@@ -71,7 +93,7 @@ func createService(ctx context.Context, m *fsm.StateMachine, service *corev1.Ser
 		return nil, err
 	}
 
-	if err := m.Client.Create(ctx, service); err != nil {
+	if err := m.Client.Create(ctx, service, client.FieldOwner(fieldManager)); err != nil {
 		m.Log.Error(err, "failed to create new Service", "Service.Namespace", service.GetNamespace(), "Service.Name", service.GetName())
 		m.State.Function.UpdateCondition(
 			serverlessv1alpha2.ConditionRunning,
@@ -89,43 +111,65 @@ func createService(ctx context.Context, m *fsm.StateMachine, service *corev1.Ser
 	return &ctrl.Result{RequeueAfter: time.Second}, nil
 }
 
-func updateServiceIfNeeded(ctx context.Context, m *fsm.StateMachine, clusterService *corev1.Service, builtService *corev1.Service) (requeueNeeded bool, err error) {
-	// Ensure the Deployment data matches the desired state
-	if !serviceChanged(clusterService, builtService) {
-		return false, nil
+// applyService applies the built service with the server-side apply, the fields set by others, like the allocated cluster IP, are left untouched
+func applyService(ctx context.Context, m *fsm.StateMachine, clusterService *corev1.Service, builtService *corev1.Service) (requeueNeeded bool, err error) {
+	if err := upgradeManagedFields(ctx, m, clusterService); err != nil {
+		return false, serviceUpdateFailed(m, clusterService, err)
 	}
 
-	// manually change fields that interest us, as clusterIP is immutable
-	clusterService.Spec.Ports = builtService.Spec.Ports
-	clusterService.Spec.Selector = builtService.Spec.Selector
-	clusterService.Spec.Type = builtService.Spec.Type
-	clusterService.ObjectMeta.Labels = builtService.GetLabels()
-	return updateService(ctx, m, clusterService)
-}
+	desired := builtService.DeepCopy()
+	if err := controllerutil.SetControllerReference(&m.State.Function, desired, m.Scheme); err != nil {
+		return false, serviceUpdateFailed(m, clusterService, err)
+	}
 
-func serviceChanged(a *corev1.Service, b *corev1.Service) bool {
-	return !mapsEqual(a.Spec.Selector, b.Spec.Selector) ||
-		!mapsEqual(a.Labels, b.Labels) ||
-		len(a.Spec.Ports) != 1 ||
-		len(b.Spec.Ports) != 1 ||
-		a.Spec.Ports[0].String() != b.Spec.Ports[0].String()
-}
+	serviceApplyConfig := &corev1ac.ServiceApplyConfiguration{}
+	if err := convertObject(desired, serviceApplyConfig); err != nil {
+		return false, serviceUpdateFailed(m, clusterService, err)
+	}
+	serviceApplyConfig.Status = nil
+	if err := m.Client.Apply(ctx, serviceApplyConfig, client.FieldOwner(fieldManager), client.ForceOwnership); err != nil {
+		return false, serviceUpdateFailed(m, clusterService, err)
+	}
 
-func updateService(ctx context.Context, m *fsm.StateMachine, clusterService *corev1.Service) (requeueNeeded bool, err error) {
-	if err := m.Client.Update(ctx, clusterService); err != nil {
-		m.Log.Error(err, "Failed to update Service", "Service.Namespace", clusterService.GetNamespace(), "Service.Name", clusterService.GetName())
-		m.State.Function.UpdateCondition(
-			serverlessv1alpha2.ConditionRunning,
-			metav1.ConditionFalse,
-			serverlessv1alpha2.ConditionReasonServiceFailed,
-			fmt.Sprintf("Service %s update failed: %s", clusterService.GetName(), err.Error()))
-		return false, err
+	appliedService := &corev1.Service{}
+	if err := convertObject(serviceApplyConfig, appliedService); err != nil {
+		return false, serviceUpdateFailed(m, clusterService, err)
+	}
+	if !serviceChanged(clusterService, appliedService) {
+		return false, nil
 	}
 	m.State.Function.UpdateCondition(
 		serverlessv1alpha2.ConditionRunning,
 		metav1.ConditionUnknown,
 		serverlessv1alpha2.ConditionReasonServiceUpdated,
 		fmt.Sprintf("Service %s updated", clusterService.GetName()))
-	// Requeue the request to ensure the Deployment is updated
+	// Requeue the request to ensure the Service is updated
 	return true, nil
 }
+
+// serviceChanged returns true when the service in the cluster has been changed by the apply
+func serviceChanged(before *corev1.Service, after *corev1.Service) bool {
+	return !equality.Semantic.DeepEqual(before.Spec, after.Spec) ||
+		!equality.Semantic.DeepEqual(before.GetLabels(), after.GetLabels()) ||
+		!equality.Semantic.DeepEqual(before.GetAnnotations(), after.GetAnnotations())
+}
+
+func serviceUpdateFailed(m *fsm.StateMachine, clusterService *corev1.Service, err error) error {
+	m.Log.Error(err, "Failed to update Service", "Service.Namespace", clusterService.GetNamespace(), "Service.Name", clusterService.GetName())
+	m.State.Function.UpdateCondition(
+		serverlessv1alpha2.ConditionRunning,
+		metav1.ConditionFalse,
+		serverlessv1alpha2.ConditionReasonServiceFailed,
+		fmt.Sprintf("Service %s update failed: %s", clusterService.GetName(), err.Error()))
+	return err
+}
+
+func serviceRoutingFailed(m *fsm.StateMachine, err error) error {
+	m.Log.Error(err, "Failed to route Service", "Service.Namespace", m.State.Function.GetNamespace(), "Service.Name", m.State.Function.GetName())
+	m.State.Function.UpdateCondition(
+		serverlessv1alpha2.ConditionRunning,
+		metav1.ConditionFalse,
+		serverlessv1alpha2.ConditionReasonServiceFailed,
+		fmt.Sprintf("Service %s routing failed: %s", m.State.Function.GetName(), err.Error()))
+	return err
+}
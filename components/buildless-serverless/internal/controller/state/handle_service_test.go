@@ -6,12 +6,16 @@ import (
 	"time"
 
 	serverlessv1alpha2 "github.com/kyma-project/serverless/components/buildless-serverless/api/v1alpha2"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/activator"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/config"
 	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/fsm"
 	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/resources"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/intstr"
@@ -23,6 +27,244 @@ import (
 )
 
 func Test_sFnHandleService(t *testing.T) {
+	t.Run("when function scales to zero should create service routed to activator", func(t *testing.T) {
+		// Arrange
+		// scheme and fake client with the activator's endpoints
+		scheme := fixActivatorRoutingScheme(t)
+		k8sClient := fixActivatorRoutingClientBuilder(t, scheme).WithObjects(fixActivatorEndpointSlice()).Build()
+		// machine with our function
+		m := fsm.StateMachine{
+			State: fsm.SystemState{
+				Function: serverlessv1alpha2.Function{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "lucid-lovelace-name",
+						Namespace: "loving-lamport-ns",
+						UID:       "lucid-lovelace-uid"},
+					Spec: serverlessv1alpha2.FunctionSpec{
+						ScaleConfig: &serverlessv1alpha2.ScaleConfig{
+							MinReplicas: ptr.To[int32](0)}}}},
+			Log:            zap.NewNop().Sugar(),
+			Client:         k8sClient,
+			Cache:          k8sClient,
+			FunctionConfig: fixActivatorRoutingConfig(),
+			Scheme:         scheme}
+
+		// Act
+		next, result, err := sFnHandleService(context.Background(), &m)
+
+		// Assert
+		require.Nil(t, err)
+		require.Nil(t, next)
+		require.Equal(t, ctrl.Result{RequeueAfter: time.Second}, *result)
+		// function got its own activator's port
+		activatorPort := m.State.Function.Status.ActivatorPort
+		require.True(t, m.FunctionConfig.ScaleToZero.ActivatorPorts.Contains(activatorPort))
+		// service has been applied to k8s
+		appliedSvc := &corev1.Service{}
+		getErr := k8sClient.Get(context.Background(), client.ObjectKey{
+			Name:      "lucid-lovelace-name",
+			Namespace: "loving-lamport-ns",
+		}, appliedSvc)
+		require.NoError(t, getErr)
+		require.Equal(t, corev1.ServiceTypeClusterIP, appliedSvc.Spec.Type)
+		require.Empty(t, appliedSvc.Spec.Selector)
+		require.Equal(t, intstr.FromInt32(activatorPort), appliedSvc.Spec.Ports[0].TargetPort)
+		// service is routed to the activator's endpoints
+		endpointSlice := &discoveryv1.EndpointSlice{}
+		require.NoError(t, k8sClient.Get(context.Background(), client.ObjectKey{
+			Name:      "lucid-lovelace-name-activator",
+			Namespace: "loving-lamport-ns",
+		}, endpointSlice))
+		require.Equal(t, "lucid-lovelace-name", endpointSlice.Labels[discoveryv1.LabelServiceName])
+		require.Len(t, endpointSlice.Endpoints, 1)
+		require.Equal(t, []string{"10.0.0.12"}, endpointSlice.Endpoints[0].Addresses)
+		require.Equal(t, activatorPort, *endpointSlice.Ports[0].Port)
+	})
+	t.Run("when scale-to-zero function has no ready pods should route service to activator", func(t *testing.T) {
+		// Arrange
+		f := fixScaleToZeroFunction()
+		scheme := fixActivatorRoutingScheme(t)
+		k8sClient := fixActivatorRoutingClientBuilder(t, scheme).WithReturnManagedFields().WithObjects(
+			fixActivatorEndpointSlice(),
+			&discoveryv1.EndpointSlice{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "serene-shannon-name-pods",
+					Namespace: "silly-sammet-ns",
+					Labels: map[string]string{
+						discoveryv1.LabelServiceName: "serene-shannon-name",
+						discoveryv1.LabelManagedBy:   "endpointslice-controller.k8s.io"}},
+				AddressType: discoveryv1.AddressTypeIPv4},
+		).Build()
+		require.NoError(t, k8sClient.Create(context.Background(), resources.NewService(&f).Service, client.FieldOwner(fieldManager)))
+		m := fsm.StateMachine{
+			State: fsm.SystemState{
+				Function:     f,
+				ScaledToZero: true},
+			Log:            zap.NewNop().Sugar(),
+			Client:         k8sClient,
+			Cache:          k8sClient,
+			FunctionConfig: fixActivatorRoutingConfig(),
+			Scheme:         scheme}
+
+		// Act
+		_, _, err := sFnHandleService(context.Background(), &m)
+
+		// Assert
+		require.NoError(t, err)
+		appliedSvc := &corev1.Service{}
+		require.NoError(t, k8sClient.Get(context.Background(), client.ObjectKeyFromObject(&f), appliedSvc))
+		require.Empty(t, appliedSvc.Spec.Selector)
+		// endpoints of the function's pods have been removed
+		endpointSlices := &discoveryv1.EndpointSliceList{}
+		require.NoError(t, k8sClient.List(context.Background(), endpointSlices, client.InNamespace("silly-sammet-ns")))
+		require.Len(t, endpointSlices.Items, 1)
+		require.Equal(t, "serene-shannon-name-activator", endpointSlices.Items[0].GetName())
+	})
+	t.Run("when scale-to-zero function is ready should route service to its pods", func(t *testing.T) {
+		// Arrange
+		f := fixScaleToZeroFunction()
+		scheme := fixActivatorRoutingScheme(t)
+		k8sClient := fixActivatorRoutingClientBuilder(t, scheme).WithReturnManagedFields().WithObjects(
+			resources.NewActivatorEndpointSlice(&f, discoveryv1.AddressTypeIPv4, []string{"10.0.0.12"}),
+		).Build()
+		require.NoError(t, k8sClient.Create(context.Background(), resources.NewService(&f, resources.ServiceActivatorPort(20001)).Service, client.FieldOwner(fieldManager)))
+		m := fsm.StateMachine{
+			State: fsm.SystemState{
+				Function: f,
+				ClusterDeployment: &appsv1.Deployment{
+					Status: appsv1.DeploymentStatus{ReadyReplicas: 1}}},
+			Log:            zap.NewNop().Sugar(),
+			Client:         k8sClient,
+			Cache:          k8sClient,
+			FunctionConfig: fixActivatorRoutingConfig(),
+			Scheme:         scheme}
+
+		// Act
+		_, _, err := sFnHandleService(context.Background(), &m)
+
+		// Assert
+		require.NoError(t, err)
+		require.Equal(t, int32(20001), m.State.Function.Status.ActivatorPort)
+		appliedSvc := &corev1.Service{}
+		require.NoError(t, k8sClient.Get(context.Background(), client.ObjectKeyFromObject(&f), appliedSvc))
+		require.Equal(t, f.SelectorLabels(), appliedSvc.Spec.Selector)
+		require.Equal(t, intstr.FromInt32(8080), appliedSvc.Spec.Ports[0].TargetPort)
+		// activator's endpoints have been removed
+		endpointSlices := &discoveryv1.EndpointSliceList{}
+		require.NoError(t, k8sClient.List(context.Background(), endpointSlices, client.InNamespace("silly-sammet-ns")))
+		require.Empty(t, endpointSlices.Items)
+	})
+	t.Run("when scale-to-zero is disabled should release activator port", func(t *testing.T) {
+		// Arrange
+		f := fixScaleToZeroFunction()
+		f.Spec.ScaleConfig = nil
+		scheme := fixActivatorRoutingScheme(t)
+		k8sClient := fixActivatorRoutingClientBuilder(t, scheme).WithReturnManagedFields().Build()
+		require.NoError(t, k8sClient.Create(context.Background(), resources.NewService(&f, resources.ServiceActivatorPort(20001)).Service, client.FieldOwner(fieldManager)))
+		m := fsm.StateMachine{
+			State:          fsm.SystemState{Function: f},
+			Log:            zap.NewNop().Sugar(),
+			Client:         k8sClient,
+			Cache:          k8sClient,
+			FunctionConfig: fixActivatorRoutingConfig(),
+			Scheme:         scheme}
+
+		// Act
+		_, _, err := sFnHandleService(context.Background(), &m)
+
+		// Assert
+		require.NoError(t, err)
+		require.Zero(t, m.State.Function.Status.ActivatorPort)
+		appliedSvc := &corev1.Service{}
+		require.NoError(t, k8sClient.Get(context.Background(), client.ObjectKeyFromObject(&f), appliedSvc))
+		require.Equal(t, f.SelectorLabels(), appliedSvc.Spec.Selector)
+	})
+	t.Run("when all activator ports are used should fail", func(t *testing.T) {
+		// Arrange
+		f := fixScaleToZeroFunction()
+		f.Status.ActivatorPort = 0
+		otherFunction := fixScaleToZeroFunction()
+		otherFunction.SetName("other-function")
+		otherFunction.SetUID("other-uid")
+		scheme := fixActivatorRoutingScheme(t)
+		k8sClient := fixActivatorRoutingClientBuilder(t, scheme).WithObjects(&otherFunction).Build()
+		m := fsm.StateMachine{
+			State:  fsm.SystemState{Function: f},
+			Log:    zap.NewNop().Sugar(),
+			Client: k8sClient,
+			Cache:  k8sClient,
+			FunctionConfig: config.FunctionConfig{
+				ScaleToZero: config.ScaleToZeroConfig{
+					ActivatorPorts: config.PortRange{Min: 20001, Max: 20001}}},
+			Scheme: scheme}
+
+		// Act
+		next, result, err := sFnHandleService(context.Background(), &m)
+
+		// Assert
+		require.EqualError(t, err, "all activator ports from 20001 to 20001 are used")
+		require.Nil(t, next)
+		require.Nil(t, result)
+		requireContainsCondition(t, m.State.Function.Status,
+			serverlessv1alpha2.ConditionRunning,
+			metav1.ConditionFalse,
+			serverlessv1alpha2.ConditionReasonServiceFailed,
+			"Service serene-shannon-name routing failed: all activator ports from 20001 to 20001 are used")
+	})
+	t.Run("when activator port is used by function created before should move to another port", func(t *testing.T) {
+		// Arrange
+		f := fixScaleToZeroFunction()
+		f.CreationTimestamp = metav1.NewTime(time.Now())
+		otherFunction := fixScaleToZeroFunction()
+		otherFunction.SetName("other-function")
+		otherFunction.SetUID("other-uid")
+		otherFunction.CreationTimestamp = metav1.NewTime(time.Now().Add(-time.Hour))
+		scheme := fixActivatorRoutingScheme(t)
+		k8sClient := fixActivatorRoutingClientBuilder(t, scheme).WithObjects(&otherFunction).Build()
+		m := fsm.StateMachine{
+			State:  fsm.SystemState{Function: f},
+			Log:    zap.NewNop().Sugar(),
+			Client: k8sClient,
+			Cache:  k8sClient,
+			FunctionConfig: config.FunctionConfig{
+				ScaleToZero: config.ScaleToZeroConfig{
+					ActivatorPorts: config.PortRange{Min: 20001, Max: 20002}}},
+			Scheme: scheme}
+
+		// Act
+		err := handleActivatorPort(context.Background(), &m)
+
+		// Assert
+		require.NoError(t, err)
+		require.Equal(t, int32(20002), m.State.Function.Status.ActivatorPort)
+	})
+	t.Run("when activator port is used by function created later should keep it", func(t *testing.T) {
+		// Arrange
+		f := fixScaleToZeroFunction()
+		f.CreationTimestamp = metav1.NewTime(time.Now().Add(-time.Hour))
+		otherFunction := fixScaleToZeroFunction()
+		otherFunction.SetName("other-function")
+		otherFunction.SetUID("other-uid")
+		otherFunction.CreationTimestamp = metav1.NewTime(time.Now())
+		scheme := fixActivatorRoutingScheme(t)
+		k8sClient := fixActivatorRoutingClientBuilder(t, scheme).WithObjects(&otherFunction).Build()
+		m := fsm.StateMachine{
+			State:  fsm.SystemState{Function: f},
+			Log:    zap.NewNop().Sugar(),
+			Client: k8sClient,
+			Cache:  k8sClient,
+			FunctionConfig: config.FunctionConfig{
+				ScaleToZero: config.ScaleToZeroConfig{
+					ActivatorPorts: config.PortRange{Min: 20001, Max: 20002}}},
+			Scheme: scheme}
+
+		// Act
+		err := handleActivatorPort(context.Background(), &m)
+
+		// Assert
+		require.NoError(t, err)
+		require.Equal(t, int32(20001), m.State.Function.Status.ActivatorPort)
+	})
 	t.Run("when service does not exist on kubernetes should create service and apply it", func(t *testing.T) {
 		// Arrange
 		// some service on k8s, but it is not the service we expect
@@ -212,7 +454,7 @@ func Test_sFnHandleService(t *testing.T) {
 		require.Nil(t, result)
 		// with expected next state
 		require.NotNil(t, next)
-		requireEqualFunc(t, sFnDeploymentStatus, next)
+		requireEqualFunc(t, sFnHandleHPA, next)
 		// service has not been created or updated
 		require.False(t, createOrUpdateWasCalled)
 		// function conditions remain unchanged
@@ -289,7 +531,7 @@ func Test_sFnHandleService(t *testing.T) {
 		require.NoError(t, serverlessv1alpha2.AddToScheme(scheme))
 		require.NoError(t, corev1.AddToScheme(scheme))
 		k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&svc).WithInterceptorFuncs(interceptor.Funcs{
-			Update: func(ctx context.Context, client client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+			Apply: func(ctx context.Context, client client.WithWatch, obj runtime.ApplyConfiguration, opts ...client.ApplyOption) error {
 				return errors.New("quirky-elion error message")
 			},
 		}).Build()
@@ -324,6 +566,68 @@ func Test_sFnHandleService(t *testing.T) {
 	})
 }
 
+func Test_applyService(t *testing.T) {
+	f := serverlessv1alpha2.Function{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "objective-banzai-name",
+			Namespace: "trusting-knuth-ns",
+			UID:       "objective-banzai-uid"}}
+	fixMachine := func(t *testing.T, service *corev1.Service) *fsm.StateMachine {
+		scheme := runtime.NewScheme()
+		require.NoError(t, serverlessv1alpha2.AddToScheme(scheme))
+		require.NoError(t, corev1.AddToScheme(scheme))
+		k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithReturnManagedFields().Build()
+		require.NoError(t, k8sClient.Create(context.Background(), service, client.FieldOwner(fieldManager)))
+		return &fsm.StateMachine{
+			State:  fsm.SystemState{Function: f},
+			Log:    zap.NewNop().Sugar(),
+			Client: k8sClient,
+			Scheme: scheme}
+	}
+	getService := func(t *testing.T, m *fsm.StateMachine) *corev1.Service {
+		service := &corev1.Service{}
+		require.NoError(t, m.Client.Get(context.Background(), client.ObjectKey{
+			Name:      "objective-banzai-name",
+			Namespace: "trusting-knuth-ns",
+		}, service))
+		return service
+	}
+
+	t.Run("revert selector changed by someone else and keep fields set by others", func(t *testing.T) {
+		built := resources.NewService(&f).Service
+		m := fixMachine(t, built.DeepCopy())
+		cluster := getService(t, m)
+		cluster.Spec.Selector = map[string]string{"knuth": "trusting"}
+		cluster.Spec.ClusterIP = "10.0.0.42"
+		metav1.SetMetaDataAnnotation(&cluster.ObjectMeta, "mutated-by", "admission-webhook")
+		require.NoError(t, m.Client.Update(context.Background(), cluster, client.FieldOwner("kubectl-edit")))
+
+		requeueNeeded, err := applyService(context.Background(), m, cluster, built)
+
+		require.NoError(t, err)
+		require.True(t, requeueNeeded)
+		requireContainsCondition(t, m.State.Function.Status,
+			serverlessv1alpha2.ConditionRunning,
+			metav1.ConditionUnknown,
+			serverlessv1alpha2.ConditionReasonServiceUpdated,
+			"Service objective-banzai-name updated")
+		applied := getService(t, m)
+		require.Equal(t, built.Spec.Selector, applied.Spec.Selector)
+		require.Equal(t, "10.0.0.42", applied.Spec.ClusterIP)
+		require.Equal(t, "admission-webhook", applied.Annotations["mutated-by"])
+	})
+	t.Run("keep service without changes", func(t *testing.T) {
+		built := resources.NewService(&f).Service
+		m := fixMachine(t, built.DeepCopy())
+
+		requeueNeeded, err := applyService(context.Background(), m, getService(t, m), built)
+
+		require.NoError(t, err)
+		require.False(t, requeueNeeded)
+		require.Empty(t, m.State.Function.Status.Conditions)
+	})
+}
+
 func Test_serviceChanged(t *testing.T) {
 	type args struct {
 		a *corev1.Service
@@ -390,7 +694,7 @@ func Test_serviceChanged(t *testing.T) {
 		},
 		{
 			// TODO: why?
-			name: "when more than one port should return true",
+			name: "when more than one port should return false",
 			args: args{
 				a: &corev1.Service{
 					Spec: corev1.ServiceSpec{
@@ -403,18 +707,17 @@ func Test_serviceChanged(t *testing.T) {
 							{Name: "eager-khorana"},
 							{Name: "laughing-swartz"}}}},
 			},
-			want: true,
+			want: false,
 		},
 		{
-			// TODO: why?
-			name: "when less than one port should return true",
+			name: "when less than one port should return false",
 			args: args{
 				a: &corev1.Service{
 					Spec: corev1.ServiceSpec{}},
 				b: &corev1.Service{
 					Spec: corev1.ServiceSpec{}},
 			},
-			want: true,
+			want: false,
 		},
 		{
 			name: "when labels are different should return true",
@@ -454,7 +757,20 @@ func Test_serviceChanged(t *testing.T) {
 			want: true,
 		},
 		{
-			name: "when not compared fields are different should return false",
+			name: "when external names are different should return true",
+			args: args{
+				a: &corev1.Service{
+					Spec: corev1.ServiceSpec{
+						Ports:        []corev1.ServicePort{{Name: "festive-williams"}},
+						ExternalName: "serverless-activator.kyma-system.svc.cluster.local"}},
+				b: &corev1.Service{
+					Spec: corev1.ServiceSpec{
+						Ports: []corev1.ServicePort{{Name: "festive-williams"}}}},
+			},
+			want: true,
+		},
+		{
+			name: "when other fields of spec are different should return true",
 			args: args{
 				a: &corev1.Service{
 					TypeMeta: metav1.TypeMeta{
@@ -482,7 +798,6 @@ func Test_serviceChanged(t *testing.T) {
 						ExternalIPs:              []string{"gifted-nash"},
 						SessionAffinity:          "gifted-nash",
 						LoadBalancerSourceRanges: []string{"gifted-nash"},
-						ExternalName:             "gifted-nash",
 						ExternalTrafficPolicy:    "gifted-nash",
 						HealthCheckNodePort:      123,
 						PublishNotReadyAddresses: false,
@@ -529,7 +844,6 @@ func Test_serviceChanged(t *testing.T) {
 						ExternalIPs:              []string{"pedantic-bartik"},
 						SessionAffinity:          "pedantic-bartik",
 						LoadBalancerSourceRanges: []string{"pedantic-bartik"},
-						ExternalName:             "pedantic-bartik",
 						ExternalTrafficPolicy:    "pedantic-bartik",
 						HealthCheckNodePort:      789,
 						PublishNotReadyAddresses: true,
@@ -551,6 +865,27 @@ func Test_serviceChanged(t *testing.T) {
 							Status: "pedantic-bartik",
 							Reason: "pedantic-bartik"}}}},
 			},
+			want: true,
+		},
+		{
+			name: "when only status and metadata other than labels and annotations are different should return false",
+			args: args{
+				a: &corev1.Service{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:            "sharp-mirzakhani",
+						ResourceVersion: "1"},
+					Spec: corev1.ServiceSpec{
+						Ports: []corev1.ServicePort{{Name: "sharp-mirzakhani"}}}},
+				b: &corev1.Service{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:            "sharp-mirzakhani",
+						ResourceVersion: "2",
+						ManagedFields:   []metav1.ManagedFieldsEntry{{Manager: "sharp-mirzakhani"}}},
+					Spec: corev1.ServiceSpec{
+						Ports: []corev1.ServicePort{{Name: "sharp-mirzakhani"}}},
+					Status: corev1.ServiceStatus{
+						Conditions: []metav1.Condition{{Type: "sharp-mirzakhani"}}}},
+			},
 			want: false,
 		},
 	}
@@ -561,3 +896,61 @@ func Test_serviceChanged(t *testing.T) {
 		})
 	}
 }
+
+func fixScaleToZeroFunction() serverlessv1alpha2.Function {
+	return serverlessv1alpha2.Function{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "serene-shannon-name",
+			Namespace: "silly-sammet-ns",
+			UID:       "serene-shannon-uid"},
+		Spec: serverlessv1alpha2.FunctionSpec{
+			ScaleConfig: &serverlessv1alpha2.ScaleConfig{
+				MinReplicas: ptr.To[int32](0)}},
+		Status: serverlessv1alpha2.FunctionStatus{
+			ActivatorPort: 20001}}
+}
+
+func fixActivatorEndpointSlice() *discoveryv1.EndpointSlice {
+	return &discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "serverless-activator-abcde",
+			Namespace: "kyma-system",
+			Labels: map[string]string{
+				discoveryv1.LabelServiceName: "serverless-activator"}},
+		AddressType: discoveryv1.AddressTypeIPv4,
+		Endpoints: []discoveryv1.Endpoint{
+			{Addresses: []string{"10.0.0.12"}, Conditions: discoveryv1.EndpointConditions{Ready: ptr.To(true)}},
+			{Addresses: []string{"10.0.0.13"}, Conditions: discoveryv1.EndpointConditions{Ready: ptr.To(false)}}}}
+}
+
+func fixActivatorRoutingConfig() config.FunctionConfig {
+	return config.FunctionConfig{
+		ScaleToZero: config.ScaleToZeroConfig{
+			ActivatorPorts:       config.PortRange{Min: 20000, Max: 20099},
+			ActivatorNamespace:   "kyma-system",
+			ActivatorServiceName: "serverless-activator"}}
+}
+
+func fixActivatorRoutingClientBuilder(t *testing.T, scheme *runtime.Scheme) *fake.ClientBuilder {
+	builder := fake.NewClientBuilder().WithScheme(scheme)
+	require.NoError(t, activator.IndexFunctions(context.Background(), clientBuilderIndexer{builder}))
+	return builder
+}
+
+// clientBuilderIndexer registers the indexes in the fake client
+type clientBuilderIndexer struct {
+	builder *fake.ClientBuilder
+}
+
+func (i clientBuilderIndexer) IndexField(_ context.Context, obj client.Object, field string, extractValue client.IndexerFunc) error {
+	i.builder.WithIndex(obj, field, extractValue)
+	return nil
+}
+
+func fixActivatorRoutingScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	require.NoError(t, serverlessv1alpha2.AddToScheme(scheme))
+	require.NoError(t, corev1.AddToScheme(scheme))
+	require.NoError(t, discoveryv1.AddToScheme(scheme))
+	return scheme
+}
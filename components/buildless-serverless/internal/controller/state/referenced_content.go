@@ -0,0 +1,81 @@
+package state
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"slices"
+
+	serverlessv1alpha2 "github.com/kyma-project/serverless/components/buildless-serverless/api/v1alpha2"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/fsm"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// sFnReferencedContent hashes the content of the Secrets and ConfigMaps consumed by the function's pods,
+// the hash is set on the pod template, so the pods are rolled out when the content changes
+func sFnReferencedContent(ctx context.Context, m *fsm.StateMachine) (fsm.StateFn, *ctrl.Result, error) {
+	contentHash, err := referencedContentHash(ctx, m.Client, deployedFunction(m))
+	if err != nil {
+		return stopWithError(errors.Wrap(err, "while hashing referenced content"))
+	}
+
+	m.State.ReferencedContentHash = contentHash
+	return nextState(sFnHandleDependencyCache)
+}
+
+// referencedContentHash returns an empty hash for functions without references, so their pods are not restarted
+func referencedContentHash(ctx context.Context, c client.Client, f *serverlessv1alpha2.Function) (string, error) {
+	secretNames := f.PodSecretNames()
+	configMapNames := f.PodConfigMapNames()
+	if len(secretNames) == 0 && len(configMapNames) == 0 {
+		return "", nil
+	}
+
+	h := sha256.New()
+	for _, name := range secretNames {
+		secret := &corev1.Secret{}
+		err := c.Get(ctx, types.NamespacedName{Namespace: f.GetNamespace(), Name: name}, secret)
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return "", errors.Wrapf(err, "while getting secret %s", name)
+		}
+		// a missing secret is hashed as an empty one, so the pods are rolled out once it's created
+		writeReferencedContent(h, "secret", name, secret.Data)
+	}
+	for _, name := range configMapNames {
+		configMap := &corev1.ConfigMap{}
+		err := c.Get(ctx, types.NamespacedName{Namespace: f.GetNamespace(), Name: name}, configMap)
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return "", errors.Wrapf(err, "while getting configmap %s", name)
+		}
+		data := map[string][]byte{}
+		for key, value := range configMap.Data {
+			data[key] = []byte(value)
+		}
+		for key, value := range configMap.BinaryData {
+			data[key] = value
+		}
+		writeReferencedContent(h, "configmap", name, data)
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func writeReferencedContent(h hash.Hash, kind, name string, data map[string][]byte) {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	fmt.Fprintf(h, "%s\x00%s\x00%d\x00", kind, name, len(keys))
+	for _, key := range keys {
+		fmt.Fprintf(h, "%s\x00%d\x00", key, len(data[key]))
+		h.Write(data[key])
+	}
+}
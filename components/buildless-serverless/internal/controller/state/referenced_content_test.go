@@ -0,0 +1,152 @@
+package state
+
+import (
+	"context"
+	"testing"
+
+	serverlessv1alpha2 "github.com/kyma-project/serverless/components/buildless-serverless/api/v1alpha2"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/fsm"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func Test_sFnReferencedContent(t *testing.T) {
+	t.Run("should not hash function without references", func(t *testing.T) {
+		m := fixReferencedContentStateMachine(t, serverlessv1alpha2.Function{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "test-namespace", Name: "test-function"},
+		})
+
+		next, result, err := sFnReferencedContent(context.Background(), m)
+
+		require.NoError(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnHandleDependencyCache, next)
+		require.Empty(t, m.State.ReferencedContentHash)
+	})
+	t.Run("should hash referenced content", func(t *testing.T) {
+		m := fixReferencedContentStateMachine(t, fixReferencingFunction(),
+			fixReferencedSecret("mounted-secret", "password", "secret"),
+			fixReferencedConfigMap("env-config", "LEVEL", "debug"),
+		)
+
+		next, result, err := sFnReferencedContent(context.Background(), m)
+
+		require.NoError(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnHandleDependencyCache, next)
+		require.Regexp(t, "^sha256:[0-9a-f]{64}$", m.State.ReferencedContentHash)
+	})
+	t.Run("should change hash when referenced secret changes", func(t *testing.T) {
+		m := fixReferencedContentStateMachine(t, fixReferencingFunction(),
+			fixReferencedSecret("mounted-secret", "password", "secret"),
+			fixReferencedConfigMap("env-config", "LEVEL", "debug"),
+		)
+		_, _, err := sFnReferencedContent(context.Background(), m)
+		require.NoError(t, err)
+		previousHash := m.State.ReferencedContentHash
+
+		require.NoError(t, m.Client.Update(context.Background(), fixReferencedSecret("mounted-secret", "password", "changed")))
+		_, _, err = sFnReferencedContent(context.Background(), m)
+
+		require.NoError(t, err)
+		require.NotEqual(t, previousHash, m.State.ReferencedContentHash)
+	})
+	t.Run("should change hash when referenced configmap changes", func(t *testing.T) {
+		m := fixReferencedContentStateMachine(t, fixReferencingFunction(),
+			fixReferencedSecret("mounted-secret", "password", "secret"),
+			fixReferencedConfigMap("env-config", "LEVEL", "debug"),
+		)
+		_, _, err := sFnReferencedContent(context.Background(), m)
+		require.NoError(t, err)
+		previousHash := m.State.ReferencedContentHash
+
+		require.NoError(t, m.Client.Update(context.Background(), fixReferencedConfigMap("env-config", "LEVEL", "info")))
+		_, _, err = sFnReferencedContent(context.Background(), m)
+
+		require.NoError(t, err)
+		require.NotEqual(t, previousHash, m.State.ReferencedContentHash)
+	})
+	t.Run("should change hash when missing reference is created", func(t *testing.T) {
+		m := fixReferencedContentStateMachine(t, fixReferencingFunction(),
+			fixReferencedSecret("mounted-secret", "password", "secret"),
+		)
+		_, _, err := sFnReferencedContent(context.Background(), m)
+		require.NoError(t, err)
+		previousHash := m.State.ReferencedContentHash
+		require.NotEmpty(t, previousHash)
+
+		require.NoError(t, m.Client.Create(context.Background(), fixReferencedConfigMap("env-config", "LEVEL", "debug")))
+		_, _, err = sFnReferencedContent(context.Background(), m)
+
+		require.NoError(t, err)
+		require.NotEqual(t, previousHash, m.State.ReferencedContentHash)
+	})
+	t.Run("should not change hash when source secret changes", func(t *testing.T) {
+		f := fixReferencingFunction()
+		f.Spec.Source.GitRepository = &serverlessv1alpha2.GitRepositorySource{
+			URL:  "https://github.com/kyma-project/serverless.git",
+			Auth: &serverlessv1alpha2.RepositoryAuth{SecretName: "git-secret"},
+		}
+		m := fixReferencedContentStateMachine(t, f,
+			fixReferencedSecret("mounted-secret", "password", "secret"),
+			fixReferencedConfigMap("env-config", "LEVEL", "debug"),
+			fixReferencedSecret("git-secret", "token", "secret"),
+		)
+		_, _, err := sFnReferencedContent(context.Background(), m)
+		require.NoError(t, err)
+		previousHash := m.State.ReferencedContentHash
+
+		require.NoError(t, m.Client.Update(context.Background(), fixReferencedSecret("git-secret", "token", "changed")))
+		_, _, err = sFnReferencedContent(context.Background(), m)
+
+		require.NoError(t, err)
+		require.Equal(t, previousHash, m.State.ReferencedContentHash)
+	})
+}
+
+func fixReferencingFunction() serverlessv1alpha2.Function {
+	return serverlessv1alpha2.Function{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "test-namespace", Name: "test-function"},
+		Spec: serverlessv1alpha2.FunctionSpec{
+			SecretMounts: []serverlessv1alpha2.SecretMount{
+				{SecretName: "mounted-secret", MountPath: "/secrets"},
+			},
+			EnvFrom: []corev1.EnvFromSource{
+				{ConfigMapRef: &corev1.ConfigMapEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "env-config"}}},
+			},
+		},
+	}
+}
+
+func fixReferencedSecret(name, key, value string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "test-namespace", Name: name},
+		Data:       map[string][]byte{key: []byte(value)},
+	}
+}
+
+func fixReferencedConfigMap(name, key, value string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "test-namespace", Name: name},
+		Data:       map[string]string{key: value},
+	}
+}
+
+func fixReferencedContentStateMachine(t *testing.T, f serverlessv1alpha2.Function, objs ...client.Object) *fsm.StateMachine {
+	scheme := runtime.NewScheme()
+	require.NoError(t, serverlessv1alpha2.AddToScheme(scheme))
+	require.NoError(t, corev1.AddToScheme(scheme))
+	return &fsm.StateMachine{
+		State: fsm.SystemState{
+			Function: f,
+		},
+		Log:    zap.NewNop().Sugar(),
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build(),
+		Scheme: scheme,
+	}
+}
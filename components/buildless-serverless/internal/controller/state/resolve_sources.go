@@ -0,0 +1,210 @@
+package state
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+
+	serverlessv1alpha2 "github.com/kyma-project/serverless/components/buildless-serverless/api/v1alpha2"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/fsm"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/oci"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/resources"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// sFnResolveSources resolves the OCI artifact, the HTTP archive or the ConfigMap source to the digest the function's pods fetch
+func sFnResolveSources(ctx context.Context, m *fsm.StateMachine) (fsm.StateFn, *ctrl.Result, error) {
+	f := deployedFunction(m)
+	sourceType := f.ResolvedSourceType()
+	if sourceType == "" {
+		return nextState(sFnConfigurationReady)
+	}
+
+	if m.State.Revision != nil && m.State.Revision.Commit() != "" {
+		// the function rolled back to the revision runs its recorded digest,
+		// the ConfigMap's files are kept in the snapshot, unless the revision was recorded without it
+		recorded, err := hasRecordedSource(ctx, m, f, m.State.Revision.Commit())
+		if err != nil {
+			return stopWithError(errors.Wrap(err, "while checking recorded source"))
+		}
+		if recorded {
+			m.State.Commit = m.State.Revision.Commit()
+			return nextState(sFnConfigurationReady)
+		}
+	}
+
+	digest, err := resolveSourceDigest(ctx, m, f)
+	if err != nil {
+		m.State.Function.UpdateCondition(
+			serverlessv1alpha2.ConditionConfigurationReady,
+			metav1.ConditionFalse,
+			serverlessv1alpha2.ConditionReasonSourceUpdateFailed,
+			fmt.Sprintf("%s source check failed: %s", sourceDescription(f), err.Error()))
+		return stopWithError(err)
+	}
+
+	if current := m.State.Function.Status.Source; current == nil || current.Digest != digest {
+		m.State.Function.UpdateCondition(
+			serverlessv1alpha2.ConditionConfigurationReady,
+			metav1.ConditionTrue,
+			serverlessv1alpha2.ConditionReasonSourceUpdated,
+			fmt.Sprintf("Function source updated to digest %s", digest))
+	}
+
+	m.State.Commit = digest
+	return nextState(sFnConfigurationReady)
+}
+
+func resolveSourceDigest(ctx context.Context, m *fsm.StateMachine, f *serverlessv1alpha2.Function) (string, error) {
+	source := f.Spec.Source
+	switch {
+	case source.OCIArtifact != nil:
+		return resolveOCIArtifactDigest(ctx, m, f)
+	case source.HTTPArchive != nil:
+		// the pods download the archive with the checksum only, so the checksum identifies the archive
+		return source.HTTPArchive.Checksum, nil
+	case source.ConfigMap != nil:
+		configMap := &corev1.ConfigMap{}
+		err := m.Client.Get(ctx, types.NamespacedName{Namespace: f.GetNamespace(), Name: source.ConfigMap.Name}, configMap)
+		if err != nil {
+			return "", err
+		}
+		digest := configMapDigest(configMap)
+		return digest, createSourceSnapshot(ctx, m, f, configMap, digest)
+	}
+	return "", errors.New("unknown source type")
+}
+
+// createSourceSnapshot copies the ConfigMap's files to the snapshot the function's pods mount,
+// so the pods run the files with the digest even when the ConfigMap changes before they start
+func createSourceSnapshot(ctx context.Context, m *fsm.StateMachine, f *serverlessv1alpha2.Function, source *corev1.ConfigMap, digest string) error {
+	name := resources.SourceSnapshotName(f, digest)
+	found, err := getSourceSnapshot(ctx, m, name)
+	if err != nil || found {
+		return err
+	}
+
+	snapshot := resources.NewSourceSnapshot(f, source, digest)
+	if err := controllerutil.SetControllerReference(&m.State.Function, snapshot, m.Scheme); err != nil {
+		return err
+	}
+	m.Log.Info("creating source snapshot", "ConfigMap.Namespace", snapshot.GetNamespace(), "ConfigMap.Name", snapshot.GetName())
+	return client.IgnoreAlreadyExists(m.Client.Create(ctx, snapshot))
+}
+
+// hasRecordedSource checks that the files of the recorded digest can be fetched,
+// the ConfigMap's files are available only in their snapshot
+func hasRecordedSource(ctx context.Context, m *fsm.StateMachine, f *serverlessv1alpha2.Function, digest string) (bool, error) {
+	if f.Spec.Source.ConfigMap == nil {
+		return true, nil
+	}
+	return getSourceSnapshot(ctx, m, resources.SourceSnapshotName(f, digest))
+}
+
+// getSourceSnapshot returns true when the function's snapshot exists, the ConfigMap not controlled by the function is refused
+func getSourceSnapshot(ctx context.Context, m *fsm.StateMachine, name string) (bool, error) {
+	snapshot := &corev1.ConfigMap{}
+	err := m.Client.Get(ctx, types.NamespacedName{Namespace: m.State.Function.GetNamespace(), Name: name}, snapshot)
+	if k8serrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if !metav1.IsControlledBy(snapshot, &m.State.Function) {
+		return false, fmt.Errorf("ConfigMap %s is not a source snapshot of the Function", name)
+	}
+	return true, nil
+}
+
+// pruneSourceSnapshots deletes the function's snapshots of the ConfigMap source, except the ones it runs or recorded in the revisions
+func pruneSourceSnapshots(ctx context.Context, m *fsm.StateMachine, revisions []*resources.Revision) error {
+	f := &m.State.Function
+	keep := []string{resources.SourceSnapshotName(f, m.State.Commit)}
+	for _, r := range revisions {
+		keep = append(keep, resources.SourceSnapshotName(f, r.Commit()))
+	}
+
+	snapshots := &corev1.ConfigMapList{}
+	err := m.Client.List(ctx, snapshots, client.InNamespace(f.GetNamespace()), client.MatchingLabels(resources.SourceSnapshotLabels(f)))
+	if err != nil {
+		return err
+	}
+	for i := range snapshots.Items {
+		snapshot := &snapshots.Items[i]
+		if slices.Contains(keep, snapshot.GetName()) {
+			continue
+		}
+		m.Log.Info("deleting source snapshot", "ConfigMap.Namespace", snapshot.GetNamespace(), "ConfigMap.Name", snapshot.GetName())
+		if err := m.Client.Delete(ctx, snapshot); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func resolveOCIArtifactDigest(ctx context.Context, m *fsm.StateMachine, f *serverlessv1alpha2.Function) (string, error) {
+	if m.OCIResolver == nil {
+		return "", errors.New("OCI artifact resolver is not configured")
+	}
+
+	artifact := f.Spec.Source.OCIArtifact
+	ctx, cancel := gitOperationContext(ctx, m)
+	defer cancel()
+
+	var dockerConfig []byte
+	if artifact.PullSecretName != "" {
+		var err error
+		dockerConfig, err = oci.LoadDockerConfig(ctx, m.Client, types.NamespacedName{Namespace: f.GetNamespace(), Name: artifact.PullSecretName})
+		if err != nil {
+			return "", err
+		}
+	}
+	return m.OCIResolver.Resolve(ctx, artifact.Reference, dockerConfig)
+}
+
+// configMapDigest returns the digest of the ConfigMap's files, it changes whenever any of the files is changed, added or removed
+func configMapDigest(configMap *corev1.ConfigMap) string {
+	files := map[string][]byte{}
+	for name, value := range configMap.Data {
+		files[name] = []byte(value)
+	}
+	for name, value := range configMap.BinaryData {
+		files[name] = value
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	h := sha256.New()
+	for _, name := range names {
+		fmt.Fprintf(h, "%s\x00%d\x00", name, len(files[name]))
+		h.Write(files[name])
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil))
+}
+
+func sourceDescription(f *serverlessv1alpha2.Function) string {
+	source := f.Spec.Source
+	switch {
+	case source.OCIArtifact != nil:
+		return fmt.Sprintf("OCI artifact: %s", source.OCIArtifact.Reference)
+	case source.HTTPArchive != nil:
+		return fmt.Sprintf("HTTP archive: %s", source.HTTPArchive.URL)
+	case source.ConfigMap != nil:
+		return fmt.Sprintf("ConfigMap: %s", source.ConfigMap.Name)
+	}
+	return "Source"
+}
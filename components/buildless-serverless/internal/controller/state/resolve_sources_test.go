@@ -0,0 +1,262 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	serverlessv1alpha2 "github.com/kyma-project/serverless/components/buildless-serverless/api/v1alpha2"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/fsm"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/resources"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const testSourceDigest = "sha256:6c3c624b58dbbcd3c0dd82b4c53f04194d1247c6eebdaab7c610cf7d66709b3b"
+
+type fakeOCIResolver struct {
+	digest       string
+	err          error
+	reference    string
+	dockerConfig []byte
+}
+
+func (r *fakeOCIResolver) Resolve(_ context.Context, reference string, dockerConfig []byte) (string, error) {
+	r.reference = reference
+	r.dockerConfig = dockerConfig
+	return r.digest, r.err
+}
+
+func Test_sFnResolveSources(t *testing.T) {
+	fixMachine := func(source serverlessv1alpha2.Source, objs ...client.Object) *fsm.StateMachine {
+		scheme := runtime.NewScheme()
+		require.NoError(t, corev1.AddToScheme(scheme))
+		require.NoError(t, serverlessv1alpha2.AddToScheme(scheme))
+		return &fsm.StateMachine{
+			State: fsm.SystemState{
+				Function: serverlessv1alpha2.Function{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "nice-matsumoto-name",
+						Namespace: "festive-dewdney-ns",
+						UID:       "nice-matsumoto-uid"},
+					Spec: serverlessv1alpha2.FunctionSpec{
+						Runtime: serverlessv1alpha2.NodeJs24,
+						Source:  source}}},
+			Log:    zap.NewNop().Sugar(),
+			Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build(),
+			Scheme: scheme,
+		}
+	}
+
+	t.Run("resolve OCI artifact with pull secret", func(t *testing.T) {
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "pull-secret", Namespace: "festive-dewdney-ns"},
+			Data:       map[string][]byte{corev1.DockerConfigJsonKey: []byte(`{"auths":{}}`)},
+		}
+		m := fixMachine(serverlessv1alpha2.Source{
+			OCIArtifact: &serverlessv1alpha2.OCIArtifactSource{
+				Reference:      "registry.example.com/team/function:1.0.0",
+				PullSecretName: "pull-secret"}}, secret)
+		resolver := &fakeOCIResolver{digest: testSourceDigest}
+		m.OCIResolver = resolver
+
+		next, result, err := sFnResolveSources(context.Background(), m)
+
+		require.Nil(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnConfigurationReady, next)
+		require.Equal(t, testSourceDigest, m.State.Commit)
+		require.Equal(t, "registry.example.com/team/function:1.0.0", resolver.reference)
+		require.Equal(t, `{"auths":{}}`, string(resolver.dockerConfig))
+		requireContainsCondition(t, m.State.Function.Status,
+			serverlessv1alpha2.ConditionConfigurationReady,
+			metav1.ConditionTrue,
+			serverlessv1alpha2.ConditionReasonSourceUpdated,
+			"Function source updated to digest "+testSourceDigest)
+	})
+	t.Run("stop when OCI artifact can't be resolved", func(t *testing.T) {
+		m := fixMachine(serverlessv1alpha2.Source{
+			OCIArtifact: &serverlessv1alpha2.OCIArtifactSource{Reference: "registry.example.com/team/function:1.0.0"}})
+		m.OCIResolver = &fakeOCIResolver{err: errors.New("unauthorized")}
+
+		next, _, err := sFnResolveSources(context.Background(), m)
+
+		require.Nil(t, next)
+		require.EqualError(t, err, "unauthorized")
+		require.Empty(t, m.State.Commit)
+		requireContainsCondition(t, m.State.Function.Status,
+			serverlessv1alpha2.ConditionConfigurationReady,
+			metav1.ConditionFalse,
+			serverlessv1alpha2.ConditionReasonSourceUpdateFailed,
+			"OCI artifact: registry.example.com/team/function:1.0.0 source check failed: unauthorized")
+	})
+	t.Run("use checksum of HTTP archive as digest", func(t *testing.T) {
+		m := fixMachine(serverlessv1alpha2.Source{
+			HTTPArchive: &serverlessv1alpha2.HTTPArchiveSource{
+				URL:      "https://example.com/function.tar.gz",
+				Checksum: testSourceDigest}})
+
+		next, _, err := sFnResolveSources(context.Background(), m)
+
+		require.Nil(t, err)
+		requireEqualFunc(t, sFnConfigurationReady, next)
+		require.Equal(t, testSourceDigest, m.State.Commit)
+	})
+	t.Run("resolve ConfigMap files to digest", func(t *testing.T) {
+		configMap := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "function-files", Namespace: "festive-dewdney-ns"},
+			Data:       map[string]string{"handler.js": "module.exports = {}", "package.json": "{}"},
+		}
+		m := fixMachine(serverlessv1alpha2.Source{
+			ConfigMap: &serverlessv1alpha2.ConfigMapSource{Name: "function-files"}}, configMap)
+
+		next, _, err := sFnResolveSources(context.Background(), m)
+
+		require.Nil(t, err)
+		requireEqualFunc(t, sFnConfigurationReady, next)
+		require.Equal(t, configMapDigest(configMap), m.State.Commit)
+		snapshot := &corev1.ConfigMap{}
+		require.NoError(t, m.Client.Get(context.Background(), client.ObjectKey{
+			Namespace: "festive-dewdney-ns",
+			Name:      resources.SourceSnapshotName(&m.State.Function, m.State.Commit),
+		}, snapshot))
+		require.Equal(t, configMap.Data, snapshot.Data)
+		require.True(t, *snapshot.Immutable)
+		require.True(t, metav1.IsControlledBy(snapshot, &m.State.Function))
+	})
+	t.Run("keep existing snapshot of ConfigMap files", func(t *testing.T) {
+		configMap := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "function-files", Namespace: "festive-dewdney-ns"},
+			Data:       map[string]string{"handler.js": "module.exports = {}"},
+		}
+		m := fixMachine(serverlessv1alpha2.Source{
+			ConfigMap: &serverlessv1alpha2.ConfigMapSource{Name: "function-files"}}, configMap)
+		_, _, err := sFnResolveSources(context.Background(), m)
+		require.Nil(t, err)
+
+		next, _, err := sFnResolveSources(context.Background(), m)
+
+		require.Nil(t, err)
+		requireEqualFunc(t, sFnConfigurationReady, next)
+		require.Equal(t, configMapDigest(configMap), m.State.Commit)
+	})
+	t.Run("stop when snapshot name is taken by other ConfigMap", func(t *testing.T) {
+		configMap := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "function-files", Namespace: "festive-dewdney-ns"},
+			Data:       map[string]string{"handler.js": "module.exports = {}"},
+		}
+		f := &serverlessv1alpha2.Function{ObjectMeta: metav1.ObjectMeta{Name: "nice-matsumoto-name"}}
+		snapshotName := resources.SourceSnapshotName(f, configMapDigest(configMap))
+		taken := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: snapshotName, Namespace: "festive-dewdney-ns"}}
+		m := fixMachine(serverlessv1alpha2.Source{
+			ConfigMap: &serverlessv1alpha2.ConfigMapSource{Name: "function-files"}}, configMap, taken)
+
+		next, _, err := sFnResolveSources(context.Background(), m)
+
+		require.Nil(t, next)
+		require.EqualError(t, err, "ConfigMap "+snapshotName+" is not a source snapshot of the Function")
+		requireContainsCondition(t, m.State.Function.Status,
+			serverlessv1alpha2.ConditionConfigurationReady,
+			metav1.ConditionFalse,
+			serverlessv1alpha2.ConditionReasonSourceUpdateFailed,
+			"ConfigMap: function-files source check failed: ConfigMap "+snapshotName+" is not a source snapshot of the Function")
+	})
+	t.Run("stop when ConfigMap does not exist", func(t *testing.T) {
+		m := fixMachine(serverlessv1alpha2.Source{
+			ConfigMap: &serverlessv1alpha2.ConfigMapSource{Name: "function-files"}})
+
+		next, _, err := sFnResolveSources(context.Background(), m)
+
+		require.Nil(t, next)
+		require.Error(t, err)
+		requireContainsCondition(t, m.State.Function.Status,
+			serverlessv1alpha2.ConditionConfigurationReady,
+			metav1.ConditionFalse,
+			serverlessv1alpha2.ConditionReasonSourceUpdateFailed,
+			`ConfigMap: function-files source check failed: configmaps "function-files" not found`)
+	})
+	t.Run("run recorded digest of revision", func(t *testing.T) {
+		m := fixMachine(serverlessv1alpha2.Source{
+			OCIArtifact: &serverlessv1alpha2.OCIArtifactSource{Reference: "registry.example.com/team/function:2.0.0"}})
+		m.OCIResolver = &fakeOCIResolver{err: errors.New("registry should not be asked")}
+		old := m.State.Function.DeepCopy()
+		old.Spec.Source.OCIArtifact.Reference = "registry.example.com/team/function:1.0.0"
+		m.State.Revision = resources.NewRevision(old, 1, testSourceDigest, "hash-1")
+
+		next, _, err := sFnResolveSources(context.Background(), m)
+
+		require.Nil(t, err)
+		requireEqualFunc(t, sFnConfigurationReady, next)
+		require.Equal(t, testSourceDigest, m.State.Commit)
+	})
+	t.Run("run recorded ConfigMap files of revision", func(t *testing.T) {
+		configMap := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "function-files", Namespace: "festive-dewdney-ns"},
+			Data:       map[string]string{"handler.js": "module.exports = {}"},
+		}
+		m := fixMachine(serverlessv1alpha2.Source{
+			ConfigMap: &serverlessv1alpha2.ConfigMapSource{Name: "function-files"}}, configMap)
+		_, _, err := sFnResolveSources(context.Background(), m)
+		require.Nil(t, err)
+		recorded := m.State.Commit
+		configMap.Data["handler.js"] = "module.exports = { main: () => {} }"
+		require.NoError(t, m.Client.Update(context.Background(), configMap))
+		m.State.Revision = resources.NewRevision(&m.State.Function, 1, recorded, "hash-1")
+
+		next, _, err := sFnResolveSources(context.Background(), m)
+
+		require.Nil(t, err)
+		requireEqualFunc(t, sFnConfigurationReady, next)
+		require.Equal(t, recorded, m.State.Commit)
+	})
+	t.Run("run current ConfigMap files of revision recorded without snapshot", func(t *testing.T) {
+		configMap := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "function-files", Namespace: "festive-dewdney-ns"},
+			Data:       map[string]string{"handler.js": "module.exports = {}"},
+		}
+		m := fixMachine(serverlessv1alpha2.Source{
+			ConfigMap: &serverlessv1alpha2.ConfigMapSource{Name: "function-files"}}, configMap)
+		m.State.Revision = resources.NewRevision(&m.State.Function, 1, testSourceDigest, "hash-1")
+
+		next, _, err := sFnResolveSources(context.Background(), m)
+
+		require.Nil(t, err)
+		requireEqualFunc(t, sFnConfigurationReady, next)
+		require.Equal(t, configMapDigest(configMap), m.State.Commit)
+	})
+	t.Run("skip inline function", func(t *testing.T) {
+		m := fixMachine(serverlessv1alpha2.Source{Inline: &serverlessv1alpha2.InlineSource{Source: "xenodochial-napier"}})
+
+		next, _, err := sFnResolveSources(context.Background(), m)
+
+		require.Nil(t, err)
+		requireEqualFunc(t, sFnConfigurationReady, next)
+		require.Empty(t, m.State.Function.Status.Conditions)
+	})
+}
+
+func Test_configMapDigest(t *testing.T) {
+	configMap := &corev1.ConfigMap{
+		Data:       map[string]string{"handler.js": "module.exports = {}"},
+		BinaryData: map[string][]byte{"logo.png": {0x89, 0x50}},
+	}
+	digest := configMapDigest(configMap)
+	require.Regexp(t, `^sha256:[0-9a-f]{64}$`, digest)
+
+	changed := configMap.DeepCopy()
+	changed.Data["handler.js"] = "module.exports = { main: () => {} }"
+	require.NotEqual(t, digest, configMapDigest(changed))
+
+	renamed := &corev1.ConfigMap{
+		Data:       map[string]string{"index.js": "module.exports = {}"},
+		BinaryData: map[string][]byte{"logo.png": {0x89, 0x50}},
+	}
+	require.NotEqual(t, digest, configMapDigest(renamed))
+
+	require.Equal(t, digest, configMapDigest(configMap.DeepCopy()))
+}
@@ -0,0 +1,250 @@
+package state
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	serverlessv1alpha2 "github.com/kyma-project/serverless/components/buildless-serverless/api/v1alpha2"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/fsm"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/resources"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	apilabels "k8s.io/apimachinery/pkg/labels"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	defaultRevisionHistoryLimit = 10
+	// maxRevisionNameConflicts limits the revision numbers skipped because their names are taken by other objects
+	maxRevisionNameConflicts = 10
+
+	warningRevisionNameConflictFormat = "Warning: revision name %s is taken by ConfigMap not controlled by the Function, revision %s is recorded instead"
+)
+
+// sFnLoadRevision loads the revision the function is rolled back to
+func sFnLoadRevision(ctx context.Context, m *fsm.StateMachine) (fsm.StateFn, *ctrl.Result, error) {
+	name := m.State.Function.RollbackRevision()
+	if name == "" {
+		m.State.Revision = nil
+		return nextState(sFnHandleGitTokenSecret)
+	}
+
+	revision, err := getRevision(ctx, m, name)
+	if err != nil {
+		m.State.Function.UpdateCondition(
+			serverlessv1alpha2.ConditionConfigurationReady,
+			metav1.ConditionFalse,
+			serverlessv1alpha2.ConditionReasonRevisionFailed,
+			fmt.Sprintf("Revision %s can't be loaded: %s", name, err.Error()))
+		return stopWithError(errors.Wrap(err, "while loading revision"))
+	}
+
+	m.State.Revision = revision
+
+	// the function may be rolled back to a revision using other runtime
+	runtime := deployedFunction(m).Spec.Runtime
+	err = loadFunctionRuntime(ctx, m, runtime)
+	if err != nil {
+		return stopWithError(errors.Wrap(err, "while loading function runtime of revision"))
+	}
+	if _, ok := m.FunctionConfig.RuntimeConfig(string(runtime)); !ok {
+		// the spec's runtime is validated, but the revision's runtime may have been removed since it was recorded
+		m.State.Function.UpdateCondition(
+			serverlessv1alpha2.ConditionConfigurationReady,
+			metav1.ConditionFalse,
+			serverlessv1alpha2.ConditionReasonRevisionFailed,
+			fmt.Sprintf("Revision %s can't be loaded: cannot find runtime: %s", name, runtime))
+		return stop()
+	}
+	return nextState(sFnHandleGitTokenSecret)
+}
+
+// sFnRecordRevision snapshots the running function and garbage-collects revisions exceeding the history limit
+func sFnRecordRevision(ctx context.Context, m *fsm.StateMachine) (fsm.StateFn, *ctrl.Result, error) {
+	f := &m.State.Function
+	if f.Status.Rollout != nil {
+		// the function runs the previous version after the failed canary rollout
+		return nextState(sFnAdjustStatus)
+	}
+
+	revisions, err := listRevisions(ctx, m)
+	if err != nil {
+		return stopWithError(errors.Wrap(err, "while listing revisions"))
+	}
+
+	limit := revisionHistoryLimit(f)
+	deployed := deployedFunction(m)
+	currentIndex := slices.IndexFunc(revisions, func(r *resources.Revision) bool {
+		return r.Matches(deployed, m.State.Commit)
+	})
+	if currentIndex == -1 && limit > 0 {
+		revision, err := createRevision(ctx, m, nextRevisionNumber(revisions), m.State.BuiltDeployment.TemplateHash())
+		if err != nil {
+			return stopWithError(errors.Wrap(err, "while creating revision"))
+		}
+		revisions = append(revisions, revision)
+		currentIndex = len(revisions) - 1
+	}
+
+	current := ""
+	if currentIndex != -1 {
+		current = revisions[currentIndex].GetName()
+	}
+	revisions, err = pruneRevisions(ctx, m, revisions, limit, current, f.RollbackRevision())
+	if err != nil {
+		return stopWithError(errors.Wrap(err, "while deleting revisions"))
+	}
+	if err := pruneSourceSnapshots(ctx, m, revisions); err != nil {
+		return stopWithError(errors.Wrap(err, "while deleting source snapshots"))
+	}
+
+	f.Status.CurrentRevision = current
+	f.Status.Revisions = nil
+	for _, r := range revisions {
+		f.Status.Revisions = append(f.Status.Revisions, r.Status())
+	}
+	return nextState(sFnAdjustStatus)
+}
+
+// deployedFunction returns the function the way it's deployed, i.e. with the source of the revision it's rolled back to
+func deployedFunction(m *fsm.StateMachine) *serverlessv1alpha2.Function {
+	if m.State.Revision == nil {
+		return &m.State.Function
+	}
+	return m.State.Revision.Function(&m.State.Function)
+}
+
+func getRevision(ctx context.Context, m *fsm.StateMachine, name string) (*resources.Revision, error) {
+	f := m.State.Function
+	cm := &corev1.ConfigMap{}
+	err := m.Client.Get(ctx, client.ObjectKey{Namespace: f.GetNamespace(), Name: name}, cm)
+	if err != nil {
+		return nil, err
+	}
+	if !apilabels.SelectorFromSet(resources.RevisionLabels(&f)).Matches(apilabels.Set(cm.GetLabels())) {
+		return nil, fmt.Errorf("ConfigMap %s is not a revision of the Function", name)
+	}
+	return resources.ParseRevision(cm)
+}
+
+// listRevisions returns the function's revisions sorted from the oldest to the newest
+func listRevisions(ctx context.Context, m *fsm.StateMachine) ([]*resources.Revision, error) {
+	f := m.State.Function
+	cms := &corev1.ConfigMapList{}
+	err := m.Client.List(ctx, cms, client.InNamespace(f.GetNamespace()), client.MatchingLabels(resources.RevisionLabels(&f)))
+	if err != nil {
+		return nil, err
+	}
+
+	revisions := []*resources.Revision{}
+	for i := range cms.Items {
+		revision, err := resources.ParseRevision(&cms.Items[i])
+		if err != nil {
+			m.Log.Warnf("skipping invalid revision: %s", err.Error())
+			continue
+		}
+		revisions = append(revisions, revision)
+	}
+	slices.SortFunc(revisions, func(a, b *resources.Revision) int {
+		return int(a.Number() - b.Number())
+	})
+	return revisions, nil
+}
+
+// createRevision records the revision with the first number whose name is free, the names taken by other ConfigMaps are skipped
+func createRevision(ctx context.Context, m *fsm.StateMachine, number int64, templateHash string) (*resources.Revision, error) {
+	taken := []string{}
+	for range maxRevisionNameConflicts + 1 {
+		revision, err := createRevisionWithNumber(ctx, m, number, templateHash)
+		if !k8serrors.IsAlreadyExists(err) {
+			if err == nil && len(taken) > 0 {
+				m.State.Function.UpdateCondition(
+					serverlessv1alpha2.ConditionConfigurationReady,
+					metav1.ConditionTrue,
+					serverlessv1alpha2.ConditionReasonRevisionNameConflict,
+					fmt.Sprintf(warningRevisionNameConflictFormat, strings.Join(taken, ", "), revision.GetName()))
+			}
+			return revision, err
+		}
+
+		existing := &corev1.ConfigMap{}
+		err = m.Client.Get(ctx, client.ObjectKeyFromObject(revision.ConfigMap), existing)
+		if client.IgnoreNotFound(err) != nil {
+			return nil, err
+		}
+		if err == nil && !metav1.IsControlledBy(existing, &m.State.Function) {
+			taken = append(taken, revision.GetName())
+		}
+		// the function's own ConfigMap not listed as its revision is skipped as well, it's not overwritten
+		m.Log.Infof("revision name %s is taken, skipping revision number %d", revision.GetName(), number)
+		number++
+	}
+
+	m.State.Function.UpdateCondition(
+		serverlessv1alpha2.ConditionConfigurationReady,
+		metav1.ConditionFalse,
+		serverlessv1alpha2.ConditionReasonRevisionNameConflict,
+		fmt.Sprintf("Revision can't be recorded: names of %d following revisions are taken by other ConfigMaps", maxRevisionNameConflicts+1))
+	return nil, fmt.Errorf("names of %d following revisions are taken", maxRevisionNameConflicts+1)
+}
+
+func createRevisionWithNumber(ctx context.Context, m *fsm.StateMachine, number int64, templateHash string) (*resources.Revision, error) {
+	revision := resources.NewRevision(deployedFunction(m), number, m.State.Commit, templateHash)
+	m.Log.Info("creating a new revision", "ConfigMap.Namespace", revision.GetNamespace(), "ConfigMap.Name", revision.GetName())
+
+	// Set the ownerRef for the ConfigMap, ensuring that the revision
+	// will be deleted when the Function CR is deleted.
+	if err := controllerutil.SetControllerReference(&m.State.Function, revision.ConfigMap, m.Scheme); err != nil {
+		m.Log.Error(err, "failed to set controller reference for new revision", "ConfigMap.Namespace", revision.GetNamespace(), "ConfigMap.Name", revision.GetName())
+		return nil, err
+	}
+	if err := m.Client.Create(ctx, revision.ConfigMap); err != nil {
+		if k8serrors.IsAlreadyExists(err) {
+			// the name is returned to let the caller check who took it
+			return revision, err
+		}
+		m.Log.Error(err, "failed to create new revision", "ConfigMap.Namespace", revision.GetNamespace(), "ConfigMap.Name", revision.GetName())
+		return nil, err
+	}
+	return revision, nil
+}
+
+// pruneRevisions deletes the oldest revisions exceeding the limit, except the ones the function runs or is rolled back to
+func pruneRevisions(ctx context.Context, m *fsm.StateMachine, revisions []*resources.Revision, limit int, protected ...string) ([]*resources.Revision, error) {
+	result := []*resources.Revision{}
+	excess := len(revisions) - limit
+	for _, revision := range revisions {
+		if excess <= 0 || slices.Contains(protected, revision.GetName()) {
+			result = append(result, revision)
+			continue
+		}
+
+		m.Log.Info("deleting revision", "ConfigMap.Namespace", revision.GetNamespace(), "ConfigMap.Name", revision.GetName())
+		if err := m.Client.Delete(ctx, revision.ConfigMap); client.IgnoreNotFound(err) != nil {
+			m.Log.Error(err, "failed to delete revision", "ConfigMap.Namespace", revision.GetNamespace(), "ConfigMap.Name", revision.GetName())
+			return nil, err
+		}
+		excess--
+	}
+	return result, nil
+}
+
+func nextRevisionNumber(revisions []*resources.Revision) int64 {
+	if len(revisions) == 0 {
+		return 1
+	}
+	return revisions[len(revisions)-1].Number() + 1
+}
+
+func revisionHistoryLimit(f *serverlessv1alpha2.Function) int {
+	if f.Spec.RevisionHistoryLimit == nil {
+		return defaultRevisionHistoryLimit
+	}
+	return int(*f.Spec.RevisionHistoryLimit)
+}
@@ -0,0 +1,320 @@
+package state
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	serverlessv1alpha2 "github.com/kyma-project/serverless/components/buildless-serverless/api/v1alpha2"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/config"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/fsm"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/resources"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func Test_sFnLoadRevision(t *testing.T) {
+	t.Run("when revision is not set should go to the next state", func(t *testing.T) {
+		m := fixRevisionStateMachine(t, fixRevisionFunction("current-source"))
+
+		next, result, err := sFnLoadRevision(context.Background(), m)
+
+		require.NoError(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnHandleGitTokenSecret, next)
+		require.Nil(t, m.State.Revision)
+	})
+	t.Run("should load revision the function is rolled back to", func(t *testing.T) {
+		f := fixRevisionFunction("current-source")
+		f.Spec.Revision = "revision-function-rev-1"
+		revision := resources.NewRevision(ptr.To(fixRevisionFunction("old-source")), 1, "", "hash-1")
+		m := fixRevisionStateMachine(t, f, revision.ConfigMap)
+
+		next, result, err := sFnLoadRevision(context.Background(), m)
+
+		require.NoError(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnHandleGitTokenSecret, next)
+		require.NotNil(t, m.State.Revision)
+		require.Equal(t, "old-source", deployedFunction(m).Spec.Source.Inline.Source)
+		require.Equal(t, "current-source", m.State.Function.Spec.Source.Inline.Source)
+	})
+	t.Run("should load revision from rollback annotation", func(t *testing.T) {
+		f := fixRevisionFunction("current-source")
+		f.Annotations = map[string]string{serverlessv1alpha2.FunctionRollbackRevisionAnnotation: "revision-function-rev-1"}
+		old := fixRevisionFunction("old-source")
+		old.Spec.Env = []corev1.EnvVar{{Name: "LOG_LEVEL", Value: "debug"}}
+		revision := resources.NewRevision(&old, 1, "", "hash-1")
+		m := fixRevisionStateMachine(t, f, revision.ConfigMap)
+
+		next, result, err := sFnLoadRevision(context.Background(), m)
+
+		require.NoError(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnHandleGitTokenSecret, next)
+		require.NotNil(t, m.State.Revision)
+		require.Equal(t, "old-source", deployedFunction(m).Spec.Source.Inline.Source)
+		require.Equal(t, old.Spec.Env, deployedFunction(m).Spec.Env)
+	})
+	t.Run("when revision does not exist should stop processing", func(t *testing.T) {
+		f := fixRevisionFunction("current-source")
+		f.Spec.Revision = "revision-function-rev-1"
+		m := fixRevisionStateMachine(t, f)
+
+		next, result, err := sFnLoadRevision(context.Background(), m)
+
+		require.ErrorContains(t, err, "while loading revision")
+		require.Nil(t, result)
+		require.Nil(t, next)
+		requireContainsConditionWithMessagePattern(t, m.State.Function.Status,
+			serverlessv1alpha2.ConditionConfigurationReady,
+			metav1.ConditionFalse,
+			serverlessv1alpha2.ConditionReasonRevisionFailed,
+			"^Revision revision-function-rev-1 can't be loaded: .*not found$")
+	})
+	t.Run("when revision's runtime does not exist should stop processing", func(t *testing.T) {
+		f := fixRevisionFunction("current-source")
+		f.Spec.Revision = "revision-function-rev-1"
+		old := fixRevisionFunction("old-source")
+		old.Spec.Runtime = "removed-runtime"
+		revision := resources.NewRevision(&old, 1, "", "hash-1")
+		m := fixRevisionStateMachine(t, f, revision.ConfigMap)
+
+		next, result, err := sFnLoadRevision(context.Background(), m)
+
+		require.NoError(t, err)
+		require.Nil(t, result)
+		require.Nil(t, next)
+		requireContainsConditionWithMessagePattern(t, m.State.Function.Status,
+			serverlessv1alpha2.ConditionConfigurationReady,
+			metav1.ConditionFalse,
+			serverlessv1alpha2.ConditionReasonRevisionFailed,
+			"^Revision revision-function-rev-1 can't be loaded: cannot find runtime: removed-runtime$")
+	})
+	t.Run("when config map is not the function's revision should stop processing", func(t *testing.T) {
+		f := fixRevisionFunction("current-source")
+		f.Spec.Revision = "other-config-map"
+		m := fixRevisionStateMachine(t, f, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "other-config-map", Namespace: f.GetNamespace()},
+		})
+
+		next, result, err := sFnLoadRevision(context.Background(), m)
+
+		require.ErrorContains(t, err, "ConfigMap other-config-map is not a revision of the Function")
+		require.Nil(t, result)
+		require.Nil(t, next)
+	})
+}
+
+func Test_sFnRecordRevision(t *testing.T) {
+	t.Run("should record new revision of running function", func(t *testing.T) {
+		f := fixRevisionFunction("current-source")
+		old := resources.NewRevision(ptr.To(fixRevisionFunction("old-source")), 4, "", "old-hash")
+		m := fixRevisionStateMachine(t, f, old.ConfigMap)
+
+		next, result, err := sFnRecordRevision(context.Background(), m)
+
+		require.NoError(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnAdjustStatus, next)
+		require.Equal(t, "revision-function-rev-5", m.State.Function.Status.CurrentRevision)
+		require.Len(t, m.State.Function.Status.Revisions, 2)
+		require.Equal(t, "revision-function-rev-4", m.State.Function.Status.Revisions[0].Name)
+		require.Equal(t, "revision-function-rev-5", m.State.Function.Status.Revisions[1].Name)
+		require.Equal(t, m.State.BuiltDeployment.TemplateHash(), m.State.Function.Status.Revisions[1].TemplateHash)
+		cm := &corev1.ConfigMap{}
+		require.NoError(t, m.Client.Get(context.Background(), client.ObjectKey{Namespace: f.GetNamespace(), Name: "revision-function-rev-5"}, cm))
+		require.Equal(t, "Function", cm.OwnerReferences[0].Kind)
+		require.Contains(t, cm.Data["spec"], "current-source")
+	})
+	t.Run("should record new revision when only pod settings changed", func(t *testing.T) {
+		f := fixRevisionFunction("current-source")
+		f.Spec.Env = []corev1.EnvVar{{Name: "LOG_LEVEL", Value: "debug"}}
+		old := resources.NewRevision(ptr.To(fixRevisionFunction("current-source")), 1, "", "old-hash")
+		m := fixRevisionStateMachine(t, f, old.ConfigMap)
+
+		_, _, err := sFnRecordRevision(context.Background(), m)
+
+		require.NoError(t, err)
+		require.Equal(t, "revision-function-rev-2", m.State.Function.Status.CurrentRevision)
+		require.Len(t, m.State.Function.Status.Revisions, 2)
+	})
+	t.Run("should not record revision when only template hash changed", func(t *testing.T) {
+		f := fixRevisionFunction("current-source")
+		// scaling is not recorded in the revision
+		f.Spec.Replicas = ptr.To[int32](3)
+		old := resources.NewRevision(ptr.To(fixRevisionFunction("current-source")), 1, "", "old-hash")
+		m := fixRevisionStateMachine(t, f, old.ConfigMap)
+
+		_, _, err := sFnRecordRevision(context.Background(), m)
+
+		require.NoError(t, err)
+		require.Equal(t, "revision-function-rev-1", m.State.Function.Status.CurrentRevision)
+		require.Len(t, m.State.Function.Status.Revisions, 1)
+	})
+	t.Run("should record new revision of other commit", func(t *testing.T) {
+		f := fixRevisionFunction("current-source")
+		old := resources.NewRevision(&f, 1, "old-commit", "old-hash")
+		m := fixRevisionStateMachine(t, f, old.ConfigMap)
+		m.State.Commit = "new-commit"
+
+		_, _, err := sFnRecordRevision(context.Background(), m)
+
+		require.NoError(t, err)
+		require.Equal(t, "revision-function-rev-2", m.State.Function.Status.CurrentRevision)
+		require.Equal(t, "new-commit", m.State.Function.Status.Revisions[1].Commit)
+	})
+	t.Run("should not record the same revision twice", func(t *testing.T) {
+		f := fixRevisionFunction("current-source")
+		m := fixRevisionStateMachine(t, f)
+		current := resources.NewRevision(&f, 2, "", m.State.BuiltDeployment.TemplateHash())
+		require.NoError(t, m.Client.Create(context.Background(), current.ConfigMap))
+
+		next, result, err := sFnRecordRevision(context.Background(), m)
+
+		require.NoError(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnAdjustStatus, next)
+		require.Equal(t, "revision-function-rev-2", m.State.Function.Status.CurrentRevision)
+		require.Len(t, m.State.Function.Status.Revisions, 1)
+	})
+	t.Run("should skip revision name taken by other config map", func(t *testing.T) {
+		f := fixRevisionFunction("current-source")
+		old := resources.NewRevision(ptr.To(fixRevisionFunction("old-source")), 1, "", "old-hash")
+		taken := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "revision-function-rev-2", Namespace: "revision-namespace"}}
+		m := fixRevisionStateMachine(t, f, old.ConfigMap, taken)
+
+		next, result, err := sFnRecordRevision(context.Background(), m)
+
+		require.NoError(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnAdjustStatus, next)
+		require.Equal(t, "revision-function-rev-3", m.State.Function.Status.CurrentRevision)
+		requireContainsCondition(t, m.State.Function.Status,
+			serverlessv1alpha2.ConditionConfigurationReady,
+			metav1.ConditionTrue,
+			serverlessv1alpha2.ConditionReasonRevisionNameConflict,
+			"Warning: revision name revision-function-rev-2 is taken by ConfigMap not controlled by the Function, revision revision-function-rev-3 is recorded instead")
+		// the other config map is not overwritten
+		cm := &corev1.ConfigMap{}
+		require.NoError(t, m.Client.Get(context.Background(), client.ObjectKeyFromObject(taken), cm))
+		require.Empty(t, cm.Data)
+	})
+	t.Run("should stop when too many revision names are taken", func(t *testing.T) {
+		f := fixRevisionFunction("current-source")
+		var cms []client.Object
+		for i := range maxRevisionNameConflicts + 1 {
+			cms = append(cms, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("revision-function-rev-%d", i+1),
+				Namespace: "revision-namespace",
+			}})
+		}
+		m := fixRevisionStateMachine(t, f, cms...)
+
+		next, result, err := sFnRecordRevision(context.Background(), m)
+
+		require.ErrorContains(t, err, "names of 11 following revisions are taken")
+		require.Nil(t, next)
+		require.Nil(t, result)
+		requireContainsCondition(t, m.State.Function.Status,
+			serverlessv1alpha2.ConditionConfigurationReady,
+			metav1.ConditionFalse,
+			serverlessv1alpha2.ConditionReasonRevisionNameConflict,
+			"Revision can't be recorded: names of 11 following revisions are taken by other ConfigMaps")
+	})
+	t.Run("should delete revisions exceeding the history limit", func(t *testing.T) {
+		f := fixRevisionFunction("current-source")
+		f.Spec.RevisionHistoryLimit = ptr.To[int32](2)
+		f.Spec.Revision = "revision-function-rev-1"
+		var cms []client.Object
+		for i, hash := range []string{"hash-1", "hash-2", "hash-3"} {
+			cms = append(cms, resources.NewRevision(ptr.To(fixRevisionFunction("old-source")), int64(i+1), "", hash).ConfigMap)
+		}
+		m := fixRevisionStateMachine(t, f, cms...)
+
+		_, _, err := sFnRecordRevision(context.Background(), m)
+
+		require.NoError(t, err)
+		// the revision the function is rolled back to is kept
+		revisionNames := []string{}
+		for _, r := range m.State.Function.Status.Revisions {
+			revisionNames = append(revisionNames, r.Name)
+		}
+		require.Equal(t, []string{"revision-function-rev-1", "revision-function-rev-4"}, revisionNames)
+		cmList := &corev1.ConfigMapList{}
+		require.NoError(t, m.Client.List(context.Background(), cmList))
+		require.Len(t, cmList.Items, 2)
+	})
+	t.Run("should delete source snapshots not recorded in revisions", func(t *testing.T) {
+		f := fixRevisionFunction("current-source")
+		f.Spec.Source = serverlessv1alpha2.Source{ConfigMap: &serverlessv1alpha2.ConfigMapSource{Name: "function-files"}}
+		recorded := resources.NewRevision(&f, 1, "sha256:1111111111111111", "old-hash")
+		snapshot := func(digest string) client.Object {
+			return resources.NewSourceSnapshot(&f, &corev1.ConfigMap{}, digest)
+		}
+		m := fixRevisionStateMachine(t, f, recorded.ConfigMap,
+			snapshot("sha256:1111111111111111"), snapshot("sha256:2222222222222222"), snapshot("sha256:3333333333333333"))
+		m.State.Commit = "sha256:3333333333333333"
+
+		_, _, err := sFnRecordRevision(context.Background(), m)
+
+		require.NoError(t, err)
+		cmList := &corev1.ConfigMapList{}
+		require.NoError(t, m.Client.List(context.Background(), cmList, client.MatchingLabels(resources.SourceSnapshotLabels(&f))))
+		snapshotNames := []string{}
+		for _, cm := range cmList.Items {
+			snapshotNames = append(snapshotNames, cm.GetName())
+		}
+		require.ElementsMatch(t, []string{"revision-function-source-111111111111", "revision-function-source-333333333333"}, snapshotNames)
+	})
+	t.Run("should not record revision when history is disabled", func(t *testing.T) {
+		f := fixRevisionFunction("current-source")
+		f.Spec.RevisionHistoryLimit = ptr.To[int32](0)
+		m := fixRevisionStateMachine(t, f)
+
+		_, _, err := sFnRecordRevision(context.Background(), m)
+
+		require.NoError(t, err)
+		require.Empty(t, m.State.Function.Status.CurrentRevision)
+		require.Empty(t, m.State.Function.Status.Revisions)
+	})
+}
+
+func fixRevisionFunction(source string) serverlessv1alpha2.Function {
+	return serverlessv1alpha2.Function{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "revision-function",
+			Namespace: "revision-namespace",
+			UID:       "revision-uid",
+		},
+		Spec: serverlessv1alpha2.FunctionSpec{
+			Runtime: serverlessv1alpha2.NodeJs24,
+			Source: serverlessv1alpha2.Source{
+				Inline: &serverlessv1alpha2.InlineSource{
+					Source: source,
+				},
+			},
+		},
+	}
+}
+
+func fixRevisionStateMachine(t *testing.T, f serverlessv1alpha2.Function, objs ...client.Object) *fsm.StateMachine {
+	scheme := runtime.NewScheme()
+	require.NoError(t, serverlessv1alpha2.AddToScheme(scheme))
+	require.NoError(t, corev1.AddToScheme(scheme))
+	return &fsm.StateMachine{
+		State: fsm.SystemState{
+			Function:        f,
+			BuiltDeployment: resources.NewDeployment(&f, &config.FunctionConfig{}, "", nil, "", false),
+		},
+		Log:    zap.NewNop().Sugar(),
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build(),
+		Scheme: scheme,
+	}
+}
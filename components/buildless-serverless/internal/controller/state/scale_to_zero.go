@@ -0,0 +1,27 @@
+package state
+
+import (
+	"time"
+
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/fsm"
+)
+
+// isIdle returns true when the scale-to-zero function has not received any request for the idle window
+func isIdle(m *fsm.StateMachine) bool {
+	return m.State.Function.IsScaleToZeroEnabled() && idleTimeLeft(m) <= 0
+}
+
+func idleTimeLeft(m *fsm.StateMachine) time.Duration {
+	idleSince := m.State.Function.LastActivityTime()
+	return m.FunctionConfig.ScaleToZero.IdleWindow - time.Since(idleSince)
+}
+
+func readyRequeueDuration(m *fsm.StateMachine) time.Duration {
+	requeueDuration := m.FunctionConfig.FunctionReadyRequeueDuration
+	if !m.State.Function.IsScaleToZeroEnabled() || m.State.ScaledToZero {
+		return requeueDuration
+	}
+
+	// reconcile the function as soon as it becomes idle to scale it to zero
+	return min(requeueDuration, idleTimeLeft(m))
+}
@@ -0,0 +1,118 @@
+package state
+
+import (
+	"testing"
+	"time"
+
+	serverlessv1alpha2 "github.com/kyma-project/serverless/components/buildless-serverless/api/v1alpha2"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/config"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/fsm"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+func Test_isIdle(t *testing.T) {
+	tests := []struct {
+		name         string
+		scaleConfig  *serverlessv1alpha2.ScaleConfig
+		created      time.Time
+		lastActivity string
+		want         bool
+	}{
+		{
+			name:    "scale config is not set",
+			created: time.Now().Add(-time.Hour),
+			want:    false,
+		},
+		{
+			name:        "scale to zero is disabled",
+			scaleConfig: &serverlessv1alpha2.ScaleConfig{MinReplicas: ptr.To[int32](1)},
+			created:     time.Now().Add(-time.Hour),
+			want:        false,
+		},
+		{
+			name:        "function created within idle window",
+			scaleConfig: &serverlessv1alpha2.ScaleConfig{MinReplicas: ptr.To[int32](0)},
+			created:     time.Now().Add(-time.Minute),
+			want:        false,
+		},
+		{
+			name:        "function without activity since creation",
+			scaleConfig: &serverlessv1alpha2.ScaleConfig{MinReplicas: ptr.To[int32](0)},
+			created:     time.Now().Add(-time.Hour),
+			want:        true,
+		},
+		{
+			name:         "function with recent activity",
+			scaleConfig:  &serverlessv1alpha2.ScaleConfig{MinReplicas: ptr.To[int32](0)},
+			created:      time.Now().Add(-time.Hour),
+			lastActivity: time.Now().Add(-time.Minute).Format(time.RFC3339),
+			want:         false,
+		},
+		{
+			name:         "function with old activity",
+			scaleConfig:  &serverlessv1alpha2.ScaleConfig{MinReplicas: ptr.To[int32](0)},
+			created:      time.Now().Add(-time.Hour),
+			lastActivity: time.Now().Add(-20 * time.Minute).Format(time.RFC3339),
+			want:         true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := serverlessv1alpha2.Function{
+				ObjectMeta: metav1.ObjectMeta{
+					CreationTimestamp: metav1.NewTime(tt.created)},
+				Spec: serverlessv1alpha2.FunctionSpec{
+					ScaleConfig: tt.scaleConfig}}
+			if tt.lastActivity != "" {
+				f.SetAnnotations(map[string]string{
+					serverlessv1alpha2.FunctionLastActivityAnnotation: tt.lastActivity})
+			}
+			m := &fsm.StateMachine{
+				State: fsm.SystemState{Function: f},
+				FunctionConfig: config.FunctionConfig{
+					ScaleToZero: config.ScaleToZeroConfig{IdleWindow: 15 * time.Minute}}}
+
+			require.Equal(t, tt.want, isIdle(m))
+		})
+	}
+}
+
+func Test_readyRequeueDuration(t *testing.T) {
+	fc := config.FunctionConfig{
+		FunctionReadyRequeueDuration: 5 * time.Minute,
+		ScaleToZero: config.ScaleToZeroConfig{
+			IdleWindow: 15 * time.Minute}}
+
+	t.Run("requeue after duration from config", func(t *testing.T) {
+		m := &fsm.StateMachine{FunctionConfig: fc}
+
+		require.Equal(t, 5*time.Minute, readyRequeueDuration(m))
+	})
+	t.Run("requeue when scale-to-zero function becomes idle", func(t *testing.T) {
+		m := &fsm.StateMachine{
+			State: fsm.SystemState{
+				Function: serverlessv1alpha2.Function{
+					ObjectMeta: metav1.ObjectMeta{
+						CreationTimestamp: metav1.NewTime(time.Now().Add(-time.Hour)),
+						Annotations: map[string]string{
+							serverlessv1alpha2.FunctionLastActivityAnnotation: time.Now().Add(-14 * time.Minute).Format(time.RFC3339)}},
+					Spec: serverlessv1alpha2.FunctionSpec{
+						ScaleConfig: &serverlessv1alpha2.ScaleConfig{MinReplicas: ptr.To[int32](0)}}}},
+			FunctionConfig: fc}
+
+		require.LessOrEqual(t, readyRequeueDuration(m), time.Minute)
+	})
+	t.Run("requeue after duration from config when function is scaled to zero", func(t *testing.T) {
+		m := &fsm.StateMachine{
+			State: fsm.SystemState{
+				Function: serverlessv1alpha2.Function{
+					Spec: serverlessv1alpha2.FunctionSpec{
+						ScaleConfig: &serverlessv1alpha2.ScaleConfig{MinReplicas: ptr.To[int32](0)}}},
+				ScaledToZero: true},
+			FunctionConfig: fc}
+
+		require.Equal(t, 5*time.Minute, readyRequeueDuration(m))
+	})
+}
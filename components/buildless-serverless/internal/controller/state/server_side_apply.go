@@ -0,0 +1,40 @@
+package state
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/fsm"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/util/csaupgrade"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// fieldManager owns the fields of the Function's resources set by the controller
+	fieldManager = "function-controller"
+	// legacyFieldManager owns the fields of the resources updated before the controller applied them,
+	// it's the name of the controller's binary used by the API server when no field manager is given
+	legacyFieldManager = "manager"
+)
+
+// upgradeManagedFields moves the fields owned by the controller's updates to the fields owned by its applies,
+// otherwise the fields removed from the applied resource would be kept by the updates' ownership
+func upgradeManagedFields(ctx context.Context, m *fsm.StateMachine, obj client.Object) error {
+	patch, err := csaupgrade.UpgradeManagedFieldsPatch(obj, sets.New(legacyFieldManager, fieldManager), fieldManager)
+	if err != nil || patch == nil {
+		return err
+	}
+	return m.Client.Patch(ctx, obj, client.RawPatch(types.JSONPatchType, patch))
+}
+
+// convertObject converts the resource to its apply configuration and the other way round,
+// both have the same JSON representation
+func convertObject(from, to any) error {
+	data, err := json.Marshal(from)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, to)
+}
@@ -24,5 +24,5 @@ func sFnValidateFunction(_ context.Context, m *fsm.StateMachine) (fsm.StateFn, *
 		return stop()
 	}
 
-	return nextState(sFnHandleGitSources)
+	return nextState(sFnLoadRevision)
 }
@@ -30,7 +30,7 @@ func Test_sFnValidateFunction(t *testing.T) {
 		require.Nil(t, result)
 		// with expected next state
 		require.NotNil(t, next)
-		requireEqualFunc(t, sFnHandleGitSources, next)
+		requireEqualFunc(t, sFnLoadRevision, next)
 		// function has unchanged conditions
 		require.Empty(t, m.State.Function.Status.Conditions)
 	})
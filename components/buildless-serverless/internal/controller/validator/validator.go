@@ -3,8 +3,10 @@ package validator
 import (
 	"errors"
 	"fmt"
+	"maps"
 	"net/url"
 	"regexp"
+	"slices"
 	"strings"
 
 	serverlessv1alpha2 "github.com/kyma-project/serverless/components/buildless-serverless/api/v1alpha2"
@@ -38,11 +40,18 @@ func (v *validator) Validate() []string {
 		v.validateInlineDeps,
 		v.validateRuntime,
 		v.validateSecretMounts,
+		v.validateConfigMapMounts,
+		v.validateServiceAccountTokenMounts,
+		v.validateMountPaths,
+		v.validateEnvFrom,
 		v.validateFunctionLabels,
 		v.validateFunctionAnnotations,
 		v.validateGitRepoURL,
 		v.validateFips,
 		v.validateFunctionResources,
+		v.validateScaleConfig,
+		v.validateProbes,
+		v.validateScheduling,
 	}
 
 	r := []string{}
@@ -69,7 +78,7 @@ func (v *validator) validateInlineDeps() []string {
 	if inlineSource == nil {
 		return []string{}
 	}
-	if err := validateDependencies(runtime, inlineSource.Dependencies); err != nil {
+	if err := validateDependencies(&v.fnConfig, runtime, inlineSource.Dependencies); err != nil {
 		return []string{
 			fmt.Sprintf("invalid source.inline.dependencies value: %s", err.Error()),
 		}
@@ -80,7 +89,7 @@ func (v *validator) validateInlineDeps() []string {
 func (v *validator) validateRuntime() []string {
 	runtime := v.instance.Spec.Runtime
 
-	if err := validateRuntime(runtime); err != nil {
+	if err := validateRuntime(&v.fnConfig, runtime); err != nil {
 		return []string{
 			fmt.Sprintf("invalid runtime value: %s", err.Error()),
 		}
@@ -91,11 +100,14 @@ func (v *validator) validateRuntime() []string {
 func (v *validator) validateSecretMounts() []string {
 	secretMounts := v.instance.Spec.SecretMounts
 	var allErrs []string
+	secretNames := []string{}
 	for _, secretMount := range secretMounts {
 		allErrs = append(allErrs,
 			utilvalidation.IsDNS1123Subdomain(secretMount.SecretName)...)
+		allErrs = append(allErrs, validateKeysToPaths(secretMount.Items)...)
+		secretNames = append(secretNames, secretMount.SecretName)
 	}
-	if !secretNamesAreUnique(secretMounts) {
+	if !areUnique(secretNames) {
 		allErrs = append(allErrs, "secretNames should be unique")
 	}
 	if len(allErrs) == 0 {
@@ -106,6 +118,126 @@ func (v *validator) validateSecretMounts() []string {
 	}
 }
 
+func (v *validator) validateConfigMapMounts() []string {
+	configMapMounts := v.instance.Spec.ConfigMapMounts
+	var allErrs []string
+	configMapNames := []string{}
+	for _, configMapMount := range configMapMounts {
+		allErrs = append(allErrs,
+			utilvalidation.IsDNS1123Subdomain(configMapMount.ConfigMapName)...)
+		allErrs = append(allErrs, validateKeysToPaths(configMapMount.Items)...)
+		configMapNames = append(configMapNames, configMapMount.ConfigMapName)
+	}
+	if !areUnique(configMapNames) {
+		allErrs = append(allErrs, "configMapNames should be unique")
+	}
+	// the secrets and config maps are mounted as the volumes named after them
+	for _, secretMount := range v.instance.Spec.SecretMounts {
+		if slices.Contains(configMapNames, secretMount.SecretName) {
+			allErrs = append(allErrs, fmt.Sprintf("configMapName %s is already used by spec.secretMounts", secretMount.SecretName))
+		}
+	}
+	if len(allErrs) == 0 {
+		return []string{}
+	}
+	return []string{
+		fmt.Sprintf("invalid spec.configMapMounts: %s", allErrs),
+	}
+}
+
+// minTokenExpirationSeconds is the shortest validity of the projected token accepted by the API server
+const minTokenExpirationSeconds = 600
+
+func (v *validator) validateServiceAccountTokenMounts() []string {
+	var allErrs []string
+	for _, tokenMount := range v.instance.Spec.ServiceAccountTokenMounts {
+		if tokenMount.Audience == "" {
+			allErrs = append(allErrs, "audience should not be empty")
+		}
+		if tokenMount.ExpirationSeconds != nil && *tokenMount.ExpirationSeconds < minTokenExpirationSeconds {
+			allErrs = append(allErrs, fmt.Sprintf("expirationSeconds(%d) should be higher than or equal to %d", *tokenMount.ExpirationSeconds, minTokenExpirationSeconds))
+		}
+		if tokenMount.Path != "" && !isRelativeFilePath(tokenMount.Path) {
+			allErrs = append(allErrs, fmt.Sprintf("path %s should be a relative path without '..'", tokenMount.Path))
+		}
+	}
+	if len(allErrs) == 0 {
+		return []string{}
+	}
+	return []string{
+		fmt.Sprintf("invalid spec.serviceAccountTokenMounts: %s", allErrs),
+	}
+}
+
+// validateMountPaths checks the mounts of all kinds, they are mounted into the same container
+func (v *validator) validateMountPaths() []string {
+	mountPaths := []string{}
+	for _, secretMount := range v.instance.Spec.SecretMounts {
+		mountPaths = append(mountPaths, secretMount.MountPath)
+	}
+	for _, configMapMount := range v.instance.Spec.ConfigMapMounts {
+		mountPaths = append(mountPaths, configMapMount.MountPath)
+	}
+	for _, tokenMount := range v.instance.Spec.ServiceAccountTokenMounts {
+		mountPaths = append(mountPaths, tokenMount.MountPath)
+	}
+	if !areUnique(mountPaths) {
+		return []string{"mountPaths of spec.secretMounts, spec.configMapMounts and spec.serviceAccountTokenMounts should be unique"}
+	}
+	return []string{}
+}
+
+func (v *validator) validateEnvFrom() []string {
+	var allErrs []string
+	sources := []string{}
+	for _, envFrom := range v.instance.Spec.EnvFrom {
+		if envFrom.Prefix != "" {
+			allErrs = append(allErrs, utilvalidation.IsEnvVarName(envFrom.Prefix)...)
+		}
+		switch {
+		case envFrom.ConfigMapRef != nil && envFrom.SecretRef != nil:
+			allErrs = append(allErrs, "only one of configMapRef and secretRef should be set")
+		case envFrom.ConfigMapRef != nil:
+			allErrs = append(allErrs, utilvalidation.IsDNS1123Subdomain(envFrom.ConfigMapRef.Name)...)
+			sources = append(sources, fmt.Sprintf("configMap/%s/%s", envFrom.ConfigMapRef.Name, envFrom.Prefix))
+		case envFrom.SecretRef != nil:
+			allErrs = append(allErrs, utilvalidation.IsDNS1123Subdomain(envFrom.SecretRef.Name)...)
+			sources = append(sources, fmt.Sprintf("secret/%s/%s", envFrom.SecretRef.Name, envFrom.Prefix))
+		default:
+			allErrs = append(allErrs, "one of configMapRef and secretRef should be set")
+		}
+	}
+	if !areUnique(sources) {
+		allErrs = append(allErrs, "configMapRefs and secretRefs with the same prefix should be unique")
+	}
+	if len(allErrs) == 0 {
+		return []string{}
+	}
+	return []string{
+		fmt.Sprintf("invalid spec.envFrom: %s", allErrs),
+	}
+}
+
+func validateKeysToPaths(items []corev1.KeyToPath) []string {
+	errs := []string{}
+	for _, item := range items {
+		if item.Key == "" {
+			errs = append(errs, "items key should not be empty")
+		}
+		if !isRelativeFilePath(item.Path) {
+			errs = append(errs, fmt.Sprintf("items path %s should be a relative path without '..'", item.Path))
+		}
+		if item.Mode != nil && (*item.Mode < 0 || *item.Mode > 0777) {
+			errs = append(errs, fmt.Sprintf("items mode %#o should be between 0 and 0777", *item.Mode))
+		}
+	}
+	return errs
+}
+
+func isRelativeFilePath(path string) bool {
+	return path != "" && !strings.HasPrefix(path, "/") && !slices.Contains(strings.Split(path, "/"), "..")
+}
+
 func (v *validator) validateFunctionLabels() []string {
 	labels := v.instance.Spec.Labels
 	path := "spec.labels"
@@ -161,10 +293,7 @@ func (v *validator) validateFips() []string {
 	if err := validateSshGitIsForbiddenInFipsMode(v.instance.Spec.Source.GitRepository); err != nil {
 		result = append(result, err.Error())
 	}
-	if err := validatePython312OrOlderIsForbiddenInFipsMode(v.instance.Spec.Runtime); err != nil {
-		result = append(result, err.Error())
-	}
-	if err := validateNodejs20OrOlderIsForbiddenInFipsMode(v.instance.Spec.Runtime); err != nil {
+	if err := validateRuntimeIsForbiddenInFipsMode(&v.fnConfig, v.instance.Spec.Runtime); err != nil {
 		result = append(result, err.Error())
 	}
 	return result
@@ -183,39 +312,135 @@ func (v *validator) validateFunctionResources() []string {
 	return []string{}
 }
 
-func validateDependencies(runtime serverlessv1alpha2.Runtime, dependencies string) error {
-	if runtime.IsRuntimeNodejs() {
-		return validateNodeJSDependencies(dependencies)
+func (v *validator) validateScaleConfig() []string {
+	scaleConfig := v.instance.Spec.ScaleConfig
+	if scaleConfig == nil || scaleConfig.MinReplicas == nil || scaleConfig.MaxReplicas == nil {
+		return []string{}
 	}
-	if runtime.IsRuntimePython() {
-		return nil
+	if *scaleConfig.MinReplicas > *scaleConfig.MaxReplicas {
+		return []string{
+			fmt.Sprintf("spec.scaleConfig.maxReplicas(%d) should be higher than or equal to spec.scaleConfig.minReplicas(%d)",
+				*scaleConfig.MaxReplicas, *scaleConfig.MinReplicas),
+		}
 	}
-	return fmt.Errorf("cannot find runtime: %s", runtime)
+	return []string{}
+}
+
+func (v *validator) validateProbes() []string {
+	probes := v.instance.Spec.Probes
+	if probes == nil {
+		return []string{}
+	}
+	result := []string{}
+	// kubernetes allows more than one success to be required only for the readiness probe
+	result = append(result, validateProbe("spec.probes.startup", probes.Startup, false)...)
+	result = append(result, validateProbe("spec.probes.readiness", probes.Readiness, true)...)
+	result = append(result, validateProbe("spec.probes.liveness", probes.Liveness, false)...)
+	return result
+}
+
+func validateProbe(path string, probe *serverlessv1alpha2.Probe, multipleSuccessesAllowed bool) []string {
+	if probe == nil {
+		return []string{}
+	}
+	result := []string{}
+	if probe.Path != "" && !isHTTPPath(probe.Path) {
+		result = append(result, fmt.Sprintf("%s.path(%s) should be an absolute HTTP path", path, probe.Path))
+	}
+	minimums := []struct {
+		name    string
+		value   *int32
+		minimum int32
+	}{
+		{name: "initialDelaySeconds", value: probe.InitialDelaySeconds, minimum: 0},
+		{name: "periodSeconds", value: probe.PeriodSeconds, minimum: 1},
+		{name: "timeoutSeconds", value: probe.TimeoutSeconds, minimum: 1},
+		{name: "successThreshold", value: probe.SuccessThreshold, minimum: 1},
+		{name: "failureThreshold", value: probe.FailureThreshold, minimum: 1},
+	}
+	for _, m := range minimums {
+		if m.value != nil && *m.value < m.minimum {
+			result = append(result, fmt.Sprintf("%s.%s(%d) should be higher than or equal to %d", path, m.name, *m.value, m.minimum))
+		}
+	}
+	if !multipleSuccessesAllowed && probe.SuccessThreshold != nil && *probe.SuccessThreshold > 1 {
+		result = append(result, fmt.Sprintf("%s.successThreshold(%d) should be equal to 1", path, *probe.SuccessThreshold))
+	}
+	return result
+}
+
+func isHTTPPath(path string) bool {
+	u, err := url.ParseRequestURI(path)
+	return err == nil && strings.HasPrefix(path, "/") && u.Host == "" && !strings.ContainsAny(path, " \t\r\n")
 }
 
-func validateNodeJSDependencies(dependencies string) error {
+func (v *validator) validateScheduling() []string {
+	template := v.instance.Spec.Template
+	if template == nil {
+		return []string{}
+	}
+	allowed := v.fnConfig.FunctionScheduling
+	result := []string{}
+	for _, key := range slices.Sorted(maps.Keys(template.NodeSelector)) {
+		if !config.IsAllowed(allowed.AllowedNodeSelectorKeys, key) {
+			result = append(result, fmt.Sprintf("spec.template.nodeSelector key '%s' is not allowed", key))
+		}
+	}
+	for _, toleration := range template.Tolerations {
+		// the toleration without the key tolerates all taints
+		if !config.IsAllowed(allowed.AllowedTolerationKeys, toleration.Key) {
+			result = append(result, fmt.Sprintf("spec.template.tolerations key '%s' is not allowed", toleration.Key))
+		}
+	}
+	if template.Affinity != nil && !allowed.AllowAffinity {
+		result = append(result, "spec.template.affinity is not allowed")
+	}
+	if len(template.TopologySpreadConstraints) != 0 && !allowed.AllowTopologySpreadConstraints {
+		result = append(result, "spec.template.topologySpreadConstraints are not allowed")
+	}
+	if template.PriorityClassName != "" && !config.IsAllowed(allowed.AllowedPriorityClassNames, template.PriorityClassName) {
+		result = append(result, fmt.Sprintf("spec.template.priorityClassName '%s' is not allowed", template.PriorityClassName))
+	}
+	if template.ServiceAccountName != "" && !config.IsAllowed(allowed.AllowedServiceAccountNames, template.ServiceAccountName) {
+		result = append(result, fmt.Sprintf("spec.template.serviceAccountName '%s' is not allowed", template.ServiceAccountName))
+	}
+	return result
+}
+
+func validateDependencies(fnConfig *config.FunctionConfig, runtime serverlessv1alpha2.Runtime, dependencies string) error {
+	runtimeConfig, ok := fnConfig.RuntimeConfig(string(runtime))
+	if !ok {
+		return fmt.Errorf("cannot find runtime: %s", runtime)
+	}
+	if runtimeConfig.DependenciesFormat == config.DependenciesFormatJSON {
+		return validateJSONDependencies(dependencies)
+	}
+	return nil
+}
+
+func validateJSONDependencies(dependencies string) error {
 	if deps := strings.TrimSpace(dependencies); deps != "" && (deps[0] != '{' || deps[len(deps)-1] != '}') {
 		return errors.New("deps should start with '{' and end with '}'")
 	}
 	return nil
 }
 
-func validateRuntime(runtime serverlessv1alpha2.Runtime) error {
+func validateRuntime(fnConfig *config.FunctionConfig, runtime serverlessv1alpha2.Runtime) error {
 	if len(runtime) == 0 {
 		return nil
 	}
-	if runtime.IsRuntimeSupported() {
+	if _, ok := fnConfig.RuntimeConfig(string(runtime)); ok {
 		return nil
 	}
 	return fmt.Errorf("cannot find runtime: %s", runtime)
 }
 
-func secretNamesAreUnique(secretMounts []serverlessv1alpha2.SecretMount) bool {
-	uniqueSecretNames := make(map[string]bool)
-	for _, secretMount := range secretMounts {
-		uniqueSecretNames[secretMount.SecretName] = true
+func areUnique(values []string) bool {
+	uniqueValues := make(map[string]bool)
+	for _, value := range values {
+		uniqueValues[value] = true
 	}
-	return len(uniqueSecretNames) == len(secretMounts)
+	return len(uniqueValues) == len(values)
 }
 
 func enrichErrors(errs []string, path string, value string) []string {
@@ -254,15 +479,9 @@ func validateSshGitIsForbiddenInFipsMode(gitRepo *serverlessv1alpha2.GitReposito
 	return nil
 }
 
-func validatePython312OrOlderIsForbiddenInFipsMode(runtime serverlessv1alpha2.Runtime) error {
-	if runtime == serverlessv1alpha2.Python312 {
-		return fmt.Errorf("runtime %s is not allowed in FIPS mode", runtime)
-	}
-	return nil
-}
-
-func validateNodejs20OrOlderIsForbiddenInFipsMode(runtime serverlessv1alpha2.Runtime) error {
-	if runtime == serverlessv1alpha2.NodeJs20 {
+func validateRuntimeIsForbiddenInFipsMode(fnConfig *config.FunctionConfig, runtime serverlessv1alpha2.Runtime) error {
+	runtimeConfig, ok := fnConfig.RuntimeConfig(string(runtime))
+	if ok && !runtimeConfig.FIPSCompliant {
 		return fmt.Errorf("runtime %s is not allowed in FIPS mode", runtime)
 	}
 	return nil
@@ -11,6 +11,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
 )
 
 func mockFipsChecker(enabled bool) fips.FipsChecker {
@@ -205,8 +206,13 @@ func Test_functionValidator_validateRuntime(t *testing.T) {
 				"invalid runtime value: cannot find runtime: practical-panini",
 			},
 		},
+		{
+			name:    "when runtime is configured in function config then no errors",
+			runtime: "go124",
+			want:    []string{},
+		},
 	}
-	for _, runtime := range []serverlessv1alpha2.Runtime{serverlessv1alpha2.NodeJs20, serverlessv1alpha2.NodeJs22, serverlessv1alpha2.NodeJs24, serverlessv1alpha2.Python312} {
+	for _, runtime := range []serverlessv1alpha2.Runtime{serverlessv1alpha2.NodeJs20, serverlessv1alpha2.NodeJs22, serverlessv1alpha2.NodeJs24, serverlessv1alpha2.NodeJs26, serverlessv1alpha2.Python312, serverlessv1alpha2.Python314} {
 		tests = append(tests, testData{
 			name:    fmt.Sprintf("when %s then no errors", runtime),
 			runtime: runtime,
@@ -221,7 +227,9 @@ func Test_functionValidator_validateRuntime(t *testing.T) {
 				},
 			}
 
-			v := New(f, config.FunctionConfig{}, mockFipsChecker(false))
+			v := New(f, config.FunctionConfig{
+				Runtimes: map[string]config.RuntimeConfig{"go124": {}},
+			}, mockFipsChecker(false))
 			r := v.validateRuntime()
 			require.ElementsMatch(t, tt.want, r)
 		})
@@ -283,6 +291,269 @@ func Test_validator_validateSecretMounts(t *testing.T) {
 	}
 }
 
+func Test_validator_validateSecretMountsItems(t *testing.T) {
+	v := &validator{
+		instance: &serverlessv1alpha2.Function{
+			Spec: serverlessv1alpha2.FunctionSpec{
+				SecretMounts: []serverlessv1alpha2.SecretMount{
+					{
+						SecretName: "valid-secret",
+						Items: []corev1.KeyToPath{
+							{Key: "tls.key", Path: "keys/tls.key", Mode: ptr.To[int32](0400)},
+							{Key: "", Path: "/etc/passwd"},
+							{Key: "ca.crt", Path: "../ca.crt", Mode: ptr.To[int32](01777)},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	got := v.validateSecretMounts()
+
+	require.Equal(t, []string{
+		"invalid spec.secretMounts: [items key should not be empty items path /etc/passwd should be a relative path without '..' items path ../ca.crt should be a relative path without '..' items mode 01777 should be between 0 and 0777]",
+	}, got)
+}
+
+func Test_validator_validateConfigMapMounts(t *testing.T) {
+	type testData struct {
+		name            string
+		configMapMounts []serverlessv1alpha2.ConfigMapMount
+		secretMounts    []serverlessv1alpha2.SecretMount
+		want            []string
+	}
+	tests := []testData{
+		{
+			name:            "when no config map mounts then no errors",
+			configMapMounts: []serverlessv1alpha2.ConfigMapMount{},
+			want:            []string{},
+		},
+		{
+			name: "when config map names are valid and unique then no errors",
+			configMapMounts: []serverlessv1alpha2.ConfigMapMount{
+				{ConfigMapName: "config", Items: []corev1.KeyToPath{{Key: "config.yaml", Path: "config.yaml"}}},
+				{ConfigMapName: "other-config"},
+			},
+			secretMounts: []serverlessv1alpha2.SecretMount{
+				{SecretName: "secret"},
+			},
+			want: []string{},
+		},
+		{
+			name: "when config map name is invalid then return error",
+			configMapMounts: []serverlessv1alpha2.ConfigMapMount{
+				{ConfigMapName: "invalid_config_map_name@#!"},
+			},
+			want: []string{
+				"invalid spec.configMapMounts: [a lowercase RFC 1123 subdomain must consist of lower case alphanumeric characters, '-' or '.', and must start and end with an alphanumeric character (e.g. 'example.com', regex used for validation is '[a-z0-9]([-a-z0-9]*[a-z0-9])?(\\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*')]",
+			},
+		},
+		{
+			name: "when config map names are not unique then return error",
+			configMapMounts: []serverlessv1alpha2.ConfigMapMount{
+				{ConfigMapName: "config"},
+				{ConfigMapName: "config"},
+			},
+			want: []string{
+				"invalid spec.configMapMounts: [configMapNames should be unique]",
+			},
+		},
+		{
+			name: "when config map name is used by secret mount then return error",
+			configMapMounts: []serverlessv1alpha2.ConfigMapMount{
+				{ConfigMapName: "shared-name"},
+			},
+			secretMounts: []serverlessv1alpha2.SecretMount{
+				{SecretName: "shared-name"},
+			},
+			want: []string{
+				"invalid spec.configMapMounts: [configMapName shared-name is already used by spec.secretMounts]",
+			},
+		},
+		{
+			name: "when items are invalid then return error",
+			configMapMounts: []serverlessv1alpha2.ConfigMapMount{
+				{ConfigMapName: "config", Items: []corev1.KeyToPath{{Key: "config.yaml"}}},
+			},
+			want: []string{
+				"invalid spec.configMapMounts: [items path  should be a relative path without '..']",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &validator{
+				instance: &serverlessv1alpha2.Function{
+					Spec: serverlessv1alpha2.FunctionSpec{
+						ConfigMapMounts: tt.configMapMounts,
+						SecretMounts:    tt.secretMounts,
+					},
+				},
+			}
+			got := v.validateConfigMapMounts()
+			require.ElementsMatch(t, tt.want, got)
+		})
+	}
+}
+
+func Test_validator_validateServiceAccountTokenMounts(t *testing.T) {
+	type testData struct {
+		name        string
+		tokenMounts []serverlessv1alpha2.ServiceAccountTokenMount
+		want        []string
+	}
+	tests := []testData{
+		{
+			name:        "when no token mounts then no errors",
+			tokenMounts: []serverlessv1alpha2.ServiceAccountTokenMount{},
+			want:        []string{},
+		},
+		{
+			name: "when token mounts are valid then no errors",
+			tokenMounts: []serverlessv1alpha2.ServiceAccountTokenMount{
+				{Audience: "sts.amazonaws.com", MountPath: "/var/run/secrets/aws"},
+				{Audience: "vault", ExpirationSeconds: ptr.To[int64](600), MountPath: "/var/run/secrets/vault", Path: "vault/token"},
+			},
+			want: []string{},
+		},
+		{
+			name: "when token mounts are invalid then return error",
+			tokenMounts: []serverlessv1alpha2.ServiceAccountTokenMount{
+				{Audience: "", ExpirationSeconds: ptr.To[int64](60), MountPath: "/var/run/secrets/token", Path: "../token"},
+			},
+			want: []string{
+				"invalid spec.serviceAccountTokenMounts: [audience should not be empty expirationSeconds(60) should be higher than or equal to 600 path ../token should be a relative path without '..']",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &validator{
+				instance: &serverlessv1alpha2.Function{
+					Spec: serverlessv1alpha2.FunctionSpec{
+						ServiceAccountTokenMounts: tt.tokenMounts,
+					},
+				},
+			}
+			got := v.validateServiceAccountTokenMounts()
+			require.ElementsMatch(t, tt.want, got)
+		})
+	}
+}
+
+func Test_validator_validateMountPaths(t *testing.T) {
+	type testData struct {
+		name string
+		spec serverlessv1alpha2.FunctionSpec
+		want []string
+	}
+	tests := []testData{
+		{
+			name: "when mount paths are unique then no errors",
+			spec: serverlessv1alpha2.FunctionSpec{
+				SecretMounts:              []serverlessv1alpha2.SecretMount{{SecretName: "secret", MountPath: "/secret"}},
+				ConfigMapMounts:           []serverlessv1alpha2.ConfigMapMount{{ConfigMapName: "config", MountPath: "/config"}},
+				ServiceAccountTokenMounts: []serverlessv1alpha2.ServiceAccountTokenMount{{Audience: "vault", MountPath: "/token"}},
+			},
+			want: []string{},
+		},
+		{
+			name: "when mount paths of different kinds are the same then return error",
+			spec: serverlessv1alpha2.FunctionSpec{
+				SecretMounts:              []serverlessv1alpha2.SecretMount{{SecretName: "secret", MountPath: "/mounted"}},
+				ServiceAccountTokenMounts: []serverlessv1alpha2.ServiceAccountTokenMount{{Audience: "vault", MountPath: "/mounted"}},
+			},
+			want: []string{
+				"mountPaths of spec.secretMounts, spec.configMapMounts and spec.serviceAccountTokenMounts should be unique",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &validator{
+				instance: &serverlessv1alpha2.Function{
+					Spec: tt.spec,
+				},
+			}
+			got := v.validateMountPaths()
+			require.ElementsMatch(t, tt.want, got)
+		})
+	}
+}
+
+func Test_validator_validateEnvFrom(t *testing.T) {
+	configMapRef := func(name string) *corev1.ConfigMapEnvSource {
+		return &corev1.ConfigMapEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: name}}
+	}
+	secretRef := func(name string) *corev1.SecretEnvSource {
+		return &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: name}}
+	}
+	type testData struct {
+		name    string
+		envFrom []corev1.EnvFromSource
+		want    []string
+	}
+	tests := []testData{
+		{
+			name:    "when no envFrom then no errors",
+			envFrom: []corev1.EnvFromSource{},
+			want:    []string{},
+		},
+		{
+			name: "when envFrom is valid then no errors",
+			envFrom: []corev1.EnvFromSource{
+				{ConfigMapRef: configMapRef("config")},
+				{SecretRef: secretRef("config")},
+				{Prefix: "DB_", SecretRef: secretRef("config")},
+			},
+			want: []string{},
+		},
+		{
+			name: "when envFrom sets none or both references then return error",
+			envFrom: []corev1.EnvFromSource{
+				{Prefix: "EMPTY_"},
+				{ConfigMapRef: configMapRef("config"), SecretRef: secretRef("secret")},
+			},
+			want: []string{
+				"invalid spec.envFrom: [one of configMapRef and secretRef should be set only one of configMapRef and secretRef should be set]",
+			},
+		},
+		{
+			name: "when envFrom sources are not unique then return error",
+			envFrom: []corev1.EnvFromSource{
+				{Prefix: "DB_", SecretRef: secretRef("db")},
+				{Prefix: "DB_", SecretRef: secretRef("db")},
+			},
+			want: []string{
+				"invalid spec.envFrom: [configMapRefs and secretRefs with the same prefix should be unique]",
+			},
+		},
+		{
+			name: "when prefix is invalid then return error",
+			envFrom: []corev1.EnvFromSource{
+				{Prefix: "1=", ConfigMapRef: configMapRef("config")},
+			},
+			want: []string{
+				"invalid spec.envFrom: [a valid environment variable name must consist of alphabetic characters, digits, '_', '-', or '.', and must not start with a digit (e.g. 'my.env-name',  or 'MY_ENV.NAME',  or 'MyEnvName1', regex used for validation is '[-._a-zA-Z][-._a-zA-Z0-9]*')]",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &validator{
+				instance: &serverlessv1alpha2.Function{
+					Spec: serverlessv1alpha2.FunctionSpec{
+						EnvFrom: tt.envFrom,
+					},
+				},
+			}
+			got := v.validateEnvFrom()
+			require.ElementsMatch(t, tt.want, got)
+		})
+	}
+}
+
 func Test_validator_validateFunctionLabels(t *testing.T) {
 	type testData struct {
 		name   string
@@ -515,6 +786,20 @@ func Test_validator_validateFips(t *testing.T) {
 			runtime:  serverlessv1alpha2.NodeJs24,
 			want:     []string{},
 		},
+		{
+			name:     "FIPS enabled with Node.js 26 runtime should return no errors",
+			fipsMode: true,
+			URL:      urlAllowedInFips,
+			runtime:  serverlessv1alpha2.NodeJs26,
+			want:     []string{},
+		},
+		{
+			name:     "FIPS enabled with Python 3.14 runtime should return no errors",
+			fipsMode: true,
+			URL:      urlAllowedInFips,
+			runtime:  serverlessv1alpha2.Python314,
+			want:     []string{},
+		},
 		{
 			name:     "FIPS disabled with Python 3.12 runtime should return no errors",
 			fipsMode: false,
@@ -529,6 +814,20 @@ func Test_validator_validateFips(t *testing.T) {
 			runtime:  serverlessv1alpha2.NodeJs24,
 			want:     []string{},
 		},
+		{
+			name:     "FIPS enabled with FIPS compliant runtime from function config should return no errors",
+			fipsMode: true,
+			URL:      urlAllowedInFips,
+			runtime:  "go124",
+			want:     []string{},
+		},
+		{
+			name:     "FIPS enabled with non FIPS compliant runtime from function config should return error",
+			fipsMode: true,
+			URL:      urlAllowedInFips,
+			runtime:  "java21",
+			want:     []string{"runtime java21 is not allowed in FIPS mode"},
+		},
 		{
 			name:     "FIPS enabled with SSH URL and Python 3.12 runtime should return both errors",
 			fipsMode: true,
@@ -554,6 +853,12 @@ func Test_validator_validateFips(t *testing.T) {
 						},
 					},
 				},
+				fnConfig: config.FunctionConfig{
+					Runtimes: map[string]config.RuntimeConfig{
+						"go124":  {FIPSCompliant: true},
+						"java21": {FIPSCompliant: false},
+					},
+				},
 				checkFips: mockFipsChecker(tt.fipsMode),
 			}
 			got := v.validateFips()
@@ -671,3 +976,260 @@ func Test_validator_validateFunctionResources(t *testing.T) {
 		})
 	}
 }
+
+func Test_validator_validateScaleConfig(t *testing.T) {
+	type testData struct {
+		name        string
+		scaleConfig *serverlessv1alpha2.ScaleConfig
+		want        []string
+	}
+	tests := []testData{
+		{
+			name:        "when scale config is not set then no errors",
+			scaleConfig: nil,
+			want:        []string{},
+		},
+		{
+			name: "when min replicas is lower than max replicas then no errors",
+			scaleConfig: &serverlessv1alpha2.ScaleConfig{
+				MinReplicas: ptr.To[int32](1),
+				MaxReplicas: ptr.To[int32](3),
+			},
+			want: []string{},
+		},
+		{
+			name: "when min replicas is equal to max replicas then no errors",
+			scaleConfig: &serverlessv1alpha2.ScaleConfig{
+				MinReplicas: ptr.To[int32](2),
+				MaxReplicas: ptr.To[int32](2),
+			},
+			want: []string{},
+		},
+		{
+			name: "when min replicas is higher than max replicas then return error",
+			scaleConfig: &serverlessv1alpha2.ScaleConfig{
+				MinReplicas: ptr.To[int32](4),
+				MaxReplicas: ptr.To[int32](2),
+			},
+			want: []string{
+				"spec.scaleConfig.maxReplicas(2) should be higher than or equal to spec.scaleConfig.minReplicas(4)",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &validator{
+				instance: &serverlessv1alpha2.Function{
+					Spec: serverlessv1alpha2.FunctionSpec{
+						ScaleConfig: tt.scaleConfig,
+					},
+				},
+			}
+			got := v.validateScaleConfig()
+			require.ElementsMatch(t, tt.want, got)
+		})
+	}
+}
+
+func Test_validator_validateProbes(t *testing.T) {
+	type testData struct {
+		name   string
+		probes *serverlessv1alpha2.Probes
+		want   []string
+	}
+	tests := []testData{
+		{
+			name:   "when probes are not set then no errors",
+			probes: nil,
+			want:   []string{},
+		},
+		{
+			name: "when probes are valid then no errors",
+			probes: &serverlessv1alpha2.Probes{
+				Startup: &serverlessv1alpha2.Probe{
+					PeriodSeconds:    ptr.To[int32](10),
+					FailureThreshold: ptr.To[int32](60),
+				},
+				Readiness: &serverlessv1alpha2.Probe{
+					Path:             "/ready?full=true",
+					SuccessThreshold: ptr.To[int32](2),
+				},
+				Liveness: &serverlessv1alpha2.Probe{
+					Path:                "/alive",
+					InitialDelaySeconds: ptr.To[int32](0),
+					TimeoutSeconds:      ptr.To[int32](10),
+					SuccessThreshold:    ptr.To[int32](1),
+				},
+			},
+			want: []string{},
+		},
+		{
+			name: "when path is not absolute HTTP path then return error",
+			probes: &serverlessv1alpha2.Probes{
+				Readiness: &serverlessv1alpha2.Probe{Path: "healthz"},
+				Liveness:  &serverlessv1alpha2.Probe{Path: "/health check"},
+				Startup:   &serverlessv1alpha2.Probe{Path: "http://example.com/healthz"},
+			},
+			want: []string{
+				"spec.probes.readiness.path(healthz) should be an absolute HTTP path",
+				"spec.probes.liveness.path(/health check) should be an absolute HTTP path",
+				"spec.probes.startup.path(http://example.com/healthz) should be an absolute HTTP path",
+			},
+		},
+		{
+			name: "when values are lower than minimums then return error",
+			probes: &serverlessv1alpha2.Probes{
+				Readiness: &serverlessv1alpha2.Probe{
+					InitialDelaySeconds: ptr.To[int32](-1),
+					PeriodSeconds:       ptr.To[int32](0),
+					TimeoutSeconds:      ptr.To[int32](0),
+					SuccessThreshold:    ptr.To[int32](0),
+					FailureThreshold:    ptr.To[int32](0),
+				},
+			},
+			want: []string{
+				"spec.probes.readiness.initialDelaySeconds(-1) should be higher than or equal to 0",
+				"spec.probes.readiness.periodSeconds(0) should be higher than or equal to 1",
+				"spec.probes.readiness.timeoutSeconds(0) should be higher than or equal to 1",
+				"spec.probes.readiness.successThreshold(0) should be higher than or equal to 1",
+				"spec.probes.readiness.failureThreshold(0) should be higher than or equal to 1",
+			},
+		},
+		{
+			name: "when startup or liveness probe requires more than one success then return error",
+			probes: &serverlessv1alpha2.Probes{
+				Startup:  &serverlessv1alpha2.Probe{SuccessThreshold: ptr.To[int32](2)},
+				Liveness: &serverlessv1alpha2.Probe{SuccessThreshold: ptr.To[int32](3)},
+			},
+			want: []string{
+				"spec.probes.startup.successThreshold(2) should be equal to 1",
+				"spec.probes.liveness.successThreshold(3) should be equal to 1",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &validator{
+				instance: &serverlessv1alpha2.Function{
+					Spec: serverlessv1alpha2.FunctionSpec{
+						Probes: tt.probes,
+					},
+				},
+			}
+			got := v.validateProbes()
+			require.ElementsMatch(t, tt.want, got)
+		})
+	}
+}
+
+func Test_validator_validateScheduling(t *testing.T) {
+	fullTemplate := &serverlessv1alpha2.Template{
+		NodeSelector: map[string]string{"pool": "functions", "zone": "a"},
+		Tolerations: []corev1.Toleration{
+			{Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "functions"},
+		},
+		Affinity: &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{}},
+		TopologySpreadConstraints: []corev1.TopologySpreadConstraint{
+			{MaxSkew: 1, TopologyKey: "topology.kubernetes.io/zone", WhenUnsatisfiable: corev1.ScheduleAnyway},
+		},
+		PriorityClassName:  "high-priority",
+		ServiceAccountName: "function-sa",
+	}
+	tests := []struct {
+		name       string
+		template   *serverlessv1alpha2.Template
+		scheduling config.SchedulingConfig
+		want       []string
+	}{
+		{
+			name:     "when template is not set then no errors",
+			template: nil,
+			want:     []string{},
+		},
+		{
+			name:     "when template sets nothing then no errors",
+			template: &serverlessv1alpha2.Template{},
+			want:     []string{},
+		},
+		{
+			name:     "when scheduling is allowed then no errors",
+			template: fullTemplate,
+			scheduling: config.SchedulingConfig{
+				AllowedNodeSelectorKeys:        []string{"pool", "zone"},
+				AllowedTolerationKeys:          []string{"dedicated"},
+				AllowedPriorityClassNames:      []string{"low-priority", "high-priority"},
+				AllowedServiceAccountNames:     []string{"function-sa"},
+				AllowAffinity:                  true,
+				AllowTopologySpreadConstraints: true,
+			},
+			want: []string{},
+		},
+		{
+			name: "when any value is allowed then no errors",
+			template: &serverlessv1alpha2.Template{
+				NodeSelector:       fullTemplate.NodeSelector,
+				Tolerations:        []corev1.Toleration{{Operator: corev1.TolerationOpExists}},
+				PriorityClassName:  fullTemplate.PriorityClassName,
+				ServiceAccountName: fullTemplate.ServiceAccountName,
+			},
+			scheduling: config.SchedulingConfig{
+				AllowedNodeSelectorKeys:    []string{config.AllowAny},
+				AllowedTolerationKeys:      []string{config.AllowAny},
+				AllowedPriorityClassNames:  []string{config.AllowAny},
+				AllowedServiceAccountNames: []string{config.AllowAny},
+			},
+			want: []string{},
+		},
+		{
+			name:       "when scheduling is not allowed then return errors",
+			template:   fullTemplate,
+			scheduling: config.SchedulingConfig{},
+			want: []string{
+				"spec.template.nodeSelector key 'pool' is not allowed",
+				"spec.template.nodeSelector key 'zone' is not allowed",
+				"spec.template.tolerations key 'dedicated' is not allowed",
+				"spec.template.affinity is not allowed",
+				"spec.template.topologySpreadConstraints are not allowed",
+				"spec.template.priorityClassName 'high-priority' is not allowed",
+				"spec.template.serviceAccountName 'function-sa' is not allowed",
+			},
+		},
+		{
+			name: "when values are not on allow-lists then return errors",
+			template: &serverlessv1alpha2.Template{
+				NodeSelector:       fullTemplate.NodeSelector,
+				Tolerations:        []corev1.Toleration{{Operator: corev1.TolerationOpExists}},
+				PriorityClassName:  "system-cluster-critical",
+				ServiceAccountName: "default",
+			},
+			scheduling: config.SchedulingConfig{
+				AllowedNodeSelectorKeys:    []string{"pool"},
+				AllowedTolerationKeys:      []string{"dedicated"},
+				AllowedPriorityClassNames:  []string{"high-priority"},
+				AllowedServiceAccountNames: []string{"function-sa"},
+			},
+			want: []string{
+				"spec.template.nodeSelector key 'zone' is not allowed",
+				"spec.template.tolerations key '' is not allowed",
+				"spec.template.priorityClassName 'system-cluster-critical' is not allowed",
+				"spec.template.serviceAccountName 'default' is not allowed",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &validator{
+				instance: &serverlessv1alpha2.Function{
+					Spec: serverlessv1alpha2.FunctionSpec{
+						Template: tt.template,
+					},
+				},
+				fnConfig: config.FunctionConfig{
+					FunctionScheduling: tt.scheduling,
+				},
+			}
+			got := v.validateScheduling()
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
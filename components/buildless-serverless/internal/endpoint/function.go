@@ -6,6 +6,7 @@ import (
 
 	"github.com/kyma-project/serverless/components/buildless-serverless/api/v1alpha2"
 	"github.com/kyma-project/serverless/components/buildless-serverless/internal/endpoint/runtime"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/functionruntime"
 	"github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/util/validation"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -30,13 +31,25 @@ func (s *Server) handleFunctionRequest(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resourceFiles, err := runtime.BuildResources(&s.functionConfig, &function, appName, s.isKymaFipsModeEnabled)
+	// the function may use a FunctionRuntime, it's resolved the same way as by the controller
+	functionConfig := s.functionConfig
+	err = functionruntime.Load(s.ctx, s.k8s, &functionConfig, string(function.Spec.Runtime))
+	if err != nil {
+		s.writeErrorResponse(w, http.StatusInternalServerError, errors.Wrapf(err, "failed to load runtime of function '%s/%s'", ns, name))
+		return
+	}
+	if _, ok := functionConfig.RuntimeConfig(string(function.Spec.Runtime)); !ok {
+		s.writeErrorResponse(w, http.StatusInternalServerError, errors.Errorf("cannot find runtime: %s", function.Spec.Runtime))
+		return
+	}
+
+	resourceFiles, err := runtime.BuildResources(&functionConfig, &function, appName, s.isKymaFipsModeEnabled)
 	if err != nil {
 		s.writeErrorResponse(w, http.StatusInternalServerError, errors.Wrapf(err, "failed to get resource files for function '%s/%s'", ns, name))
 		return
 	}
 
-	runtimeFiles, err := runtime.ReadFiles(&function)
+	runtimeFiles, err := runtime.ReadFiles(&functionConfig, &function)
 	if err != nil {
 		s.writeErrorResponse(w, http.StatusInternalServerError, errors.Wrapf(err, "failed to get runtime files for function '%s/%s'", ns, name))
 		return
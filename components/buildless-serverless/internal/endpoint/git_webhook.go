@@ -0,0 +1,161 @@
+package endpoint
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/kyma-project/serverless/components/buildless-serverless/api/v1alpha2"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/endpoint/gitwebhook"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/endpoint/types"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+const (
+	// GitWebhookSecretKey is the key of the webhook secret in the Secret referenced by the Function
+	GitWebhookSecretKey = "secret"
+
+	maxGitWebhookBodySize = 5 << 20 // 5 MiB
+
+	gitWebhookRepositoryIndex = "spec.source.gitRepository.webhookRepository"
+)
+
+// IndexGitWebhookFunctions indexes the Functions with the git webhook by their normalized repository URL
+// so a push lists only the Functions of the pushed repository
+func IndexGitWebhookFunctions(ctx context.Context, indexer client.FieldIndexer) error {
+	return indexer.IndexField(ctx, &v1alpha2.Function{}, gitWebhookRepositoryIndex, gitWebhookRepository)
+}
+
+func gitWebhookRepository(obj client.Object) []string {
+	f := obj.(*v1alpha2.Function)
+	if !f.HasGitWebhook() {
+		return nil
+	}
+	return []string{gitwebhook.NormalizeURL(f.Spec.Source.GitRepository.URL)}
+}
+
+func (s *Server) handleGitWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.writeErrorResponse(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxGitWebhookBodySize))
+	if err != nil {
+		s.writeErrorResponse(w, http.StatusBadRequest, errors.Wrap(err, "failed to read request body"))
+		return
+	}
+
+	pushEvent, err := gitwebhook.Parse(r.Header, body)
+	if err != nil {
+		s.writeErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if pushEvent == nil {
+		s.log.Debug("ignoring git webhook event other than push")
+		s.writeGitWebhookResponse(w, 0)
+		return
+	}
+
+	if !pushEvent.HasCredentials(r.Header) {
+		s.writeErrorResponse(w, http.StatusUnauthorized, errors.New("git webhook request not verified"))
+		return
+	}
+
+	s.log.Infof("handling %s git webhook push of %v to %v", pushEvent.Provider, pushEvent.Refs, pushEvent.RepositoryURLs)
+
+	functions, err := s.listGitWebhookFunctions(pushEvent)
+	if err != nil {
+		s.writeErrorResponse(w, http.StatusInternalServerError, errors.Wrap(err, "failed to list functions"))
+		return
+	}
+
+	// the Functions of the same namespace often share the secret, so each secret is read once
+	secrets := map[client.ObjectKey][]byte{}
+	matched, enqueued := 0, 0
+	for i := range functions {
+		f := &functions[i]
+		if !pushEvent.Matches(f.Spec.Source.GitRepository.URL, f.Spec.Source.GitRepository.Reference) {
+			continue
+		}
+		matched++
+
+		secretKey := client.ObjectKey{Namespace: f.GetNamespace(), Name: f.Spec.Source.GitRepository.Webhook.SecretName}
+		secret, ok := secrets[secretKey]
+		if !ok {
+			secret, err = s.getGitWebhookSecret(secretKey)
+			if err != nil {
+				s.log.Warnf("failed to get git webhook secret of function '%s/%s': %s", f.GetNamespace(), f.GetName(), err)
+			}
+			secrets[secretKey] = secret
+		}
+		if !pushEvent.Verify(r.Header, body, secret) {
+			s.log.Warnf("git webhook request not verified for function '%s/%s'", f.GetNamespace(), f.GetName())
+			continue
+		}
+
+		// forget the cached commit, so the function checks the latest commit again
+		s.gitChecker.InvalidateOrder(string(f.GetUID()))
+		select {
+		case s.functionEvents <- event.GenericEvent{Object: f}:
+			enqueued++
+		case <-s.ctx.Done():
+			s.writeErrorResponse(w, http.StatusServiceUnavailable, errors.New("server is shutting down"))
+			return
+		}
+	}
+
+	if matched != 0 && enqueued == 0 {
+		s.writeErrorResponse(w, http.StatusUnauthorized, errors.New("git webhook request not verified"))
+		return
+	}
+
+	s.log.Infof("git webhook push enqueued %d functions", enqueued)
+	s.writeGitWebhookResponse(w, enqueued)
+}
+
+// listGitWebhookFunctions lists the Functions with the git webhook configured for the pushed repository
+func (s *Server) listGitWebhookFunctions(pushEvent *gitwebhook.PushEvent) ([]v1alpha2.Function, error) {
+	var functions []v1alpha2.Function
+	for _, repository := range pushEvent.Repositories() {
+		functionList := v1alpha2.FunctionList{}
+		err := s.functionCache.List(s.ctx, &functionList, client.MatchingFields{gitWebhookRepositoryIndex: repository})
+		if err != nil {
+			return nil, err
+		}
+		functions = append(functions, functionList.Items...)
+	}
+	return functions, nil
+}
+
+func (s *Server) getGitWebhookSecret(key client.ObjectKey) ([]byte, error) {
+	secret := corev1.Secret{}
+	if err := s.k8s.Get(s.ctx, key, &secret); err != nil {
+		return nil, err
+	}
+
+	value, ok := secret.Data[GitWebhookSecretKey]
+	if !ok {
+		return nil, fmt.Errorf("missing '%s' key in secret '%s'", GitWebhookSecretKey, key.Name)
+	}
+	return value, nil
+}
+
+func (s *Server) writeGitWebhookResponse(w http.ResponseWriter, enqueuedFunctions int) {
+	buf := bytes.NewBuffer([]byte{})
+	err := json.NewEncoder(buf).Encode(types.GitWebhookResponse{EnqueuedFunctions: enqueuedFunctions})
+	if err != nil {
+		s.writeErrorResponse(w, http.StatusInternalServerError, errors.Wrap(err, "failed to encode response"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, buf.String())
+}
@@ -0,0 +1,193 @@
+package endpoint
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kyma-project/serverless/components/buildless-serverless/api/v1alpha2"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/git/automock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+const testPushBody = `{"ref":"refs/heads/main","repository":{"clone_url":"https://github.com/org/repo.git"}}`
+
+func TestServer_handleGitWebhook(t *testing.T) {
+	t.Run("should enqueue verified functions", func(t *testing.T) {
+		gitChecker := automock.NewAsyncLatestCommitChecker(t)
+		gitChecker.On("InvalidateOrder", "main-uid").Once()
+		s, events := fixGitWebhookServer(t, gitChecker,
+			fixWebhookFunction("main-function", "main-uid", "https://github.com/org/repo", "main", "webhook-secret"),
+			fixWebhookFunction("develop-function", "develop-uid", "https://github.com/org/repo", "develop", "webhook-secret"),
+			fixWebhookFunction("other-function", "other-uid", "https://github.com/org/other-repo", "main", "webhook-secret"),
+			fixWebhookSecret("webhook-secret", "secret-value"),
+		)
+
+		w := httptest.NewRecorder()
+		s.handleGitWebhook(w, fixGitHubPushRequest(testPushBody, "secret-value"))
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.JSONEq(t, `{"enqueuedFunctions":1}`, w.Body.String())
+		require.Len(t, events, 1)
+		e := <-events
+		require.Equal(t, types.NamespacedName{Namespace: "default", Name: "main-function"}, client.ObjectKeyFromObject(e.Object))
+	})
+	t.Run("should reject request signed with other secret", func(t *testing.T) {
+		s, events := fixGitWebhookServer(t, automock.NewAsyncLatestCommitChecker(t),
+			fixWebhookFunction("main-function", "main-uid", "https://github.com/org/repo", "main", "webhook-secret"),
+			fixWebhookSecret("webhook-secret", "secret-value"),
+		)
+
+		w := httptest.NewRecorder()
+		s.handleGitWebhook(w, fixGitHubPushRequest(testPushBody, "other-secret"))
+
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+		require.Empty(t, events)
+	})
+	t.Run("should reject request when secret is missing", func(t *testing.T) {
+		s, events := fixGitWebhookServer(t, automock.NewAsyncLatestCommitChecker(t),
+			fixWebhookFunction("main-function", "main-uid", "https://github.com/org/repo", "main", "webhook-secret"),
+		)
+
+		w := httptest.NewRecorder()
+		s.handleGitWebhook(w, fixGitHubPushRequest(testPushBody, "secret-value"))
+
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+		require.Empty(t, events)
+	})
+	t.Run("should skip functions without webhook", func(t *testing.T) {
+		f := fixWebhookFunction("main-function", "main-uid", "https://github.com/org/repo", "main", "")
+		f.Spec.Source.GitRepository.Webhook = nil
+		s, events := fixGitWebhookServer(t, automock.NewAsyncLatestCommitChecker(t), f)
+
+		w := httptest.NewRecorder()
+		s.handleGitWebhook(w, fixGitHubPushRequest(testPushBody, "secret-value"))
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.JSONEq(t, `{"enqueuedFunctions":0}`, w.Body.String())
+		require.Empty(t, events)
+	})
+	t.Run("should enqueue functions with semver constraint matching pushed tag", func(t *testing.T) {
+		gitChecker := automock.NewAsyncLatestCommitChecker(t)
+		gitChecker.On("InvalidateOrder", "constraint-uid").Once()
+		s, events := fixGitWebhookServer(t, gitChecker,
+			fixWebhookFunction("constraint-function", "constraint-uid", "git@github.com:org/repo.git", "^1.4", "webhook-secret"),
+			fixWebhookFunction("other-constraint-function", "other-constraint-uid", "https://github.com/org/repo", "^2.0", "webhook-secret"),
+			fixWebhookSecret("webhook-secret", "secret-value"),
+		)
+
+		w := httptest.NewRecorder()
+		s.handleGitWebhook(w, fixGitHubPushRequest(`{"ref":"refs/tags/v1.5.0","repository":{"clone_url":"https://github.com/org/repo.git"}}`, "secret-value"))
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.JSONEq(t, `{"enqueuedFunctions":1}`, w.Body.String())
+		e := <-events
+		require.Equal(t, "constraint-function", e.Object.GetName())
+	})
+	t.Run("should reject unsigned request before reading functions", func(t *testing.T) {
+		s, events := fixGitWebhookServer(t, automock.NewAsyncLatestCommitChecker(t),
+			fixWebhookFunction("main-function", "main-uid", "https://github.com/org/repo", "main", "webhook-secret"),
+			fixWebhookSecret("webhook-secret", "secret-value"),
+		)
+		s.functionCache = nil
+		r := fixGitHubPushRequest(testPushBody, "secret-value")
+		r.Header.Del("X-Hub-Signature-256")
+
+		w := httptest.NewRecorder()
+		s.handleGitWebhook(w, r)
+
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+		require.Empty(t, events)
+	})
+	t.Run("should ignore events other than push", func(t *testing.T) {
+		s, _ := fixGitWebhookServer(t, automock.NewAsyncLatestCommitChecker(t))
+		r := httptest.NewRequest(http.MethodPost, "/internal/git/webhook", strings.NewReader(`{}`))
+		r.Header.Set("X-GitHub-Event", "ping")
+
+		w := httptest.NewRecorder()
+		s.handleGitWebhook(w, r)
+
+		require.Equal(t, http.StatusOK, w.Code)
+	})
+	t.Run("should reject invalid payload", func(t *testing.T) {
+		s, _ := fixGitWebhookServer(t, automock.NewAsyncLatestCommitChecker(t))
+
+		w := httptest.NewRecorder()
+		s.handleGitWebhook(w, fixGitHubPushRequest(`{`, "secret-value"))
+
+		require.Equal(t, http.StatusBadRequest, w.Code)
+	})
+	t.Run("should reject method other than post", func(t *testing.T) {
+		s, _ := fixGitWebhookServer(t, automock.NewAsyncLatestCommitChecker(t))
+
+		w := httptest.NewRecorder()
+		s.handleGitWebhook(w, httptest.NewRequest(http.MethodGet, "/internal/git/webhook", nil))
+
+		require.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+}
+
+func fixGitWebhookServer(t *testing.T, gitChecker *automock.AsyncLatestCommitChecker, objs ...client.Object) (*Server, chan event.GenericEvent) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, v1alpha2.AddToScheme(scheme))
+	require.NoError(t, corev1.AddToScheme(scheme))
+	events := make(chan event.GenericEvent, 10)
+	k8s := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(objs...).
+		WithIndex(&v1alpha2.Function{}, gitWebhookRepositoryIndex, gitWebhookRepository).
+		Build()
+	return &Server{
+		ctx:            context.Background(),
+		k8s:            k8s,
+		functionCache:  k8s,
+		log:            zap.NewNop().Sugar(),
+		gitChecker:     gitChecker,
+		functionEvents: events,
+	}, events
+}
+
+func fixGitHubPushRequest(body, secret string) *http.Request {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	r := httptest.NewRequest(http.MethodPost, "/internal/git/webhook", strings.NewReader(body))
+	r.Header.Set("X-GitHub-Event", "push")
+	r.Header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	return r
+}
+
+func fixWebhookFunction(name, uid, url, reference, secretName string) *v1alpha2.Function {
+	return &v1alpha2.Function{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", UID: types.UID(uid)},
+		Spec: v1alpha2.FunctionSpec{
+			Runtime: v1alpha2.NodeJs24,
+			Source: v1alpha2.Source{
+				GitRepository: &v1alpha2.GitRepositorySource{
+					URL:        url,
+					Webhook:    &v1alpha2.RepositoryWebhook{SecretName: secretName},
+					Repository: v1alpha2.Repository{BaseDir: "/", Reference: reference},
+				},
+			},
+		},
+	}
+}
+
+func fixWebhookSecret(name, value string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Data:       map[string][]byte{GitWebhookSecretKey: []byte(value)},
+	}
+}
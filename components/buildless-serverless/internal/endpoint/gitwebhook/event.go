@@ -0,0 +1,248 @@
+package gitwebhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+type Provider string
+
+const (
+	ProviderGitHub    Provider = "github"
+	ProviderGitLab    Provider = "gitlab"
+	ProviderBitbucket Provider = "bitbucket"
+	ProviderGeneric   Provider = "generic"
+)
+
+const (
+	githubEventHeader     = "X-GitHub-Event"
+	gitlabEventHeader     = "X-Gitlab-Event"
+	gitlabTokenHeader     = "X-Gitlab-Token"
+	bitbucketEventHeader  = "X-Event-Key"
+	signatureHeader       = "X-Hub-Signature"
+	signatureSHA256Header = "X-Hub-Signature-256"
+	signatureSHA256Prefix = "sha256="
+)
+
+// PushEvent describes the refs pushed to the repository
+type PushEvent struct {
+	Provider Provider
+	// RepositoryURLs are all URLs the repository is known by, like the HTTP and SSH clone URLs
+	RepositoryURLs []string
+	// Refs are the pushed refs, either full like `refs/heads/main` or short like `main`
+	Refs []string
+}
+
+// Parse reads the push event sent by the provider recognized by the request headers
+// nil is returned for the events other than push
+func Parse(header http.Header, body []byte) (*PushEvent, error) {
+	var event *PushEvent
+	var err error
+	switch {
+	case header.Get(githubEventHeader) != "":
+		if header.Get(githubEventHeader) != "push" {
+			return nil, nil
+		}
+		event, err = parseGitHub(body)
+	case header.Get(gitlabEventHeader) != "":
+		if !slices.Contains([]string{"Push Hook", "Tag Push Hook"}, header.Get(gitlabEventHeader)) {
+			return nil, nil
+		}
+		event, err = parseGitLab(body)
+	case header.Get(bitbucketEventHeader) != "":
+		if !slices.Contains([]string{"repo:push", "repo:refs_changed"}, header.Get(bitbucketEventHeader)) {
+			return nil, nil
+		}
+		event, err = parseBitbucket(body)
+	default:
+		event, err = parseGeneric(body)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid push event")
+	}
+	if len(event.RepositoryURLs) == 0 || len(event.Refs) == 0 {
+		return nil, errors.New("invalid push event: missing repository or ref")
+	}
+	return event, nil
+}
+
+// HasCredentials checks the request carries the token or the well-formed signature expected from the provider
+// so the requests without them are rejected before the Functions and their secrets are read
+func (e *PushEvent) HasCredentials(header http.Header) bool {
+	if e.Provider == ProviderGitLab {
+		return header.Get(gitlabTokenHeader) != ""
+	}
+	_, ok := e.signature(header)
+	return ok
+}
+
+// Verify checks the request was sent by the provider knowing the secret
+// GitLab sends the secret token as is, other providers sign the body with HMAC SHA-256
+func (e *PushEvent) Verify(header http.Header, body, secret []byte) bool {
+	if len(secret) == 0 {
+		return false
+	}
+
+	if e.Provider == ProviderGitLab {
+		return subtle.ConstantTimeCompare([]byte(header.Get(gitlabTokenHeader)), secret) == 1
+	}
+
+	expected, ok := e.signature(header)
+	if !ok {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+// signature returns the HMAC SHA-256 signature of the body sent by the provider
+func (e *PushEvent) signature(header http.Header) ([]byte, bool) {
+	signature := header.Get(signatureSHA256Header)
+	if e.Provider == ProviderBitbucket {
+		signature = header.Get(signatureHeader)
+	}
+	signature, ok := strings.CutPrefix(signature, signatureSHA256Prefix)
+	if !ok {
+		return nil, false
+	}
+	decoded, err := hex.DecodeString(signature)
+	if err != nil || len(decoded) != sha256.Size {
+		return nil, false
+	}
+	return decoded, true
+}
+
+type githubPushEvent struct {
+	Ref        string `json:"ref"`
+	Repository struct {
+		CloneURL string `json:"clone_url"`
+		SSHURL   string `json:"ssh_url"`
+		GitURL   string `json:"git_url"`
+		HTMLURL  string `json:"html_url"`
+	} `json:"repository"`
+}
+
+func parseGitHub(body []byte) (*PushEvent, error) {
+	payload := githubPushEvent{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, err
+	}
+	repo := payload.Repository
+	return &PushEvent{
+		Provider:       ProviderGitHub,
+		RepositoryURLs: nonEmpty(repo.CloneURL, repo.SSHURL, repo.GitURL, repo.HTMLURL),
+		Refs:           nonEmpty(payload.Ref),
+	}, nil
+}
+
+type gitlabPushEvent struct {
+	Ref     string `json:"ref"`
+	Project struct {
+		GitHTTPURL string `json:"git_http_url"`
+		GitSSHURL  string `json:"git_ssh_url"`
+		WebURL     string `json:"web_url"`
+	} `json:"project"`
+}
+
+func parseGitLab(body []byte) (*PushEvent, error) {
+	payload := gitlabPushEvent{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, err
+	}
+	project := payload.Project
+	return &PushEvent{
+		Provider:       ProviderGitLab,
+		RepositoryURLs: nonEmpty(project.GitHTTPURL, project.GitSSHURL, project.WebURL),
+		Refs:           nonEmpty(payload.Ref),
+	}, nil
+}
+
+type bitbucketLink struct {
+	Href string `json:"href"`
+}
+
+// bitbucketPushEvent covers both the Bitbucket Cloud `repo:push` and the Bitbucket Data Center `repo:refs_changed` events
+type bitbucketPushEvent struct {
+	// Bitbucket Cloud
+	Push struct {
+		Changes []struct {
+			New *struct {
+				Name string `json:"name"`
+			} `json:"new"`
+		} `json:"changes"`
+	} `json:"push"`
+	// Bitbucket Data Center
+	Changes []struct {
+		Ref struct {
+			ID string `json:"id"`
+		} `json:"ref"`
+	} `json:"changes"`
+	Repository struct {
+		Links struct {
+			HTML  *bitbucketLink  `json:"html"`
+			Clone []bitbucketLink `json:"clone"`
+		} `json:"links"`
+	} `json:"repository"`
+}
+
+func parseBitbucket(body []byte) (*PushEvent, error) {
+	payload := bitbucketPushEvent{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, err
+	}
+
+	event := &PushEvent{Provider: ProviderBitbucket}
+	if payload.Repository.Links.HTML != nil {
+		event.RepositoryURLs = nonEmpty(payload.Repository.Links.HTML.Href)
+	}
+	for _, link := range payload.Repository.Links.Clone {
+		event.RepositoryURLs = append(event.RepositoryURLs, nonEmpty(link.Href)...)
+	}
+	for _, change := range payload.Push.Changes {
+		// the new state is empty for the deleted branches and tags
+		if change.New != nil {
+			event.Refs = append(event.Refs, nonEmpty(change.New.Name)...)
+		}
+	}
+	for _, change := range payload.Changes {
+		event.Refs = append(event.Refs, nonEmpty(change.Ref.ID)...)
+	}
+	return event, nil
+}
+
+type genericPushEvent struct {
+	Repository string `json:"repository"`
+	Ref        string `json:"ref"`
+}
+
+func parseGeneric(body []byte) (*PushEvent, error) {
+	payload := genericPushEvent{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, err
+	}
+	return &PushEvent{
+		Provider:       ProviderGeneric,
+		RepositoryURLs: nonEmpty(payload.Repository),
+		Refs:           nonEmpty(payload.Ref),
+	}, nil
+}
+
+func nonEmpty(values ...string) []string {
+	var result []string
+	for _, value := range values {
+		if value != "" {
+			result = append(result, value)
+		}
+	}
+	return result
+}
@@ -0,0 +1,228 @@
+package gitwebhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+		body   string
+		want   *PushEvent
+	}{
+		{
+			name:   "github push",
+			header: http.Header{"X-Github-Event": {"push"}},
+			body: `{"ref":"refs/heads/main","repository":{
+				"clone_url":"https://github.com/org/repo.git",
+				"ssh_url":"git@github.com:org/repo.git",
+				"html_url":"https://github.com/org/repo"}}`,
+			want: &PushEvent{
+				Provider:       ProviderGitHub,
+				RepositoryURLs: []string{"https://github.com/org/repo.git", "git@github.com:org/repo.git", "https://github.com/org/repo"},
+				Refs:           []string{"refs/heads/main"},
+			},
+		},
+		{
+			name:   "github ping",
+			header: http.Header{"X-Github-Event": {"ping"}},
+			body:   `{"zen":"Keep it logically awesome."}`,
+		},
+		{
+			name:   "gitlab tag push",
+			header: http.Header{"X-Gitlab-Event": {"Tag Push Hook"}},
+			body: `{"ref":"refs/tags/v1.0.0","project":{
+				"git_http_url":"https://gitlab.com/org/repo.git",
+				"git_ssh_url":"git@gitlab.com:org/repo.git"}}`,
+			want: &PushEvent{
+				Provider:       ProviderGitLab,
+				RepositoryURLs: []string{"https://gitlab.com/org/repo.git", "git@gitlab.com:org/repo.git"},
+				Refs:           []string{"refs/tags/v1.0.0"},
+			},
+		},
+		{
+			name:   "gitlab merge request",
+			header: http.Header{"X-Gitlab-Event": {"Merge Request Hook"}},
+			body:   `{}`,
+		},
+		{
+			name:   "bitbucket cloud push",
+			header: http.Header{"X-Event-Key": {"repo:push"}},
+			body: `{"push":{"changes":[{"new":{"type":"branch","name":"main"}},{"new":null}]},
+				"repository":{"links":{"html":{"href":"https://bitbucket.org/org/repo"}}}}`,
+			want: &PushEvent{
+				Provider:       ProviderBitbucket,
+				RepositoryURLs: []string{"https://bitbucket.org/org/repo"},
+				Refs:           []string{"main"},
+			},
+		},
+		{
+			name:   "bitbucket data center push",
+			header: http.Header{"X-Event-Key": {"repo:refs_changed"}},
+			body: `{"changes":[{"ref":{"id":"refs/heads/main"}}],
+				"repository":{"links":{"clone":[
+					{"href":"ssh://git@bitbucket.example.com:7999/org/repo.git"},
+					{"href":"https://bitbucket.example.com/scm/org/repo.git"}]}}}`,
+			want: &PushEvent{
+				Provider: ProviderBitbucket,
+				RepositoryURLs: []string{
+					"ssh://git@bitbucket.example.com:7999/org/repo.git",
+					"https://bitbucket.example.com/scm/org/repo.git",
+				},
+				Refs: []string{"refs/heads/main"},
+			},
+		},
+		{
+			name:   "generic push",
+			header: http.Header{},
+			body:   `{"repository":"https://git.example.com/org/repo.git","ref":"main"}`,
+			want: &PushEvent{
+				Provider:       ProviderGeneric,
+				RepositoryURLs: []string{"https://git.example.com/org/repo.git"},
+				Refs:           []string{"main"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.header, []byte(tt.body))
+
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("invalid json", func(t *testing.T) {
+		got, err := Parse(http.Header{"X-Github-Event": {"push"}}, []byte(`{`))
+
+		require.ErrorContains(t, err, "invalid push event")
+		require.Nil(t, got)
+	})
+	t.Run("missing ref", func(t *testing.T) {
+		got, err := Parse(http.Header{}, []byte(`{"repository":"https://git.example.com/org/repo.git"}`))
+
+		require.EqualError(t, err, "invalid push event: missing repository or ref")
+		require.Nil(t, got)
+	})
+}
+
+func TestPushEvent_Verify(t *testing.T) {
+	body := []byte(`{"ref":"refs/heads/main"}`)
+	secret := []byte("webhook-secret")
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	signature := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	tests := []struct {
+		name     string
+		provider Provider
+		header   http.Header
+		secret   []byte
+		want     bool
+	}{
+		{
+			name:     "github valid signature",
+			provider: ProviderGitHub,
+			header:   http.Header{"X-Hub-Signature-256": {signature}},
+			secret:   secret,
+			want:     true,
+		},
+		{
+			name:     "github signed with other secret",
+			provider: ProviderGitHub,
+			header:   http.Header{"X-Hub-Signature-256": {signature}},
+			secret:   []byte("other-secret"),
+			want:     false,
+		},
+		{
+			name:     "github missing signature",
+			provider: ProviderGitHub,
+			header:   http.Header{},
+			secret:   secret,
+			want:     false,
+		},
+		{
+			name:     "github malformed signature",
+			provider: ProviderGitHub,
+			header:   http.Header{"X-Hub-Signature-256": {"sha256=not-hex"}},
+			secret:   secret,
+			want:     false,
+		},
+		{
+			name:     "bitbucket valid signature",
+			provider: ProviderBitbucket,
+			header:   http.Header{"X-Hub-Signature": {signature}},
+			secret:   secret,
+			want:     true,
+		},
+		{
+			name:     "generic valid signature",
+			provider: ProviderGeneric,
+			header:   http.Header{"X-Hub-Signature-256": {signature}},
+			secret:   secret,
+			want:     true,
+		},
+		{
+			name:     "gitlab valid token",
+			provider: ProviderGitLab,
+			header:   http.Header{"X-Gitlab-Token": {"webhook-secret"}},
+			secret:   secret,
+			want:     true,
+		},
+		{
+			name:     "gitlab invalid token",
+			provider: ProviderGitLab,
+			header:   http.Header{"X-Gitlab-Token": {"other-secret"}},
+			secret:   secret,
+			want:     false,
+		},
+		{
+			name:     "empty secret",
+			provider: ProviderGitLab,
+			header:   http.Header{"X-Gitlab-Token": {""}},
+			secret:   nil,
+			want:     false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &PushEvent{Provider: tt.provider}
+
+			require.Equal(t, tt.want, e.Verify(tt.header, body, tt.secret))
+		})
+	}
+}
+
+func TestPushEvent_HasCredentials(t *testing.T) {
+	signature := "sha256=" + strings.Repeat("ab", sha256.Size)
+
+	tests := []struct {
+		name     string
+		provider Provider
+		header   http.Header
+		want     bool
+	}{
+		{name: "github signature", provider: ProviderGitHub, header: http.Header{"X-Hub-Signature-256": {signature}}, want: true},
+		{name: "github missing signature", provider: ProviderGitHub, header: http.Header{}, want: false},
+		{name: "github truncated signature", provider: ProviderGitHub, header: http.Header{"X-Hub-Signature-256": {"sha256=abab"}}, want: false},
+		{name: "github sha1 signature", provider: ProviderGitHub, header: http.Header{"X-Hub-Signature": {"sha1=abab"}}, want: false},
+		{name: "bitbucket signature", provider: ProviderBitbucket, header: http.Header{"X-Hub-Signature": {signature}}, want: true},
+		{name: "gitlab token", provider: ProviderGitLab, header: http.Header{"X-Gitlab-Token": {"webhook-secret"}}, want: true},
+		{name: "gitlab missing token", provider: ProviderGitLab, header: http.Header{}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &PushEvent{Provider: tt.provider}
+
+			require.Equal(t, tt.want, e.HasCredentials(tt.header))
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package gitwebhook
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/git"
+)
+
+const (
+	branchRefPrefix = "refs/heads/"
+	tagRefPrefix    = "refs/tags/"
+)
+
+// Matches checks if the push event changes the reference of the repository
+// the pushed tags are matched against the semver constraint or the pattern the same way the reference is resolved
+func (e *PushEvent) Matches(repositoryURL, reference string) bool {
+	if !slices.Contains(e.Repositories(), NormalizeURL(repositoryURL)) {
+		return false
+	}
+
+	return slices.ContainsFunc(e.Refs, func(ref string) bool {
+		if ref == reference {
+			return true
+		}
+		if branch, ok := strings.CutPrefix(ref, branchRefPrefix); ok {
+			return branch == reference
+		}
+		// the short refs sent by some providers may be both branches and tags
+		tag, _ := strings.CutPrefix(ref, tagRefPrefix)
+		return git.MatchesTag(reference, tag)
+	})
+}
+
+// Repositories returns the normalized URLs of the pushed repository
+func (e *PushEvent) Repositories() []string {
+	var repositories []string
+	for _, url := range e.RepositoryURLs {
+		repositories = append(repositories, NormalizeURL(url))
+	}
+	slices.Sort(repositories)
+	return slices.Compact(repositories)
+}
+
+// NormalizeURL reduces the HTTP, SSH and SCP-like repository URLs to the `host/path` form
+// so the repository configured in the Function matches any URL sent by the provider
+func NormalizeURL(url string) string {
+	url = strings.ToLower(strings.TrimSpace(url))
+
+	if _, rest, ok := strings.Cut(url, "://"); ok {
+		url = rest
+	} else if host, path, ok := strings.Cut(url, ":"); ok && !strings.Contains(host, "/") {
+		// git@github.com:org/repo.git
+		url = host + "/" + path
+	}
+
+	host, path, _ := strings.Cut(url, "/")
+	if i := strings.LastIndex(host, "@"); i != -1 {
+		host = host[i+1:]
+	}
+	if h, _, ok := strings.Cut(host, ":"); ok {
+		// the port differs between the HTTP and SSH URLs
+		host = h
+	}
+
+	path = strings.Trim(path, "/")
+	path = strings.TrimSuffix(path, ".git")
+	return host + "/" + path
+}
@@ -6,100 +6,95 @@ import (
 	"os"
 
 	"github.com/kyma-project/serverless/components/buildless-serverless/api/v1alpha2"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/config"
 	"github.com/kyma-project/serverless/components/buildless-serverless/internal/endpoint/packagejson"
 	"github.com/kyma-project/serverless/components/buildless-serverless/internal/endpoint/types"
 	"github.com/pkg/errors"
 )
 
-func ReadFiles(f *v1alpha2.Function) ([]types.FileResponse, error) {
+func ReadFiles(c *config.FunctionConfig, f *v1alpha2.Function) ([]types.FileResponse, error) {
 	runtimeDir := fmt.Sprintf("runtimes/%s", f.Spec.Runtime)
+	rc, ok := c.RuntimeConfig(string(f.Spec.Runtime))
+	if !ok {
+		return nil, errors.Errorf("cannot find runtime: %s", f.Spec.Runtime)
+	}
 
-	if f.HasPythonRuntime() {
-		return readPythonFiles(f.Spec.Source.Inline, runtimeDir)
+	if _, err := os.Stat(runtimeDir); os.IsNotExist(err) {
+		// the custom runtimes ship their server files with the image only
+		return readFunctionFiles(rc, f.Spec.Source.Inline), nil
 	}
 
-	return readNodejsFiles(f.Spec.Source.Inline, runtimeDir)
+	return readRuntimeFiles(rc, f.Spec.Source.Inline, runtimeDir)
 }
 
-func readNodejsFiles(inline *v1alpha2.InlineSource, runtimeDir string) ([]types.FileResponse, error) {
-	commonFiles, err := readCommonFiles(runtimeDir)
-	if err != nil {
-		return nil, err
-	}
-
-	// read package.json and merge function dependencies
-	packagejsonFile, err := os.ReadFile(runtimeDir + "/package.json")
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to read package.json")
+func readFunctionFiles(rc config.RuntimeConfig, inline *v1alpha2.InlineSource) []types.FileResponse {
+	files := []types.FileResponse{
+		{Name: rc.HandlerFile, Data: base64.StdEncoding.EncodeToString([]byte(inline.Source))},
 	}
-
-	if inline.Dependencies != "" {
-		packagejsonFile, err = packagejson.Merge([]byte(inline.Dependencies), packagejsonFile)
-		if err != nil {
-			return nil, errors.Wrap(err, "failed to merge package.json")
-		}
+	if rc.DependenciesFile == "" {
+		return files
 	}
 
-	// read server.mjs
-	serverFile, err := os.ReadFile(runtimeDir + "/server.mjs")
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to read server.mjs")
+	dependencies := inline.Dependencies
+	if dependencies == "" {
+		dependencies = rc.EmptyDependencies
 	}
-
-	return append(commonFiles, []types.FileResponse{
-		{Name: "package.json", Data: base64.StdEncoding.EncodeToString(packagejsonFile)},
-		{Name: "server.mjs", Data: base64.StdEncoding.EncodeToString(serverFile)},
-		{Name: "handler.js", Data: base64.StdEncoding.EncodeToString([]byte(inline.Source))},
-	}...), nil
+	return append(files, types.FileResponse{Name: rc.DependenciesFile, Data: base64.StdEncoding.EncodeToString([]byte(dependencies))})
 }
 
-func readPythonFiles(inline *v1alpha2.InlineSource, runtimeDir string) ([]types.FileResponse, error) {
+func readRuntimeFiles(rc config.RuntimeConfig, inline *v1alpha2.InlineSource, runtimeDir string) ([]types.FileResponse, error) {
 	commonFiles, err := readCommonFiles(runtimeDir)
 	if err != nil {
 		return nil, err
 	}
 
-	// read requirements.txt and append function dependencies
-	requirementsFile, err := os.ReadFile(runtimeDir + "/requirements.txt")
+	// read the dependencies file and merge function dependencies
+	dependenciesFile, err := os.ReadFile(fmt.Sprintf("%s/%s", runtimeDir, rc.DependenciesFile))
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to read requirements.txt")
+		return nil, errors.Wrapf(err, "failed to read %s", rc.DependenciesFile)
 	}
 
 	if inline.Dependencies != "" {
-		requirementsFile = []byte(fmt.Sprintf("%s\n%s", string(requirementsFile), inline.Dependencies))
+		dependenciesFile, err = mergeDependencies(rc.DependenciesFormat, []byte(inline.Dependencies), dependenciesFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to merge %s", rc.DependenciesFile)
+		}
 	}
 
-	// read server.py
-	serverFile, err := os.ReadFile(runtimeDir + "/server.py")
+	// read the server file
+	serverFile, err := os.ReadFile(fmt.Sprintf("%s/%s", runtimeDir, rc.ServerFile))
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to read server.py")
+		return nil, errors.Wrapf(err, "failed to read %s", rc.ServerFile)
+	}
+
+	// read sdk files, the sdk package is shipped only with runtimes using the new API
+	if _, statErr := os.Stat(runtimeDir + "/sdk"); statErr == nil {
+		sdkFiles, err := readDirFiles(runtimeDir, "sdk")
+		if err != nil {
+			return nil, err
+		}
+		commonFiles = append(commonFiles, sdkFiles...)
 	}
 
 	return append(commonFiles, []types.FileResponse{
-		{Name: "requirements.txt", Data: base64.StdEncoding.EncodeToString(requirementsFile)},
-		{Name: "server.py", Data: base64.StdEncoding.EncodeToString(serverFile)},
-		{Name: "handler.py", Data: base64.StdEncoding.EncodeToString([]byte(inline.Source))},
+		{Name: rc.DependenciesFile, Data: base64.StdEncoding.EncodeToString(dependenciesFile)},
+		{Name: rc.ServerFile, Data: base64.StdEncoding.EncodeToString(serverFile)},
+		{Name: rc.HandlerFile, Data: base64.StdEncoding.EncodeToString([]byte(inline.Source))},
 	}...), nil
 }
 
-func readCommonFiles(runtimeDir string) ([]types.FileResponse, error) {
-	// read lib files
-	libFilesInfo, dirErr := os.ReadDir(runtimeDir + "/lib")
-	if dirErr != nil {
-		return nil, errors.Wrap(dirErr, "failed to read lib directory")
+func mergeDependencies(format config.DependenciesFormat, dependencies, runtimeDependencies []byte) ([]byte, error) {
+	if format == config.DependenciesFormatJSON {
+		return packagejson.Merge(dependencies, runtimeDependencies)
 	}
+	return []byte(fmt.Sprintf("%s\n%s", string(runtimeDependencies), string(dependencies))), nil
+}
 
-	libFiles := make([]types.FileResponse, 0, len(libFilesInfo))
-	for _, f := range libFilesInfo {
-		if f.IsDir() {
-			continue
-		}
-
-		data, err := os.ReadFile(runtimeDir + "/lib/" + f.Name())
-		if err != nil {
-			return nil, errors.Wrapf(err, "failed to read lib file '%s'", f.Name())
-		}
-		libFiles = append(libFiles, types.FileResponse{Name: fmt.Sprintf("/lib/%s", f.Name()), Data: base64.StdEncoding.EncodeToString(data)})
+func readCommonFiles(runtimeDir string) ([]types.FileResponse, error) {
+	// read lib files
+	libFiles, err := readDirFiles(runtimeDir, "lib")
+	if err != nil {
+		return nil, err
 	}
 
 	// read .gitignore
@@ -140,3 +135,24 @@ func readCommonFiles(runtimeDir string) ([]types.FileResponse, error) {
 		{Name: "Makefile", Data: base64.StdEncoding.EncodeToString(makefileFile)},
 	}...), nil
 }
+
+func readDirFiles(runtimeDir, dir string) ([]types.FileResponse, error) {
+	filesInfo, dirErr := os.ReadDir(fmt.Sprintf("%s/%s", runtimeDir, dir))
+	if dirErr != nil {
+		return nil, errors.Wrapf(dirErr, "failed to read %s directory", dir)
+	}
+
+	files := make([]types.FileResponse, 0, len(filesInfo))
+	for _, f := range filesInfo {
+		if f.IsDir() {
+			continue
+		}
+
+		data, err := os.ReadFile(fmt.Sprintf("%s/%s/%s", runtimeDir, dir, f.Name()))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read %s file '%s'", dir, f.Name())
+		}
+		files = append(files, types.FileResponse{Name: fmt.Sprintf("/%s/%s", dir, f.Name()), Data: base64.StdEncoding.EncodeToString(data)})
+	}
+	return files, nil
+}
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/kyma-project/serverless/components/buildless-serverless/api/v1alpha2"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/config"
 	"github.com/kyma-project/serverless/components/buildless-serverless/internal/endpoint/types"
 	"github.com/stretchr/testify/require"
 )
@@ -16,6 +17,22 @@ const (
 )
 
 func Test_readNodejsFiles(t *testing.T) {
+	t.Run("read true nodejs26 runtime files", func(t *testing.T) {
+		inline := &v1alpha2.InlineSource{
+			Source:       handlerData,
+			Dependencies: "{}",
+		}
+		runtimeDir := fmt.Sprintf("%s/%s", runtimesDir, "nodejs26")
+
+		gotList, gotErr := readRuntimeFiles(fixRuntimeConfig(t, "nodejs26"), inline, runtimeDir)
+		require.NoError(t, gotErr)
+		require.Len(t, gotList, 13)
+		requireFileWithName(t, gotList, "package.json")
+		requireFileWithName(t, gotList, "/sdk/index.js")
+		requireFileWithName(t, gotList, "/sdk/package.json")
+		require.Contains(t, gotList, types.FileResponse{Name: "handler.js", Data: handlerBase64Data})
+	})
+
 	t.Run("read true nodejs24 runtime files", func(t *testing.T) {
 		inline := &v1alpha2.InlineSource{
 			Source:       handlerData,
@@ -23,7 +40,7 @@ func Test_readNodejsFiles(t *testing.T) {
 		}
 		runtimeDir := fmt.Sprintf("%s/%s", runtimesDir, "nodejs24")
 
-		gotList, gotErr := readNodejsFiles(inline, runtimeDir)
+		gotList, gotErr := readRuntimeFiles(fixRuntimeConfig(t, "nodejs24"), inline, runtimeDir)
 		require.NoError(t, gotErr)
 		require.Len(t, gotList, 12)
 		requireFileWithName(t, gotList, "package.json")
@@ -37,7 +54,7 @@ func Test_readNodejsFiles(t *testing.T) {
 		}
 		runtimeDir := fmt.Sprintf("%s/%s", runtimesDir, "nodejs22")
 
-		gotList, gotErr := readNodejsFiles(inline, runtimeDir)
+		gotList, gotErr := readRuntimeFiles(fixRuntimeConfig(t, "nodejs22"), inline, runtimeDir)
 		require.NoError(t, gotErr)
 		require.Len(t, gotList, 12)
 		requireFileWithName(t, gotList, "package.json")
@@ -51,7 +68,7 @@ func Test_readNodejsFiles(t *testing.T) {
 		}
 		runtimeDir := fmt.Sprintf("%s/%s", runtimesDir, "nodejs20")
 
-		gotList, gotErr := readNodejsFiles(inline, runtimeDir)
+		gotList, gotErr := readRuntimeFiles(fixRuntimeConfig(t, "nodejs20"), inline, runtimeDir)
 		require.NoError(t, gotErr)
 		require.Len(t, gotList, 12)
 		requireFileWithName(t, gotList, "package.json")
@@ -65,13 +82,28 @@ func Test_readNodejsFiles(t *testing.T) {
 		}
 		runtimeDir := fmt.Sprintf("%s/%s", runtimesDir, "nodejs")
 
-		gotList, gotErr := readNodejsFiles(inline, runtimeDir)
+		gotList, gotErr := readRuntimeFiles(fixRuntimeConfig(t, "nodejs24"), inline, runtimeDir)
 		require.Error(t, gotErr)
 		require.Nil(t, gotList)
 	})
 }
 
 func Test_readPythonFiles(t *testing.T) {
+	t.Run("read true python314 runtime files", func(t *testing.T) {
+		inline := &v1alpha2.InlineSource{
+			Source:       handlerData,
+			Dependencies: "",
+		}
+		runtimeDir := fmt.Sprintf("%s/%s", runtimesDir, "python314")
+
+		gotList, gotErr := readRuntimeFiles(fixRuntimeConfig(t, "python314"), inline, runtimeDir)
+		require.NoError(t, gotErr)
+		require.Len(t, gotList, 11)
+		requireFileWithName(t, gotList, "requirements.txt")
+		requireFileWithName(t, gotList, "/lib/sdk.py")
+		require.Contains(t, gotList, types.FileResponse{Name: "handler.py", Data: handlerBase64Data})
+	})
+
 	t.Run("read true python312 runtime files", func(t *testing.T) {
 		inline := &v1alpha2.InlineSource{
 			Source:       handlerData,
@@ -79,7 +111,7 @@ func Test_readPythonFiles(t *testing.T) {
 		}
 		runtimeDir := fmt.Sprintf("%s/%s", runtimesDir, "python312")
 
-		gotList, gotErr := readPythonFiles(inline, runtimeDir)
+		gotList, gotErr := readRuntimeFiles(fixRuntimeConfig(t, "python312"), inline, runtimeDir)
 		require.NoError(t, gotErr)
 		require.Len(t, gotList, 10)
 		requireFileWithName(t, gotList, "requirements.txt")
@@ -93,12 +125,55 @@ func Test_readPythonFiles(t *testing.T) {
 		}
 		runtimeDir := fmt.Sprintf("%s/%s", runtimesDir, "python")
 
-		gotList, gotErr := readPythonFiles(inline, runtimeDir)
+		gotList, gotErr := readRuntimeFiles(fixRuntimeConfig(t, "python312"), inline, runtimeDir)
 		require.Error(t, gotErr)
 		require.Nil(t, gotList)
 	})
 }
 
+func TestReadFiles(t *testing.T) {
+	t.Run("unknown runtime", func(t *testing.T) {
+		f := &v1alpha2.Function{
+			Spec: v1alpha2.FunctionSpec{
+				Runtime: "custom",
+				Source: v1alpha2.Source{
+					Inline: &v1alpha2.InlineSource{Source: handlerData},
+				},
+			},
+		}
+
+		gotList, gotErr := ReadFiles(&config.FunctionConfig{}, f)
+		require.ErrorContains(t, gotErr, "cannot find runtime: custom")
+		require.Nil(t, gotList)
+	})
+	t.Run("custom runtime without runtime files", func(t *testing.T) {
+		f := &v1alpha2.Function{
+			Spec: v1alpha2.FunctionSpec{
+				Runtime: "go124",
+				Source: v1alpha2.Source{
+					Inline: &v1alpha2.InlineSource{Source: handlerData},
+				},
+			},
+		}
+		c := &config.FunctionConfig{Runtimes: map[string]config.RuntimeConfig{
+			"go124": {HandlerFile: "handler.go", DependenciesFile: "go.mod", EmptyDependencies: "module function"},
+		}}
+
+		gotList, gotErr := ReadFiles(c, f)
+		require.NoError(t, gotErr)
+		require.Equal(t, []types.FileResponse{
+			{Name: "handler.go", Data: handlerBase64Data},
+			{Name: "go.mod", Data: "bW9kdWxlIGZ1bmN0aW9u"},
+		}, gotList)
+	})
+}
+
+func fixRuntimeConfig(t *testing.T, runtime string) config.RuntimeConfig {
+	rc, ok := (&config.FunctionConfig{}).RuntimeConfig(runtime)
+	require.True(t, ok)
+	return rc
+}
+
 func requireFileWithName(t *testing.T, files []types.FileResponse, name string) {
 	for _, f := range files {
 		if f.Name == name {
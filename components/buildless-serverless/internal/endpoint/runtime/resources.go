@@ -59,6 +59,9 @@ func buildDeploymentFileData(functionConfig *config.FunctionConfig, function *v1
 		// TODO: support git source
 		return nil, errors.New("ejecting functions with git source is not supported")
 	}
+	if sourceType := function.ResolvedSourceType(); sourceType != "" {
+		return nil, errors.Errorf("ejecting functions with %s source is not supported", sourceType)
+	}
 
 	deployName := appName
 	if deployName == "" {
@@ -68,7 +71,6 @@ func buildDeploymentFileData(functionConfig *config.FunctionConfig, function *v1
 	deploy := resources.NewDeployment(
 		function,
 		functionConfig,
-		nil,
 		"",
 		nil,
 		appName,
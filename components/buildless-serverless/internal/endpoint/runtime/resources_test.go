@@ -8,7 +8,10 @@ import (
 	"github.com/kyma-project/serverless/components/buildless-serverless/api/v1alpha2"
 	"github.com/kyma-project/serverless/components/buildless-serverless/internal/config"
 	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
 )
 
 func TestBuildResources(t *testing.T) {
@@ -79,6 +82,59 @@ func TestBuildResources(t *testing.T) {
 		require.Equal(t, "k8s/deployment.yaml", files[1].Name)
 		requireEqualBase64Objects(t, fixDeployment("test-app-name", "test-app-name"), files[1].Data)
 	})
+
+	t.Run("build deployment scheduled with function's template", func(t *testing.T) {
+		files, err := BuildResources(&config.FunctionConfig{}, &v1alpha2.Function{
+			Spec: v1alpha2.FunctionSpec{
+				Runtime: "nodejs24",
+				Source: v1alpha2.Source{
+					Inline: &v1alpha2.InlineSource{
+						Source:       "console.log('Hello World')",
+						Dependencies: "{}",
+					},
+				},
+				Template: &v1alpha2.Template{
+					NodeSelector: map[string]string{"pool": "functions"},
+					Tolerations: []corev1.Toleration{
+						{Key: "dedicated", Operator: corev1.TolerationOpExists},
+					},
+					TopologySpreadConstraints: []corev1.TopologySpreadConstraint{
+						{MaxSkew: 1, TopologyKey: "topology.kubernetes.io/zone", WhenUnsatisfiable: corev1.ScheduleAnyway},
+					},
+					PriorityClassName:  "high-priority",
+					ServiceAccountName: "function-sa",
+				},
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-function",
+				Namespace: "test-namespace",
+			},
+		}, "", false)
+
+		require.NoError(t, err)
+		require.Len(t, files, 2)
+		data, err := base64.StdEncoding.DecodeString(files[1].Data)
+		require.NoError(t, err)
+		deployment := appsv1.Deployment{}
+		require.NoError(t, yaml.Unmarshal(data, &deployment))
+
+		podSpec := deployment.Spec.Template.Spec
+		require.Equal(t, map[string]string{"pool": "functions"}, podSpec.NodeSelector)
+		require.Equal(t, []corev1.Toleration{
+			{Key: "dedicated", Operator: corev1.TolerationOpExists},
+		}, podSpec.Tolerations)
+		require.Equal(t, []corev1.TopologySpreadConstraint{
+			{
+				MaxSkew:           1,
+				TopologyKey:       "topology.kubernetes.io/zone",
+				WhenUnsatisfiable: corev1.ScheduleAnyway,
+				// the ejected pods are selected by the ejected deployment's selector
+				LabelSelector: deployment.Spec.Selector,
+			},
+		}, podSpec.TopologySpreadConstraints)
+		require.Equal(t, "high-priority", podSpec.PriorityClassName)
+		require.Equal(t, "function-sa", podSpec.ServiceAccountName)
+	})
 }
 
 func fixTestService(appName string) string {
@@ -96,6 +152,7 @@ spec:
   selector:
     app.kubernetes.io/instance: %s
     serverless.kyma-project.io/resource: deployment
+  type: ClusterIP
 status:
   loadBalancer: {}
 `, appName, appName)
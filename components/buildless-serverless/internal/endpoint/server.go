@@ -6,30 +6,43 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/kyma-project/serverless/components/buildless-serverless/internal/config"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/controller/git"
 	"go.uber.org/zap"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 )
 
 type Server struct {
 	ctx                   context.Context
 	mux                   *mux.Router
 	k8s                   client.Client
+	functionCache         client.Reader
 	log                   *zap.SugaredLogger
 	functionConfig        config.FunctionConfig
 	isKymaFipsModeEnabled bool
+	gitChecker            git.AsyncLatestCommitChecker
+	functionEvents        chan<- event.GenericEvent
 }
 
-func NewInternalServer(ctx context.Context, log *zap.SugaredLogger, k8s client.Client, functionConfig config.FunctionConfig, isKymaFipsModeEnabled bool) *Server {
+// NewInternalServer creates the server handling the function eject requests and the git webhooks
+// the functions pushed by the git webhook are sent to the functionEvents channel
+// the functionCache must serve the index registered by IndexGitWebhookFunctions
+func NewInternalServer(ctx context.Context, log *zap.SugaredLogger, k8s client.Client, functionCache client.Reader, functionConfig config.FunctionConfig, isKymaFipsModeEnabled bool,
+	gitChecker git.AsyncLatestCommitChecker, functionEvents chan<- event.GenericEvent) *Server {
 	server := &Server{
 		ctx:                   ctx,
 		mux:                   mux.NewRouter(),
 		k8s:                   k8s,
+		functionCache:         functionCache,
 		log:                   log,
 		functionConfig:        functionConfig,
 		isKymaFipsModeEnabled: isKymaFipsModeEnabled,
+		gitChecker:            gitChecker,
+		functionEvents:        functionEvents,
 	}
 
 	server.mux.HandleFunc("/internal/function/eject/", server.handleFunctionRequest)
+	server.mux.HandleFunc("/internal/git/webhook", server.handleGitWebhook)
 
 	return server
 }
@@ -13,3 +13,7 @@ type FilesListResponse struct {
 	OutputMessage string         `json:"outputMessage"`
 	Files         []FileResponse `json:"files"`
 }
+
+type GitWebhookResponse struct {
+	EnqueuedFunctions int `json:"enqueuedFunctions"`
+}
@@ -0,0 +1,76 @@
+// Package functionruntime resolves the runtimes described by the FunctionRuntime resources
+package functionruntime
+
+import (
+	"context"
+	"maps"
+
+	serverlessv1alpha2 "github.com/kyma-project/serverless/components/buildless-serverless/api/v1alpha2"
+	"github.com/kyma-project/serverless/components/buildless-serverless/internal/config"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Load adds the FunctionRuntime to the runtimes when the runtime is not one of the configured runtimes
+// the missing FunctionRuntime is not an error, the runtime stays unknown
+func Load(ctx context.Context, k8s client.Reader, c *config.FunctionConfig, runtime string) error {
+	if _, ok := c.RuntimeConfig(runtime); ok {
+		return nil
+	}
+
+	functionRuntime := &serverlessv1alpha2.FunctionRuntime{}
+	err := k8s.Get(ctx, client.ObjectKey{Name: runtime}, functionRuntime)
+	if k8serrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// the runtimes map is shared with the controller's config, so it's copied before it's extended
+	runtimes := maps.Clone(c.Runtimes)
+	if runtimes == nil {
+		runtimes = config.Runtimes{}
+	}
+	runtimes[runtime] = functionRuntimeConfig(functionRuntime)
+	c.Runtimes = runtimes
+	return nil
+}
+
+func functionRuntimeConfig(fr *serverlessv1alpha2.FunctionRuntime) config.RuntimeConfig {
+	spec := fr.Spec
+	rc := config.RuntimeConfig{
+		Image:                     spec.Image,
+		Deprecated:                spec.Deprecated,
+		FIPSCompliant:             spec.FIPSCompliant,
+		WorkingDir:                spec.WorkingDir,
+		HandlerFile:               spec.HandlerFile,
+		ServerFile:                spec.ServerFile,
+		DependenciesFile:          spec.DependenciesFile,
+		DependenciesFormat:        config.DependenciesFormat(spec.DependenciesFormat),
+		EmptyDependencies:         spec.EmptyDependencies,
+		PackageRegistryConfigFile: spec.PackageRegistryConfigFile,
+		InstallCommand:            spec.InstallCommand,
+		StartCommand:              spec.StartCommand,
+		Env:                       functionRuntimeEnvs(spec.Env),
+		SourceEnv:                 functionRuntimeEnvs(spec.SourceEnv),
+	}
+	if spec.DependencyCache != nil {
+		rc.DependencyCache = config.RuntimeDependencyCacheConfig{
+			SaveCommand:    spec.DependencyCache.SaveCommand,
+			RestoreCommand: spec.DependencyCache.RestoreCommand,
+		}
+	}
+	for _, emptyDir := range spec.EmptyDirs {
+		rc.EmptyDirs = append(rc.EmptyDirs, config.RuntimeEmptyDir{Name: emptyDir.Name, MountPath: emptyDir.MountPath})
+	}
+	return rc
+}
+
+func functionRuntimeEnvs(envs []serverlessv1alpha2.FunctionRuntimeEnvVar) []config.RuntimeEnvVar {
+	var result []config.RuntimeEnvVar
+	for _, env := range envs {
+		result = append(result, config.RuntimeEnvVar{Name: env.Name, Value: env.Value})
+	}
+	return result
+}
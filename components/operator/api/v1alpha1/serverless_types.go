@@ -32,6 +32,22 @@ type Endpoint struct {
 	Endpoint string `json:"endpoint"`
 }
 
+// FunctionScheduling lists the scheduling settings the Functions are allowed to set in their template
+type FunctionScheduling struct {
+	// Lists the keys of the node labels the Functions can select nodes by. Use `*` to allow any key
+	AllowedNodeSelectorKeys []string `json:"allowedNodeSelectorKeys,omitempty"`
+	// Lists the keys of the node taints the Functions can tolerate. Use `*` to allow any key
+	AllowedTolerationKeys []string `json:"allowedTolerationKeys,omitempty"`
+	// Lists the PriorityClasses the Functions can run with. Use `*` to allow any PriorityClass
+	AllowedPriorityClassNames []string `json:"allowedPriorityClassNames,omitempty"`
+	// Lists the ServiceAccounts the Functions can run as. Use `*` to allow any ServiceAccount
+	AllowedServiceAccountNames []string `json:"allowedServiceAccountNames,omitempty"`
+	// When set to true, the Functions can set the node affinity, and the affinity and anti-affinity to other Pods
+	AllowAffinity bool `json:"allowAffinity,omitempty"`
+	// When set to false, the Functions can't spread their Pods across topology domains. By default, it's set to true
+	AllowTopologySpreadConstraints *bool `json:"allowTopologySpreadConstraints,omitempty"`
+}
+
 // ServerlessSpec defines the desired state of Serverless
 type ServerlessSpec struct {
 	// Used Tracing endpoint
@@ -40,7 +56,7 @@ type ServerlessSpec struct {
 	Eventing *Endpoint `json:"eventing,omitempty"`
 	// Deprecated: No longer has any effect. Docker registry is not used by the serverless module.
 	DockerRegistry *DockerRegistry `json:"dockerRegistry,omitempty"`
-	// Deprecated: No longer has any effect. CPU utilization scaling is not used by the serverless module.
+	// Sets the target average CPU utilization of Functions scaled by the HorizontalPodAutoscaler created from their scaleConfig. Must be an integer between 1 and 100. By default, it's set to 50
 	TargetCPUUtilizationPercentage string `json:"targetCPUUtilizationPercentage,omitempty"`
 	// Sets the requeue duration for Function. By default, the Function associated with the default configuration is requeued every 5 minutes
 	FunctionRequeueDuration string `json:"functionRequeueDuration,omitempty"`
@@ -60,6 +76,8 @@ type ServerlessSpec struct {
 	LogFormat string `json:"logFormat,omitempty"`
 	// Deprecated: No longer has any effect. Network policies are always enabled.
 	EnableNetworkPolicies bool `json:"enableNetworkPolicies,omitempty"`
+	// Restricts the scheduling settings the Functions can set in their template. By default, the Functions can only spread their Pods across topology domains
+	FunctionScheduling *FunctionScheduling `json:"functionScheduling,omitempty"`
 }
 
 type State string
@@ -112,7 +130,6 @@ type ServerlessStatus struct {
 	EventingEndpoint string `json:"eventingEndpoint,omitempty"`
 	TracingEndpoint  string `json:"tracingEndpoint,omitempty"`
 
-	// Deprecated: No longer has any effect.
 	CPUUtilizationPercentage string `json:"targetCPUUtilizationPercentage,omitempty"`
 	RequeueDuration          string `json:"functionRequeueDuration,omitempty"`
 	// Deprecated: No longer has any effect.
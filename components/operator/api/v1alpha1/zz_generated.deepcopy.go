@@ -65,6 +65,46 @@ func (in *Endpoint) DeepCopy() *Endpoint {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FunctionScheduling) DeepCopyInto(out *FunctionScheduling) {
+	*out = *in
+	if in.AllowedNodeSelectorKeys != nil {
+		in, out := &in.AllowedNodeSelectorKeys, &out.AllowedNodeSelectorKeys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowedTolerationKeys != nil {
+		in, out := &in.AllowedTolerationKeys, &out.AllowedTolerationKeys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowedPriorityClassNames != nil {
+		in, out := &in.AllowedPriorityClassNames, &out.AllowedPriorityClassNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowedServiceAccountNames != nil {
+		in, out := &in.AllowedServiceAccountNames, &out.AllowedServiceAccountNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowTopologySpreadConstraints != nil {
+		in, out := &in.AllowTopologySpreadConstraints, &out.AllowTopologySpreadConstraints
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FunctionScheduling.
+func (in *FunctionScheduling) DeepCopy() *FunctionScheduling {
+	if in == nil {
+		return nil
+	}
+	out := new(FunctionScheduling)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Serverless) DeepCopyInto(out *Serverless) {
 	*out = *in
@@ -142,6 +182,11 @@ func (in *ServerlessSpec) DeepCopyInto(out *ServerlessSpec) {
 		*out = new(DockerRegistry)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.FunctionScheduling != nil {
+		in, out := &in.FunctionScheduling, &out.FunctionScheduling
+		*out = new(FunctionScheduling)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServerlessSpec.
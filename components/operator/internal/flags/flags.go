@@ -2,6 +2,7 @@ package flags
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/kyma-project/manager-toolkit/installation/chart"
 )
@@ -18,13 +19,14 @@ func NewBuilder() *Builder {
 	}
 }
 
-func (b *Builder) WithControllerConfiguration(requeueDuration, healthzLivenessTimeout string) *Builder {
+func (b *Builder) WithControllerConfiguration(requeueDuration, healthzLivenessTimeout, targetCPUUtilizationPercentage string) *Builder {
 	optionalFlags := []struct {
 		key   string
 		value string
 	}{
 		{"functionRequeueDuration", requeueDuration},
 		{"healthzLivenessTimeout", healthzLivenessTimeout},
+		{"targetCPUUtilizationPercentage", targetCPUUtilizationPercentage},
 	}
 
 	for _, flag := range optionalFlags {
@@ -37,6 +39,32 @@ func (b *Builder) WithControllerConfiguration(requeueDuration, healthzLivenessTi
 	return b
 }
 
+func (b *Builder) WithFunctionScheduling(nodeSelectorKeys, tolerationKeys, priorityClassNames, serviceAccountNames []string, allowAffinity bool, allowTopologySpreadConstraints *bool) *Builder {
+	allowLists := []struct {
+		key    string
+		values []string
+	}{
+		{"allowedNodeSelectorKeys", nodeSelectorKeys},
+		{"allowedTolerationKeys", tolerationKeys},
+		{"allowedPriorityClassNames", priorityClassNames},
+		{"allowedServiceAccountNames", serviceAccountNames},
+	}
+
+	for _, allowList := range allowLists {
+		if len(allowList.values) != 0 {
+			fullPath := fmt.Sprintf("containers.manager.configuration.data.functionScheduling.%s", allowList.key)
+			b.With(fullPath, fmt.Sprintf("{%s}", strings.Join(allowList.values, ",")))
+		}
+	}
+
+	b.With("containers.manager.configuration.data.functionScheduling.allowAffinity", allowAffinity)
+	if allowTopologySpreadConstraints != nil {
+		b.With("containers.manager.configuration.data.functionScheduling.allowTopologySpreadConstraints", *allowTopologySpreadConstraints)
+	}
+
+	return b
+}
+
 func (b *Builder) WithOptionalDependencies(publisherURL, traceCollectorURL string) *Builder {
 	b.With("containers.manager.configuration.data.functionTraceCollectorEndpoint", traceCollectorURL)
 	b.With("containers.manager.configuration.data.functionPublisherProxyAddress", publisherURL)
@@ -111,7 +139,17 @@ func (b *Builder) WithImageFunctionRuntimeNodejs24(image string) *Builder {
 	return b
 }
 
+func (b *Builder) WithImageFunctionRuntimeNodejs26(image string) *Builder {
+	b.With("global.images.function_runtime_nodejs26", image)
+	return b
+}
+
 func (b *Builder) WithImageFunctionRuntimePython312(image string) *Builder {
 	b.With("global.images.function_runtime_python312", image)
 	return b
 }
+
+func (b *Builder) WithImageFunctionRuntimePython314(image string) *Builder {
+	b.With("global.images.function_runtime_python314", image)
+	return b
+}
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
 )
 
 func TestWithFipsModeEnabled(t *testing.T) {
@@ -43,3 +44,101 @@ func TestWithFipsModeEnabled(t *testing.T) {
 		require.Equal(t, expected, flagsMap)
 	})
 }
+
+func TestWithControllerConfiguration(t *testing.T) {
+	t.Run("set all values", func(t *testing.T) {
+		fb := NewBuilder()
+		fb.WithControllerConfiguration("5m", "10s", "70")
+
+		flagsMap, err := fb.Build()
+		require.NoError(t, err)
+
+		expected := map[string]interface{}{
+			"containers": map[string]interface{}{
+				"manager": map[string]interface{}{
+					"configuration": map[string]interface{}{
+						"data": map[string]interface{}{
+							"functionRequeueDuration":        "5m",
+							"healthzLivenessTimeout":         "10s",
+							"targetCPUUtilizationPercentage": int64(70),
+						},
+					},
+				},
+			},
+		}
+
+		require.Equal(t, expected, flagsMap)
+	})
+
+	t.Run("skip empty values", func(t *testing.T) {
+		fb := NewBuilder()
+		fb.WithControllerConfiguration("", "", "")
+
+		flagsMap, err := fb.Build()
+		require.NoError(t, err)
+
+		require.Empty(t, flagsMap)
+	})
+}
+
+func TestWithFunctionScheduling(t *testing.T) {
+	t.Run("set all values", func(t *testing.T) {
+		fb := NewBuilder()
+		fb.WithFunctionScheduling(
+			[]string{"kubernetes.io/hostname", "pool"},
+			[]string{"*"},
+			[]string{"high-priority"},
+			[]string{"function-sa"},
+			true,
+			ptr.To(false),
+		)
+
+		flagsMap, err := fb.Build()
+		require.NoError(t, err)
+
+		expected := map[string]interface{}{
+			"containers": map[string]interface{}{
+				"manager": map[string]interface{}{
+					"configuration": map[string]interface{}{
+						"data": map[string]interface{}{
+							"functionScheduling": map[string]interface{}{
+								"allowedNodeSelectorKeys":        []interface{}{"kubernetes.io/hostname", "pool"},
+								"allowedTolerationKeys":          []interface{}{"*"},
+								"allowedPriorityClassNames":      []interface{}{"high-priority"},
+								"allowedServiceAccountNames":     []interface{}{"function-sa"},
+								"allowAffinity":                  true,
+								"allowTopologySpreadConstraints": false,
+							},
+						},
+					},
+				},
+			},
+		}
+
+		require.Equal(t, expected, flagsMap)
+	})
+
+	t.Run("skip empty values", func(t *testing.T) {
+		fb := NewBuilder()
+		fb.WithFunctionScheduling(nil, nil, nil, nil, false, nil)
+
+		flagsMap, err := fb.Build()
+		require.NoError(t, err)
+
+		expected := map[string]interface{}{
+			"containers": map[string]interface{}{
+				"manager": map[string]interface{}{
+					"configuration": map[string]interface{}{
+						"data": map[string]interface{}{
+							"functionScheduling": map[string]interface{}{
+								"allowAffinity": false,
+							},
+						},
+					},
+				},
+			},
+		}
+
+		require.Equal(t, expected, flagsMap)
+	})
+}
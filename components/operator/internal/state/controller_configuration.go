@@ -2,6 +2,8 @@ package state
 
 import (
 	"context"
+	"fmt"
+	"strconv"
 
 	"github.com/kyma-project/serverless/components/operator/api/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
@@ -17,7 +19,19 @@ const (
 )
 
 func sFnControllerConfiguration(ctx context.Context, r *reconciler, s *systemState) (stateFn, *controllerruntime.Result, error) {
-	err := updateControllerConfigurationStatus(ctx, r, &s.instance)
+	err := validateControllerConfiguration(s.instance.Spec)
+	if err != nil {
+		// the invalid configuration would stop the controller from starting, so it's not applied until it's fixed
+		s.setState(v1alpha1.StateError)
+		s.instance.UpdateConditionFalse(
+			v1alpha1.ConditionTypeConfigured,
+			v1alpha1.ConditionReasonConfigurationErr,
+			err,
+		)
+		return stop()
+	}
+
+	err = updateControllerConfigurationStatus(ctx, r, &s.instance)
 	if err != nil {
 		return stopWithEventualError(err)
 	}
@@ -34,6 +48,18 @@ func sFnControllerConfiguration(ctx context.Context, r *reconciler, s *systemSta
 	return nextState(sFnConfigureNetworkPolicies)
 }
 
+func validateControllerConfiguration(spec v1alpha1.ServerlessSpec) error {
+	if spec.TargetCPUUtilizationPercentage == "" {
+		return nil
+	}
+
+	percentage, err := strconv.Atoi(spec.TargetCPUUtilizationPercentage)
+	if err != nil || percentage < 1 || percentage > 100 {
+		return fmt.Errorf("targetCPUUtilizationPercentage must be an integer between 1 and 100, got '%s'", spec.TargetCPUUtilizationPercentage)
+	}
+	return nil
+}
+
 func updateControllerConfigurationStatus(ctx context.Context, r *reconciler, instance *v1alpha1.Serverless) error {
 	nodesLen, err := getNodesLen(ctx, r.client)
 	if err != nil {
@@ -50,6 +76,7 @@ func updateControllerConfigurationStatus(ctx context.Context, r *reconciler, ins
 	fields := fieldsToUpdate{
 		{spec.FunctionRequeueDuration, &instance.Status.RequeueDuration, "Function requeue duration", ""},
 		{spec.HealthzLivenessTimeout, &instance.Status.HealthzLivenessTimeout, "Duration of health check", ""},
+		{spec.TargetCPUUtilizationPercentage, &instance.Status.CPUUtilizationPercentage, "CPU utilization", ""},
 		{spec.DefaultRuntimePodPreset, &instance.Status.DefaultRuntimePodPreset, "Default runtime pod preset", defaultRuntimePreset},
 		{spec.LogLevel, &instance.Status.LogLevel, "Log level", defaultLogLevel},
 		{spec.LogFormat, &instance.Status.LogFormat, "Log format", defaultLogFormat},
@@ -64,6 +91,7 @@ func configureControllerConfigurationFlags(s *systemState) {
 		WithControllerConfiguration(
 			s.instance.Status.RequeueDuration,
 			s.instance.Status.HealthzLivenessTimeout,
+			s.instance.Status.CPUUtilizationPercentage,
 		).
 		WithDefaultPresetFlags(
 			s.instance.Status.DefaultRuntimePodPreset,
@@ -71,6 +99,17 @@ func configureControllerConfigurationFlags(s *systemState) {
 		WithLogLevel(s.instance.Status.LogLevel).
 		WithLogFormat(s.instance.Status.LogFormat).
 		WithLogFormatRestartAnnotation(s.instance.Status.LogFormat)
+
+	if scheduling := s.instance.Spec.FunctionScheduling; scheduling != nil {
+		s.flagsBuilder.WithFunctionScheduling(
+			scheduling.AllowedNodeSelectorKeys,
+			scheduling.AllowedTolerationKeys,
+			scheduling.AllowedPriorityClassNames,
+			scheduling.AllowedServiceAccountNames,
+			scheduling.AllowAffinity,
+			scheduling.AllowTopologySpreadConstraints,
+		)
+	}
 }
 
 func getNodesLen(ctx context.Context, c client.Client) (int, error) {
@@ -17,6 +17,7 @@ import (
 const (
 	requeueDurationTest        = "test-requeue-duration"
 	healthzLivenessTimeoutTest = "test-healthz-liveness-timeout"
+	cpuUtilizationTest         = "60"
 	runtimePodPresetTest       = "test-default-runtime-pod-preset"
 	logLevelTest               = "test-log-level"
 	logFormatTest              = "test-log-format"
@@ -155,11 +156,12 @@ func Test_sFnControllerConfiguration(t *testing.T) {
 		s := &systemState{
 			instance: v1alpha1.Serverless{
 				Spec: v1alpha1.ServerlessSpec{
-					FunctionRequeueDuration: requeueDurationTest,
-					HealthzLivenessTimeout:  healthzLivenessTimeoutTest,
-					DefaultRuntimePodPreset: runtimePodPresetTest,
-					LogLevel:                logLevelTest,
-					LogFormat:               logFormatTest,
+					FunctionRequeueDuration:        requeueDurationTest,
+					HealthzLivenessTimeout:         healthzLivenessTimeoutTest,
+					TargetCPUUtilizationPercentage: cpuUtilizationTest,
+					DefaultRuntimePodPreset:        runtimePodPresetTest,
+					LogLevel:                       logLevelTest,
+					LogFormat:                      logFormatTest,
 				},
 			},
 			flagsBuilder: flags.NewBuilder(),
@@ -176,6 +178,7 @@ func Test_sFnControllerConfiguration(t *testing.T) {
 		status := s.instance.Status
 		require.Equal(t, requeueDurationTest, status.RequeueDuration)
 		require.Equal(t, healthzLivenessTimeoutTest, status.HealthzLivenessTimeout)
+		require.Equal(t, cpuUtilizationTest, status.CPUUtilizationPercentage)
 		require.Equal(t, runtimePodPresetTest, status.DefaultRuntimePodPreset)
 		require.Equal(t, logLevelTest, status.LogLevel)
 		require.Equal(t, logFormatTest, status.LogFormat)
@@ -191,6 +194,7 @@ func Test_sFnControllerConfiguration(t *testing.T) {
 		expectedEvents := []string{
 			"Normal Configuration Function requeue duration set from '' to 'test-requeue-duration'",
 			"Normal Configuration Duration of health check set from '' to 'test-healthz-liveness-timeout'",
+			"Normal Configuration CPU utilization set from '' to '60'",
 			"Normal Configuration Default runtime pod preset set from '' to 'test-default-runtime-pod-preset'",
 			"Normal Configuration Log level set from '' to 'test-log-level'",
 			"Normal Configuration Log format set from '' to 'test-log-format'",
@@ -201,6 +205,36 @@ func Test_sFnControllerConfiguration(t *testing.T) {
 		}
 	})
 
+	t.Run("reject invalid target CPU utilization percentage", func(t *testing.T) {
+		for _, percentage := range []string{"50%", "0", "101", "high"} {
+			s := &systemState{
+				instance: v1alpha1.Serverless{
+					Spec: v1alpha1.ServerlessSpec{
+						TargetCPUUtilizationPercentage: percentage,
+					},
+				},
+				flagsBuilder: flags.NewBuilder(),
+			}
+
+			c := fake.NewClientBuilder().Build()
+			r := &reconciler{log: zap.NewNop().Sugar(), k8s: k8s{client: c, EventRecorder: record.NewFakeRecorder(10)}}
+			next, result, err := sFnControllerConfiguration(context.TODO(), r, s)
+			require.Nil(t, err)
+			require.Nil(t, result)
+			require.Nil(t, next)
+
+			status := s.instance.Status
+			require.Empty(t, status.CPUUtilizationPercentage)
+			require.Equal(t, v1alpha1.StateError, status.State)
+			requireContainsCondition(t, status,
+				v1alpha1.ConditionTypeConfigured,
+				metav1.ConditionFalse,
+				v1alpha1.ConditionReasonConfigurationErr,
+				"targetCPUUtilizationPercentage must be an integer between 1 and 100, got '"+percentage+"'",
+			)
+		}
+	})
+
 	t.Run("reconcile from configurationError", func(t *testing.T) {
 		s := &systemState{
 			instance: v1alpha1.Serverless{
@@ -248,6 +282,40 @@ func Test_sFnControllerConfiguration(t *testing.T) {
 			configurationReadyMsg)
 		require.Equal(t, v1alpha1.StateProcessing, s.instance.Status.State)
 	})
+
+	t.Run("configure function scheduling", func(t *testing.T) {
+		s := &systemState{
+			instance: v1alpha1.Serverless{
+				Spec: v1alpha1.ServerlessSpec{
+					FunctionScheduling: &v1alpha1.FunctionScheduling{
+						AllowedNodeSelectorKeys: []string{"pool"},
+						AllowAffinity:           true,
+					},
+				},
+			},
+			flagsBuilder: flags.NewBuilder(),
+		}
+		r := &reconciler{
+			log: zap.NewNop().Sugar(),
+			k8s: k8s{
+				client:        fake.NewClientBuilder().Build(),
+				EventRecorder: record.NewFakeRecorder(4),
+			},
+		}
+
+		next, result, err := sFnControllerConfiguration(context.Background(), r, s)
+		require.NoError(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnApplyResources, next)
+
+		flagsMap, err := s.flagsBuilder.Build()
+		require.NoError(t, err)
+		data := flagsMap["containers"].(map[string]interface{})["manager"].(map[string]interface{})["configuration"].(map[string]interface{})["data"].(map[string]interface{})
+		require.Equal(t, map[string]interface{}{
+			"allowedNodeSelectorKeys": []interface{}{"pool"},
+			"allowAffinity":           true,
+		}, data["functionScheduling"])
+	})
 }
 
 func fixTestNode(name string) *corev1.Node {
@@ -18,11 +18,16 @@ export default [
     { text: 'Override Runtime Image', link: './tutorials/01-110-override-runtime-image' },
     { text: 'Inject Environment Variables', link: './tutorials/01-120-inject-envs' },
     { text: 'Use External Scalers', link: './tutorials/01-130-use-external-scalers' },
-    { text: 'Access to Secrets Mounted as Volume', link: './tutorials/01-140-use-secret-mounts' }
+    { text: 'Access to Secrets Mounted as Volume', link: './tutorials/01-140-use-secret-mounts' },
+    { text: 'Scale a Function to Zero', link: './tutorials/01-150-scale-function-to-zero' },
+    { text: 'Roll Back a Function', link: './tutorials/01-160-roll-back-function' },
+    { text: 'Cache Function Dependencies', link: './tutorials/01-170-cache-function-dependencies' },
+    { text: 'Redeploy a Function on Git Push', link: './tutorials/01-180-redeploy-function-on-git-push' }
     ] },
   { text: 'Resources', link: './resources/README', collapsed: true, items: [
     { text: 'Function CR', link: './resources/06-10-function-cr' },
-    { text: 'Serverless CR', link: './resources/06-20-serverless-cr' }
+    { text: 'Serverless CR', link: './resources/06-20-serverless-cr' },
+    { text: 'FunctionRuntime CR', link: './resources/06-30-functionruntime-cr' }
     ] },
   { text: 'Technical Reference', link: './technical-reference/README', collapsed: true, items: [
     { text: 'Serverless Architecture', link: './technical-reference/04-10-architecture' },
@@ -30,8 +35,10 @@ export default [
     { text: 'Sample Functions', link: './technical-reference/07-10-sample-functions' },
     { text: 'Function Processing', link: './technical-reference/07-20-function-processing-stages' },
     { text: 'Git Source Type', link: './technical-reference/07-40-git-source-type' },
+    { text: 'Other Source Types', link: './technical-reference/07-50-other-source-types' },
     { text: 'Function\'s Specification', link: './technical-reference/07-70-function-specification' },
-    { text: 'Available Presets', link: './technical-reference/07-80-available-presets' }
+    { text: 'Available Presets', link: './technical-reference/07-80-available-presets' },
+    { text: 'Custom Runtimes', link: './technical-reference/07-90-custom-runtimes' }
     ] },
   { text: 'Troubleshooting Guides', link: './troubleshooting-guides/README', collapsed: true, items: [
     { text: 'Serverless Periodically Restarting', link: './troubleshooting-guides/03-50-serverless-periodically-restaring' },
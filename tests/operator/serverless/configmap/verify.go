@@ -13,9 +13,11 @@ import (
 
 type FunctionConfig struct {
 	Images struct {
-		Nodejs20 string `yaml:"nodejs20"`
-		Nodejs22 string `yaml:"nodejs22"`
-		Nodejs24 string `yaml:"nodejs24"`
+		Nodejs20  string `yaml:"nodejs20"`
+		Nodejs22  string `yaml:"nodejs22"`
+		Nodejs24  string `yaml:"nodejs24"`
+		Nodejs26  string `yaml:"nodejs26"`
+		Python314 string `yaml:"python314"`
 	} `yaml:"images"`
 }
 
@@ -49,6 +51,12 @@ func verifyConfigmap(testutils *utils.TestUtils, configmap *corev1.ConfigMap, se
 		if !isFipsImage(cfg.Images.Nodejs24) {
 			return fmt.Errorf("expected FIPS image for nodejs24, got %s", cfg.Images.Nodejs24)
 		}
+		if !isFipsImage(cfg.Images.Nodejs26) {
+			return fmt.Errorf("expected FIPS image for nodejs26, got %s", cfg.Images.Nodejs26)
+		}
+		if !isFipsImage(cfg.Images.Python314) {
+			return fmt.Errorf("expected FIPS image for python314, got %s", cfg.Images.Python314)
+		}
 	}
 
 	//TODO:  verify if all data from the spec is reflected in the configmap
@@ -28,6 +28,26 @@ func BasicNodeJSFunction(msg string, rtm serverlessv1alpha2.Runtime) serverlessv
 	}
 }
 
+func BasicNodeJSFunctionNewAPI(msg string, rtm serverlessv1alpha2.Runtime) serverlessv1alpha2.FunctionSpec {
+	return serverlessv1alpha2.FunctionSpec{
+		Runtime: rtm,
+		Source: serverlessv1alpha2.Source{
+			Inline: &serverlessv1alpha2.InlineSource{
+				Source:       fmt.Sprintf(`module.exports = { main: function(req, res) { res.send("%s") } }`, msg),
+				Dependencies: `{ "name": "hellobasic", "version": "0.0.1", "dependencies": {} }`,
+			},
+		},
+		ResourceConfiguration: &serverlessv1alpha2.ResourceConfiguration{
+			Function: &serverlessv1alpha2.ResourceRequirements{
+				Profile: "M",
+			},
+			Build: &serverlessv1alpha2.ResourceRequirements{
+				Profile: "fast",
+			},
+		},
+	}
+}
+
 func BasicTracingNodeFunction(rtm serverlessv1alpha2.Runtime, externalSvcURL string) serverlessv1alpha2.FunctionSpec {
 	dpd := `{
   "name": "sanitise-fn",
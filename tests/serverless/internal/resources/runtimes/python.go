@@ -35,6 +35,33 @@ arrow==0.15.8`
 	}
 }
 
+func BasicPythonFunctionNewAPI(msg string, runtime serverlessv1alpha2.Runtime) serverlessv1alpha2.FunctionSpec {
+	src := fmt.Sprintf(`import arrow
+def main():
+	return "%s"`, msg)
+
+	dpd := `requests==2.31.0
+arrow==1.3.0`
+
+	return serverlessv1alpha2.FunctionSpec{
+		Runtime: runtime,
+		Source: serverlessv1alpha2.Source{
+			Inline: &serverlessv1alpha2.InlineSource{
+				Source:       src,
+				Dependencies: dpd,
+			},
+		},
+		ResourceConfiguration: &serverlessv1alpha2.ResourceConfiguration{
+			Function: &serverlessv1alpha2.ResourceRequirements{
+				Profile: "M",
+			},
+			Build: &serverlessv1alpha2.ResourceRequirements{
+				Profile: "fast",
+			},
+		},
+	}
+}
+
 func BasicTracingPythonFunction(runtime serverlessv1alpha2.Runtime, externalURL string) serverlessv1alpha2.FunctionSpec {
 
 	// TODO: New Buildless Serverless cannot use deprecated lib with new (0.50b0) opentelemetry libs - https://github.com/kyma-project/serverless/issues/1211#issuecomment-2636352928
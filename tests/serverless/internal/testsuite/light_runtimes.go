@@ -69,6 +69,8 @@ func SimpleFunction(restConfig *rest.Config, cfg internal.Config, logf *logrus.E
 			newSimpleNodejs20TestRunner(logf, poll, genericContainer, cfg.KubectlProxyEnabled),
 			newSimpleNodejs22TestRunner(logf, poll, genericContainer, cfg.KubectlProxyEnabled),
 			newSimpleNodejs24TestRunner(logf, poll, genericContainer, cfg.KubectlProxyEnabled),
+			newSimpleNodejs26TestRunner(logf, poll, genericContainer, cfg.KubectlProxyEnabled),
+			newSimplePython314TestRunner(logf, poll, genericContainer, cfg.KubectlProxyEnabled),
 		),
 	), nil
 }
@@ -100,6 +102,8 @@ func SimpleFunctionFIPS(restConfig *rest.Config, cfg internal.Config, logf *logr
 		executor.NewParallelRunner(logf, "Fn tests",
 			newSimpleNodejs22TestRunner(logf, poll, genericContainer, cfg.KubectlProxyEnabled),
 			newSimpleNodejs24TestRunner(logf, poll, genericContainer, cfg.KubectlProxyEnabled),
+			newSimpleNodejs26TestRunner(logf, poll, genericContainer, cfg.KubectlProxyEnabled),
+			newSimplePython314TestRunner(logf, poll, genericContainer, cfg.KubectlProxyEnabled),
 		),
 	), nil
 }
@@ -177,6 +181,18 @@ func newSimpleNodejs24TestRunner(logf *logrus.Entry, poll utils.Poller, genericC
 	)
 }
 
+func newSimpleNodejs26TestRunner(logf *logrus.Entry, poll utils.Poller, genericContainer utils.Container, kubectlProxyEnabled bool) *executor.SerialRunner {
+	nodejs26Logger := logf.WithField(runtimeKey, "nodejs26")
+	nodejs26Fn := function.NewFunction("nodejs26", genericContainer.Namespace, kubectlProxyEnabled, genericContainer.WithLogger(nodejs26Logger))
+
+	return executor.NewSerialTestRunner(nodejs26Logger, "NodeJS26 test",
+		function.CreateFunction(nodejs26Logger, nodejs26Fn, "Create NodeJS26 Function", runtimes.BasicNodeJSFunctionNewAPI("Hello from nodejs26", serverlessv1alpha2.NodeJs26)),
+		assertion.NewHTTPCheck(nodejs26Logger, "NodeJS26 pre update simple check through service", nodejs26Fn.FunctionURL, poll, "Hello from nodejs26"),
+		function.UpdateFunction(nodejs26Logger, nodejs26Fn, "Update NodeJS26 Function", runtimes.BasicNodeJSFunctionNewAPI("Hello from updated nodejs26", serverlessv1alpha2.NodeJs26)),
+		assertion.NewHTTPCheck(nodejs26Logger, "NodeJS26 post update simple check through service", nodejs26Fn.FunctionURL, poll, "Hello from updated nodejs26"),
+	)
+}
+
 func newSimplePython312TestRunner(logf *logrus.Entry, poll utils.Poller, genericContainer utils.Container, kubectlProxyEnabled bool) *executor.SerialRunner {
 	python312Logger := logf.WithField(runtimeKey, "python312")
 	python312Fn := function.NewFunction("python312", genericContainer.Namespace, kubectlProxyEnabled, genericContainer.WithLogger(python312Logger))
@@ -188,3 +204,15 @@ func newSimplePython312TestRunner(logf *logrus.Entry, poll utils.Poller, generic
 		assertion.NewHTTPCheck(python312Logger, "Python312 post update simple check through service", python312Fn.FunctionURL, poll, "Hello From updated python"),
 	)
 }
+
+func newSimplePython314TestRunner(logf *logrus.Entry, poll utils.Poller, genericContainer utils.Container, kubectlProxyEnabled bool) *executor.SerialRunner {
+	python314Logger := logf.WithField(runtimeKey, "python314")
+	python314Fn := function.NewFunction("python314", genericContainer.Namespace, kubectlProxyEnabled, genericContainer.WithLogger(python314Logger))
+
+	return executor.NewSerialTestRunner(python314Logger, "Python314 test",
+		function.CreateFunction(python314Logger, python314Fn, "Create Python314 Function", runtimes.BasicPythonFunctionNewAPI("Hello From python314", serverlessv1alpha2.Python314)),
+		assertion.NewHTTPCheck(python314Logger, "Python314 pre update simple check through service", python314Fn.FunctionURL, poll, "Hello From python314"),
+		function.UpdateFunction(python314Logger, python314Fn, "Update Python314 Function", runtimes.BasicPythonFunctionNewAPI("Hello From updated python314", serverlessv1alpha2.Python314)),
+		assertion.NewHTTPCheck(python314Logger, "Python314 post update simple check through service", python314Fn.FunctionURL, poll, "Hello From updated python314"),
+	)
+}